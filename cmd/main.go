@@ -28,6 +28,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
@@ -36,6 +37,7 @@ import (
 	grpcserver "github.com/aaronlmathis/gosight-server/internal/grpc"
 	httpserver "github.com/aaronlmathis/gosight-server/internal/http"
 	"github.com/aaronlmathis/gosight-server/internal/otel"
+	"github.com/aaronlmathis/gosight-server/internal/store/eventstore"
 	"github.com/aaronlmathis/gosight-server/internal/syslog"
 	"github.com/aaronlmathis/gosight-shared/utils"
 	"google.golang.org/grpc/encoding/gzip"
@@ -73,6 +75,24 @@ func run(configFlag *string) {
 	// Start all sync loops — this blocks until ctx is canceled
 	go sys.SyncMgr.Run()
 
+	// Start the rule scheduler for rules with an eval_interval
+	go sys.Tele.Scheduler.Run()
+
+	// Resolve event-rule alerts once they expire
+	go sys.Tele.Alerts.Run(ctx)
+
+	// Retry failed notifications until they're delivered or dead-lettered
+	go sys.Tele.Dispatcher.Run(ctx)
+
+	// Resolve incidents that have gone quiet
+	go sys.Tele.Incidents.Run(ctx)
+
+	// Publish events to the configured sinks; the sinks close on shutdown
+	go sys.Tele.Sinks.Run(ctx)
+
+	// Delete events past the configured retention
+	go eventstore.RunRetention(ctx, sys.Stores.Events, sys.Cfg.EventStore.Retention)
+
 	// Start HTTP server for admin console/api
 	srv := httpserver.NewServer(sys)
 
@@ -160,12 +180,18 @@ func run(configFlag *string) {
 	if err := sys.Stores.Users.Close(); err != nil {
 		utils.Warn("Failed to close userstore: %v", err)
 	}
+	if closer, ok := sys.Stores.Events.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			utils.Warn("Failed to close event store: %v", err)
+		}
+	}
 }
 
 // main is the entry point for the GoSight server.
 func main() {
 	versionFlag := flag.Bool("version", false, "print version information and exit")
 	configFlag := flag.String("config", "", "Path to server config file")
+	importRulesFlag := flag.String("import-rules", "", "import a YAML or JSON rules file into the configured rule store and exit")
 	flag.Parse()
 	if *versionFlag {
 		fmt.Printf(
@@ -174,5 +200,12 @@ func main() {
 		)
 		os.Exit(0)
 	}
+	if *importRulesFlag != "" {
+		if err := bootstrap.ImportRules(context.Background(), configFlag, *importRulesFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Rule import failed: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 	run(configFlag)
 }
@@ -0,0 +1,194 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alertmodel
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed five-field cron expression
+// (minute hour day-of-month month day-of-week). Each field accepts "*",
+// numbers, ranges ("1-5"), lists ("1,15") and steps ("*/10", "0-30/5").
+// Day-of-week runs 0-6 from Sunday; 7 is also accepted for Sunday. As in
+// standard cron, when both day fields are restricted a time matches if
+// either one does.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// ParseCron parses a five-field cron expression.
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	var c CronSchedule
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("cron %q minute: %w", expr, err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("cron %q hour: %w", expr, err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("cron %q day of month: %w", expr, err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("cron %q month: %w", expr, err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("cron %q day of week: %w", expr, err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+	return &c, nil
+}
+
+// parseCronField parses one field into a bit set of allowed values.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step %q", stepPart)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			n, err := strconv.Atoi(from)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", from)
+			}
+			lo, hi = n, n
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("bad value %q", to)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Matches reports whether the schedule fires in the minute containing t.
+func (c *CronSchedule) Matches(t time.Time) bool {
+	return c.minute&(1<<uint(t.Minute())) != 0 &&
+		c.hour&(1<<uint(t.Hour())) != 0 &&
+		c.month&(1<<uint(t.Month())) != 0 &&
+		c.dayMatches(t)
+}
+
+// dayMatches applies the day-of-month and day-of-week fields to t's date.
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	domOK := c.dom&(1<<uint(t.Day())) != 0
+	dowOK := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dowOK
+	case c.dowAny:
+		return domOK
+	default:
+		return domOK || dowOK
+	}
+}
+
+// Prev returns the start of the latest minute at or before t in which the
+// schedule fires, or the zero time if it does not fire between limit and t.
+// It works in t's location and skips whole months, days and hours that
+// cannot match, so looking back a week costs a handful of steps.
+func (c *CronSchedule) Prev(t, limit time.Time) time.Time {
+	loc := t.Location()
+	m := t.Truncate(time.Minute)
+	for !m.Before(limit) {
+		var next time.Time
+		switch {
+		case c.month&(1<<uint(m.Month())) == 0:
+			// Last minute of the previous month.
+			next = time.Date(m.Year(), m.Month(), 1, 0, 0, 0, 0, loc).Add(-time.Minute)
+		case !c.dayMatches(m):
+			next = time.Date(m.Year(), m.Month(), m.Day(), 0, 0, 0, 0, loc).Add(-time.Minute)
+		case c.hour&(1<<uint(m.Hour())) == 0:
+			if h := prevBit(c.hour, m.Hour()); h >= 0 {
+				next = time.Date(m.Year(), m.Month(), m.Day(), h, 59, 0, 0, loc)
+			} else {
+				next = time.Date(m.Year(), m.Month(), m.Day(), 0, 0, 0, 0, loc).Add(-time.Minute)
+			}
+		case c.minute&(1<<uint(m.Minute())) == 0:
+			if min := prevBit(c.minute, m.Minute()); min >= 0 {
+				next = time.Date(m.Year(), m.Month(), m.Day(), m.Hour(), min, 0, 0, loc)
+			} else {
+				next = time.Date(m.Year(), m.Month(), m.Day(), m.Hour(), 0, 0, 0, loc).Add(-time.Minute)
+			}
+		default:
+			return m
+		}
+		m = wallBefore(next, m)
+	}
+	return time.Time{}
+}
+
+// wallBefore fixes up next, built with time.Date, for the hour that repeats
+// when clocks go back: time.Date may pick either instant showing that wall
+// time. It returns the later one that is still before m, or m less a minute
+// if both are at or after it.
+func wallBefore(next, m time.Time) time.Time {
+	_, nextOff := next.Zone()
+	_, mOff := m.Zone()
+	if later := next.Add(time.Duration(nextOff-mOff) * time.Second); later.After(next) && later.Before(m) &&
+		later.Hour() == next.Hour() && later.Minute() == next.Minute() {
+		return later
+	}
+	if !next.Before(m) {
+		return m.Add(-time.Minute)
+	}
+	return next
+}
+
+// prevBit returns the highest value at or below from in the bit set, or -1.
+func prevBit(set uint64, from int) int {
+	return bits.Len64(set&(1<<uint(from+1)-1)) - 1
+}
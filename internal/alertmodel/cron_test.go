@@ -0,0 +1,130 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alertmodel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, expr := range []string{"* * * * *", "*/15 9-17 * * 1-5", "0 0 1,15 * *", "30 2 * * 7", "0-30/10 * * 1-12 0"} {
+		_, err := ParseCron(expr)
+		assert.NoError(t, err, expr)
+	}
+	for _, expr := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCronMatches(t *testing.T) {
+	at := func(s string) time.Time {
+		ts, err := time.Parse("2006-01-02 15:04", s)
+		require.NoError(t, err)
+		return ts
+	}
+	tests := []struct {
+		expr string
+		time string
+		want bool
+	}{
+		{"*/15 9-17 * * 1-5", "2026-10-16 09:45", true}, // Friday
+		{"*/15 9-17 * * 1-5", "2026-10-16 09:40", false},
+		{"*/15 9-17 * * 1-5", "2026-10-17 09:45", false}, // Saturday
+		{"0 2 * * 7", "2026-10-18 02:00", true},          // 7 is Sunday
+		{"0 0 1 * 1", "2026-10-01 00:00", true},          // either day field
+		{"0 0 1 * 1", "2026-10-19 00:00", true},
+		{"0 0 1 * 1", "2026-10-20 00:00", false},
+		{"0 0 * 2 *", "2026-10-01 00:00", false},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, c.Matches(at(tt.time)), "%s at %s", tt.expr, tt.time)
+	}
+}
+
+func TestCronPrev(t *testing.T) {
+	at := func(s string) time.Time {
+		ts, err := time.Parse("2006-01-02 15:04:05", s)
+		require.NoError(t, err)
+		return ts
+	}
+	week := 7 * 24 * time.Hour
+	tests := []struct {
+		expr string
+		from string
+		want string // empty when the schedule doesn't fire within a week
+	}{
+		{"* * * * *", "2026-10-16 12:34:56", "2026-10-16 12:34:00"},
+		{"0 2 * * *", "2026-10-16 01:59:00", "2026-10-15 02:00:00"},
+		{"0 2 * * *", "2026-10-16 02:00:30", "2026-10-16 02:00:00"},
+		{"30 22 * * 5", "2026-10-16 12:00:00", "2026-10-09 22:30:00"},
+		{"*/20 9-17 * * 1-5", "2026-10-19 08:00:00", "2026-10-16 17:40:00"}, // Monday morning back to Friday
+		{"0 0 31 * *", "2026-10-16 00:00:00", ""},                           // none since 31 August
+		{"0 0 1 1 *", "2026-10-16 00:00:00", ""},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		require.NoError(t, err)
+		from := at(tt.from)
+		got := c.Prev(from, from.Add(-week))
+		if tt.want == "" {
+			assert.True(t, got.IsZero(), "%s from %s: got %s", tt.expr, tt.from, got)
+			continue
+		}
+		assert.Equal(t, at(tt.want), got, "%s from %s", tt.expr, tt.from)
+	}
+}
+
+func TestCronPrevAgreesWithMatches(t *testing.T) {
+	// Each range spans a daylight saving change, where wall-clock hours are
+	// skipped or repeated.
+	for _, tz := range []struct {
+		name  string
+		start time.Time
+	}{
+		{"Europe/Berlin", time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC)},
+		{"America/New_York", time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC)},
+		{"America/New_York", time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},
+	} {
+		loc, err := time.LoadLocation(tz.name)
+		require.NoError(t, err)
+		start := tz.start.In(loc)
+		for _, expr := range []string{"*/7 1-3 * * *", "0 2 * * 0", "45 */5 1,2,6-9 * *", "0 0 * * 1"} {
+			c, err := ParseCron(expr)
+			require.NoError(t, err)
+			var last time.Time
+			for m := start; m.Before(start.Add(4 * 24 * time.Hour)); m = m.Add(time.Minute) {
+				if c.Matches(m) {
+					last = m
+				}
+				got := c.Prev(m, start)
+				require.True(t, last.Equal(got), "%s in %s at %s: want %s, got %s", expr, tz.name, m, last, got)
+			}
+		}
+	}
+}
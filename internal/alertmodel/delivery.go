@@ -0,0 +1,203 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alertmodel
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Delivery states. A delivery is pending until its first attempt, retrying
+// while attempts fail, and dead once it runs out of attempts; dead
+// deliveries form the dead-letter list and can be retried by hand.
+const (
+	DeliveryPending   = "pending"
+	DeliveryRetrying  = "retrying"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// Payload kinds of a delivery: a single alert event or a group notification.
+const (
+	PayloadEvent = "event"
+	PayloadGroup = "group"
+)
+
+// redacted replaces secrets when deliveries and routes are shown through the
+// API.
+const redacted = "********"
+
+// RetryPolicy overrides the server's retry settings for one action. Backoff
+// is the delay before the first retry and doubles with every further
+// attempt, up to MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts int    `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+	Backoff     string `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	MaxBackoff  string `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`
+}
+
+// Delivery is one notification sent to one route action, with every attempt
+// made to send it. Action holds the action with its templates already
+// rendered, so a retry sends exactly what the first attempt did.
+type Delivery struct {
+	ID          string            `json:"id"`
+	RouteID     string            `json:"route_id,omitempty"`
+	RuleID      string            `json:"rule_id,omitempty"`
+	AlertID     string            `json:"alert_id,omitempty"`
+	GroupKey    string            `json:"group_key,omitempty"`
+	Action      ActionSpec        `json:"action"`
+	Kind        string            `json:"kind"` // event or group
+	Payload     json.RawMessage   `json:"payload"`
+	Status      string            `json:"status"`
+	MaxAttempts int               `json:"max_attempts"`
+	Attempts    []DeliveryAttempt `json:"attempts"`
+	LastError   string            `json:"last_error,omitempty"`
+	NextAttempt time.Time         `json:"next_attempt,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	DeliveredAt *time.Time        `json:"delivered_at,omitempty"`
+}
+
+// DeliveryAttempt records one try at sending a delivery.
+type DeliveryAttempt struct {
+	Attempt    int       `json:"attempt"`
+	Timestamp  time.Time `json:"timestamp"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Redacted returns a copy of the delivery with the action's credentials and
+// header values masked (see ActionSpec.Redacted). The URL is masked in the
+// recorded errors as well.
+func (d Delivery) Redacted() Delivery {
+	a := d.Action.Redacted()
+	if d.Action.URL != "" {
+		d.LastError = strings.ReplaceAll(d.LastError, d.Action.URL, a.URL)
+		attempts := make([]DeliveryAttempt, len(d.Attempts))
+		for i, at := range d.Attempts {
+			at.Error = strings.ReplaceAll(at.Error, d.Action.URL, a.URL)
+			attempts[i] = at
+		}
+		d.Attempts = attempts
+	}
+	d.Action = a
+	return d
+}
+
+// Redacted returns a copy of the action with its credentials and header
+// values masked. Only the scheme and host of the URL are kept, since webhook
+// URLs such as Slack's embed their token.
+func (a ActionSpec) Redacted() ActionSpec {
+	if a.URL != "" {
+		masked := redacted
+		if u, err := url.Parse(a.URL); err == nil && u.Host != "" {
+			masked = u.Scheme + "://" + u.Host + "/" + redacted
+		}
+		a.URL = masked
+	}
+	if a.RoutingKey != "" {
+		a.RoutingKey = redacted
+	}
+	if a.APIKey != "" {
+		a.APIKey = redacted
+	}
+	if len(a.Headers) > 0 {
+		headers := make(map[string]string, len(a.Headers))
+		for k := range a.Headers {
+			headers[k] = redacted
+		}
+		a.Headers = headers
+	}
+	return a
+}
+
+// Unredacted returns a copy of the action with the fields that still hold
+// the masked values of prev restored from prev, so an action read through
+// the API can be sent back without re-entering its secrets.
+func (a ActionSpec) Unredacted(prev ActionSpec) ActionSpec {
+	masked := prev.Redacted()
+	if a.URL != "" && a.URL == masked.URL {
+		a.URL = prev.URL
+	}
+	if a.RoutingKey == redacted {
+		a.RoutingKey = prev.RoutingKey
+	}
+	if a.APIKey == redacted {
+		a.APIKey = prev.APIKey
+	}
+	if len(a.Headers) > 0 {
+		headers := make(map[string]string, len(a.Headers))
+		for k, v := range a.Headers {
+			if old, ok := prev.Headers[k]; ok && v == redacted {
+				v = old
+			}
+			headers[k] = v
+		}
+		a.Headers = headers
+	}
+	return a
+}
+
+// Redacted returns a copy of the route with the credentials of its actions,
+// and its child routes' actions, masked.
+func (r Route) Redacted() Route {
+	actions := make([]ActionSpec, len(r.Actions))
+	for i, a := range r.Actions {
+		actions[i] = a.Redacted()
+	}
+	r.Actions = actions
+	if len(r.Routes) > 0 {
+		children := make([]Route, len(r.Routes))
+		for i, c := range r.Routes {
+			children[i] = c.Redacted()
+		}
+		r.Routes = children
+	}
+	return r
+}
+
+// Unredacted returns a copy of the route with masked credentials restored
+// from prev (see ActionSpec.Unredacted). Actions are paired by position and
+// type, child routes by position and ID.
+func (r Route) Unredacted(prev Route) Route {
+	actions := make([]ActionSpec, len(r.Actions))
+	for i, a := range r.Actions {
+		if i < len(prev.Actions) && a.Type == prev.Actions[i].Type {
+			a = a.Unredacted(prev.Actions[i])
+		}
+		actions[i] = a
+	}
+	r.Actions = actions
+	if len(r.Routes) > 0 {
+		children := make([]Route, len(r.Routes))
+		for i, c := range r.Routes {
+			if i < len(prev.Routes) && c.ID == prev.Routes[i].ID {
+				c = c.Unredacted(prev.Routes[i])
+			}
+			children[i] = c
+		}
+		r.Routes = children
+	}
+	return r
+}
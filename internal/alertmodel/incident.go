@@ -0,0 +1,99 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alertmodel
+
+import "time"
+
+// Incident statuses.
+const (
+	IncidentOpen     = "open"
+	IncidentResolved = "resolved"
+)
+
+// Incident groups related events: events on the same host or target close
+// together in time, an alert's firing and resolve events, or an agent going
+// offline and the container-down events that follow.
+type Incident struct {
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`             // message of the event that opened the incident
+	Status      string          `json:"status"`            // open or resolved
+	Level       string          `json:"level"`             // most severe level of its events
+	HostID      string          `json:"host_id,omitempty"` // host the events were correlated on, if known
+	EndpointIDs []string        `json:"endpoint_ids,omitempty"`
+	Targets     []string        `json:"targets,omitempty"`
+	AlertIDs    []string        `json:"alert_ids,omitempty"`
+	Active      []string        `json:"active,omitempty"` // firing alerts and down targets, as "alert:<id>" and "down:<target>"
+	StartedAt   time.Time       `json:"started_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	ResolvedAt  time.Time       `json:"resolved_at,omitempty"`
+	ResolvedBy  string          `json:"resolved_by,omitempty"` // user who resolved it; empty when resolved automatically
+	EventCount  int             `json:"event_count"`
+	Timeline    []IncidentEvent `json:"timeline,omitempty"`
+}
+
+// IncidentEvent is one event on an incident's timeline.
+type IncidentEvent struct {
+	EventID    string    `json:"event_id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Level      string    `json:"level"`
+	Category   string    `json:"category"`
+	Source     string    `json:"source,omitempty"`
+	Scope      string    `json:"scope,omitempty"`
+	Target     string    `json:"target,omitempty"`
+	EndpointID string    `json:"endpoint_id,omitempty"`
+	Message    string    `json:"message"`
+}
+
+// IncidentFilter selects incidents to list. Zero fields match everything.
+type IncidentFilter struct {
+	Status     string
+	EndpointID string
+	Start      time.Time // started at or after
+	End        time.Time // started before
+	Limit      int
+}
+
+// Matches reports whether the incident passes the filter, ignoring Limit.
+func (f IncidentFilter) Matches(inc Incident) bool {
+	if f.Status != "" && inc.Status != f.Status {
+		return false
+	}
+	if f.EndpointID != "" && !containsString(inc.EndpointIDs, f.EndpointID) {
+		return false
+	}
+	if !f.Start.IsZero() && inc.StartedAt.Before(f.Start) {
+		return false
+	}
+	if !f.End.IsZero() && !inc.StartedAt.Before(f.End) {
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alertmodel
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Revision actions recorded in a rule's history.
+const (
+	RevisionCreate   = "create"
+	RevisionUpdate   = "update"
+	RevisionDelete   = "delete"
+	RevisionEnable   = "enable"
+	RevisionDisable  = "disable"
+	RevisionRollback = "rollback"
+)
+
+// RuleRevision is one entry in a rule's change history. Rule is the rule as
+// it was after the change (before it, for deletes), so any revision can be
+// restored as-is. Diff lists the fields that changed relative to the
+// previous state.
+type RuleRevision struct {
+	RuleID    string        `json:"rule_id"`
+	Version   int           `json:"version"`
+	Action    string        `json:"action"`
+	Author    string        `json:"author"`
+	Timestamp time.Time     `json:"timestamp"`
+	Rule      AlertRule     `json:"rule"`
+	Diff      []FieldChange `json:"diff,omitempty"`
+	// RestoredFrom is the version a rollback restored.
+	RestoredFrom int `json:"restored_from,omitempty"`
+}
+
+// FieldChange is a single changed field, addressed by its dotted JSON path
+// (e.g. "expression.value").
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+}
+
+// DiffRules returns the fields that differ between two rules, sorted by
+// path. Nested objects are compared key by key; lists are compared whole.
+func DiffRules(old, new AlertRule) []FieldChange {
+	var changes []FieldChange
+	diffValues("", toJSONValue(old), toJSONValue(new), &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// toJSONValue converts a value to its generic JSON representation.
+func toJSONValue(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out interface{}
+	_ = json.Unmarshal(data, &out)
+	return out
+}
+
+// diffValues appends the differences between two generic JSON values.
+func diffValues(path string, old, new interface{}, changes *[]FieldChange) {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make(map[string]bool, len(oldMap)+len(newMap))
+		for k := range oldMap {
+			keys[k] = true
+		}
+		for k := range newMap {
+			keys[k] = true
+		}
+		for k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			diffValues(child, oldMap[k], newMap[k], changes)
+		}
+		return
+	}
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, FieldChange{Field: path, Old: old, New: new})
+	}
+}
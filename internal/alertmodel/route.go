@@ -0,0 +1,122 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alertmodel
+
+import "fmt"
+
+// Route mirrors the shared model.ActionRoute (same JSON and YAML keys) and
+// adds notification grouping. When GroupBy is set, events matching the route
+// are collected per distinct set of GroupBy label values and sent as one
+// notification: GroupWait after the first event of a new group, then at most
+// every GroupInterval while the group keeps changing.
+//
+// Routes can nest. An event matching a route is passed to its child Routes
+// in order and goes to the first child that matches, and to the following
+// ones too while the matching children have Continue set. The route's own
+// actions are used only when no child matches. Children inherit the
+// grouping settings they don't set. Top-level routes are all evaluated, as
+// if each had Continue set.
+type Route struct {
+	ID            string       `json:"id" yaml:"id"`
+	Match         RouteMatch   `json:"match" yaml:"match"`
+	Actions       []ActionSpec `json:"actions" yaml:"actions"`
+	GroupBy       []string     `json:"group_by,omitempty" yaml:"group_by,omitempty"`
+	GroupWait     string       `json:"group_wait,omitempty" yaml:"group_wait,omitempty"`
+	GroupInterval string       `json:"group_interval,omitempty" yaml:"group_interval,omitempty"`
+	Continue      bool         `json:"continue,omitempty" yaml:"continue,omitempty"`
+	Routes        []Route      `json:"routes,omitempty" yaml:"routes,omitempty"`
+}
+
+// RouteSet is the layout of the routes file.
+type RouteSet struct {
+	Routes       []Route       `json:"routes" yaml:"routes"`
+	InhibitRules []InhibitRule `json:"inhibit_rules,omitempty" yaml:"inhibit_rules,omitempty"`
+}
+
+// InhibitRule mutes alerts matching TargetMatchers while an alert matching
+// SourceMatchers is firing and both have the same values for the Equal
+// labels, e.g. the disk alerts of an endpoint whose agent is offline.
+// Matchers see the same labels as silence matchers.
+type InhibitRule struct {
+	SourceMatchers []Matcher `json:"source_matchers" yaml:"source_matchers"`
+	TargetMatchers []Matcher `json:"target_matchers" yaml:"target_matchers"`
+	Equal          []string  `json:"equal,omitempty" yaml:"equal,omitempty"`
+}
+
+// RouteMatch selects the events a route applies to. Every field that is set
+// must match. The event fields are compared exactly and Tags with the
+// event's meta labels. Matchers add regex and negative matches on a meta
+// label or on level, rule_id, category, source, scope, target or
+// endpoint_id.
+type RouteMatch struct {
+	Level      string            `json:"level,omitempty" yaml:"level,omitempty"`
+	RuleID     string            `json:"rule_id,omitempty" yaml:"rule_id,omitempty"`
+	Category   string            `json:"category,omitempty" yaml:"category,omitempty"`
+	Source     string            `json:"source,omitempty" yaml:"source,omitempty"`
+	Scope      string            `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Target     string            `json:"target,omitempty" yaml:"target,omitempty"`
+	EndpointID string            `json:"endpoint_id,omitempty" yaml:"endpoint_id,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Matchers   []Matcher         `json:"matchers,omitempty" yaml:"matchers,omitempty"`
+}
+
+// ActionSpec describes one notification target of a route. Type is one of
+// webhook, script, email, slack, msteams, pagerduty or opsgenie. For slack
+// and msteams URL is the incoming webhook; for pagerduty and opsgenie it
+// optionally overrides the vendor's API endpoint (e.g. Opsgenie's EU region).
+// Email actions are sent through the server's SMTP settings.
+//
+// Subject, Body, TextBody, HTMLBody and the Headers values are Go templates
+// executed for each notification. Subject replaces the title of chat and
+// incident notifications; Body replaces the JSON payload of webhooks and
+// scripts and the message text of the integrations. Email actions fall back
+// to built-in templates, with Body standing in for an empty TextBody.
+type ActionSpec struct {
+	Type       string            `json:"type" yaml:"type"`
+	URL        string            `json:"url,omitempty" yaml:"url,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Command    string            `json:"command,omitempty" yaml:"command,omitempty"`
+	Args       []string          `json:"args,omitempty" yaml:"args,omitempty"`
+	Channel    string            `json:"channel,omitempty" yaml:"channel,omitempty"`         // slack: overrides the webhook's channel
+	RoutingKey string            `json:"routing_key,omitempty" yaml:"routing_key,omitempty"` // pagerduty: Events v2 integration key
+	APIKey     string            `json:"api_key,omitempty" yaml:"api_key,omitempty"`         // opsgenie: API integration key
+	Body       string            `json:"body,omitempty" yaml:"body,omitempty"`               // payload or message text template
+	To         []string          `json:"to,omitempty" yaml:"to,omitempty"`                   // email: recipient addresses
+	Subject    string            `json:"subject,omitempty" yaml:"subject,omitempty"`         // subject or title template
+	TextBody   string            `json:"text_body,omitempty" yaml:"text_body,omitempty"`     // email: text/plain body template
+	HTMLBody   string            `json:"html_body,omitempty" yaml:"html_body,omitempty"`     // email: text/html body template
+	Retry      *RetryPolicy      `json:"retry,omitempty" yaml:"retry,omitempty"`             // overrides the server's retry settings
+}
+
+// Validate checks that the inhibition rule has both matchers and that their
+// regexes compile.
+func (r InhibitRule) Validate() error {
+	if len(r.SourceMatchers) == 0 || len(r.TargetMatchers) == 0 {
+		return fmt.Errorf("inhibit rule needs source_matchers and target_matchers")
+	}
+	for _, m := range append(append([]Matcher{}, r.SourceMatchers...), r.TargetMatchers...) {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package alertmodel defines the server-side alerting types used by the rule
+// stores, the rules engine and the alert manager. AlertRule mirrors the shared
+// model.AlertRule field-for-field (same JSON and YAML keys) so existing rule
+// files and API clients keep working, and adds the evaluation settings that
+// only the server needs.
+package alertmodel
+
+import "time"
+
+// AlertRule defines a single alerting rule: what to match, what to evaluate
+// and what to do when the expression holds.
+type AlertRule struct {
+	ID          string        `json:"id" yaml:"id"`
+	Name        string        `json:"name" yaml:"name"`
+	Description string        `json:"description,omitempty" yaml:"description,omitempty"`
+	Message     string        `json:"message" yaml:"message"`
+	Level       string        `json:"level" yaml:"level"`
+	Enabled     bool          `json:"enabled" yaml:"enabled"`
+	Type        string        `json:"type" yaml:"type"`
+	Match       MatchCriteria `json:"match" yaml:"match"`
+	Scope       Scope         `json:"scope" yaml:"scope"`
+	Expression  Expression    `json:"expression" yaml:"expression"`
+	Actions     []string      `json:"actions" yaml:"actions"`
+	Options     Options       `json:"options" yaml:"options"`
+	Composite   *Composite    `json:"composite,omitempty" yaml:"composite,omitempty"`
+	Threshold   *Threshold    `json:"threshold,omitempty" yaml:"threshold,omitempty"`
+}
+
+// MatchCriteria selects the endpoints and sources a rule applies to.
+type MatchCriteria struct {
+	EndpointIDs []string          `json:"endpoint_ids,omitempty" yaml:"endpoint_ids,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Category    string            `json:"category,omitempty" yaml:"category,omitempty"`
+	Source      string            `json:"source,omitempty" yaml:"source,omitempty"`
+	Scope       string            `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Target      string            `json:"target,omitempty" yaml:"target,omitempty"`
+}
+
+// Scope identifies the metric a metric rule is evaluated against.
+// When Window is set the rule looks at the metric's history over that
+// window (queried from the metric store every Step) instead of the
+// latest sample.
+type Scope struct {
+	Namespace    string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	SubNamespace string `json:"subnamespace,omitempty" yaml:"subnamespace,omitempty"`
+	Metric       string `json:"metric,omitempty" yaml:"metric,omitempty"`
+	Window       string `json:"window,omitempty" yaml:"window,omitempty"`
+	Step         string `json:"step,omitempty" yaml:"step,omitempty"`
+}
+
+// Expression is the condition a rule checks, e.g. "> 90" or "contains foo".
+// Function reduces the samples in the scope's window to the value that is
+// compared: avg, min, max, sum, count, last, rate, increase, a percentile
+// such as p95, or absent (fires when the window holds no samples at all).
+//
+// Field selects what a log rule checks using a dotted path: a LogEntry field
+// such as "message" or "severity_number", or a key under "fields.",
+// "labels.", "attributes." (nested maps are walked) or "meta.".
+type Expression struct {
+	Operator string      `json:"operator" yaml:"operator"`
+	Value    interface{} `json:"value" yaml:"value"`
+	Datatype string      `json:"datatype,omitempty" yaml:"datatype,omitempty"`
+	Function string      `json:"function,omitempty" yaml:"function,omitempty"`
+	Field    string      `json:"field,omitempty" yaml:"field,omitempty"`
+}
+
+// Threshold turns a log rule into a rate rule: instead of alerting on every
+// matching line, it fires when more than Count lines from the same endpoint
+// match within Window, and resolves once the count drops back to Count or
+// below.
+type Threshold struct {
+	Count  int    `json:"count" yaml:"count"`
+	Window string `json:"window" yaml:"window"`
+}
+
+// Composite combines several conditions into a single rule of type
+// "composite". Conditions are tracked per endpoint: with Operator "and"
+// every condition must have held within Window, with "or" any one of them.
+type Composite struct {
+	Operator   string      `json:"operator" yaml:"operator"`
+	Window     string      `json:"window,omitempty" yaml:"window,omitempty"`
+	Conditions []Condition `json:"conditions" yaml:"conditions"`
+}
+
+// Condition is one child of a composite rule. It either references another
+// rule by RuleID (holding while that rule fires for the endpoint) or is an
+// inline metric or log check using the same Scope and Expression semantics
+// as a standalone rule. Match narrows log conditions by category and source.
+type Condition struct {
+	Name       string        `json:"name,omitempty" yaml:"name,omitempty"`
+	RuleID     string        `json:"rule_id,omitempty" yaml:"rule_id,omitempty"`
+	Type       string        `json:"type,omitempty" yaml:"type,omitempty"`
+	Match      MatchCriteria `json:"match,omitempty" yaml:"match,omitempty"`
+	Scope      Scope         `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Expression Expression    `json:"expression,omitempty" yaml:"expression,omitempty"`
+}
+
+// Options holds the timing and notification settings of a rule.
+// All durations are Go duration strings ("30s", "5m").
+//
+// For is how long the expression must hold before a pending alert starts
+// firing. KeepFiringFor is how long a firing alert stays firing after the
+// expression stops holding, which smooths over flapping values.
+type Options struct {
+	Cooldown        string `json:"cooldown,omitempty" yaml:"cooldown,omitempty"`
+	EvalInterval    string `json:"eval_interval,omitempty" yaml:"eval_interval,omitempty"`
+	RepeatInterval  string `json:"repeat_interval,omitempty" yaml:"repeat_interval,omitempty"`
+	NotifyOnResolve bool   `json:"notify_on_resolve,omitempty" yaml:"notify_on_resolve,omitempty"`
+	For             string `json:"for,omitempty" yaml:"for,omitempty"`
+	KeepFiringFor   string `json:"keep_firing_for,omitempty" yaml:"keep_firing_for,omitempty"`
+}
+
+// ParseDuration parses an option duration string.
+// Empty or invalid values yield zero, which disables the option; Validate
+// rejects invalid values before a rule is stored, so only rules that were
+// never validated reach the fallback.
+func ParseDuration(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,208 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alertmodel
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// maxWindowDuration bounds recurring maintenance windows.
+const maxWindowDuration = 7 * 24 * time.Hour
+
+// Silence mutes notifications for alerts whose labels match all of its
+// matchers. A one-off silence is active between StartsAt and EndsAt. A
+// recurring maintenance window sets Schedule (five-field cron, evaluated in
+// Timezone) and Duration: it is open for Duration after every time the
+// schedule fires, optionally bounded by StartsAt and EndsAt.
+type Silence struct {
+	ID        string    `json:"id" yaml:"id"`
+	Matchers  []Matcher `json:"matchers" yaml:"matchers"`
+	StartsAt  time.Time `json:"starts_at" yaml:"starts_at"`
+	EndsAt    time.Time `json:"ends_at,omitempty" yaml:"ends_at,omitempty"`
+	Schedule  string    `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	Duration  string    `json:"duration,omitempty" yaml:"duration,omitempty"`
+	Timezone  string    `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	CreatedBy string    `json:"created_by" yaml:"created_by"`
+	Comment   string    `json:"comment" yaml:"comment"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+}
+
+// Matcher matches one alert label. Besides the alert's own labels, the
+// names rule_id, endpoint_id, level, scope and target are available.
+// Regex values must match the whole label; Negate inverts the match, so
+// {name: env, value: prod, negate: true} matches every other environment.
+type Matcher struct {
+	Name   string `json:"name" yaml:"name"`
+	Value  string `json:"value" yaml:"value"`
+	Regex  bool   `json:"regex,omitempty" yaml:"regex,omitempty"`
+	Negate bool   `json:"negate,omitempty" yaml:"negate,omitempty"`
+
+	re *regexp.Regexp // Value compiled when the matcher is decoded
+}
+
+// Validate checks that the silence can ever match and has a sensible time
+// range or schedule.
+func (s Silence) Validate() error {
+	if len(s.Matchers) == 0 {
+		return fmt.Errorf("at least one matcher is required")
+	}
+	for _, m := range s.Matchers {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if s.Schedule == "" {
+		if s.EndsAt.IsZero() || !s.EndsAt.After(s.StartsAt) {
+			return fmt.Errorf("ends_at must be after starts_at")
+		}
+		return nil
+	}
+
+	if _, err := ParseCron(s.Schedule); err != nil {
+		return err
+	}
+	d := ParseDuration(s.Duration)
+	if d <= 0 || d > maxWindowDuration {
+		return fmt.Errorf("duration must be between 1m and %s", maxWindowDuration)
+	}
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", s.Timezone)
+		}
+	}
+	return nil
+}
+
+// ActiveAt reports whether the silence mutes alerts at t.
+func (s Silence) ActiveAt(t time.Time) bool {
+	if !s.StartsAt.IsZero() && t.Before(s.StartsAt) {
+		return false
+	}
+	if !s.EndsAt.IsZero() && !t.Before(s.EndsAt) {
+		return false
+	}
+	if s.Schedule == "" {
+		return true
+	}
+
+	cron, err := ParseCron(s.Schedule)
+	if err != nil {
+		return false
+	}
+	loc := time.UTC
+	if s.Timezone != "" {
+		if l, err := time.LoadLocation(s.Timezone); err == nil {
+			loc = l
+		}
+	}
+	d := ParseDuration(s.Duration)
+	last := cron.Prev(t.In(loc), t.Add(-d))
+	return !last.IsZero() && t.Sub(last) < d
+}
+
+// Expired reports whether the silence can no longer become active.
+func (s Silence) Expired(t time.Time) bool {
+	return !s.EndsAt.IsZero() && !t.Before(s.EndsAt)
+}
+
+// Matches reports whether all matchers match the given alert labels.
+func (s Silence) Matches(labels map[string]string) bool {
+	return MatchLabels(s.Matchers, labels)
+}
+
+// Validate checks that the matcher has a name and that its regex compiles.
+func (m Matcher) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("matcher name is required")
+	}
+	if m.Regex {
+		if _, err := m.compile(); err != nil {
+			return fmt.Errorf("matcher %s: bad regex: %v", m.Name, err)
+		}
+	}
+	return nil
+}
+
+// MatchLabels reports whether all matchers match the given labels.
+func MatchLabels(matchers []Matcher, labels map[string]string) bool {
+	for _, m := range matchers {
+		if !m.Matches(labels[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether the matcher matches a label value. A regex that
+// doesn't compile matches nothing, negated or not.
+func (m Matcher) Matches(val string) bool {
+	if !m.Regex {
+		return (val == m.Value) != m.Negate
+	}
+	re := m.re
+	if re == nil {
+		var err error
+		if re, err = m.compile(); err != nil {
+			return false
+		}
+	}
+	return re.MatchString(val) != m.Negate
+}
+
+// compile compiles the matcher's value anchored to the whole label.
+func (m Matcher) compile() (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + m.Value + ")$")
+}
+
+// UnmarshalJSON decodes a matcher and compiles its regex once, so matching
+// does not compile it again for every label.
+func (m *Matcher) UnmarshalJSON(data []byte) error {
+	type plain Matcher
+	if err := json.Unmarshal(data, (*plain)(m)); err != nil {
+		return err
+	}
+	m.cache()
+	return nil
+}
+
+// UnmarshalYAML is UnmarshalJSON for YAML.
+func (m *Matcher) UnmarshalYAML(unmarshal func(any) error) error {
+	type plain Matcher
+	if err := unmarshal((*plain)(m)); err != nil {
+		return err
+	}
+	m.cache()
+	return nil
+}
+
+// cache keeps the compiled regex on the matcher. An invalid regex is left
+// for Validate to report.
+func (m *Matcher) cache() {
+	m.re = nil
+	if m.Regex {
+		m.re, _ = m.compile()
+	}
+}
@@ -0,0 +1,155 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alertmodel
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSilenceActiveAt(t *testing.T) {
+	start := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	oneOff := Silence{StartsAt: start, EndsAt: start.Add(time.Hour)}
+	assert.False(t, oneOff.ActiveAt(start.Add(-time.Second)))
+	assert.True(t, oneOff.ActiveAt(start))
+	assert.True(t, oneOff.ActiveAt(start.Add(59*time.Minute)))
+	assert.False(t, oneOff.ActiveAt(start.Add(time.Hour)), "ends_at is exclusive")
+}
+
+func TestRecurringSilenceActiveAt(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// Saturdays 22:00 to 02:00 Berlin time.
+	window := Silence{Schedule: "0 22 * * 6", Duration: "4h", Timezone: "Europe/Berlin"}
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 10, day, hour, min, 0, 0, berlin)
+	}
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"before it opens", at(17, 21, 59), false},
+		{"as it opens", at(17, 22, 0), true},
+		{"past midnight", at(18, 1, 59), true},
+		{"as it closes", at(18, 2, 0), false},
+		{"on another day", at(14, 23, 0), false},
+		{"a week later", at(24, 23, 30), true},
+		{"over the daylight saving change", at(25, 1, 30), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, window.ActiveAt(tt.t))
+		})
+	}
+
+	// Evaluated in UTC the same schedule opens two hours later in summer.
+	utc := window
+	utc.Timezone = ""
+	assert.False(t, utc.ActiveAt(at(17, 22, 30)))
+	assert.True(t, utc.ActiveAt(at(18, 0, 30)))
+
+	// StartsAt and EndsAt bound the recurrences.
+	bounded := window
+	bounded.StartsAt = at(20, 0, 0)
+	bounded.EndsAt = at(25, 0, 0)
+	assert.False(t, bounded.ActiveAt(at(17, 23, 0)), "before starts_at")
+	assert.True(t, bounded.ActiveAt(at(24, 23, 0)))
+	assert.False(t, bounded.ActiveAt(at(25, 1, 0)), "after ends_at")
+	assert.True(t, bounded.Expired(at(25, 0, 0)))
+}
+
+func TestSilenceValidate(t *testing.T) {
+	matchers := []Matcher{{Name: "env", Value: "prod"}}
+	now := time.Now()
+	tests := []struct {
+		name    string
+		silence Silence
+		wantErr bool
+	}{
+		{"one-off", Silence{Matchers: matchers, StartsAt: now, EndsAt: now.Add(time.Hour)}, false},
+		{"no matchers", Silence{StartsAt: now, EndsAt: now.Add(time.Hour)}, true},
+		{"ends before it starts", Silence{Matchers: matchers, StartsAt: now, EndsAt: now.Add(-time.Hour)}, true},
+		{"bad regex", Silence{Matchers: []Matcher{{Name: "env", Value: "(", Regex: true}}, StartsAt: now, EndsAt: now.Add(time.Hour)}, true},
+		{"recurring", Silence{Matchers: matchers, Schedule: "0 2 * * *", Duration: "1h", Timezone: "Europe/Berlin"}, false},
+		{"bad schedule", Silence{Matchers: matchers, Schedule: "0 2 * *", Duration: "1h"}, true},
+		{"no duration", Silence{Matchers: matchers, Schedule: "0 2 * * *"}, true},
+		{"duration over a week", Silence{Matchers: matchers, Schedule: "0 2 * * *", Duration: "8d"}, true},
+		{"unknown timezone", Silence{Matchers: matchers, Schedule: "0 2 * * *", Duration: "1h", Timezone: "Mars/Olympus"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.silence.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMatcherMatches(t *testing.T) {
+	tests := []struct {
+		matcher Matcher
+		value   string
+		want    bool
+	}{
+		{Matcher{Value: "prod"}, "prod", true},
+		{Matcher{Value: "prod"}, "production", false},
+		{Matcher{Value: "prod", Negate: true}, "staging", true},
+		{Matcher{Value: "prod|staging", Regex: true}, "staging", true},
+		{Matcher{Value: "prod", Regex: true}, "production", false}, // anchored
+		{Matcher{Value: "web-.*", Regex: true, Negate: true}, "db-1", true},
+		{Matcher{Value: "(", Regex: true}, "(", false},
+		{Matcher{Value: "(", Regex: true, Negate: true}, "x", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.matcher.Matches(tt.value), "%+v on %q", tt.matcher, tt.value)
+	}
+}
+
+func TestDecodedMatcherKeepsCompiledRegex(t *testing.T) {
+	var fromJSON Silence
+	require.NoError(t, json.Unmarshal([]byte(`{"matchers":[{"name":"host","value":"web-[0-9]+","regex":true},{"name":"env","value":"prod"}]}`), &fromJSON))
+	require.Len(t, fromJSON.Matchers, 2)
+	assert.NotNil(t, fromJSON.Matchers[0].re)
+	assert.Nil(t, fromJSON.Matchers[1].re)
+	assert.True(t, fromJSON.Matches(map[string]string{"host": "web-12", "env": "prod"}))
+	assert.False(t, fromJSON.Matches(map[string]string{"host": "web-x", "env": "prod"}))
+
+	var fromYAML InhibitRule
+	require.NoError(t, yaml.Unmarshal([]byte("source_matchers:\n  - {name: level, value: crit|error, regex: true}\ntarget_matchers:\n  - {name: level, value: warning}\n"), &fromYAML))
+	require.Len(t, fromYAML.SourceMatchers, 1)
+	assert.NotNil(t, fromYAML.SourceMatchers[0].re)
+	assert.True(t, MatchLabels(fromYAML.SourceMatchers, map[string]string{"level": "crit"}))
+
+	// The compiled regex is not serialized.
+	data, err := json.Marshal(fromJSON.Matchers[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"host","value":"web-[0-9]+","regex":true}`, string(data))
+}
@@ -0,0 +1,37 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alertmodel
+
+// Alert instance states. An instance starts pending while its rule's
+// "for" duration elapses, then fires, and finally resolves. A pending
+// instance whose expression stops holding before it fires goes back to ok.
+// A firing instance that matches an active silence is recorded as silenced
+// instead, and one suppressed by an inhibition rule as inhibited; no
+// notifications are sent for either.
+const (
+	StateOK        = "ok"
+	StatePending   = "pending"
+	StateFiring    = "firing"
+	StateSilenced  = "silenced"
+	StateInhibited = "inhibited"
+	StateResolved  = "resolved"
+)
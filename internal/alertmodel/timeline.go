@@ -0,0 +1,67 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alertmodel
+
+import "time"
+
+// Timeline actions taken by users. Automatic transitions are recorded with
+// the alert state they moved to as the action.
+const (
+	ActionAcknowledged   = "acknowledged"
+	ActionUnacknowledged = "unacknowledged"
+	ActionAssigned       = "assigned"
+)
+
+// TimelineEntry is one change in the life of an alert instance: a state
+// transition made by the evaluator, or an acknowledgement, assignment or
+// manual resolve made by a user.
+type TimelineEntry struct {
+	AlertID   string    `json:"alert_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Action    string    `json:"action"`             // an alert state or one of the Action constants
+	State     string    `json:"state"`              // alert state after the change
+	Actor     string    `json:"actor,omitempty"`    // user who made the change; empty for automatic transitions
+	Assignee  string    `json:"assignee,omitempty"` // for ActionAssigned
+	Comment   string    `json:"comment,omitempty"`
+}
+
+// Workflow is what users have done with an open alert: who acknowledged it
+// and who it is assigned to. It is kept out of the alert's labels, which
+// decide how the alert is grouped, routed, silenced and inhibited, and can
+// be rebuilt from the alert's timeline.
+type Workflow struct {
+	AcknowledgedBy string `json:"acknowledged_by,omitempty"`
+	Assignee       string `json:"assignee,omitempty"`
+}
+
+// Apply updates the workflow with a timeline entry. Entries other than
+// acknowledgements and assignments leave it unchanged.
+func (w *Workflow) Apply(e TimelineEntry) {
+	switch e.Action {
+	case ActionAcknowledged:
+		w.AcknowledgedBy = e.Actor
+	case ActionUnacknowledged:
+		w.AcknowledgedBy = ""
+	case ActionAssigned:
+		w.Assignee = e.Assignee
+	}
+}
@@ -0,0 +1,231 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alertmodel
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRule is wrapped by the errors Validate returns, so callers can
+// tell a rejected rule apart from a storage failure.
+var ErrInvalidRule = errors.New("invalid rule")
+
+// Validate checks the parts of a rule that would otherwise only fail,
+// silently, at evaluation time: the rule type and composite operator must be
+// known, durations must parse and not be negative, window functions must be
+// known, regex patterns must compile, in/not_in need a list and ordered
+// comparisons need a number. Every rule store calls it before persisting a
+// rule.
+func (r AlertRule) Validate() error {
+	switch r.Type {
+	case "metric", "log", "event", "composite":
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidRule, r.Type)
+	}
+	for _, d := range []struct{ name, value string }{
+		{"for", r.Options.For},
+		{"keep_firing_for", r.Options.KeepFiringFor},
+		{"cooldown", r.Options.Cooldown},
+		{"eval_interval", r.Options.EvalInterval},
+		{"repeat_interval", r.Options.RepeatInterval},
+	} {
+		if err := checkDuration(d.value); err != nil {
+			return fmt.Errorf("%w: options.%s: %v", ErrInvalidRule, d.name, err)
+		}
+	}
+	if err := r.Scope.validate(); err != nil {
+		return fmt.Errorf("%w: scope.%v", ErrInvalidRule, err)
+	}
+	if err := r.Expression.validate(); err != nil {
+		return fmt.Errorf("%w: expression: %v", ErrInvalidRule, err)
+	}
+	if r.Threshold != nil {
+		if err := checkDuration(r.Threshold.Window); err != nil {
+			return fmt.Errorf("%w: threshold.window: %v", ErrInvalidRule, err)
+		}
+		if ParseDuration(r.Threshold.Window) == 0 {
+			return fmt.Errorf("%w: threshold needs a positive window", ErrInvalidRule)
+		}
+	}
+	if r.Composite != nil {
+		switch strings.ToLower(r.Composite.Operator) {
+		case "and", "or":
+		default:
+			return fmt.Errorf("%w: composite.operator must be and or or, not %q", ErrInvalidRule, r.Composite.Operator)
+		}
+		if err := checkDuration(r.Composite.Window); err != nil {
+			return fmt.Errorf("%w: composite.window: %v", ErrInvalidRule, err)
+		}
+		for i, cond := range r.Composite.Conditions {
+			if err := cond.Scope.validate(); err != nil {
+				return fmt.Errorf("%w: condition %d: scope.%v", ErrInvalidRule, i+1, err)
+			}
+			if err := cond.Expression.validate(); err != nil {
+				return fmt.Errorf("%w: condition %d: %v", ErrInvalidRule, i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks the window and step of a scope.
+func (s Scope) validate() error {
+	if err := checkDuration(s.Window); err != nil {
+		return fmt.Errorf("window: %v", err)
+	}
+	if err := checkDuration(s.Step); err != nil {
+		return fmt.Errorf("step: %v", err)
+	}
+	return nil
+}
+
+// checkDuration reports why a duration option can't be used. Empty values
+// are fine; they leave the option unset.
+func checkDuration(s string) error {
+	if s == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("bad duration %q", s)
+	}
+	if d < 0 {
+		return fmt.Errorf("negative duration %q", s)
+	}
+	return nil
+}
+
+// ValidFunction reports whether fn is a supported window function.
+func ValidFunction(fn string) bool {
+	switch strings.ToLower(fn) {
+	case "", "avg", "min", "max", "sum", "count", "last", "rate", "increase", "absent":
+		return true
+	}
+	_, err := Percentile(fn)
+	return err == nil
+}
+
+// Percentile parses a percentile function such as "p95" or "p99.9".
+func Percentile(fn string) (float64, error) {
+	fn = strings.ToLower(fn)
+	if !strings.HasPrefix(fn, "p") {
+		return 0, fmt.Errorf("not a percentile: %q", fn)
+	}
+	p, err := strconv.ParseFloat(fn[1:], 64)
+	if err != nil || p <= 0 || p > 100 {
+		return 0, fmt.Errorf("invalid percentile: %q", fn)
+	}
+	return p, nil
+}
+
+// validate checks a single expression.
+func (e Expression) validate() error {
+	if !ValidFunction(e.Function) {
+		return fmt.Errorf("unknown function %q", e.Function)
+	}
+	switch strings.ToLower(e.Operator) {
+	case "regex", "not_regex":
+		pattern, ok := e.Value.(string)
+		if !ok {
+			return fmt.Errorf("regex value must be a string, got %T", e.Value)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("bad regex %q: %v", pattern, err)
+		}
+	case "in", "not_in":
+		if len(ValueList(e.Value)) == 0 {
+			return fmt.Errorf("%s needs a non-empty list value", e.Operator)
+		}
+	case ">", "<", ">=", "<=":
+		if _, ok := ParseNumber(e.Value); !ok {
+			return fmt.Errorf("%s needs a numeric value, got %v", e.Operator, e.Value)
+		}
+	}
+	return nil
+}
+
+// ValueList returns an expression value as a list of strings. It accepts a
+// YAML/JSON list or a comma-separated string.
+func ValueList(v interface{}) []string {
+	switch val := v.(type) {
+	case []string:
+		return val
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			out = append(out, FormatValue(item))
+		}
+		return out
+	case string:
+		if strings.TrimSpace(val) == "" {
+			return nil
+		}
+		parts := strings.Split(val, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		return parts
+	default:
+		return nil
+	}
+}
+
+// ParseNumber returns an expression value or extracted field as a float.
+func ParseNumber(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case uint32:
+		return float64(val), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// FormatValue renders a value for string comparison without the fixed
+// precision formatting used for metric values, so 500 stays "500".
+func FormatValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
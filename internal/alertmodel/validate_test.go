@@ -0,0 +1,118 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alertmodel
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertRuleValidate(t *testing.T) {
+	windowed := func(edit func(r *AlertRule)) AlertRule {
+		r := AlertRule{
+			ID:         "cpu",
+			Type:       "metric",
+			Scope:      Scope{Namespace: "system", SubNamespace: "cpu", Metric: "usage_percent", Window: "5m"},
+			Expression: Expression{Operator: ">", Value: 90, Function: "avg"},
+		}
+		edit(&r)
+		return r
+	}
+	composite := func(edit func(c *Composite)) AlertRule {
+		c := &Composite{
+			Operator: "and",
+			Window:   "5m",
+			Conditions: []Condition{
+				{RuleID: "cpu"},
+				{Type: "metric", Scope: Scope{Metric: "mem", Window: "1m"}, Expression: Expression{Operator: ">", Value: 80, Function: "max"}},
+			},
+		}
+		edit(c)
+		return AlertRule{ID: "both", Type: "composite", Composite: c}
+	}
+
+	tests := []struct {
+		name    string
+		rule    AlertRule
+		wantErr string
+	}{
+		{"valid windowed rule", windowed(func(r *AlertRule) {}), ""},
+		{"all options set", windowed(func(r *AlertRule) {
+			r.Options = Options{For: "1m", KeepFiringFor: "2m", Cooldown: "30s", EvalInterval: "15s", RepeatInterval: "1h"}
+			r.Scope.Step = "10s"
+		}), ""},
+		{"percentile function", windowed(func(r *AlertRule) { r.Expression.Function = "p99.9" }), ""},
+		{"function is case-insensitive", windowed(func(r *AlertRule) { r.Expression.Function = "AVG" }), ""},
+		{"valid composite", composite(func(c *Composite) {}), ""},
+		{"operator is case-insensitive", composite(func(c *Composite) { c.Operator = "OR" }), ""},
+		{"log rule", AlertRule{ID: "logs", Type: "log"}, ""},
+		{"event rule", AlertRule{ID: "offline", Type: "event"}, ""},
+
+		{"missing type", AlertRule{ID: "cpu"}, "unknown type"},
+		{"unknown type", windowed(func(r *AlertRule) { r.Type = "trace" }), `unknown type "trace"`},
+		{"type is case-sensitive", windowed(func(r *AlertRule) { r.Type = "Metric" }), "unknown type"},
+		{"missing composite operator", composite(func(c *Composite) { c.Operator = "" }), "composite.operator"},
+		{"unknown composite operator", composite(func(c *Composite) { c.Operator = "xor" }), `composite.operator must be and or or, not "xor"`},
+
+		{"bad for", windowed(func(r *AlertRule) { r.Options.For = "five minutes" }), "options.for"},
+		{"negative for", windowed(func(r *AlertRule) { r.Options.For = "-1m" }), "options.for"},
+		{"bad keep_firing_for", windowed(func(r *AlertRule) { r.Options.KeepFiringFor = "1x" }), "options.keep_firing_for"},
+		{"negative cooldown", windowed(func(r *AlertRule) { r.Options.Cooldown = "-30s" }), "options.cooldown"},
+		{"bad eval_interval", windowed(func(r *AlertRule) { r.Options.EvalInterval = "often" }), "options.eval_interval"},
+		{"bad repeat_interval", windowed(func(r *AlertRule) { r.Options.RepeatInterval = "10" }), "options.repeat_interval"},
+		{"bad scope window", windowed(func(r *AlertRule) { r.Scope.Window = "5 min" }), "scope.window"},
+		{"negative scope step", windowed(func(r *AlertRule) { r.Scope.Step = "-10s" }), "scope.step"},
+		{"unknown function", windowed(func(r *AlertRule) { r.Expression.Function = "median" }), "unknown function"},
+		{"percentile out of range", windowed(func(r *AlertRule) { r.Expression.Function = "p101" }), "unknown function"},
+		{"bad composite window", composite(func(c *Composite) { c.Window = "soon" }), "composite.window"},
+		{"negative composite window", composite(func(c *Composite) { c.Window = "-5m" }), "composite.window"},
+		{"bad condition window", composite(func(c *Composite) { c.Conditions[1].Scope.Window = "1 minute" }), "condition 2: scope.window"},
+		{"unknown condition function", composite(func(c *Composite) { c.Conditions[1].Expression.Function = "mode" }), "condition 2: unknown function"},
+		{"bad threshold window", AlertRule{ID: "errors", Type: "log", Threshold: &Threshold{Count: 5, Window: "1 hour"}}, "threshold.window"},
+		{"zero threshold window", AlertRule{ID: "errors", Type: "log", Threshold: &Threshold{Count: 5}}, "positive window"},
+		{"bad regex", AlertRule{ID: "logs", Type: "log", Expression: Expression{Operator: "regex", Value: "("}}, "bad regex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.True(t, errors.Is(err, ErrInvalidRule))
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidFunction(t *testing.T) {
+	for _, fn := range []string{"", "avg", "min", "max", "sum", "count", "last", "rate", "increase", "absent", "p50", "P95", "p99.9", "p100"} {
+		assert.True(t, ValidFunction(fn), fn)
+	}
+	for _, fn := range []string{"median", "p0", "p101", "p", "pxx", "average"} {
+		assert.False(t, ValidFunction(fn), fn)
+	}
+}
@@ -29,12 +29,15 @@ package alerts
 
 import (
 	"context"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
 	"github.com/aaronlmathis/gosight-server/internal/core/events/dispatcher"
 	"github.com/aaronlmathis/gosight-server/internal/events"
 	"github.com/aaronlmathis/gosight-server/internal/store/alertstore"
+	"github.com/aaronlmathis/gosight-server/internal/store/silencestore"
 	"github.com/aaronlmathis/gosight-server/internal/websocket"
 	"github.com/aaronlmathis/gosight-shared/model"
 	"github.com/aaronlmathis/gosight-shared/utils"
@@ -47,23 +50,48 @@ import (
 type Manager struct {
 	lock       sync.RWMutex
 	active     map[string]*model.AlertInstance // key: ruleID|endpointID
+	pending    map[string]*model.AlertInstance // key: ruleID|endpointID
+	logAlerts  map[string]*model.AlertInstance // key: ruleID|endpointID|log timestamp
+	events     map[string]*eventAlert          // key: ruleID|target
+	workflow   map[string]alertmodel.Workflow  // key: alert ID of a tracked alert
+	rules      map[string]alertmodel.AlertRule // key: rule ID; the rule as last evaluated, for closing its alerts
 	emitter    *events.Emitter
 	dispatcher *dispatcher.Dispatcher
 	store      alertstore.AlertStore
+	silences   silencestore.SilenceStore
+	inhibit    []alertmodel.InhibitRule
 	hub        *websocket.AlertsHub
+
+	listenerLock sync.RWMutex
+	listeners    []StateListener
+}
+
+// StateListener is told of every change recorded on an alert's timeline:
+// state transitions as well as acknowledgements, assignments and manual
+// resolves. It is called synchronously, in order, while the manager holds its
+// lock, so it must not block or call back into the manager.
+type StateListener interface {
+	OnAlertChange(ctx context.Context, inst model.AlertInstance, entry alertmodel.TimelineEntry)
 }
 
 // NewManager creates a new Manager instance.
 // It takes an emitter for emitting events, a dispatcher for triggering actions,
-// a store for persisting alert instances, and a hub for broadcasting alerts.
+// a store for persisting alert instances, a store of silences that mute notifications,
+// and a hub for broadcasting alerts.
 // The manager maintains a map of active alert instances, keyed by rule ID and endpoint ID.
 // It also provides methods for handling alert state changes and listing active alerts.
-func NewManager(emitter *events.Emitter, dispatcher *dispatcher.Dispatcher, store alertstore.AlertStore, hub *websocket.AlertsHub) *Manager {
+func NewManager(emitter *events.Emitter, dispatcher *dispatcher.Dispatcher, store alertstore.AlertStore, silences silencestore.SilenceStore, hub *websocket.AlertsHub) *Manager {
 	return &Manager{
 		active:     make(map[string]*model.AlertInstance),
+		pending:    make(map[string]*model.AlertInstance),
+		logAlerts:  make(map[string]*model.AlertInstance),
+		events:     make(map[string]*eventAlert),
+		workflow:   make(map[string]alertmodel.Workflow),
+		rules:      make(map[string]alertmodel.AlertRule),
 		emitter:    emitter,
 		dispatcher: dispatcher,
 		store:      store,
+		silences:   silences,
 		hub:        hub,
 	}
 }
@@ -74,40 +102,42 @@ func key(ruleID, endpointID string) string {
 	return ruleID + "|" + endpointID
 }
 
-// HandleState processes the state of an alert based on the given rule, metadata, value, and triggered status.
-func (m *Manager) HandleState(ctx context.Context, rule model.AlertRule, meta *model.Meta, value float64, triggered bool) {
+// HandleState processes the state of an alert based on the given rule, metadata, value and evaluated state.
+// The state is one of alertmodel.StatePending, alertmodel.StateFiring or alertmodel.StateOK.
+// A pending instance is persisted and broadcast but not dispatched; it is promoted to firing
+// (keeping its ID) once the rule's "for" duration has elapsed, or dropped back to ok if the
+// expression stops holding first. Firing instances resolve when the state returns to ok.
+// A firing alert that matches an active silence or is suppressed by an inhibition rule is
+// recorded as silenced or inhibited and nothing is dispatched for it; once the silence or
+// the inhibiting alert ends it goes back to firing and notifies as usual.
+// While firing, the alert is notified again every repeat_interval unless it has been
+// acknowledged. Every state change is added to the alert's timeline.
+func (m *Manager) HandleState(ctx context.Context, rule alertmodel.AlertRule, meta *model.Meta, value float64, state string) {
 	k := key(rule.ID, meta.EndpointID)
 	now := time.Now().UTC()
 
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
+	m.rules[rule.ID] = rule
 	current := m.active[k]
 
-	if triggered {
+	switch state {
+	case alertmodel.StatePending:
 		if current != nil {
-			if rule.Options.RepeatInterval != "" {
-				repeatDur, _ := time.ParseDuration(rule.Options.RepeatInterval)
-				if now.Sub(current.LastFired) < repeatDur {
-					return
-				}
-			}
-			current.LastFired = now
-			current.LastValue = value
-			_ = m.store.UpsertAlert(ctx, current)
-			m.hub.Broadcast(*current)
-			m.emitAlertFiringEvent(ctx, rule, meta, now)
-			m.dispatchFiringEvent(ctx, rule, meta, current.Target, now, current.Message)
+			return
+		}
+		if pending := m.pending[k]; pending != nil {
+			pending.LastValue = value
 			return
 		}
 
 		scope, target := inferScopeAndTarget(meta)
-
 		inst := &model.AlertInstance{
 			ID:         uuid.NewString(),
 			RuleID:     rule.ID,
-			State:      "firing",
-			Previous:   "ok",
+			State:      alertmodel.StatePending,
+			Previous:   alertmodel.StateOK,
 			Scope:      scope,
 			Target:     target,
 			FirstFired: now,
@@ -118,6 +148,68 @@ func (m *Manager) HandleState(ctx context.Context, rule model.AlertRule, meta *m
 			Message:    rule.Message,
 			Labels:     utils.SafeCopyLabels(meta),
 		}
+		m.pending[k] = inst
+		_ = m.store.UpsertAlert(ctx, inst)
+		m.hub.Broadcast(*inst)
+		m.recordTimeline(ctx, inst, alertmodel.StatePending, "", "")
+
+	case alertmodel.StateFiring:
+		if current != nil {
+			// A muted alert is checked every time so it notifies as soon as it is no longer muted.
+			wasMuted := muted(current)
+			if !wasMuted {
+				repeatDur := alertmodel.ParseDuration(rule.Options.RepeatInterval)
+				if repeatDur <= 0 || now.Sub(current.LastFired) < repeatDur {
+					return
+				}
+				if m.workflow[current.ID].AcknowledgedBy != "" {
+					return
+				}
+			}
+			current.LastFired = now
+			current.LastValue = value
+			if m.mute(ctx, current) {
+				m.recordTimeline(ctx, current, current.State, "", "")
+			}
+			if muted(current) || (wasMuted && m.workflow[current.ID].AcknowledgedBy != "") {
+				_ = m.store.UpsertAlert(ctx, current)
+				m.hub.Broadcast(*current)
+				return
+			}
+			_ = m.store.UpsertAlert(ctx, current)
+			m.hub.Broadcast(*current)
+			m.emitAlertFiringEvent(ctx, rule, meta, current, now)
+			m.dispatchFiringEvent(ctx, rule, meta, current, now, current.Message)
+			return
+		}
+
+		scope, target := inferScopeAndTarget(meta)
+
+		inst := m.pending[k]
+		if inst != nil {
+			delete(m.pending, k)
+			inst.State = alertmodel.StateFiring
+			inst.Previous = alertmodel.StatePending
+			inst.FirstFired = now
+			inst.LastFired = now
+			inst.LastValue = value
+		} else {
+			inst = &model.AlertInstance{
+				ID:         uuid.NewString(),
+				RuleID:     rule.ID,
+				State:      alertmodel.StateFiring,
+				Previous:   alertmodel.StateOK,
+				Scope:      scope,
+				Target:     target,
+				FirstFired: now,
+				LastFired:  now,
+				LastOK:     now,
+				LastValue:  value,
+				Level:      rule.Level,
+				Message:    rule.Message,
+				Labels:     utils.SafeCopyLabels(meta),
+			}
+		}
 
 		event := model.EventEntry{
 			Timestamp: now,
@@ -130,6 +222,16 @@ func (m *Manager) HandleState(ctx context.Context, rule model.AlertRule, meta *m
 			Meta:      utils.SafeCopyLabels(meta),
 		}
 		event.Meta["rule_id"] = rule.ID
+		setAlertMeta(event.Meta, inst)
+		event.Meta["alert_state"] = alertmodel.StateFiring
+
+		if m.mute(ctx, inst) {
+			m.active[k] = inst
+			_ = m.store.UpsertAlert(ctx, inst)
+			m.hub.Broadcast(*inst)
+			m.recordTimeline(ctx, inst, inst.State, "", "")
+			return
+		}
 
 		if len(rule.Actions) > 0 {
 			for _, actionID := range rule.Actions {
@@ -139,6 +241,7 @@ func (m *Manager) HandleState(ctx context.Context, rule model.AlertRule, meta *m
 			m.active[k] = inst
 			_ = m.store.UpsertAlert(ctx, inst)
 			m.hub.Broadcast(*inst)
+			m.recordTimeline(ctx, inst, alertmodel.StateFiring, "", "")
 			m.emitter.Emit(ctx, event)
 			return
 		}
@@ -146,32 +249,59 @@ func (m *Manager) HandleState(ctx context.Context, rule model.AlertRule, meta *m
 		m.active[k] = inst
 		_ = m.store.UpsertAlert(ctx, inst)
 		m.hub.Broadcast(*inst)
-		m.emitAlertFiringEvent(ctx, rule, meta, now)
-		m.dispatchFiringEvent(ctx, rule, meta, target, now, rule.Message)
-	} else {
+		m.recordTimeline(ctx, inst, alertmodel.StateFiring, "", "")
+		m.emitAlertFiringEvent(ctx, rule, meta, inst, now)
+		m.dispatchFiringEvent(ctx, rule, meta, inst, now, rule.Message)
+
+	default:
+		if pending := m.pending[k]; pending != nil {
+			delete(m.pending, k)
+			delete(m.workflow, pending.ID)
+			pending.State = alertmodel.StateOK
+			pending.Previous = alertmodel.StatePending
+			pending.LastOK = now
+			pending.LastValue = value
+			_ = m.store.UpsertAlert(ctx, pending)
+			m.hub.Broadcast(*pending)
+			m.recordTimeline(ctx, pending, alertmodel.StateOK, "", "")
+		}
 		if current != nil {
 			delete(m.active, k)
-			m.emitAlertResolvedEvent(ctx, rule, meta, now)
-			if rule.Options.NotifyOnResolve {
-				m.dispatchResolvedEvent(ctx, rule, meta, current.Target, now, "Resolved: "+rule.Message)
+			delete(m.workflow, current.ID)
+			if !muted(current) {
+				m.emitAlertResolvedEvent(ctx, rule, meta, current, now)
+				if rule.Options.NotifyOnResolve {
+					m.dispatchResolvedEvent(ctx, rule, meta, current, now, "Resolved: "+rule.Message)
+				} else {
+					_, target := inferScopeAndTarget(meta)
+					m.dispatcher.Withdraw(rule.ID, target)
+				}
 			}
 			_ = m.store.ResolveAlert(ctx, rule.ID, current.Target, now)
+			current.Previous = current.State
+			current.State = alertmodel.StateResolved
+			current.LastOK = now
+			current.LastValue = value
+			current.ResolvedAt = &now
 			m.hub.Broadcast(*current)
+			m.recordTimeline(ctx, current, alertmodel.StateResolved, "", "")
 		}
 	}
 }
 
 // HandleLogState processes the state of a log-based alert based on the given rule, metadata, log entry, and triggered status.
 // It creates a new alert instance if the alert is triggered and updates the existing instance if it is already firing.
-// It also emits an event for the log alert and triggers any actions associated with the rule.
+// It also emits an event for the log alert and triggers any actions associated with the rule,
+// unless the alert is silenced or inhibited.
 // The log entry is expected to be in the format of model.LogEntry, and the metadata is expected to be in the format of model.Meta.
-func (m *Manager) HandleLogState(ctx context.Context, rule model.AlertRule, meta *model.Meta, log model.LogEntry, triggered bool) {
+func (m *Manager) HandleLogState(ctx context.Context, rule alertmodel.AlertRule, meta *model.Meta, log model.LogEntry, triggered bool) {
 	k := key(rule.ID, meta.EndpointID+"|"+log.Timestamp.Format(time.RFC3339Nano))
 	now := time.Now().UTC()
 
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
+	m.rules[rule.ID] = rule
 	if triggered {
 		inst := &model.AlertInstance{
 			ID:         uuid.NewString(),
@@ -200,30 +330,127 @@ func (m *Manager) HandleLogState(ctx context.Context, rule model.AlertRule, meta
 			Meta:      utils.SafeCopyLabels(meta),
 		}
 		event.Meta["rule_id"] = rule.ID
+		setAlertMeta(event.Meta, inst)
+
+		if m.mute(ctx, inst) {
+			m.logAlerts[k] = inst
+			_ = m.store.UpsertAlert(ctx, inst)
+			m.hub.Broadcast(*inst)
+			m.recordTimeline(ctx, inst, inst.State, "", "")
+			return
+		}
 
 		if len(rule.Actions) > 0 {
 			for _, actionID := range rule.Actions {
 				m.dispatcher.TriggerActionByID(ctx, actionID, event)
 			}
-			m.active[k] = inst
+			m.logAlerts[k] = inst
 			_ = m.store.UpsertAlert(ctx, inst)
 			m.hub.Broadcast(*inst)
+			m.recordTimeline(ctx, inst, alertmodel.StateFiring, "", "")
 			m.emitter.Emit(ctx, event)
 			return
 		}
 
-		m.active[k] = inst
+		m.logAlerts[k] = inst
 		_ = m.store.UpsertAlert(ctx, inst)
 		m.hub.Broadcast(*inst)
-		m.emitLogAlertFiringEvent(ctx, rule, meta, log, now)
+		m.recordTimeline(ctx, inst, alertmodel.StateFiring, "", "")
+		m.emitter.Emit(ctx, event)
+		m.dispatcher.Dispatch(ctx, event)
 	}
 }
 
+// HandleEventState raises an alert for an event that matched an event rule.
+// Every matching event raises a new alert, which is sent to the rule's actions
+// or, when it has none, through the dispatcher's routes, unless it is silenced
+// or inhibited. The latest alert of a rule per target stays open, and can
+// inhibit other alerts, until the rule's keep_firing_for (default
+// defaultEventAlertTTL) passes, the target reports it is back up, or a newer
+// event replaces it; see HandleEventRecovery.
+func (m *Manager) HandleEventState(ctx context.Context, rule alertmodel.AlertRule, meta *model.Meta, source model.EventEntry) {
+	now := time.Now().UTC()
+
+	scope, target := source.Scope, source.Target
+	if scope == "" || target == "" {
+		scope, target = inferScopeAndTarget(meta)
+	}
+	message := rule.Message
+	if message == "" {
+		message = source.Message
+	} else if source.Message != "" {
+		message += ": " + source.Message
+	}
+
+	inst := &model.AlertInstance{
+		ID:         uuid.NewString(),
+		RuleID:     rule.ID,
+		State:      alertmodel.StateFiring,
+		Previous:   alertmodel.StateOK,
+		Scope:      scope,
+		Target:     target,
+		FirstFired: now,
+		LastFired:  now,
+		LastOK:     now,
+		Level:      rule.Level,
+		Message:    message,
+		Labels:     utils.SafeCopyLabels(meta),
+	}
+	if inst.Labels == nil {
+		inst.Labels = make(map[string]string)
+	}
+	if source.EndpointID != "" {
+		// The target may be an agent or container; inhibition rules compare endpoints.
+		inst.Labels["endpoint_id"] = source.EndpointID
+	}
+
+	event := model.EventEntry{
+		Timestamp:  now,
+		Level:      rule.Level,
+		Category:   "event_alert",
+		Message:    message,
+		Source:     source.Source,
+		Scope:      scope,
+		Target:     target,
+		EndpointID: source.EndpointID,
+		Meta:       utils.SafeCopyLabels(meta),
+	}
+	event.Meta["rule_id"] = rule.ID
+	setAlertMeta(event.Meta, inst)
+
+	m.lock.Lock()
+	m.rules[rule.ID] = rule
+	k := key(rule.ID, target)
+	if m.events[k] != nil {
+		m.closeEventAlert(ctx, k, now)
+	}
+	m.mute(ctx, inst)
+	m.events[k] = &eventAlert{inst: inst, expires: now.Add(eventAlertTTL(rule))}
+	m.lock.Unlock()
+	_ = m.store.UpsertAlert(ctx, inst)
+	m.hub.Broadcast(*inst)
+	m.recordTimeline(ctx, inst, inst.State, "", "")
+	if muted(inst) {
+		return
+	}
+
+	if len(rule.Actions) > 0 {
+		for _, actionID := range rule.Actions {
+			m.dispatcher.TriggerActionByID(ctx, actionID, event)
+		}
+		m.emitter.Emit(ctx, event)
+		return
+	}
+
+	m.emitter.Emit(ctx, event)
+	m.dispatcher.Dispatch(ctx, event)
+}
+
 // emitAlertFiringEvent emits an event for a firing alert.
-// It creates an EventEntry with the alert's details and dispatches it to the event emitter and dispatcher.
+// It creates an EventEntry with the alert's details and hands it to the event emitter.
 // The event includes the alert's timestamp, level, category, message, source, scope, target, and metadata.
-// The event is also broadcasted to the websocket hub for real-time updates.
-func (m *Manager) emitAlertFiringEvent(ctx context.Context, rule model.AlertRule, meta *model.Meta, now time.Time) {
+// Notifications are sent separately by dispatchFiringEvent.
+func (m *Manager) emitAlertFiringEvent(ctx context.Context, rule alertmodel.AlertRule, meta *model.Meta, inst *model.AlertInstance, now time.Time) {
 	scope, target := inferScopeAndTarget(meta)
 	event := model.EventEntry{
 		Timestamp: now,
@@ -236,15 +463,16 @@ func (m *Manager) emitAlertFiringEvent(ctx context.Context, rule model.AlertRule
 		Meta:      utils.SafeCopyLabels(meta),
 	}
 	event.Meta["rule_id"] = rule.ID
+	setAlertMeta(event.Meta, inst)
+	event.Meta["alert_state"] = alertmodel.StateFiring
 	m.emitter.Emit(ctx, event)
-	m.dispatcher.Dispatch(ctx, event)
 }
 
 // emitAlertResolvedEvent emits an event for a resolved alert.
-// It creates an EventEntry with the alert's details and dispatches it to the event emitter and dispatcher.
+// It creates an EventEntry with the alert's details and hands it to the event emitter.
 // The event includes the alert's timestamp, level, category, message, source, scope, target, and metadata.
-// The event is also broadcasted to the websocket hub for real-time updates.
-func (m *Manager) emitAlertResolvedEvent(ctx context.Context, rule model.AlertRule, meta *model.Meta, now time.Time) {
+// Notifications are sent separately by dispatchResolvedEvent, when the rule asks for them.
+func (m *Manager) emitAlertResolvedEvent(ctx context.Context, rule alertmodel.AlertRule, meta *model.Meta, inst *model.AlertInstance, now time.Time) {
 	scope, target := inferScopeAndTarget(meta)
 	event := model.EventEntry{
 		Timestamp: now,
@@ -257,28 +485,9 @@ func (m *Manager) emitAlertResolvedEvent(ctx context.Context, rule model.AlertRu
 		Meta:      utils.SafeCopyLabels(meta),
 	}
 	event.Meta["rule_id"] = rule.ID
+	setAlertMeta(event.Meta, inst)
+	event.Meta["alert_state"] = alertmodel.StateResolved
 	m.emitter.Emit(ctx, event)
-	m.dispatcher.Dispatch(ctx, event)
-}
-
-// emitLogAlertFiringEvent emits an event for a log-based alert firing.
-// It creates an EventEntry with the log's details and dispatches it to the event emitter and dispatcher.
-// The event includes the log's timestamp, level, category, message, source, scope, target, and metadata.
-// The event is also broadcasted to the websocket hub for real-time updates.
-func (m *Manager) emitLogAlertFiringEvent(ctx context.Context, rule model.AlertRule, meta *model.Meta, log model.LogEntry, now time.Time) {
-	event := model.EventEntry{
-		Timestamp: now,
-		Level:     rule.Level,
-		Category:  "log_alert",
-		Message:   rule.Message + ": " + log.Message,
-		Source:    log.Source,
-		Scope:     "endpoint",
-		Target:    meta.EndpointID,
-		Meta:      utils.SafeCopyLabels(meta),
-	}
-	event.Meta["rule_id"] = rule.ID
-	m.emitter.Emit(ctx, event)
-	m.dispatcher.Dispatch(ctx, event)
 }
 
 // ListActive returns a list of all active alert instances.
@@ -292,9 +501,26 @@ func (m *Manager) ListActive() []model.AlertInstance {
 	for _, v := range m.active {
 		list = append(list, *v)
 	}
+	for _, v := range m.logAlerts {
+		list = append(list, *v)
+	}
+	now := time.Now().UTC()
+	for _, v := range m.events {
+		if now.Before(v.expires) {
+			list = append(list, *v.inst)
+		}
+	}
 	return list
 }
 
+// setAlertMeta adds the alert instance's ID, last value and first firing
+// time to the meta of an event about it, for actions and their templates.
+func setAlertMeta(meta map[string]string, inst *model.AlertInstance) {
+	meta["alert_id"] = inst.ID
+	meta["value"] = strconv.FormatFloat(inst.LastValue, 'f', -1, 64)
+	meta["fired_at"] = inst.FirstFired.Format(time.RFC3339)
+}
+
 // inferScopeAndTarget determines the scope and target for the alert instance.
 // It checks if the endpoint ID is present in the metadata.
 // If it is, the scope is set to "endpoint" and the target is set to the endpoint ID.
@@ -310,9 +536,9 @@ func inferScopeAndTarget(meta *model.Meta) (string, string) {
 // It creates an EventEntry with the alert's details and dispatches it to the event dispatcher.
 // The event includes the alert's timestamp, level, category, message, source, scope, target, and metadata.
 // The event is also broadcasted to the websocket hub for real-time updates.
-func (m *Manager) dispatchFiringEvent(ctx context.Context, rule model.AlertRule, meta *model.Meta, target string, now time.Time, message string) {
+func (m *Manager) dispatchFiringEvent(ctx context.Context, rule alertmodel.AlertRule, meta *model.Meta, inst *model.AlertInstance, now time.Time, message string) {
 	scope, target := inferScopeAndTarget(meta)
-	m.dispatcher.Dispatch(ctx, model.EventEntry{
+	event := model.EventEntry{
 		Timestamp: now,
 		Level:     rule.Level,
 		Category:  "alert",
@@ -321,16 +547,20 @@ func (m *Manager) dispatchFiringEvent(ctx context.Context, rule model.AlertRule,
 		Scope:     scope,
 		Target:    target,
 		Meta:      utils.SafeCopyLabels(meta),
-	})
+	}
+	event.Meta["rule_id"] = rule.ID
+	setAlertMeta(event.Meta, inst)
+	event.Meta["alert_state"] = alertmodel.StateFiring
+	m.dispatcher.Dispatch(ctx, event)
 }
 
 // dispatchResolvedEvent dispatches a resolved event for the alert instance.
-// It creates an EventEntry with the alert's details and dispatches it to the event dispatcher.
+// It creates an EventEntry with the alert's details and sends it where the
+// alert's firing event went; see sendResolved.
 // The event includes the alert's timestamp, level, category, message, source, scope, target, and metadata.
-// The event is also broadcasted to the websocket hub for real-time updates.
-func (m *Manager) dispatchResolvedEvent(ctx context.Context, rule model.AlertRule, meta *model.Meta, target string, now time.Time, message string) {
+func (m *Manager) dispatchResolvedEvent(ctx context.Context, rule alertmodel.AlertRule, meta *model.Meta, inst *model.AlertInstance, now time.Time, message string) {
 	scope, target := inferScopeAndTarget(meta)
-	m.dispatcher.Dispatch(ctx, model.EventEntry{
+	event := model.EventEntry{
 		Timestamp: now,
 		Level:     rule.Level,
 		Category:  "alert",
@@ -339,5 +569,22 @@ func (m *Manager) dispatchResolvedEvent(ctx context.Context, rule model.AlertRul
 		Scope:     scope,
 		Target:    target,
 		Meta:      utils.SafeCopyLabels(meta),
-	})
+	}
+	event.Meta["rule_id"] = rule.ID
+	setAlertMeta(event.Meta, inst)
+	event.Meta["alert_state"] = alertmodel.StateResolved
+	m.sendResolved(ctx, rule, event)
+}
+
+// sendResolved sends the resolve of a firing alert to the rule's own
+// actions, so the integration that opened an incident for it can close it,
+// or through the routes when the rule has none.
+func (m *Manager) sendResolved(ctx context.Context, rule alertmodel.AlertRule, event model.EventEntry) {
+	if len(rule.Actions) == 0 {
+		m.dispatcher.Dispatch(ctx, event)
+		return
+	}
+	for _, actionID := range rule.Actions {
+		m.dispatcher.TriggerActionByID(ctx, actionID, event)
+	}
 }
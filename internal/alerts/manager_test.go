@@ -0,0 +1,403 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alerts
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/core/events/dispatcher"
+	"github.com/aaronlmathis/gosight-server/internal/events"
+	"github.com/aaronlmathis/gosight-server/internal/store/alertstore"
+	"github.com/aaronlmathis/gosight-server/internal/store/eventstore"
+	"github.com/aaronlmathis/gosight-server/internal/store/notificationstore"
+	"github.com/aaronlmathis/gosight-server/internal/websocket"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memAlertStore keeps alerts and timelines in memory. Methods the manager
+// doesn't use panic through the nil embedded interface.
+type memAlertStore struct {
+	alertstore.AlertStore
+	mu       sync.Mutex
+	alerts   map[string]model.AlertInstance
+	timeline []alertmodel.TimelineEntry
+}
+
+func newMemAlertStore() *memAlertStore {
+	return &memAlertStore{alerts: make(map[string]model.AlertInstance)}
+}
+
+func (s *memAlertStore) UpsertAlert(ctx context.Context, a *model.AlertInstance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts[a.ID] = *a
+	return nil
+}
+
+func (s *memAlertStore) ResolveAlert(ctx context.Context, ruleID, target string, resolvedAt time.Time) error {
+	return nil
+}
+
+func (s *memAlertStore) GetByID(ctx context.Context, id string) (model.AlertInstance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.alerts[id]
+	if !ok {
+		return a, ErrAlertNotFound
+	}
+	return a, nil
+}
+
+func (s *memAlertStore) AddTimelineEntry(ctx context.Context, e alertmodel.TimelineEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeline = append(s.timeline, e)
+	return nil
+}
+
+func (s *memAlertStore) ListTimeline(ctx context.Context, alertID string) ([]alertmodel.TimelineEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []alertmodel.TimelineEntry
+	for _, e := range s.timeline {
+		if e.AlertID == alertID {
+			list = append(list, e)
+		}
+	}
+	return list, nil
+}
+
+func newTestManager(t *testing.T) (*Manager, *memAlertStore) {
+	return newTestManagerWith(t, dispatcher.NewDispatcher(map[string]alertmodel.Route{}))
+}
+
+func newTestManagerWith(t *testing.T, d *dispatcher.Dispatcher) (*Manager, *memAlertStore) {
+	es, err := eventstore.NewJSONEventStore("")
+	require.NoError(t, err)
+	store := newMemAlertStore()
+	m := NewManager(events.NewEmitter(es, nil), d, store, nil, websocket.NewAlertsHub(nil))
+	return m, store
+}
+
+// newRoutedManager returns a manager whose dispatcher routes every event to
+// a webhook and records the deliveries, without sending them.
+func newRoutedManager(t *testing.T) (*Manager, *notificationstore.JSONNotificationStore) {
+	deliveries, err := notificationstore.NewJSONNotificationStore("")
+	require.NoError(t, err)
+	d := dispatcher.NewDispatcher(map[string]alertmodel.Route{
+		"all": {ID: "all", Actions: []alertmodel.ActionSpec{{Type: "webhook", URL: "http://127.0.0.1:0"}}},
+	})
+	d.SetDeliveryStore(deliveries, dispatcher.RetrySettings{})
+	m, _ := newTestManagerWith(t, d)
+	return m, deliveries
+}
+
+func deliveryCount(t *testing.T, store notificationstore.NotificationStore) int {
+	list, err := store.ListDeliveries(context.Background(), notificationstore.DeliveryQuery{})
+	require.NoError(t, err)
+	return len(list)
+}
+
+// activeByRule returns the open alert of a rule on an endpoint.
+func activeByRule(t *testing.T, m *Manager, ruleID, endpointID string) model.AlertInstance {
+	for _, a := range m.ListActive() {
+		if a.RuleID == ruleID && alertLabels(&a)["endpoint_id"] == endpointID {
+			return a
+		}
+	}
+	t.Fatalf("no open %s alert on %s", ruleID, endpointID)
+	return model.AlertInstance{}
+}
+
+var (
+	offlineRule = alertmodel.AlertRule{ID: "agent-offline", Type: "event", Level: "warning", Message: "Agent offline"}
+	diskRule    = alertmodel.AlertRule{ID: "disk-full", Type: "metric", Level: "warning", Message: "Disk full"}
+)
+
+func agentEvent(status string) model.EventEntry {
+	return model.EventEntry{
+		Level:      "warning",
+		Category:   "system",
+		Message:    "Agent web-1 changed status to " + status,
+		Source:     "agent.lifecycle",
+		Scope:      "endpoint",
+		Target:     "agent-1",
+		EndpointID: "host-1",
+		Meta:       map[string]string{"status": status},
+	}
+}
+
+func offlineInhibits() []alertmodel.InhibitRule {
+	return []alertmodel.InhibitRule{{
+		SourceMatchers: []alertmodel.Matcher{{Name: "rule_id", Value: "agent-offline"}},
+		TargetMatchers: []alertmodel.Matcher{{Name: "rule_id", Value: "(disk|stale)-.*", Regex: true}},
+		Equal:          []string{"endpoint_id"},
+	}}
+}
+
+func TestEventAlertInhibitsMetricAlertOnSameEndpoint(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+	m.SetInhibitRules(offlineInhibits())
+
+	m.HandleEventState(ctx, offlineRule, &model.Meta{EndpointID: "host-1"}, agentEvent("Offline"))
+	offline := activeByRule(t, m, "agent-offline", "host-1")
+
+	m.HandleState(ctx, diskRule, &model.Meta{EndpointID: "host-1"}, 97, alertmodel.StateFiring)
+	m.HandleState(ctx, diskRule, &model.Meta{EndpointID: "host-2"}, 97, alertmodel.StateFiring)
+
+	disk := activeByRule(t, m, "disk-full", "host-1")
+	assert.Equal(t, alertmodel.StateInhibited, disk.State)
+	assert.Equal(t, offline.ID, disk.Labels["inhibited_by"])
+	assert.Equal(t, alertmodel.StateFiring, activeByRule(t, m, "disk-full", "host-2").State)
+
+	// The agent comes back: the offline alert resolves and the disk alert
+	// notifies on its next evaluation.
+	m.HandleEventRecovery(ctx, agentEvent("Online"))
+	m.HandleState(ctx, diskRule, &model.Meta{EndpointID: "host-1"}, 97, alertmodel.StateFiring)
+	assert.Equal(t, alertmodel.StateFiring, activeByRule(t, m, "disk-full", "host-1").State)
+	for _, a := range m.ListActive() {
+		assert.NotEqual(t, "agent-offline", a.RuleID)
+	}
+}
+
+func TestEventAlertStopsInhibitingWhenExpired(t *testing.T) {
+	m, store := newTestManager(t)
+	ctx := context.Background()
+	m.SetInhibitRules(offlineInhibits())
+
+	rule := offlineRule
+	rule.Options.KeepFiringFor = "1h"
+	m.HandleEventState(ctx, rule, &model.Meta{EndpointID: "host-1"}, agentEvent("Offline"))
+	offline := activeByRule(t, m, "agent-offline", "host-1")
+
+	m.HandleState(ctx, diskRule, &model.Meta{EndpointID: "host-1"}, 97, alertmodel.StateFiring)
+	assert.Equal(t, alertmodel.StateInhibited, activeByRule(t, m, "disk-full", "host-1").State)
+
+	m.lock.Lock()
+	for _, ea := range m.events {
+		assert.WithinDuration(t, time.Now().Add(time.Hour), ea.expires, time.Minute)
+		ea.expires = time.Now().Add(-time.Second)
+	}
+	m.lock.Unlock()
+
+	m.HandleState(ctx, diskRule, &model.Meta{EndpointID: "host-1"}, 97, alertmodel.StateFiring)
+	assert.Equal(t, alertmodel.StateFiring, activeByRule(t, m, "disk-full", "host-1").State)
+
+	stored, err := store.GetByID(ctx, offline.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alertmodel.StateResolved, stored.State)
+	assert.NotNil(t, stored.ResolvedAt)
+}
+
+func TestSweepResolvesExpiredEventAlerts(t *testing.T) {
+	m, store := newTestManager(t)
+	ctx := context.Background()
+
+	rule := offlineRule
+	rule.Options.KeepFiringFor = "10m"
+	m.HandleEventState(ctx, rule, &model.Meta{EndpointID: "host-1"}, agentEvent("Offline"))
+	offline := activeByRule(t, m, "agent-offline", "host-1")
+
+	m.sweep(ctx, time.Now().UTC().Add(5*time.Minute))
+	assert.Len(t, m.ListActive(), 1, "not expired yet")
+
+	m.sweep(ctx, time.Now().UTC().Add(11*time.Minute))
+	assert.Empty(t, m.ListActive())
+	stored, err := store.GetByID(ctx, offline.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alertmodel.StateResolved, stored.State)
+	assert.NotNil(t, stored.ResolvedAt)
+
+	timeline, err := store.ListTimeline(ctx, offline.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alertmodel.StateResolved, timeline[len(timeline)-1].Action)
+}
+
+func TestAlertIsDispatchedOnce(t *testing.T) {
+	ctx := context.Background()
+	meta := &model.Meta{EndpointID: "host-1"}
+
+	m, deliveries := newRoutedManager(t)
+	m.HandleState(ctx, diskRule, meta, 97, alertmodel.StateFiring)
+	assert.Equal(t, 1, deliveryCount(t, deliveries), "firing")
+
+	m.HandleState(ctx, diskRule, meta, 40, alertmodel.StateOK)
+	assert.Equal(t, 1, deliveryCount(t, deliveries), "resolve without notify_on_resolve")
+
+	rule := diskRule
+	rule.Options.NotifyOnResolve = true
+	m, deliveries = newRoutedManager(t)
+	m.HandleState(ctx, rule, meta, 97, alertmodel.StateFiring)
+	m.HandleState(ctx, rule, meta, 40, alertmodel.StateOK)
+	assert.Equal(t, 2, deliveryCount(t, deliveries), "firing and resolve")
+
+	m, deliveries = newRoutedManager(t)
+	m.HandleLogState(ctx, alertmodel.AlertRule{ID: "oom", Type: "log", Message: "OOM"}, meta, model.LogEntry{Message: "killed"}, true)
+	assert.Equal(t, 1, deliveryCount(t, deliveries), "log alert")
+}
+
+func TestAcknowledgeAndAssignKeepLabels(t *testing.T) {
+	ctx := context.Background()
+	meta := &model.Meta{EndpointID: "host-1"}
+	rule := diskRule
+	rule.Options.RepeatInterval = "1ns"
+
+	m, deliveries := newRoutedManager(t)
+	m.HandleState(ctx, rule, meta, 97, alertmodel.StateFiring)
+	alert := activeByRule(t, m, "disk-full", "host-1")
+	require.Equal(t, 1, deliveryCount(t, deliveries))
+
+	_, err := m.Acknowledge(ctx, alert.ID, "alice", "looking")
+	require.NoError(t, err)
+	_, err = m.Assign(ctx, alert.ID, "bob", "alice", "")
+	require.NoError(t, err)
+
+	alert = activeByRule(t, m, "disk-full", "host-1")
+	assert.NotContains(t, alert.Labels, "acknowledged_by")
+	assert.NotContains(t, alert.Labels, "assignee")
+	assert.Equal(t, alertmodel.Workflow{AcknowledgedBy: "alice", Assignee: "bob"}, m.Workflow(ctx, alert.ID))
+
+	m.HandleState(ctx, rule, meta, 97, alertmodel.StateFiring)
+	assert.Equal(t, 1, deliveryCount(t, deliveries), "acknowledged alerts don't repeat")
+
+	_, err = m.Unacknowledge(ctx, alert.ID, "alice", "")
+	require.NoError(t, err)
+	m.HandleState(ctx, rule, meta, 97, alertmodel.StateFiring)
+	assert.Equal(t, 2, deliveryCount(t, deliveries), "repeats resume once unacknowledged")
+
+	// Once the alert is resolved its workflow comes from the timeline.
+	_, err = m.Resolve(ctx, alert.ID, "alice", "")
+	require.NoError(t, err)
+	assert.Equal(t, alertmodel.Workflow{Assignee: "bob"}, m.Workflow(ctx, alert.ID))
+}
+
+func TestManualResolveIsDispatched(t *testing.T) {
+	ctx := context.Background()
+	rule := diskRule
+	rule.Options.NotifyOnResolve = true
+	m, deliveries := newRoutedManager(t)
+	m.HandleState(ctx, rule, &model.Meta{EndpointID: "host-1"}, 97, alertmodel.StateFiring)
+	alert := activeByRule(t, m, "disk-full", "host-1")
+
+	resolved, err := m.Resolve(ctx, alert.ID, "alice", "fixed")
+	require.NoError(t, err)
+	assert.Equal(t, alertmodel.StateResolved, resolved.State)
+	assert.Empty(t, m.ListActive())
+
+	list, err := deliveries.ListDeliveries(ctx, notificationstore.DeliveryQuery{AlertID: alert.ID})
+	require.NoError(t, err)
+	require.Len(t, list, 2, "firing and resolve")
+	assert.Contains(t, string(list[0].Payload), `"alert_state":"resolved"`)
+	assert.Contains(t, string(list[0].Payload), `"resolved_by":"alice"`)
+
+	_, err = m.Resolve(ctx, alert.ID, "alice", "")
+	assert.ErrorIs(t, err, ErrAlertClosed)
+}
+
+func TestManualResolveRespectsNotifyOnResolve(t *testing.T) {
+	ctx := context.Background()
+	m, deliveries := newRoutedManager(t)
+	m.HandleState(ctx, diskRule, &model.Meta{EndpointID: "host-1"}, 97, alertmodel.StateFiring)
+	alert := activeByRule(t, m, "disk-full", "host-1")
+
+	_, err := m.Resolve(ctx, alert.ID, "alice", "")
+	require.NoError(t, err)
+	assert.Empty(t, m.ListActive())
+	assert.Equal(t, 1, deliveryCount(t, deliveries), "only the firing notification")
+}
+
+func TestResolveGoesToRuleActions(t *testing.T) {
+	ctx := context.Background()
+	meta := &model.Meta{EndpointID: "host-1"}
+	rule := diskRule
+	rule.Actions = []string{"pager"}
+	rule.Options.NotifyOnResolve = true
+
+	// The pager route matches no event, so only sends by ID reach it.
+	newManager := func() (*Manager, *notificationstore.JSONNotificationStore) {
+		deliveries, err := notificationstore.NewJSONNotificationStore("")
+		require.NoError(t, err)
+		d := dispatcher.NewDispatcher(map[string]alertmodel.Route{
+			"pager": {
+				ID:      "pager",
+				Match:   alertmodel.RouteMatch{RuleID: "none"},
+				Actions: []alertmodel.ActionSpec{{Type: "webhook", URL: "http://127.0.0.1:0"}},
+			},
+		})
+		d.SetDeliveryStore(deliveries, dispatcher.RetrySettings{})
+		m, _ := newTestManagerWith(t, d)
+		return m, deliveries
+	}
+	routed := func(t *testing.T, deliveries notificationstore.NotificationStore) int {
+		list, err := deliveries.ListDeliveries(ctx, notificationstore.DeliveryQuery{RouteID: "pager"})
+		require.NoError(t, err)
+		return len(list)
+	}
+
+	m, deliveries := newManager()
+	m.HandleState(ctx, rule, meta, 97, alertmodel.StateFiring)
+	m.HandleState(ctx, rule, meta, 40, alertmodel.StateOK)
+	assert.Equal(t, 2, routed(t, deliveries), "firing and resolve")
+
+	m, deliveries = newManager()
+	m.HandleState(ctx, rule, meta, 97, alertmodel.StateFiring)
+	_, err := m.Resolve(ctx, activeByRule(t, m, "disk-full", "host-1").ID, "alice", "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, routed(t, deliveries), "firing and manual resolve")
+}
+
+func TestResolveRuleClosesOpenAlerts(t *testing.T) {
+	ctx := context.Background()
+	m, store := newTestManager(t)
+
+	pendingRule := alertmodel.AlertRule{ID: "disk-full", Type: "metric", Message: "Disk full", Options: alertmodel.Options{For: "5m"}}
+	m.HandleState(ctx, diskRule, &model.Meta{EndpointID: "host-1"}, 97, alertmodel.StateFiring)
+	m.HandleState(ctx, pendingRule, &model.Meta{EndpointID: "host-2"}, 97, alertmodel.StatePending)
+	m.HandleEventState(ctx, offlineRule, &model.Meta{EndpointID: "host-1"}, agentEvent("Offline"))
+	firing := activeByRule(t, m, "disk-full", "host-1")
+
+	m.ResolveRule(ctx, "disk-full", "alice", "rule disabled")
+
+	for _, a := range m.ListActive() {
+		assert.NotEqual(t, "disk-full", a.RuleID)
+	}
+	assert.Equal(t, map[string]bool{"agent-offline": true}, m.RuleIDs())
+
+	stored, err := store.GetByID(ctx, firing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alertmodel.StateResolved, stored.State)
+	assert.NotNil(t, stored.ResolvedAt)
+
+	timeline, err := store.ListTimeline(ctx, firing.ID)
+	require.NoError(t, err)
+	last := timeline[len(timeline)-1]
+	assert.Equal(t, alertmodel.StateResolved, last.Action)
+	assert.Equal(t, "alice", last.Actor)
+	assert.Equal(t, "rule disabled", last.Comment)
+}
@@ -0,0 +1,257 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alerts
+
+import (
+	"context"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/tracker"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+const (
+	// defaultEventAlertTTL is how long an event rule's alert stays open when
+	// the rule sets no keep_firing_for.
+	defaultEventAlertTTL = 5 * time.Minute
+
+	// eventAlertSweepInterval is how often Run resolves expired event-rule
+	// alerts.
+	eventAlertSweepInterval = 15 * time.Second
+)
+
+// eventAlert is the open alert of an event rule for one target. Events have
+// no ok state, so it closes when it expires or its target recovers.
+type eventAlert struct {
+	inst    *model.AlertInstance
+	expires time.Time
+}
+
+// eventAlertTTL returns how long an event rule's alert stays open.
+func eventAlertTTL(rule alertmodel.AlertRule) time.Duration {
+	if d := alertmodel.ParseDuration(rule.Options.KeepFiringFor); d > 0 {
+		return d
+	}
+	return defaultEventAlertTTL
+}
+
+// HandleEventRecovery resolves the open event-rule alerts on an agent or
+// container when a lifecycle event reports that it is back up, such as the
+// agent-offline alert of an agent coming back online.
+func (m *Manager) HandleEventRecovery(ctx context.Context, event model.EventEntry) {
+	if event.Target == "" || !tracker.IsUpStatus(event.Meta["status"]) {
+		return
+	}
+	if event.Source != "agent.lifecycle" && event.Source != "container.lifecycle" {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now().UTC()
+	for k, ea := range m.events {
+		if ea.inst.Target == event.Target {
+			m.closeEventAlert(ctx, k, now)
+		}
+	}
+}
+
+// Run resolves event-rule alerts as they expire, so they stop firing and
+// inhibiting other alerts without waiting for another alert to be
+// evaluated. It blocks until ctx is canceled.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(eventAlertSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.sweep(ctx, now.UTC())
+		}
+	}
+}
+
+// sweep resolves the expired event-rule alerts.
+func (m *Manager) sweep(ctx context.Context, now time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.expireEventAlerts(ctx, now)
+}
+
+// expireEventAlerts resolves the event-rule alerts whose time is up. The
+// caller must hold the lock.
+func (m *Manager) expireEventAlerts(ctx context.Context, now time.Time) {
+	for k, ea := range m.events {
+		if !now.Before(ea.expires) {
+			m.closeEventAlert(ctx, k, now)
+		}
+	}
+}
+
+// closeEventAlert resolves an open event-rule alert and stops tracking it.
+// Nothing is dispatched; the event that raised it was the notification.
+// The caller must hold the lock.
+func (m *Manager) closeEventAlert(ctx context.Context, k string, now time.Time) {
+	ea := m.events[k]
+	delete(m.events, k)
+	delete(m.workflow, ea.inst.ID)
+
+	inst := ea.inst
+	inst.Previous = inst.State
+	inst.State = alertmodel.StateResolved
+	inst.LastOK = now
+	inst.ResolvedAt = &now
+	_ = m.store.UpsertAlert(ctx, inst)
+	m.hub.Broadcast(*inst)
+	m.recordTimeline(ctx, inst, alertmodel.StateResolved, "", "")
+}
+
+// SetInhibitRules replaces the inhibition rules. They take effect the next
+// time each alert is evaluated.
+func (m *Manager) SetInhibitRules(rules []alertmodel.InhibitRule) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.inhibit = rules
+}
+
+// muted reports whether an alert is open but must not notify.
+func muted(inst *model.AlertInstance) bool {
+	return inst.State == alertmodel.StateSilenced || inst.State == alertmodel.StateInhibited
+}
+
+// mute checks an open alert against the silences and inhibition rules and
+// sets its state to silenced, inhibited or firing accordingly, recording the
+// responsible silence or alert ID in the silenced_by or inhibited_by label.
+// It reports whether the state changed. The caller must hold the lock.
+func (m *Manager) mute(ctx context.Context, inst *model.AlertInstance) bool {
+	if inst.Labels == nil {
+		inst.Labels = make(map[string]string)
+	}
+	delete(inst.Labels, "silenced_by")
+	delete(inst.Labels, "inhibited_by")
+
+	m.expireEventAlerts(ctx, time.Now().UTC())
+
+	labels := alertLabels(inst)
+	state := alertmodel.StateFiring
+	if id := m.silencedBy(ctx, labels); id != "" {
+		state = alertmodel.StateSilenced
+		inst.Labels["silenced_by"] = id
+	} else if id := m.inhibitedBy(inst, labels); id != "" {
+		state = alertmodel.StateInhibited
+		inst.Labels["inhibited_by"] = id
+	}
+
+	if inst.State == state {
+		return false
+	}
+	inst.Previous = inst.State
+	inst.State = state
+	return true
+}
+
+// alertLabels returns the labels silences and inhibition rules match
+// against: the alert's labels plus rule_id, endpoint_id, level, scope and
+// target. endpoint_id falls back to the target of endpoint-scoped alerts.
+func alertLabels(inst *model.AlertInstance) map[string]string {
+	labels := make(map[string]string, len(inst.Labels)+5)
+	for k, v := range inst.Labels {
+		labels[k] = v
+	}
+	labels["rule_id"] = inst.RuleID
+	labels["level"] = inst.Level
+	labels["scope"] = inst.Scope
+	labels["target"] = inst.Target
+	if inst.Scope == "endpoint" && labels["endpoint_id"] == "" {
+		labels["endpoint_id"] = inst.Target
+	}
+	return labels
+}
+
+// silencedBy returns the ID of the first active silence matching the
+// labels, or "" when the alert is not silenced.
+func (m *Manager) silencedBy(ctx context.Context, labels map[string]string) string {
+	if m.silences == nil {
+		return ""
+	}
+	silences, err := m.silences.ListSilences(ctx)
+	if err != nil {
+		utils.Warn("Failed to list silences: %v", err)
+		return ""
+	}
+
+	now := time.Now().UTC()
+	for _, s := range silences {
+		if s.ActiveAt(now) && s.Matches(labels) {
+			return s.ID
+		}
+	}
+	return ""
+}
+
+// inhibitedBy returns the ID of an open alert that inhibits inst, or "".
+// Only firing and silenced alerts of stateful and event rules inhibit others;
+// log alerts never resolve, and an inhibited alert does not inhibit so two
+// alerts can't keep each other quiet. The caller must hold the lock.
+func (m *Manager) inhibitedBy(inst *model.AlertInstance, labels map[string]string) string {
+	sources := make([]*model.AlertInstance, 0, len(m.active)+len(m.events))
+	for _, source := range m.active {
+		sources = append(sources, source)
+	}
+	for _, ea := range m.events {
+		sources = append(sources, ea.inst)
+	}
+
+	for _, rule := range m.inhibit {
+		if !alertmodel.MatchLabels(rule.TargetMatchers, labels) {
+			continue
+		}
+		for _, source := range sources {
+			if source.ID == inst.ID {
+				continue
+			}
+			if source.State != alertmodel.StateFiring && source.State != alertmodel.StateSilenced {
+				continue
+			}
+			sourceLabels := alertLabels(source)
+			if !alertmodel.MatchLabels(rule.SourceMatchers, sourceLabels) {
+				continue
+			}
+			equal := true
+			for _, name := range rule.Equal {
+				if sourceLabels[name] != labels[name] {
+					equal = false
+					break
+				}
+			}
+			if equal {
+				return source.ID
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,347 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package alerts
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+var (
+	// ErrAlertNotFound is returned when no alert instance has the given ID.
+	ErrAlertNotFound = errors.New("alert not found")
+	// ErrAlertClosed is returned when acting on an alert that already resolved.
+	ErrAlertClosed = errors.New("alert is already resolved")
+)
+
+// Acknowledge marks an alert as acknowledged by actor. An acknowledged alert
+// gets no repeat notifications; if it resolves and fires again, the new
+// alert notifies as usual.
+func (m *Manager) Acknowledge(ctx context.Context, id, actor, comment string) (model.AlertInstance, error) {
+	return m.update(ctx, id, func(inst *model.AlertInstance) {
+		m.applyWorkflow(ctx, inst, alertmodel.TimelineEntry{Action: alertmodel.ActionAcknowledged, Actor: actor, Comment: comment})
+	})
+}
+
+// Unacknowledge removes the acknowledgement of an alert so that repeat
+// notifications resume.
+func (m *Manager) Unacknowledge(ctx context.Context, id, actor, comment string) (model.AlertInstance, error) {
+	return m.update(ctx, id, func(inst *model.AlertInstance) {
+		m.applyWorkflow(ctx, inst, alertmodel.TimelineEntry{Action: alertmodel.ActionUnacknowledged, Actor: actor, Comment: comment})
+	})
+}
+
+// Assign assigns an alert to a user. An empty assignee unassigns it.
+func (m *Manager) Assign(ctx context.Context, id, assignee, actor, comment string) (model.AlertInstance, error) {
+	return m.update(ctx, id, func(inst *model.AlertInstance) {
+		m.applyWorkflow(ctx, inst, alertmodel.TimelineEntry{Action: alertmodel.ActionAssigned, Actor: actor, Assignee: assignee, Comment: comment})
+	})
+}
+
+// Workflow returns who acknowledged an alert and who it is assigned to.
+func (m *Manager) Workflow(ctx context.Context, id string) alertmodel.Workflow {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.workflowOf(ctx, id)
+}
+
+// workflowOf returns the workflow of an alert. Tracked alerts keep theirs in
+// memory; others are rebuilt from their timeline. The caller must hold the
+// lock.
+func (m *Manager) workflowOf(ctx context.Context, id string) alertmodel.Workflow {
+	if w, ok := m.workflow[id]; ok || m.tracked(id) != nil {
+		return w
+	}
+	var w alertmodel.Workflow
+	entries, err := m.store.ListTimeline(ctx, id)
+	if err != nil {
+		utils.Warn("Failed to load timeline of alert %s: %v", id, err)
+	}
+	for _, e := range entries {
+		w.Apply(e)
+	}
+	return w
+}
+
+// applyWorkflow records a user action on an alert's timeline and, for a
+// tracked alert, updates the workflow the evaluator checks. The caller must
+// hold the lock.
+func (m *Manager) applyWorkflow(ctx context.Context, inst *model.AlertInstance, entry alertmodel.TimelineEntry) {
+	if m.tracked(inst.ID) != nil {
+		w := m.workflow[inst.ID]
+		w.Apply(entry)
+		m.workflow[inst.ID] = w
+	}
+	m.addTimeline(ctx, inst, entry)
+}
+
+// Resolve closes an alert by hand. A firing alert's resolve is handled like
+// one found by the evaluator: when the rule notifies on resolve it is sent
+// to the rule's actions or through the routes, so integrations and
+// notification groups learn that it ended; otherwise the alert is quietly
+// withdrawn from its groups. If the rule still holds, its next evaluation
+// opens a new alert.
+func (m *Manager) Resolve(ctx context.Context, id, actor, comment string) (model.AlertInstance, error) {
+	return m.update(ctx, id, func(inst *model.AlertInstance) {
+		notify := false
+		for k, v := range m.active {
+			if v.ID == id {
+				delete(m.active, k)
+				notify = v.State == alertmodel.StateFiring
+			}
+		}
+		for k, v := range m.pending {
+			if v.ID == id {
+				delete(m.pending, k)
+			}
+		}
+		for k, v := range m.logAlerts {
+			if v.ID == id {
+				delete(m.logAlerts, k)
+			}
+		}
+		for k, v := range m.events {
+			if v.inst.ID == id {
+				delete(m.events, k)
+			}
+		}
+		delete(m.workflow, id)
+
+		now := time.Now().UTC()
+		inst.Previous = inst.State
+		inst.State = alertmodel.StateResolved
+		inst.LastOK = now
+		inst.ResolvedAt = &now
+		m.recordTimeline(ctx, inst, alertmodel.StateResolved, actor, comment)
+		if !notify {
+			return
+		}
+		if rule := m.ruleOf(inst); rule.Options.NotifyOnResolve {
+			m.sendResolved(ctx, rule, resolvedEvent(inst, actor, now))
+		} else {
+			m.dispatcher.Withdraw(rule.ID, inst.Target)
+		}
+	})
+}
+
+// resolvedEvent builds the event announcing the manual resolve of a metric
+// alert. Its rule ID, target and labels match the alert's firing event, so
+// it takes the same routes and closes the same notification groups.
+func resolvedEvent(inst *model.AlertInstance, actor string, now time.Time) model.EventEntry {
+	event := model.EventEntry{
+		Timestamp: now,
+		Level:     inst.Level,
+		Category:  "alert",
+		Message:   "Resolved: " + inst.Message,
+		Scope:     inst.Scope,
+		Target:    inst.Target,
+		Meta:      make(map[string]string, len(inst.Labels)+6),
+	}
+	for k, v := range inst.Labels {
+		event.Meta[k] = v
+	}
+	event.Meta["rule_id"] = inst.RuleID
+	setAlertMeta(event.Meta, inst)
+	event.Meta["alert_state"] = alertmodel.StateResolved
+	event.Meta["resolved_by"] = actor
+	return event
+}
+
+// ruleOf returns the rule an alert was raised by as last evaluated, or a
+// rule with only its ID when the manager hasn't seen it. The caller must
+// hold the lock.
+func (m *Manager) ruleOf(inst *model.AlertInstance) alertmodel.AlertRule {
+	if rule, ok := m.rules[inst.RuleID]; ok {
+		return rule
+	}
+	return alertmodel.AlertRule{ID: inst.RuleID}
+}
+
+// ResolveRule closes the open alerts of a rule that was disabled or deleted
+// and records actor and comment on their timelines. Pending alerts go back
+// to ok. Firing alerts leave their notification groups without a resolve
+// notification, since their condition was never seen to clear.
+func (m *Manager) ResolveRule(ctx context.Context, ruleID, actor, comment string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.rules, ruleID)
+	now := time.Now().UTC()
+	for k, inst := range m.pending {
+		if inst.RuleID == ruleID {
+			delete(m.pending, k)
+			m.closeAlert(ctx, inst, alertmodel.StateOK, actor, comment, now)
+		}
+	}
+	for k, inst := range m.active {
+		if inst.RuleID == ruleID {
+			delete(m.active, k)
+			if inst.State == alertmodel.StateFiring {
+				m.dispatcher.Withdraw(ruleID, inst.Target)
+			}
+			m.closeAlert(ctx, inst, alertmodel.StateResolved, actor, comment, now)
+		}
+	}
+	for k, inst := range m.logAlerts {
+		if inst.RuleID == ruleID {
+			delete(m.logAlerts, k)
+			m.closeAlert(ctx, inst, alertmodel.StateResolved, actor, comment, now)
+		}
+	}
+	for k, ea := range m.events {
+		if ea.inst.RuleID == ruleID {
+			delete(m.events, k)
+			m.closeAlert(ctx, ea.inst, alertmodel.StateResolved, actor, comment, now)
+		}
+	}
+}
+
+// RuleIDs returns the IDs of the rules with open alerts.
+func (m *Manager) RuleIDs() map[string]bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	ids := make(map[string]bool)
+	for _, inst := range m.pending {
+		ids[inst.RuleID] = true
+	}
+	for _, inst := range m.active {
+		ids[inst.RuleID] = true
+	}
+	for _, inst := range m.logAlerts {
+		ids[inst.RuleID] = true
+	}
+	for _, ea := range m.events {
+		ids[ea.inst.RuleID] = true
+	}
+	return ids
+}
+
+// closeAlert moves an alert the manager stopped tracking to state, then
+// persists, broadcasts and records the change. The caller must hold the lock.
+func (m *Manager) closeAlert(ctx context.Context, inst *model.AlertInstance, state, actor, comment string, now time.Time) {
+	delete(m.workflow, inst.ID)
+	inst.Previous = inst.State
+	inst.State = state
+	inst.LastOK = now
+	if state == alertmodel.StateResolved {
+		inst.ResolvedAt = &now
+	}
+	_ = m.store.UpsertAlert(ctx, inst)
+	m.hub.Broadcast(*inst)
+	m.recordTimeline(ctx, inst, state, actor, comment)
+}
+
+// update applies a user action to an open alert and persists and broadcasts
+// the result. Alerts the manager is tracking are changed in place so the
+// evaluator sees the change; others are loaded from the store.
+func (m *Manager) update(ctx context.Context, id string, apply func(inst *model.AlertInstance)) (model.AlertInstance, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	inst := m.tracked(id)
+	if inst == nil {
+		stored, err := m.store.GetByID(ctx, id)
+		if err != nil {
+			return model.AlertInstance{}, ErrAlertNotFound
+		}
+		inst = &stored
+	}
+	if inst.State == alertmodel.StateResolved || inst.State == alertmodel.StateOK {
+		return *inst, ErrAlertClosed
+	}
+	if inst.Labels == nil {
+		inst.Labels = make(map[string]string)
+	}
+
+	apply(inst)
+	if err := m.store.UpsertAlert(ctx, inst); err != nil {
+		return *inst, err
+	}
+	m.hub.Broadcast(*inst)
+	return *inst, nil
+}
+
+// tracked returns the active or pending instance with the given ID, if any.
+// The caller must hold the lock.
+func (m *Manager) tracked(id string) *model.AlertInstance {
+	for _, inst := range m.active {
+		if inst.ID == id {
+			return inst
+		}
+	}
+	for _, inst := range m.pending {
+		if inst.ID == id {
+			return inst
+		}
+	}
+	for _, inst := range m.logAlerts {
+		if inst.ID == id {
+			return inst
+		}
+	}
+	for _, ea := range m.events {
+		if ea.inst.ID == id {
+			return ea.inst
+		}
+	}
+	return nil
+}
+
+// Timeline returns the state changes and user actions of an alert, oldest first.
+func (m *Manager) Timeline(ctx context.Context, id string) ([]alertmodel.TimelineEntry, error) {
+	return m.store.ListTimeline(ctx, id)
+}
+
+// recordTimeline adds an entry with the given action to an alert's timeline.
+func (m *Manager) recordTimeline(ctx context.Context, inst *model.AlertInstance, action, actor, comment string) {
+	m.addTimeline(ctx, inst, alertmodel.TimelineEntry{Action: action, Actor: actor, Comment: comment})
+}
+
+// addTimeline fills in the alert, time and resulting state of a timeline
+// entry and stores it. Failures are logged; the change itself has been made.
+func (m *Manager) addTimeline(ctx context.Context, inst *model.AlertInstance, entry alertmodel.TimelineEntry) {
+	entry.AlertID = inst.ID
+	entry.State = inst.State
+	entry.Timestamp = time.Now().UTC()
+	if err := m.store.AddTimelineEntry(ctx, entry); err != nil {
+		utils.Warn("Failed to record timeline of alert %s: %v", inst.ID, err)
+	}
+
+	m.listenerLock.RLock()
+	defer m.listenerLock.RUnlock()
+	for _, l := range m.listeners {
+		l.OnAlertChange(ctx, *inst, entry)
+	}
+}
+
+// AddStateListener registers a listener that is told of every alert change.
+func (m *Manager) AddStateListener(l StateListener) {
+	m.listenerLock.Lock()
+	defer m.listenerLock.Unlock()
+	m.listeners = append(m.listeners, l)
+}
@@ -0,0 +1,148 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/routestore"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/gorilla/mux"
+)
+
+// HandleListRoutesAPI handles GET /api/v1/alerts/routes
+// It lists the notification routes with their credentials masked.
+func (h *AlertsHandler) HandleListRoutesAPI(w http.ResponseWriter, r *http.Request) {
+	routes := h.Sys.Stores.Actions.Routes()
+	for i := range routes {
+		routes[i] = routes[i].Redacted()
+	}
+	utils.JSON(w, http.StatusOK, routes)
+}
+
+// HandleGetRouteAPI handles GET /api/v1/alerts/routes/{id}
+func (h *AlertsHandler) HandleGetRouteAPI(w http.ResponseWriter, r *http.Request) {
+	route, err := h.Sys.Stores.Actions.GetRoute(mux.Vars(r)["id"])
+	if err != nil {
+		h.writeRouteError(w, err)
+		return
+	}
+	utils.JSON(w, http.StatusOK, route.Redacted())
+}
+
+// HandleCreateRouteAPI handles POST /api/v1/alerts/routes
+// The route is validated like the routes file and takes effect immediately.
+func (h *AlertsHandler) HandleCreateRouteAPI(w http.ResponseWriter, r *http.Request) {
+	var route alertmodel.Route
+	if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := routestore.ValidateRoute(route); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.Sys.Stores.Actions.AddRoute(r.Context(), route); err != nil {
+		h.writeRouteError(w, err)
+		return
+	}
+	utils.JSON(w, http.StatusCreated, route.Redacted())
+}
+
+// HandleUpdateRouteAPI handles PUT /api/v1/alerts/routes/{id}
+// It replaces the route. Credentials sent back masked, as returned by GET,
+// keep their stored values.
+func (h *AlertsHandler) HandleUpdateRouteAPI(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	existing, err := h.Sys.Stores.Actions.GetRoute(id)
+	if err != nil {
+		h.writeRouteError(w, err)
+		return
+	}
+
+	var route alertmodel.Route
+	if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	route.ID = id
+	route = route.Unredacted(existing)
+	if err := routestore.ValidateRoute(route); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.Sys.Stores.Actions.UpdateRoute(r.Context(), route); err != nil {
+		h.writeRouteError(w, err)
+		return
+	}
+	utils.JSON(w, http.StatusOK, route.Redacted())
+}
+
+// HandleDeleteRouteAPI handles DELETE /api/v1/alerts/routes/{id}
+func (h *AlertsHandler) HandleDeleteRouteAPI(w http.ResponseWriter, r *http.Request) {
+	if err := h.Sys.Stores.Actions.DeleteRoute(r.Context(), mux.Vars(r)["id"]); err != nil {
+		h.writeRouteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleTestRouteAPI handles POST /api/v1/alerts/routes/{id}/test
+// It sends a synthetic alert through each of the route's actions once and
+// reports the outcome per action. Test sends are not grouped, queued or
+// retried.
+func (h *AlertsHandler) HandleTestRouteAPI(w http.ResponseWriter, r *http.Request) {
+	route, err := h.Sys.Stores.Actions.GetRoute(mux.Vars(r)["id"])
+	if err != nil {
+		h.writeRouteError(w, err)
+		return
+	}
+
+	results := h.Sys.Tele.Dispatcher.TestRoute(r.Context(), route)
+	ok := true
+	for _, res := range results {
+		if res.Error != "" {
+			ok = false
+		}
+	}
+	utils.JSON(w, http.StatusOK, map[string]interface{}{
+		"route_id": route.ID,
+		"ok":       ok,
+		"results":  results,
+	})
+}
+
+// writeRouteError maps route store errors to HTTP responses.
+func (h *AlertsHandler) writeRouteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, routestore.ErrRouteNotFound):
+		http.Error(w, "route not found", http.StatusNotFound)
+	case errors.Is(err, routestore.ErrRouteExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		utils.Error("Route store error: %v", err)
+		http.Error(w, "route store error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,340 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/contextutil"
+	"github.com/aaronlmathis/gosight-server/internal/store/rulestore"
+	"github.com/aaronlmathis/gosight-server/internal/store/userstore"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/gorilla/mux"
+)
+
+// HandleGetAlertRuleAPI handles GET /api/v1/alerts/rules/{id}
+func (h *AlertsHandler) HandleGetAlertRuleAPI(w http.ResponseWriter, r *http.Request) {
+	rule, err := h.Sys.Stores.Rules.GetRuleByID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "rule not found", http.StatusNotFound)
+		return
+	}
+	utils.JSON(w, http.StatusOK, rule)
+}
+
+// HandleUpdateAlertRuleAPI handles PUT /api/v1/alerts/rules/{id}
+// It replaces the whole rule; the ID in the path wins over any ID in the body.
+func (h *AlertsHandler) HandleUpdateAlertRuleAPI(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+
+	existing, err := h.Sys.Stores.Rules.GetRuleByID(ctx, id)
+	if err != nil {
+		http.Error(w, "rule not found", http.StatusNotFound)
+		return
+	}
+
+	var rule alertmodel.AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	rule.ID = id
+
+	h.saveRuleChange(w, r, alertmodel.RevisionUpdate, existing, rule)
+}
+
+// HandlePatchAlertRuleAPI handles PATCH /api/v1/alerts/rules/{id}
+// The body is a JSON merge patch (RFC 7386): fields present replace the
+// current values, null removes them, and nested objects are merged.
+func (h *AlertsHandler) HandlePatchAlertRuleAPI(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+
+	existing, err := h.Sys.Stores.Rules.GetRuleByID(ctx, id)
+	if err != nil {
+		http.Error(w, "rule not found", http.StatusNotFound)
+		return
+	}
+
+	var patch map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	current, err := json.Marshal(existing)
+	if err != nil {
+		http.Error(w, "failed to encode rule", http.StatusInternalServerError)
+		return
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(current, &doc); err != nil {
+		http.Error(w, "failed to encode rule", http.StatusInternalServerError)
+		return
+	}
+	merged, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		http.Error(w, "invalid patch", http.StatusBadRequest)
+		return
+	}
+
+	var rule alertmodel.AlertRule
+	if err := json.Unmarshal(merged, &rule); err != nil {
+		http.Error(w, "invalid patch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	rule.ID = id
+
+	h.saveRuleChange(w, r, alertmodel.RevisionUpdate, existing, rule)
+}
+
+// HandleDeleteAlertRuleAPI handles DELETE /api/v1/alerts/rules/{id}
+// The deleted rule is kept in its history so it can be restored by rollback.
+// Its open alerts are resolved.
+func (h *AlertsHandler) HandleDeleteAlertRuleAPI(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+
+	existing, err := h.Sys.Stores.Rules.GetRuleByID(ctx, id)
+	if err != nil {
+		http.Error(w, "rule not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.Sys.Stores.Rules.DeleteRule(ctx, id); err != nil {
+		utils.Error("Failed to delete rule %s: %v", id, err)
+		http.Error(w, "failed to delete rule", http.StatusInternalServerError)
+		return
+	}
+	h.recordRuleRevision(ctx, alertmodel.RevisionDelete, &existing, existing, 0)
+	h.Sys.Tele.Evaluator.RetireRule(ctx, id, h.actingUser(ctx), "rule deleted")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleEnableAlertRuleAPI handles POST /api/v1/alerts/rules/{id}/enable
+func (h *AlertsHandler) HandleEnableAlertRuleAPI(w http.ResponseWriter, r *http.Request) {
+	h.setRuleEnabled(w, r, true)
+}
+
+// HandleDisableAlertRuleAPI handles POST /api/v1/alerts/rules/{id}/disable
+// The rule's open alerts are resolved.
+func (h *AlertsHandler) HandleDisableAlertRuleAPI(w http.ResponseWriter, r *http.Request) {
+	h.setRuleEnabled(w, r, false)
+}
+
+// setRuleEnabled toggles a rule and records the change.
+func (h *AlertsHandler) setRuleEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	existing, err := h.Sys.Stores.Rules.GetRuleByID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "rule not found", http.StatusNotFound)
+		return
+	}
+
+	rule := existing
+	rule.Enabled = enabled
+	action := alertmodel.RevisionDisable
+	if enabled {
+		action = alertmodel.RevisionEnable
+	}
+	h.saveRuleChange(w, r, action, existing, rule)
+}
+
+// HandleAlertRuleRevisionsAPI handles GET /api/v1/alerts/rules/{id}/revisions
+// It returns the rule's change history, newest first. The history of a deleted
+// rule is still available.
+func (h *AlertsHandler) HandleAlertRuleRevisionsAPI(w http.ResponseWriter, r *http.Request) {
+	revisions, err := h.Sys.Stores.Revisions.ListRevisions(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "failed to load revisions", http.StatusInternalServerError)
+		return
+	}
+	if revisions == nil {
+		revisions = []alertmodel.RuleRevision{}
+	}
+	utils.JSON(w, http.StatusOK, revisions)
+}
+
+// HandleRollbackAlertRuleAPI handles POST /api/v1/alerts/rules/{id}/rollback
+// The body names the revision to restore: {"version": 3}. Restoring is itself
+// recorded as a new revision, so a rollback can be undone the same way.
+func (h *AlertsHandler) HandleRollbackAlertRuleAPI(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+
+	var req struct {
+		Version int `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Version <= 0 {
+		http.Error(w, "a positive version is required", http.StatusBadRequest)
+		return
+	}
+
+	rev, err := h.Sys.Stores.Revisions.GetRevision(ctx, id, req.Version)
+	if errors.Is(err, rulestore.ErrRevisionNotFound) {
+		http.Error(w, "revision "+strconv.Itoa(req.Version)+" not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to load revision", http.StatusInternalServerError)
+		return
+	}
+
+	restored := rev.Rule
+	restored.ID = id
+
+	var before *alertmodel.AlertRule
+	if existing, err := h.Sys.Stores.Rules.GetRuleByID(ctx, id); err == nil {
+		before = &existing
+	}
+	if conflict := h.ruleNameTaken(ctx, restored); conflict {
+		http.Error(w, "rule name already exists", http.StatusConflict)
+		return
+	}
+
+	if err := h.Sys.Stores.Rules.UpdateRule(ctx, restored); err != nil {
+		h.writeRuleSaveError(w, err)
+		return
+	}
+	h.recordRuleRevision(ctx, alertmodel.RevisionRollback, before, restored, rev.Version)
+	if before != nil {
+		h.retireIfDisabled(ctx, *before, restored)
+	}
+
+	utils.JSON(w, http.StatusOK, restored)
+}
+
+// saveRuleChange validates and stores an updated rule, records the revision
+// and writes the saved rule as the response.
+func (h *AlertsHandler) saveRuleChange(w http.ResponseWriter, r *http.Request, action string, before, after alertmodel.AlertRule) {
+	ctx := r.Context()
+
+	if h.ruleNameTaken(ctx, after) {
+		http.Error(w, "rule name already exists", http.StatusConflict)
+		return
+	}
+	if err := h.Sys.Stores.Rules.UpdateRule(ctx, after); err != nil {
+		h.writeRuleSaveError(w, err)
+		return
+	}
+	h.recordRuleRevision(ctx, action, &before, after, 0)
+	h.retireIfDisabled(ctx, before, after)
+
+	utils.JSON(w, http.StatusOK, after)
+}
+
+// retireIfDisabled resolves the open alerts and clears the evaluation state
+// of a rule that a change disabled.
+func (h *AlertsHandler) retireIfDisabled(ctx context.Context, before, after alertmodel.AlertRule) {
+	if before.Enabled && !after.Enabled {
+		h.Sys.Tele.Evaluator.RetireRule(ctx, after.ID, h.actingUser(ctx), "rule disabled")
+	}
+}
+
+// ruleNameTaken reports whether another rule already uses the rule's name.
+func (h *AlertsHandler) ruleNameTaken(ctx context.Context, rule alertmodel.AlertRule) bool {
+	existing, err := h.Sys.Stores.Rules.GetRuleByName(ctx, rule.Name)
+	return err == nil && existing.ID != "" && existing.ID != rule.ID
+}
+
+// writeRuleSaveError maps a rule store error to a response.
+func (h *AlertsHandler) writeRuleSaveError(w http.ResponseWriter, err error) {
+	if errors.Is(err, alertmodel.ErrInvalidRule) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	utils.Error("Failed to save rule: %v", err)
+	http.Error(w, "failed to save rule", http.StatusInternalServerError)
+}
+
+// recordRuleRevision appends a revision to the rule's history. before is nil
+// when the rule did not exist. Failures are logged rather than returned: the
+// rule change itself has already been applied.
+func (h *AlertsHandler) recordRuleRevision(ctx context.Context, action string, before *alertmodel.AlertRule, after alertmodel.AlertRule, restoredFrom int) {
+	if h.Sys.Stores.Revisions == nil {
+		return
+	}
+
+	rev := alertmodel.RuleRevision{
+		RuleID:       after.ID,
+		Action:       action,
+		Author:       h.actingUser(ctx),
+		Rule:         after,
+		RestoredFrom: restoredFrom,
+	}
+	switch {
+	case action == alertmodel.RevisionDelete:
+		// The snapshot is the deleted rule; nothing to diff.
+	case before == nil:
+		rev.Diff = alertmodel.DiffRules(alertmodel.AlertRule{}, after)
+	default:
+		rev.Diff = alertmodel.DiffRules(*before, after)
+	}
+
+	if _, err := h.Sys.Stores.Revisions.AddRevision(ctx, rev); err != nil {
+		utils.Error("Failed to record revision of rule %s: %v", after.ID, err)
+	}
+}
+
+// actingUser returns the username of the session user, falling back to the
+// user ID when the user can't be looked up.
+func (h *AlertsHandler) actingUser(ctx context.Context) string {
+	return sessionUsername(ctx, h.Sys.Stores.Users)
+}
+
+// sessionUsername looks up the username of the session user in users.
+func sessionUsername(ctx context.Context, users userstore.UserStore) string {
+	userID, ok := contextutil.GetUserID(ctx)
+	if !ok {
+		return "unknown"
+	}
+	if user, err := users.GetUserByID(ctx, userID); err == nil && user != nil && user.Username != "" {
+		return user.Username
+	}
+	return userID
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to doc.
+func mergePatch(doc, patch map[string]interface{}) map[string]interface{} {
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(doc, k)
+			continue
+		}
+		if sub, ok := v.(map[string]interface{}); ok {
+			current, _ := doc[k].(map[string]interface{})
+			doc[k] = mergePatch(current, sub)
+			continue
+		}
+		doc[k] = v
+	}
+	return doc
+}
@@ -24,12 +24,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/rules"
 	"github.com/aaronlmathis/gosight-server/internal/sys"
 	"github.com/aaronlmathis/gosight-shared/model"
 	"github.com/aaronlmathis/gosight-shared/utils"
@@ -105,9 +108,10 @@ func (h *AlertsHandler) HandleAlertsAPI(w http.ResponseWriter, r *http.Request)
 func (h *AlertsHandler) HandleActiveAlertsAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	alerts := h.Sys.Tele.Alerts.ListActive()
-	if alerts == nil {
-		alerts = []model.AlertInstance{}
+	active := h.Sys.Tele.Alerts.ListActive()
+	alerts := make([]workflowAlert, 0, len(active))
+	for _, a := range active {
+		alerts = append(alerts, workflowAlert{a, h.Sys.Tele.Alerts.Workflow(r.Context(), a.ID)})
 	}
 
 	if err := json.NewEncoder(w).Encode(alerts); err != nil {
@@ -127,7 +131,7 @@ func (h *AlertsHandler) HandleAlertRulesAPI(w http.ResponseWriter, r *http.Reque
 	}
 
 	if alertRules == nil {
-		alertRules = []model.AlertRule{}
+		alertRules = []alertmodel.AlertRule{}
 	}
 
 	if err := json.NewEncoder(w).Encode(alertRules); err != nil {
@@ -175,7 +179,7 @@ func (h *AlertsHandler) HandleAlertsSummaryAPI(w http.ResponseWriter, r *http.Re
 func (h *AlertsHandler) HandleCreateAlertRuleAPI(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	var rule model.AlertRule
+	var rule alertmodel.AlertRule
 	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
 		utils.Error("Failed to decode alert rule: %v", err)
 		http.Error(w, "invalid JSON", http.StatusBadRequest)
@@ -196,10 +200,10 @@ func (h *AlertsHandler) HandleCreateAlertRuleAPI(w http.ResponseWriter, r *http.
 	}
 
 	if err := h.Sys.Stores.Rules.AddRule(ctx, rule); err != nil {
-		utils.Error("Failed to save rule: %v", err)
-		http.Error(w, "failed to save rule", http.StatusInternalServerError)
+		h.writeRuleSaveError(w, err)
 		return
 	}
+	h.recordRuleRevision(ctx, alertmodel.RevisionCreate, nil, rule, 0)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -211,11 +215,67 @@ func (h *AlertsHandler) HandleCreateAlertRuleAPI(w http.ResponseWriter, r *http.
 	}
 }
 
+// testAlertRuleRequest is the body of POST /api/v1/alerts/rules/test.
+type testAlertRuleRequest struct {
+	Rule  alertmodel.AlertRule `json:"rule"`
+	Start time.Time            `json:"start"`
+	End   time.Time            `json:"end"`
+	Step  string               `json:"step,omitempty"`
+}
+
+// HandleTestAlertRuleAPI handles POST /api/v1/alerts/rules/test
+// It replays historical metrics or logs through the rule evaluation logic and returns
+// when the rule would have fired and resolved, per endpoint. Nothing is stored or dispatched.
+// The range defaults to the last 24 hours.
+func (h *AlertsHandler) HandleTestAlertRuleAPI(w http.ResponseWriter, r *http.Request) {
+	var req testAlertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := req.Rule.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.End.IsZero() {
+		req.End = time.Now().UTC()
+	}
+	if req.Start.IsZero() {
+		req.Start = req.End.Add(-24 * time.Hour)
+	}
+
+	var step time.Duration
+	if req.Step != "" {
+		var err error
+		step, err = time.ParseDuration(req.Step)
+		if err != nil || step <= 0 {
+			http.Error(w, "invalid step", http.StatusBadRequest)
+			return
+		}
+	}
+
+	backtester := rules.NewBacktester(h.Sys.Stores.Metrics, h.Sys.Stores.Logs)
+	result, err := backtester.Run(req.Rule, req.Start, req.End, step)
+	if err != nil {
+		if errors.Is(err, rules.ErrBacktestUnsupported) || errors.Is(err, rules.ErrInvalidBacktest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.Warn("Backtest of rule %s failed: %v", req.Rule.ID, err)
+		http.Error(w, "failed to query historical data", http.StatusInternalServerError)
+		return
+	}
+
+	utils.JSON(w, http.StatusOK, result)
+}
+
 // AlertContextResponse represents the response structure for alert context
 type AlertContextResponse struct {
-	Alert  model.AlertInstance `json:"alert"`
-	Logs   []model.LogEntry    `json:"logs"`
-	Events []model.EventEntry  `json:"events"`
+	Alert    model.AlertInstance        `json:"alert"`
+	Logs     []model.LogEntry           `json:"logs"`
+	Events   []model.EventEntry         `json:"events"`
+	Timeline []alertmodel.TimelineEntry `json:"timeline"`
 }
 
 // HandleAlertContext handles requests to /api/alerts/{id}/context
@@ -282,11 +342,22 @@ func (h *AlertsHandler) HandleAlertContext(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Fetch the alert's state changes, acknowledgements and assignments
+	timeline, err := h.Sys.Tele.Alerts.Timeline(r.Context(), alert.ID)
+	if err != nil {
+		http.Error(w, "failed to fetch alert timeline", http.StatusInternalServerError)
+		return
+	}
+	if timeline == nil {
+		timeline = []alertmodel.TimelineEntry{}
+	}
+
 	// Build the response structure
 	resp := AlertContextResponse{
-		Alert:  alert,  // Include the alert instance in the response
-		Logs:   logs,   // Include the fetched logs in the response
-		Events: events, // Include the fetched events in the response
+		Alert:    alert,    // Include the alert instance in the response
+		Logs:     logs,     // Include the fetched logs in the response
+		Events:   events,   // Include the fetched events in the response
+		Timeline: timeline, // Include the alert's timeline in the response
 	}
 
 	// Send the response as JSON
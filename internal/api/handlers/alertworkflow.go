@@ -0,0 +1,122 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/alerts"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/gorilla/mux"
+)
+
+// workflowAlert is an alert together with who acknowledged it and who it
+// is assigned to.
+type workflowAlert struct {
+	model.AlertInstance
+	alertmodel.Workflow
+}
+
+// alertActionRequest is the optional body of the alert workflow endpoints.
+type alertActionRequest struct {
+	Assignee string `json:"assignee,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HandleAckAlertAPI handles POST /api/v1/alerts/{id}/ack
+// Repeat notifications stop until the alert resolves and fires again.
+func (h *AlertsHandler) HandleAckAlertAPI(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAlertAction(w, r)
+	if !ok {
+		return
+	}
+	alert, err := h.Sys.Tele.Alerts.Acknowledge(r.Context(), mux.Vars(r)["id"], h.actingUser(r.Context()), req.Comment)
+	h.writeAlertActionResult(w, r, alert, err)
+}
+
+// HandleUnackAlertAPI handles POST /api/v1/alerts/{id}/unack
+func (h *AlertsHandler) HandleUnackAlertAPI(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAlertAction(w, r)
+	if !ok {
+		return
+	}
+	alert, err := h.Sys.Tele.Alerts.Unacknowledge(r.Context(), mux.Vars(r)["id"], h.actingUser(r.Context()), req.Comment)
+	h.writeAlertActionResult(w, r, alert, err)
+}
+
+// HandleAssignAlertAPI handles POST /api/v1/alerts/{id}/assign
+// The body names the assignee; without one the alert is assigned to the
+// session user.
+func (h *AlertsHandler) HandleAssignAlertAPI(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAlertAction(w, r)
+	if !ok {
+		return
+	}
+	actor := h.actingUser(r.Context())
+	if req.Assignee == "" {
+		req.Assignee = actor
+	}
+	alert, err := h.Sys.Tele.Alerts.Assign(r.Context(), mux.Vars(r)["id"], req.Assignee, actor, req.Comment)
+	h.writeAlertActionResult(w, r, alert, err)
+}
+
+// HandleResolveAlertAPI handles POST /api/v1/alerts/{id}/resolve
+// It closes the alert by hand; a firing alert's resolve is sent to its routes.
+func (h *AlertsHandler) HandleResolveAlertAPI(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAlertAction(w, r)
+	if !ok {
+		return
+	}
+	alert, err := h.Sys.Tele.Alerts.Resolve(r.Context(), mux.Vars(r)["id"], h.actingUser(r.Context()), req.Comment)
+	h.writeAlertActionResult(w, r, alert, err)
+}
+
+// decodeAlertAction reads the optional request body of a workflow endpoint.
+func decodeAlertAction(w http.ResponseWriter, r *http.Request) (alertActionRequest, bool) {
+	var req alertActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// writeAlertActionResult writes the updated alert and its workflow, or maps
+// the manager's error to an HTTP status.
+func (h *AlertsHandler) writeAlertActionResult(w http.ResponseWriter, r *http.Request, alert model.AlertInstance, err error) {
+	switch {
+	case err == nil:
+		utils.JSON(w, http.StatusOK, workflowAlert{alert, h.Sys.Tele.Alerts.Workflow(r.Context(), alert.ID)})
+	case errors.Is(err, alerts.ErrAlertNotFound):
+		http.Error(w, "alert not found", http.StatusNotFound)
+	case errors.Is(err, alerts.ErrAlertClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		utils.Error("Failed to update alert %s: %v", alert.ID, err)
+		http.Error(w, "failed to update alert", http.StatusInternalServerError)
+	}
+}
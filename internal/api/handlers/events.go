@@ -4,15 +4,15 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
-	"sort"
-	"strconv"
 	"time"
 
+	"github.com/aaronlmathis/gosight-server/internal/store/eventstore"
 	"github.com/aaronlmathis/gosight-server/internal/sys"
 	"github.com/aaronlmathis/gosight-shared/model"
 	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/gorilla/mux"
 )
 
 // EventsHandler provides handlers for events API endpoints
@@ -27,99 +27,109 @@ func NewEventsHandler(sys *sys.SystemContext) *EventsHandler {
 	}
 }
 
-// HandleEventsAPI handles api calls to /api/events.
-// It returns a list of events based on the provided filters.
+// EventResponse represents the response structure for event API calls
+type EventResponse struct {
+	Events     []model.EventEntry `json:"events"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+	HasMore    bool               `json:"has_more"`
+	Count      int                `json:"count"`
+}
+
+// HandleEventsAPI handles api calls to /api/v1/events.
+// It returns a page of events based on the provided filters.
 // The filters are:
 // - level: the level of the event (e.g., "info", "error")
 // - type: the type of the event (e.g., "alert", "notification")
 // - category: the category of the event (e.g., "system", "application")
 // - source: the source of the event (e.g., "agent", "server")
-// - contains: a string that must be contained in the event message
 // - scope: the scope of the event (e.g., "global", "local")
 // - target: the target of the event (e.g., "user", "system")
+// - endpoint_id: the endpoint the event is about
+// - host_id: the host the event is about, from its host_id meta
+// - q: full-text search; every word must appear in the message or meta
 // - start: the start timestamp for the event (RFC3339 format)
 // - end: the end timestamp for the event (RFC3339 format)
-// - limit: the maximum number of events to return (default: 100)
-// The response is a JSON array of event entries.
-// If no filters are provided, all events are returned.
+// - sort: "asc" for oldest first (default: newest first)
+// - limit: the maximum number of events to return (default: 100, max: 1000)
+// - cursor: the next_cursor of the previous page
+// The response holds the events and, when there are more, the cursor of
+// the next page.
 func (h *EventsHandler) HandleEventsAPI(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	utils.Debug("Received request for events API with query: %s", q.Encode())
 
-	filter := model.EventFilter{}
-
-	if limit := q.Get("limit"); limit != "" {
-		if n, err := strconv.Atoi(limit); err == nil {
-			filter.Limit = n
+	query := eventstore.EventQuery{
+		Level:      q.Get("level"),
+		Type:       q.Get("type"),
+		Category:   q.Get("category"),
+		Scope:      q.Get("scope"),
+		Target:     q.Get("target"),
+		Source:     q.Get("source"),
+		EndpointID: q.Get("endpoint_id"),
+		HostID:     q.Get("host_id"),
+		Search:     q.Get("q"),
+		Cursor:     q.Get("cursor"),
+		Order:      q.Get("sort"),
+		Limit:      utils.ParseIntOrDefault(q.Get("limit"), 100),
+	}
+	if query.EndpointID == "" {
+		query.EndpointID = q.Get("endpointID")
+	}
+	if query.HostID == "" {
+		query.HostID = q.Get("hostID")
+	}
+	if query.Search == "" {
+		query.Search = q.Get("contains")
+	}
+	if query.Limit <= 0 || query.Limit > 1000 {
+		query.Limit = 100
+	}
+	for key, t := range map[string]*time.Time{"start": &query.Start, "end": &query.End} {
+		v := q.Get(key)
+		if v == "" {
+			continue
 		}
-	} else {
-		filter.Limit = 100
-	}
-	if v := q.Get("level"); v != "" {
-		filter.Level = v
-	}
-	if v := q.Get("type"); v != "" {
-		filter.Type = v
-	}
-	if v := q.Get("category"); v != "" {
-		filter.Category = v
-	}
-	if v := q.Get("scope"); v != "" {
-		filter.Scope = v
-	}
-	if v := q.Get("target"); v != "" {
-		filter.Target = v
-	}
-	if v := q.Get("source"); v != "" {
-		filter.Source = v
-	}
-	if v := q.Get("contains"); v != "" {
-		filter.Contains = v
-	}
-	if v := q.Get("start"); v != "" {
-		t, err := time.Parse(time.RFC3339, v)
-		if err == nil {
-			filter.Start = &t
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "invalid "+key+" time", http.StatusBadRequest)
+			return
 		}
-	}
-	if v := q.Get("hostID"); v != "" {
-		filter.HostID = v
+		*t = parsed
 	}
 
-	if v := q.Get("endpointID"); v != "" {
-		filter.EndpointID = v
-	}
-	if v := q.Get("end"); v != "" {
-		t, err := time.Parse(time.RFC3339, v)
-		if err == nil {
-			filter.End = &t
-		}
-	}
-	if v := q.Get("sort"); v != "" {
-		filter.SortOrder = v
+	page, err := h.Sys.Stores.Events.QueryEvents(r.Context(), query)
+	if errors.Is(err, eventstore.ErrInvalidCursor) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	utils.Debug("Calling GetRecentEvents with filter: %+v", filter)
-	results, err := h.Sys.Stores.Events.GetRecentEvents(r.Context(), filter)
 	if err != nil {
 		http.Error(w, "Failed to query events", http.StatusInternalServerError)
 		utils.Error("Error querying events: %v", err)
 		return
 	}
-	switch filter.SortOrder {
-	case "asc":
-		sort.Slice(results, func(i, j int) bool {
-			return results[i].Timestamp.Before(results[j].Timestamp)
-		})
-	case "desc":
-		sort.Slice(results, func(i, j int) bool {
-			return results[i].Timestamp.After(results[j].Timestamp)
-		})
+	if page.Events == nil {
+		page.Events = []model.EventEntry{}
 	}
 
-	if results == nil {
-		results = []model.EventEntry{}
-	}
+	utils.JSON(w, http.StatusOK, EventResponse{
+		Events:     page.Events,
+		NextCursor: page.NextCursor,
+		HasMore:    page.NextCursor != "",
+		Count:      len(page.Events),
+	})
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(results)
+// HandleGetEventAPI handles GET /api/v1/events/{id}
+func (h *EventsHandler) HandleGetEventAPI(w http.ResponseWriter, r *http.Request) {
+	event, err := h.Sys.Stores.Events.GetByID(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, eventstore.ErrEventNotFound) {
+		http.Error(w, "event not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.Error("Error fetching event: %v", err)
+		http.Error(w, "Failed to fetch event", http.StatusInternalServerError)
+		return
+	}
+	utils.JSON(w, http.StatusOK, event)
 }
@@ -0,0 +1,123 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/incidentstore"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/gorilla/mux"
+)
+
+// HandleListIncidentsAPI handles GET /api/v1/incidents
+// It returns incidents newest first, without their timelines. The filters are:
+// - status: "open" or "resolved"
+// - endpoint_id: incidents involving this endpoint
+// - start, end: incidents started in this range (RFC3339 format)
+// - limit: the maximum number of incidents to return (default: 100, max: 1000)
+func (h *EventsHandler) HandleListIncidentsAPI(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := alertmodel.IncidentFilter{
+		Status:     q.Get("status"),
+		EndpointID: q.Get("endpoint_id"),
+		Limit:      utils.ParseIntOrDefault(q.Get("limit"), 100),
+	}
+	if filter.Limit <= 0 || filter.Limit > 1000 {
+		filter.Limit = 100
+	}
+	for key, t := range map[string]*time.Time{"start": &filter.Start, "end": &filter.End} {
+		v := q.Get(key)
+		if v == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "invalid "+key+" time", http.StatusBadRequest)
+			return
+		}
+		*t = parsed
+	}
+
+	list, err := h.Sys.Stores.Incidents.ListIncidents(r.Context(), filter)
+	if err != nil {
+		utils.Error("Failed to list incidents: %v", err)
+		http.Error(w, "failed to list incidents", http.StatusInternalServerError)
+		return
+	}
+	incidents := make([]alertmodel.Incident, 0, len(list))
+	for _, inc := range list {
+		inc.Timeline = nil
+		incidents = append(incidents, inc)
+	}
+	utils.JSON(w, http.StatusOK, incidents)
+}
+
+// HandleGetIncidentAPI handles GET /api/v1/incidents/{id}
+// The incident is returned with its timeline.
+func (h *EventsHandler) HandleGetIncidentAPI(w http.ResponseWriter, r *http.Request) {
+	inc, err := h.Sys.Stores.Incidents.GetIncident(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeIncidentError(w, err)
+		return
+	}
+	utils.JSON(w, http.StatusOK, inc)
+}
+
+// HandleIncidentTimelineAPI handles GET /api/v1/incidents/{id}/timeline
+func (h *EventsHandler) HandleIncidentTimelineAPI(w http.ResponseWriter, r *http.Request) {
+	inc, err := h.Sys.Stores.Incidents.GetIncident(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeIncidentError(w, err)
+		return
+	}
+	if inc.Timeline == nil {
+		inc.Timeline = []alertmodel.IncidentEvent{}
+	}
+	utils.JSON(w, http.StatusOK, inc.Timeline)
+}
+
+// HandleResolveIncidentAPI handles POST /api/v1/incidents/{id}/resolve
+// The incident is closed even if alerts in it are still firing; later events
+// open a new incident.
+func (h *EventsHandler) HandleResolveIncidentAPI(w http.ResponseWriter, r *http.Request) {
+	actor := sessionUsername(r.Context(), h.Sys.Stores.Users)
+	inc, err := h.Sys.Tele.Incidents.Resolve(r.Context(), mux.Vars(r)["id"], actor)
+	if err != nil {
+		writeIncidentError(w, err)
+		return
+	}
+	utils.JSON(w, http.StatusOK, inc)
+}
+
+// writeIncidentError maps incident store errors to HTTP responses.
+func writeIncidentError(w http.ResponseWriter, err error) {
+	if errors.Is(err, incidentstore.ErrIncidentNotFound) {
+		http.Error(w, "incident not found", http.StatusNotFound)
+		return
+	}
+	utils.Error("Incident request failed: %v", err)
+	http.Error(w, "incident request failed", http.StatusInternalServerError)
+}
@@ -0,0 +1,115 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/core/events/dispatcher"
+	"github.com/aaronlmathis/gosight-server/internal/store/notificationstore"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/gorilla/mux"
+)
+
+// HandleListNotificationsAPI handles GET /api/v1/notifications
+// It lists notification deliveries newest first, filtered by status
+// (pending, retrying, delivered, dead), route_id, rule_id, alert_id and
+// action type, with limit and page.
+func (h *AlertsHandler) HandleListNotificationsAPI(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	h.listNotifications(w, r, strings.TrimSpace(query.Get("status")))
+}
+
+// HandleDeadLetterNotificationsAPI handles GET /api/v1/notifications/dead
+// It lists the deliveries that ran out of attempts.
+func (h *AlertsHandler) HandleDeadLetterNotificationsAPI(w http.ResponseWriter, r *http.Request) {
+	h.listNotifications(w, r, alertmodel.DeliveryDead)
+}
+
+// HandleGetNotificationAPI handles GET /api/v1/notifications/{id}
+func (h *AlertsHandler) HandleGetNotificationAPI(w http.ResponseWriter, r *http.Request) {
+	del, err := h.Sys.Stores.Notifications.GetDelivery(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.writeNotificationError(w, err)
+		return
+	}
+	utils.JSON(w, http.StatusOK, del.Redacted())
+}
+
+// HandleRetryNotificationAPI handles POST /api/v1/notifications/{id}/retry
+// It sends a failed or dead-lettered notification again right away.
+func (h *AlertsHandler) HandleRetryNotificationAPI(w http.ResponseWriter, r *http.Request) {
+	del, err := h.Sys.Tele.Dispatcher.Retry(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.writeNotificationError(w, err)
+		return
+	}
+	utils.JSON(w, http.StatusOK, del.Redacted())
+}
+
+// listNotifications writes the deliveries matching the request's filters.
+func (h *AlertsHandler) listNotifications(w http.ResponseWriter, r *http.Request, status string) {
+	query := r.URL.Query()
+	limit := utils.ParseIntOrDefault(query.Get("limit"), 100)
+	page := utils.ParseIntOrDefault(query.Get("page"), 1)
+	if limit <= 0 {
+		limit = 100
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	list, err := h.Sys.Stores.Notifications.ListDeliveries(r.Context(), notificationstore.DeliveryQuery{
+		Status:     status,
+		RouteID:    strings.TrimSpace(query.Get("route_id")),
+		RuleID:     strings.TrimSpace(query.Get("rule_id")),
+		AlertID:    strings.TrimSpace(query.Get("alert_id")),
+		ActionType: strings.TrimSpace(query.Get("type")),
+		Limit:      limit,
+		Offset:     (page - 1) * limit,
+	})
+	if err != nil {
+		h.writeNotificationError(w, err)
+		return
+	}
+	for i := range list {
+		list[i] = list[i].Redacted()
+	}
+	utils.JSON(w, http.StatusOK, list)
+}
+
+// writeNotificationError maps notification store and retry errors to HTTP
+// responses.
+func (h *AlertsHandler) writeNotificationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, notificationstore.ErrDeliveryNotFound):
+		http.Error(w, "notification not found", http.StatusNotFound)
+	case errors.Is(err, dispatcher.ErrAlreadyDelivered), errors.Is(err, dispatcher.ErrDeliveryInFlight):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		utils.Error("Notification store error: %v", err)
+		http.Error(w, "notification store error", http.StatusInternalServerError)
+	}
+}
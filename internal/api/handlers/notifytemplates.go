@@ -0,0 +1,77 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aaronlmathis/gosight-server/internal/core/events/dispatcher"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+// templatePreviewRequest is the body of the template preview endpoint.
+type templatePreviewRequest struct {
+	Template string `json:"template"`
+	Format   string `json:"format,omitempty"`   // "text" (default) or "html"
+	AlertID  string `json:"alert_id,omitempty"` // render against this alert instead of a sample
+}
+
+// templatePreviewResponse is the rendered template.
+type templatePreviewResponse struct {
+	Rendered string `json:"rendered"`
+	AlertID  string `json:"alert_id,omitempty"`
+}
+
+// HandlePreviewTemplateAPI handles POST /api/v1/alerts/templates/preview
+// It renders a notification template against a stored alert, or a sample
+// alert when no alert_id is given, the way a route action would render it.
+func (h *AlertsHandler) HandlePreviewTemplateAPI(w http.ResponseWriter, r *http.Request) {
+	var req templatePreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Format != "" && req.Format != "text" && req.Format != "html" {
+		http.Error(w, "format must be text or html", http.StatusBadRequest)
+		return
+	}
+
+	var event *model.EventEntry
+	if req.AlertID != "" {
+		alert, err := h.Sys.Stores.Alerts.GetByID(r.Context(), req.AlertID)
+		if err != nil {
+			http.Error(w, "alert not found", http.StatusNotFound)
+			return
+		}
+		e := dispatcher.AlertEvent(&alert)
+		event = &e
+	}
+
+	rendered, err := h.Sys.Tele.Dispatcher.Preview(r.Context(), req.Template, req.Format == "html", event)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	utils.JSON(w, http.StatusOK, templatePreviewResponse{Rendered: rendered, AlertID: req.AlertID})
+}
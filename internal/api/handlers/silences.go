@@ -0,0 +1,152 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/silencestore"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// HandleListSilencesAPI handles GET /api/v1/alerts/silences
+// With ?active=true only silences muting alerts right now are returned.
+func (h *AlertsHandler) HandleListSilencesAPI(w http.ResponseWriter, r *http.Request) {
+	silences, err := h.Sys.Stores.Silences.ListSilences(r.Context())
+	if err != nil {
+		utils.Error("Failed to list silences: %v", err)
+		http.Error(w, "failed to list silences", http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("active") == "true" {
+		now := time.Now().UTC()
+		active := make([]alertmodel.Silence, 0, len(silences))
+		for _, s := range silences {
+			if s.ActiveAt(now) {
+				active = append(active, s)
+			}
+		}
+		silences = active
+	}
+	if silences == nil {
+		silences = []alertmodel.Silence{}
+	}
+	utils.JSON(w, http.StatusOK, silences)
+}
+
+// HandleCreateSilenceAPI handles POST /api/v1/alerts/silences
+// The creator is taken from the session; starts_at defaults to now.
+func (h *AlertsHandler) HandleCreateSilenceAPI(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var silence alertmodel.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	silence.ID = uuid.NewString()
+	silence.CreatedBy = h.actingUser(ctx)
+	silence.CreatedAt = time.Now().UTC()
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = silence.CreatedAt
+	}
+
+	if err := silence.Validate(); err != nil {
+		http.Error(w, "invalid silence: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.Sys.Stores.Silences.UpsertSilence(ctx, silence); err != nil {
+		utils.Error("Failed to save silence: %v", err)
+		http.Error(w, "failed to save silence", http.StatusInternalServerError)
+		return
+	}
+	utils.JSON(w, http.StatusCreated, silence)
+}
+
+// HandleGetSilenceAPI handles GET /api/v1/alerts/silences/{id}
+func (h *AlertsHandler) HandleGetSilenceAPI(w http.ResponseWriter, r *http.Request) {
+	silence, err := h.Sys.Stores.Silences.GetSilence(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.writeSilenceError(w, err)
+		return
+	}
+	utils.JSON(w, http.StatusOK, silence)
+}
+
+// HandleUpdateSilenceAPI handles PUT /api/v1/alerts/silences/{id}
+// The ID, creator and creation time of the existing silence are kept.
+func (h *AlertsHandler) HandleUpdateSilenceAPI(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	existing, err := h.Sys.Stores.Silences.GetSilence(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		h.writeSilenceError(w, err)
+		return
+	}
+
+	var silence alertmodel.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	silence.ID = existing.ID
+	silence.CreatedBy = existing.CreatedBy
+	silence.CreatedAt = existing.CreatedAt
+
+	if err := silence.Validate(); err != nil {
+		http.Error(w, "invalid silence: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.Sys.Stores.Silences.UpsertSilence(ctx, silence); err != nil {
+		utils.Error("Failed to save silence %s: %v", silence.ID, err)
+		http.Error(w, "failed to save silence", http.StatusInternalServerError)
+		return
+	}
+	utils.JSON(w, http.StatusOK, silence)
+}
+
+// HandleDeleteSilenceAPI handles DELETE /api/v1/alerts/silences/{id}
+// Alerts it muted notify again on their next evaluation.
+func (h *AlertsHandler) HandleDeleteSilenceAPI(w http.ResponseWriter, r *http.Request) {
+	if err := h.Sys.Stores.Silences.DeleteSilence(r.Context(), mux.Vars(r)["id"]); err != nil {
+		h.writeSilenceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeSilenceError maps silence store errors to HTTP responses.
+func (h *AlertsHandler) writeSilenceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, silencestore.ErrSilenceNotFound) {
+		http.Error(w, "silence not found", http.StatusNotFound)
+		return
+	}
+	utils.Error("Silence store error: %v", err)
+	http.Error(w, "silence store error", http.StatusInternalServerError)
+}
@@ -41,10 +41,23 @@ import (
 //   - GET /alerts/{id} - Get alert by ID (requires gosight:api:events:view permission)
 //   - PUT /alerts/{id} - Update alert (requires gosight:api:events:update permission)
 //   - DELETE /alerts/{id} - Delete alert (requires gosight:api:events:delete permission)
-//   - GET /alerts/{id}/context - Get alert context (requires gosight:api:events:view permission)
-//   - GET /events - List events (requires gosight:api:events:view permission)
-//   - POST /events - Create event (requires gosight:api:events:create permission)
-//   - GET /events/{id} - Get event by ID (requires gosight:api:events:view permission)
+//   - GET /alerts/{id}/context - Get alert context and timeline (requires gosight:api:events:view permission)
+//   - POST /alerts/{id}/ack, /unack, /assign, /resolve - Acknowledge, assign or close an alert (requires gosight:api:alerts:update permission)
+//   - GET/PUT/PATCH/DELETE /alerts/rules/{id} - Read, replace, patch or delete a rule (view/update/delete permissions)
+//   - POST /alerts/rules/{id}/enable, /disable - Toggle a rule (requires gosight:api:alerts:update permission)
+//   - GET /alerts/rules/{id}/revisions - Rule change history (requires gosight:api:alerts:view permission)
+//   - POST /alerts/rules/{id}/rollback - Restore a prior revision (requires gosight:api:alerts:update permission)
+//   - POST /alerts/rules/test - Backtest a rule against stored data (requires gosight:api:alerts:view permission)
+//   - GET/POST /alerts/routes - List or create notification routes (view/create permissions)
+//   - GET/PUT/DELETE /alerts/routes/{id} - Read, replace or delete a route (view/update/delete permissions)
+//   - POST /alerts/routes/{id}/test - Send a test alert through a route's actions (requires gosight:api:alerts:update permission)
+//   - GET/POST /alerts/silences - List (?active=true) or create silences (view/create permissions)
+//   - GET/PUT/DELETE /alerts/silences/{id} - Read, replace or delete a silence (view/update/delete permissions)
+//   - POST /alerts/templates/preview - Render a notification template against a sample or stored alert (requires gosight:api:alerts:view permission)
+//   - GET /notifications - Delivery history, filtered by status, route_id, rule_id, alert_id, type (requires gosight:api:alerts:view permission)
+//   - GET /notifications/dead - Dead-lettered deliveries (requires gosight:api:alerts:view permission)
+//   - GET /notifications/{id} - A delivery and its attempts (requires gosight:api:alerts:view permission)
+//   - POST /notifications/{id}/retry - Send a failed delivery again (requires gosight:api:alerts:update permission)
 func SetupAlertsRoutes(router *mux.Router, alertsHandler *handlers.AlertsHandler, withAccessLog func(http.Handler) http.Handler) {
 	// Configure middleware
 	withAuth := gosightauth.AuthMiddleware(alertsHandler.Sys.Stores.Users)
@@ -71,6 +84,90 @@ func SetupAlertsRoutes(router *mux.Router, alertsHandler *handlers.AlertsHandler
 		secure("gosight:api:alerts:create", http.HandlerFunc(alertsHandler.HandleCreateAlertRuleAPI))).
 		Methods("POST")
 
+	router.Handle("/alerts/rules/test",
+		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandleTestAlertRuleAPI))).
+		Methods("POST")
+
+	router.Handle("/alerts/rules/{id}",
+		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandleGetAlertRuleAPI))).
+		Methods("GET")
+
+	router.Handle("/alerts/rules/{id}",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandleUpdateAlertRuleAPI))).
+		Methods("PUT")
+
+	router.Handle("/alerts/rules/{id}",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandlePatchAlertRuleAPI))).
+		Methods("PATCH")
+
+	router.Handle("/alerts/rules/{id}",
+		secure("gosight:api:alerts:delete", http.HandlerFunc(alertsHandler.HandleDeleteAlertRuleAPI))).
+		Methods("DELETE")
+
+	router.Handle("/alerts/rules/{id}/enable",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandleEnableAlertRuleAPI))).
+		Methods("POST")
+
+	router.Handle("/alerts/rules/{id}/disable",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandleDisableAlertRuleAPI))).
+		Methods("POST")
+
+	router.Handle("/alerts/rules/{id}/revisions",
+		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandleAlertRuleRevisionsAPI))).
+		Methods("GET")
+
+	router.Handle("/alerts/rules/{id}/rollback",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandleRollbackAlertRuleAPI))).
+		Methods("POST")
+
+	router.Handle("/alerts/routes",
+		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandleListRoutesAPI))).
+		Methods("GET")
+
+	router.Handle("/alerts/routes",
+		secure("gosight:api:alerts:create", http.HandlerFunc(alertsHandler.HandleCreateRouteAPI))).
+		Methods("POST")
+
+	router.Handle("/alerts/routes/{id}",
+		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandleGetRouteAPI))).
+		Methods("GET")
+
+	router.Handle("/alerts/routes/{id}",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandleUpdateRouteAPI))).
+		Methods("PUT")
+
+	router.Handle("/alerts/routes/{id}",
+		secure("gosight:api:alerts:delete", http.HandlerFunc(alertsHandler.HandleDeleteRouteAPI))).
+		Methods("DELETE")
+
+	router.Handle("/alerts/routes/{id}/test",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandleTestRouteAPI))).
+		Methods("POST")
+
+	router.Handle("/alerts/silences",
+		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandleListSilencesAPI))).
+		Methods("GET")
+
+	router.Handle("/alerts/silences",
+		secure("gosight:api:alerts:create", http.HandlerFunc(alertsHandler.HandleCreateSilenceAPI))).
+		Methods("POST")
+
+	router.Handle("/alerts/silences/{id}",
+		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandleGetSilenceAPI))).
+		Methods("GET")
+
+	router.Handle("/alerts/silences/{id}",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandleUpdateSilenceAPI))).
+		Methods("PUT")
+
+	router.Handle("/alerts/silences/{id}",
+		secure("gosight:api:alerts:delete", http.HandlerFunc(alertsHandler.HandleDeleteSilenceAPI))).
+		Methods("DELETE")
+
+	router.Handle("/alerts/templates/preview",
+		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandlePreviewTemplateAPI))).
+		Methods("POST")
+
 	router.Handle("/alerts/summary",
 		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandleAlertsSummaryAPI))).
 		Methods("GET")
@@ -79,6 +176,39 @@ func SetupAlertsRoutes(router *mux.Router, alertsHandler *handlers.AlertsHandler
 		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandleAlertContext))).
 		Methods("GET")
 
+	router.Handle("/alerts/{id}/ack",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandleAckAlertAPI))).
+		Methods("POST")
+
+	router.Handle("/alerts/{id}/unack",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandleUnackAlertAPI))).
+		Methods("POST")
+
+	router.Handle("/alerts/{id}/assign",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandleAssignAlertAPI))).
+		Methods("POST")
+
+	router.Handle("/alerts/{id}/resolve",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandleResolveAlertAPI))).
+		Methods("POST")
+
+	// Notification delivery history and dead letters
+	router.Handle("/notifications",
+		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandleListNotificationsAPI))).
+		Methods("GET")
+
+	router.Handle("/notifications/dead",
+		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandleDeadLetterNotificationsAPI))).
+		Methods("GET")
+
+	router.Handle("/notifications/{id}",
+		secure("gosight:api:alerts:view", http.HandlerFunc(alertsHandler.HandleGetNotificationAPI))).
+		Methods("GET")
+
+	router.Handle("/notifications/{id}/retry",
+		secure("gosight:api:alerts:update", http.HandlerFunc(alertsHandler.HandleRetryNotificationAPI))).
+		Methods("POST")
+
 	// Event management endpoints (placeholder for future implementation)
 	// Events are currently handled by the alerts system but may be separated later
 }
@@ -0,0 +1,78 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package routes provides HTTP route configuration for the GoSight API server.
+// This file contains event query and incident routes and handlers.
+package routes
+
+import (
+	"net/http"
+
+	"github.com/aaronlmathis/gosight-server/internal/api/handlers"
+	gosightauth "github.com/aaronlmathis/gosight-server/internal/auth"
+	"github.com/gorilla/mux"
+)
+
+// SetupEventsRoutes configures event query and incident API routes.
+// It sets up endpoints for paging through, searching and reading stored
+// events, and for the incidents that group related events, with appropriate
+// middleware for authentication, authorization, and logging.
+//
+// Protected routes:
+//   - GET /events - Page through and search events (requires gosight:api:events:view permission)
+//   - GET /events/{id} - Get event by ID (requires gosight:api:events:view permission)
+//   - GET /incidents - List incidents (requires gosight:api:events:view permission)
+//   - GET /incidents/{id} - Get incident with its timeline (requires gosight:api:events:view permission)
+//   - GET /incidents/{id}/timeline - Get an incident's timeline (requires gosight:api:events:view permission)
+//   - POST /incidents/{id}/resolve - Resolve an incident (requires gosight:api:events:update permission)
+func SetupEventsRoutes(router *mux.Router, eventsHandler *handlers.EventsHandler, withAccessLog func(http.Handler) http.Handler) {
+	// Configure middleware
+	withAuth := gosightauth.AuthMiddleware(eventsHandler.Sys.Stores.Users)
+
+	// Helper function to create secure handler with permission check
+	secure := func(permission string, handler http.Handler) http.Handler {
+		return withAccessLog(withAuth(gosightauth.RequirePermission(permission, handler, eventsHandler.Sys.Stores.Users)))
+	}
+
+	router.Handle("/events",
+		secure("gosight:api:events:view", http.HandlerFunc(eventsHandler.HandleEventsAPI))).
+		Methods("GET")
+
+	router.Handle("/events/{id}",
+		secure("gosight:api:events:view", http.HandlerFunc(eventsHandler.HandleGetEventAPI))).
+		Methods("GET")
+
+	router.Handle("/incidents",
+		secure("gosight:api:events:view", http.HandlerFunc(eventsHandler.HandleListIncidentsAPI))).
+		Methods("GET")
+
+	router.Handle("/incidents/{id}",
+		secure("gosight:api:events:view", http.HandlerFunc(eventsHandler.HandleGetIncidentAPI))).
+		Methods("GET")
+
+	router.Handle("/incidents/{id}/timeline",
+		secure("gosight:api:events:view", http.HandlerFunc(eventsHandler.HandleIncidentTimelineAPI))).
+		Methods("GET")
+
+	router.Handle("/incidents/{id}/resolve",
+		secure("gosight:api:events:update", http.HandlerFunc(eventsHandler.HandleResolveIncidentAPI))).
+		Methods("POST")
+}
@@ -156,6 +156,7 @@ func setupVersionedRoutes(apiRouter *mux.Router, version string, sys *sys.System
 	rolesHandler := &handlers.RoleHandler{Sys: sys}
 	permissionsHandler := &handlers.PermissionHandler{Sys: sys}
 	alertsHandler := &handlers.AlertsHandler{Sys: sys}
+	eventsHandler := &handlers.EventsHandler{Sys: sys}
 	endpointsHandler := &handlers.EndpointsHandler{Sys: sys}
 	//agentsHandler := &handlers.AgentsHandler{Sys: sys}
 	logsHandler := &handlers.LogsHandler{Sys: sys}
@@ -171,12 +172,12 @@ func setupVersionedRoutes(apiRouter *mux.Router, version string, sys *sys.System
 	switch version {
 	case "v1":
 		setupV1Routes(versionRouter, sys, authHandler, usersHandler, rolesHandler, permissionsHandler, alertsHandler,
-			endpointsHandler, logsHandler, metricsHandler, commandsHandler,
+			eventsHandler, endpointsHandler, logsHandler, metricsHandler, commandsHandler,
 			tagsHandler, labelsHandler, debugHandler, telemetryHandler, withAccessLog)
 	default:
 		// For future versions, we can add more cases here
 		setupV1Routes(versionRouter, sys, authHandler, usersHandler, rolesHandler, permissionsHandler, alertsHandler,
-			endpointsHandler, logsHandler, metricsHandler, commandsHandler,
+			eventsHandler, endpointsHandler, logsHandler, metricsHandler, commandsHandler,
 			tagsHandler, labelsHandler, debugHandler, telemetryHandler, withAccessLog)
 	}
 }
@@ -190,6 +191,7 @@ func setupV1Routes(
 	rolesHandler *handlers.RoleHandler,
 	permissionsHandler *handlers.PermissionHandler,
 	alertsHandler *handlers.AlertsHandler,
+	eventsHandler *handlers.EventsHandler,
 	endpointsHandler *handlers.EndpointsHandler,
 	logsHandler *handlers.LogsHandler,
 	metricsHandler *handlers.MetricsHandler,
@@ -221,6 +223,9 @@ func setupV1Routes(
 	// Setup alerts and events routes
 	SetupAlertsRoutes(router, alertsHandler, withAccessLog)
 
+	// Setup event query routes
+	SetupEventsRoutes(router, eventsHandler, withAccessLog)
+
 	// Setup endpoint management routes
 	SetupEndpointsRoutes(router, endpointsHandler, withAccessLog)
 
@@ -49,6 +49,7 @@ import (
 //   - Buffer size: Maximum items to buffer before forcing a flush
 //   - Flush interval: Time-based automatic flushing
 //   - Workers: Parallel processing threads for the buffer engine
+//   - Overflow policy, failed-flush retry and disk WAL fallback (see bufferOptions)
 //
 // Parameters:
 //   - ctx: Context for buffer engine lifecycle management
@@ -75,16 +76,21 @@ func InitBufferEngine(ctx context.Context, cfg *config.BufferEngineConfig, store
 		if cfg.Metrics.FlushInterval > 0 {
 			interval = cfg.Metrics.FlushInterval
 		}
-		metricBuffer := bufferengine.NewBufferedMetricStore("metrics", stores.Metrics, cfg.Metrics.BufferSize, interval)
+		opts := bufferOptions("metrics", cfg.Metrics.OverflowPolicy, cfg.Metrics.RetryFailedFlush, cfg.Metrics.FallbackDisk)
+		metricBuffer := bufferengine.NewBufferedMetricStore("metrics", stores.Metrics, cfg.Metrics.BufferSize, interval, opts)
 		buffers.Metrics = metricBuffer
 		e.RegisterStore(metricBuffer)
+		if cfg.Metrics.FlushOnDisconnect {
+			buffers.FlushOnDisconnect = append(buffers.FlushOnDisconnect, metricBuffer)
+		}
 	}
 	utils.Info("InitBufferEngine: Log buffering enabled = %v", cfg.Logs.Enabled)
 	if cfg.Logs.Enabled && stores.Logs != nil {
 		if cfg.Logs.FlushInterval > 0 {
 			interval = cfg.Logs.FlushInterval
 		}
-		logBuffer := bufferengine.NewBufferedLogStore("logs", stores.Logs, cfg.Logs.BufferSize, interval)
+		opts := bufferOptions("logs", cfg.Logs.OverflowPolicy, cfg.Logs.RetryFailedFlush, cfg.Logs.FallbackDisk)
+		logBuffer := bufferengine.NewBufferedLogStore("logs", stores.Logs, cfg.Logs.BufferSize, interval, opts)
 		buffers.Logs = logBuffer
 		e.RegisterStore(logBuffer)
 	}
@@ -93,9 +99,13 @@ func InitBufferEngine(ctx context.Context, cfg *config.BufferEngineConfig, store
 		if cfg.Data.FlushInterval > 0 {
 			interval = cfg.Data.FlushInterval
 		}
-		dataBuffer := bufferengine.NewBufferedDataStore(ctx, "data", stores.Data, cfg.Logs.BufferSize, interval)
+		opts := bufferOptions("data", cfg.Data.OverflowPolicy, cfg.Data.RetryFailedFlush, cfg.Data.FallbackDisk)
+		dataBuffer := bufferengine.NewBufferedDataStore(ctx, "data", stores.Data, cfg.Data.BufferSize, interval, opts)
 		buffers.Data = dataBuffer
 		e.RegisterStore(dataBuffer)
+		if cfg.Data.FlushOnDisconnect {
+			buffers.FlushOnDisconnect = append(buffers.FlushOnDisconnect, dataBuffer)
+		}
 	}
 
 	if cfg.Alerts.Enabled && stores.Alerts != nil {
@@ -105,3 +115,37 @@ func InitBufferEngine(ctx context.Context, cfg *config.BufferEngineConfig, store
 	e.Start()
 	return &buffers
 }
+
+// bufferOptions maps a buffer's config onto bufferengine.Options. Without an
+// overflow_policy a full buffer drops new payloads, so ingestion never blocks
+// unless block is configured explicitly. The WAL is capped at
+// max_disk_size_mb (1 GiB if unset).
+func bufferOptions(name, policy string, retry bool, disk config.DiskBufferConfig) bufferengine.Options {
+	switch policy {
+	case bufferengine.OverflowDropOldest, bufferengine.OverflowDropNewest, bufferengine.OverflowBlock:
+	case "":
+		policy = bufferengine.OverflowDropNewest
+	default:
+		utils.Warn("InitBufferEngine: unknown overflow_policy %q for %s buffer, using %s", policy, name, bufferengine.OverflowDropNewest)
+		policy = bufferengine.OverflowDropNewest
+	}
+
+	opts := bufferengine.Options{
+		Overflow:         policy,
+		RetryFailedFlush: retry,
+	}
+	if disk.Enabled {
+		if disk.Path == "" {
+			utils.Warn("InitBufferEngine: fallback_disk enabled for %s buffer without a path; disk fallback disabled", name)
+		} else {
+			maxMB := disk.MaxDiskSizeMB
+			if maxMB <= 0 {
+				maxMB = 1024
+			}
+			opts.WALPath = disk.Path
+			opts.WALMaxBytes = int64(maxMB) << 20
+		}
+	}
+	utils.Info("InitBufferEngine: %s buffer overflow=%s retry=%v fallback_disk=%q", name, opts.Overflow, opts.RetryFailedFlush, opts.WALPath)
+	return opts
+}
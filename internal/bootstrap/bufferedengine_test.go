@@ -0,0 +1,52 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/aaronlmathis/gosight-server/internal/bufferengine"
+	"github.com/aaronlmathis/gosight-server/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferOptionsOverflowPolicy(t *testing.T) {
+	for policy, want := range map[string]string{
+		"":            bufferengine.OverflowDropNewest,
+		"drop_oldest": bufferengine.OverflowDropOldest,
+		"drop_newest": bufferengine.OverflowDropNewest,
+		"block":       bufferengine.OverflowBlock,
+		"wait":        bufferengine.OverflowDropNewest,
+	} {
+		opts := bufferOptions("metrics", policy, true, config.DiskBufferConfig{})
+		assert.Equal(t, want, opts.Overflow, "overflow_policy %q", policy)
+	}
+}
+
+func TestBufferOptionsDiskCap(t *testing.T) {
+	opts := bufferOptions("logs", "", false, config.DiskBufferConfig{Enabled: true, Path: "/var/lib/gosight/buffers/logs"})
+	assert.Equal(t, "/var/lib/gosight/buffers/logs", opts.WALPath)
+	assert.Equal(t, int64(1024)<<20, opts.WALMaxBytes)
+
+	opts = bufferOptions("logs", "", false, config.DiskBufferConfig{Enabled: true})
+	assert.Empty(t, opts.WALPath, "fallback_disk without a path is disabled")
+}
@@ -0,0 +1,72 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package bootstrap
+
+import (
+	"github.com/aaronlmathis/gosight-server/internal/config"
+	"github.com/aaronlmathis/gosight-server/internal/core/events/dispatcher"
+	"github.com/aaronlmathis/gosight-server/internal/store/notificationstore"
+	"github.com/aaronlmathis/gosight-server/internal/store/routestore"
+	"github.com/aaronlmathis/gosight-server/internal/store/rulestore"
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+// InitDispatcher creates the notification dispatcher for the routes in the
+// route store. Notification templates resolve alerts' rules through the rule
+// store and link back to the web console at web.base_url. Deliveries are
+// recorded in the notification store and retried as configured under
+// notifications. Email actions are enabled when smtp.host is set.
+//
+// Parameters:
+//   - cfg: Configuration containing SMTP, notification and web settings
+//   - routeStore: Loaded notification routes
+//   - ruleStore: Rule store used to look up an alert's rule
+//   - notificationStore: Delivery queue and history
+//
+// Returns:
+//   - *dispatcher.Dispatcher: Dispatcher for alert notifications
+func InitDispatcher(cfg *config.Config, routeStore *routestore.RouteStore, ruleStore rulestore.RuleStore, notificationStore notificationstore.NotificationStore) *dispatcher.Dispatcher {
+	d := dispatcher.NewDispatcher(routeStore.BuildMap())
+	d.SetTemplateContext(ruleStore.GetRuleByID, cfg.Web.BaseURL)
+	d.SetDeliveryStore(notificationStore, dispatcher.RetrySettings{
+		MaxAttempts: cfg.Notifications.MaxAttempts,
+		Backoff:     cfg.Notifications.Backoff,
+		MaxBackoff:  cfg.Notifications.MaxBackoff,
+		Retention:   cfg.Notifications.Retention,
+	})
+
+	if cfg.SMTP.Host != "" {
+		utils.Info("Email notifications via %s", cfg.SMTP.Host)
+		d.SetMailer(dispatcher.NewMailer(dispatcher.SMTPSettings{
+			Host:               cfg.SMTP.Host,
+			Port:               cfg.SMTP.Port,
+			Username:           cfg.SMTP.Username,
+			Password:           cfg.SMTP.Password,
+			From:               cfg.SMTP.From,
+			TLS:                cfg.SMTP.TLS,
+			InsecureSkipVerify: cfg.SMTP.InsecureSkipVerify,
+			RateLimit:          cfg.SMTP.RateLimit,
+			RateWindow:         cfg.SMTP.RateWindow,
+		}))
+	}
+	return d
+}
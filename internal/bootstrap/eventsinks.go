@@ -0,0 +1,124 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package bootstrap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aaronlmathis/gosight-server/internal/config"
+	"github.com/aaronlmathis/gosight-server/internal/events/sinks"
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+// InitEventSinks creates the outbound event sinks from the event_sinks list.
+// With none configured the returned Sinks publishes nothing.
+//
+// Supported sink types:
+//   - http: NDJSON batches POSTed to url
+//   - nats: records published to subject, optionally with JetStream acks
+//   - kafka: records produced to topic on the brokers
+//
+// Parameters:
+//   - cfg: Configuration containing the event sinks
+//
+// Returns:
+//   - *sinks.Sinks: The sinks, to be registered with the emitter and alert manager
+//   - error: If a sink is misconfigured or its spool cannot be opened
+func InitEventSinks(cfg *config.Config) (*sinks.Sinks, error) {
+	var publishers []*sinks.Publisher
+	names := make(map[string]bool)
+	for i, sc := range cfg.EventSinks {
+		if sc.Name == "" {
+			sc.Name = fmt.Sprintf("%s-%d", sc.Type, i)
+		}
+		if names[sc.Name] {
+			return nil, fmt.Errorf("duplicate event sink name %q", sc.Name)
+		}
+		names[sc.Name] = true
+
+		var sink sinks.Sink
+		var err error
+		switch sc.Type {
+		case "http":
+			sink, err = sinks.NewHTTPSink(sinks.HTTPSettings{
+				URL:                sc.URL,
+				Headers:            sc.Headers,
+				InsecureSkipVerify: sc.InsecureSkipVerify,
+			})
+		case "nats":
+			var urls []string
+			for _, u := range strings.Split(sc.URL, ",") {
+				if u = strings.TrimSpace(u); u != "" {
+					urls = append(urls, u)
+				}
+			}
+			sink, err = sinks.NewNATSSink(sinks.NATSSettings{
+				URLs:               urls,
+				Subject:            sc.Subject,
+				JetStream:          sc.JetStream,
+				Username:           sc.Username,
+				Password:           sc.Password,
+				Token:              sc.Token,
+				InsecureSkipVerify: sc.InsecureSkipVerify,
+			})
+		case "kafka":
+			sink, err = sinks.NewKafkaSink(sinks.KafkaSettings{
+				Brokers: sc.Brokers,
+				Topic:   sc.Topic,
+			})
+		default:
+			err = fmt.Errorf("unsupported type %q", sc.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("event sink %s: %w", sc.Name, err)
+		}
+
+		settings := sinks.PublisherSettings{
+			Name:       sc.Name,
+			Events:     len(sc.Publish) == 0,
+			BatchSize:  sc.BatchSize,
+			QueueSize:  sc.QueueSize,
+			SpoolDir:   sc.SpoolDir,
+			Timeout:    sc.Timeout,
+			Backoff:    sc.Backoff,
+			MaxBackoff: sc.MaxBackoff,
+		}
+		for _, kind := range sc.Publish {
+			switch kind {
+			case "events":
+				settings.Events = true
+			case "alerts":
+				settings.Alerts = true
+			default:
+				return nil, fmt.Errorf("event sink %s: unknown publish kind %q", sc.Name, kind)
+			}
+		}
+		p, err := sinks.NewPublisher(sink, settings)
+		if err != nil {
+			return nil, err
+		}
+		utils.Info("Event sink %s: publishing to %s", sc.Name, sc.Type)
+		publishers = append(publishers, p)
+	}
+	return sinks.New(publishers...), nil
+}
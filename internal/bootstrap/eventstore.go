@@ -27,6 +27,7 @@ import (
 
 	"github.com/aaronlmathis/gosight-server/internal/config"
 	"github.com/aaronlmathis/gosight-server/internal/store/eventstore"
+	"github.com/aaronlmathis/gosight-server/internal/store/eventstore/localeventstore"
 	"github.com/aaronlmathis/gosight-server/internal/store/eventstore/pgeventstore"
 	"github.com/aaronlmathis/gosight-shared/utils"
 )
@@ -37,7 +38,10 @@ import (
 // debugging, and maintaining system observability.
 //
 // Supported storage engines:
-//   - json: File-based JSON storage for development and small deployments
+//   - memory: In-memory storage for testing and development
+//   - json: File-based JSON Lines storage for development and small deployments
+//   - local: Embedded append-only segment store in the path directory, for
+//     single-node deployments without PostgreSQL
 //   - postgres: PostgreSQL database backend for production environments
 //
 // The JSON backend stores events in local files and is suitable for testing
@@ -51,9 +55,12 @@ import (
 //   - eventstore.EventStore: Initialized event store interface implementation
 //   - error: If storage initialization fails or unsupported engine is specified
 func InitEventStore(cfg *config.Config) (eventstore.EventStore, error) {
-	utils.Info("Initializing user store type: %s", cfg.EventStore.Engine)
+	utils.Info("Initializing event store type: %s", cfg.EventStore.Engine)
 
 	switch cfg.EventStore.Engine {
+	case "memory":
+		return eventstore.NewJSONEventStore("")
+
 	case "json":
 		store, err := eventstore.NewJSONEventStore(cfg.EventStore.Path)
 		if err != nil {
@@ -61,6 +68,12 @@ func InitEventStore(cfg *config.Config) (eventstore.EventStore, error) {
 		}
 		return store, nil
 
+	case "local":
+		return localeventstore.NewLocalEventStore(cfg.EventStore.Path, localeventstore.Options{
+			SegmentSize:     cfg.EventStore.SegmentSize,
+			SegmentDuration: cfg.EventStore.SegmentDuration,
+		})
+
 	case "postgres":
 
 		db, err := sql.Open("postgres", cfg.EventStore.DSN) // TODO 	Fix this to be more generic
@@ -0,0 +1,78 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package bootstrap
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/aaronlmathis/gosight-server/internal/config"
+	"github.com/aaronlmathis/gosight-server/internal/store/incidentstore"
+	"github.com/aaronlmathis/gosight-server/internal/store/incidentstore/pgincidentstore"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	_ "github.com/lib/pq"
+)
+
+// InitIncidentStore initializes the store for incidents built by correlating
+// events. Without an explicit engine, incidents are kept next to the alert
+// instances: in postgres when the alert store uses it.
+//
+// Supported storage engines:
+//   - json: A JSON file at incidents.path
+//   - memory: In-memory storage; incidents are lost on restart
+//   - postgres: The incidents table; the DSN defaults to the alert store's
+//
+// Parameters:
+//   - cfg: Configuration containing incident and alert store settings
+//
+// Returns:
+//   - incidentstore.IncidentStore: Initialized incident store
+//   - error: If the store cannot be opened or the engine is unsupported
+func InitIncidentStore(cfg *config.Config) (incidentstore.IncidentStore, error) {
+	engine := cfg.Incidents.Engine
+	if engine == "" {
+		engine = cfg.AlertStore.Engine
+	}
+
+	utils.Info("Initializing incident store type: %s", engine)
+	switch engine {
+	case "json":
+		return incidentstore.NewJSONIncidentStore(cfg.Incidents.Path)
+	case "memory":
+		return incidentstore.NewJSONIncidentStore("")
+	case "postgres":
+		dsn := cfg.Incidents.DSN
+		if dsn == "" {
+			dsn = cfg.AlertStore.DSN
+		}
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.Ping(); err != nil {
+			return nil, err
+		}
+		return pgincidentstore.NewPGIncidentStore(db), nil
+	default:
+		return nil, fmt.Errorf("unsupported incident store type: %s", engine)
+	}
+}
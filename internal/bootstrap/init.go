@@ -26,10 +26,11 @@ import (
 	"fmt"
 
 	"github.com/aaronlmathis/gosight-server/internal/alerts"
-	"github.com/aaronlmathis/gosight-server/internal/core/events/dispatcher"
 	"github.com/aaronlmathis/gosight-server/internal/events"
+	"github.com/aaronlmathis/gosight-server/internal/incidents"
 	"github.com/aaronlmathis/gosight-server/internal/rules"
 	"github.com/aaronlmathis/gosight-server/internal/store/metastore"
+	"github.com/aaronlmathis/gosight-server/internal/store/routestore"
 	"github.com/aaronlmathis/gosight-server/internal/syncmanager"
 	"github.com/aaronlmathis/gosight-server/internal/sys"
 	"github.com/aaronlmathis/gosight-server/internal/tracker"
@@ -125,25 +126,62 @@ func InitGoSight(ctx context.Context, configFlag *string) (*sys.SystemContext, e
 	alertStore, err := InitAlertStore(cfg)
 	utils.Must("Alert store", err)
 
+	// Initialize silence store
+	silenceStore, err := InitSilenceStore(cfg)
+	utils.Must("Silence store", err)
+
+	// Initialize notification store
+	notificationStore, err := InitNotificationStore(cfg)
+	utils.Must("Notification store", err)
+
+	// Initialize incident store
+	incidentStore, err := InitIncidentStore(cfg)
+	utils.Must("Incident store", err)
+
 	// Initialize rule store
-	ruleStore, err := InitRuleStore(cfg)
+	ruleStore, err := InitRuleStore(ctx, cfg)
 	utils.Must("Rule store", err)
 
+	// Initialize rule revision history
+	revisionStore, err := InitRuleRevisionStore(cfg, ruleStore)
+	utils.Must("Rule revision store", err)
+
 	// Initialize action store
-	actionStore, err := InitRouteStore(cfg)
+	actionStore, err := InitRouteStore(ctx, cfg)
 	utils.Must("Action store", err)
 
 	// Initialize emitter
 	emitter := events.NewEmitter(eventStore, wsHub.Events)
 
 	// Initialize dispatcher
-	dispatcher := dispatcher.NewDispatcher(actionStore.BuildMap())
+	dispatcher := InitDispatcher(cfg, actionStore, ruleStore, notificationStore)
 
 	// Initialize alert manager
-	alertMgr := alerts.NewManager(emitter, dispatcher, alertStore, wsHub.Alerts)
+	alertMgr := alerts.NewManager(emitter, dispatcher, alertStore, silenceStore, wsHub.Alerts)
+	alertMgr.SetInhibitRules(actionStore.InhibitRules())
+
+	// Apply route changes from the API or the routes file without a restart
+	actionStore.OnChange(func(rs *routestore.RouteStore) {
+		dispatcher.SetRoutes(rs.BuildMap())
+		alertMgr.SetInhibitRules(rs.InhibitRules())
+	})
 
 	// Initialize the evaluator
 	evaluator := rules.NewEvaluator(ruleStore, alertMgr)
+	emitter.AddListener(evaluator)
+
+	// Group related events into incidents
+	correlator, err := incidents.NewCorrelator(ctx, incidentStore, wsHub.Events, cfg.Incidents.Window)
+	utils.Must("Incident correlator", err)
+	emitter.AddListener(correlator)
+
+	// Publish events and alert changes to external sinks
+	eventSinks, err := InitEventSinks(cfg)
+	utils.Must("Event sinks", err)
+	if eventSinks.Len() > 0 {
+		emitter.AddListener(eventSinks)
+		alertMgr.AddStateListener(eventSinks)
+	}
 
 	// Initialize user store
 	userStore, err := InitUserStore(cfg)
@@ -172,6 +210,9 @@ func InitGoSight(ctx context.Context, configFlag *string) (*sys.SystemContext, e
 	metricStore, err := InitMetricStore(ctx, cfg, caches.Metrics)
 	utils.Must("Metric store", err)
 
+	// Initialize the rule scheduler (rules with an eval_interval)
+	scheduler := rules.NewScheduler(ctx, evaluator, metricStore)
+
 	// Initialize log store
 	logStore, err := InitLogStore(ctx, cfg, caches.Logs)
 	utils.Must("Log store", err)
@@ -187,8 +228,12 @@ func InitGoSight(ctx context.Context, configFlag *string) (*sys.SystemContext, e
 		dataStore,
 		eventStore,
 		ruleStore,
+		revisionStore,
 		actionStore,
 		alertStore,
+		silenceStore,
+		notificationStore,
+		incidentStore,
 		resourceStore,
 	)
 	buffers := InitBufferEngine(ctx, &cfg.BufferEngine, stores)
@@ -197,9 +242,12 @@ func InitGoSight(ctx context.Context, configFlag *string) (*sys.SystemContext, e
 		metricIndex,
 		metaTracker,
 		evaluator,
+		scheduler,
 		alertMgr,
 		emitter,
 		dispatcher,
+		correlator,
+		eventSinks,
 		resourceDiscovery,
 	)
 
@@ -0,0 +1,79 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package bootstrap
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/aaronlmathis/gosight-server/internal/config"
+	"github.com/aaronlmathis/gosight-server/internal/store/notificationstore"
+	"github.com/aaronlmathis/gosight-server/internal/store/notificationstore/pgnotificationstore"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	_ "github.com/lib/pq"
+)
+
+// InitNotificationStore initializes the store behind the notification
+// delivery queue, which also keeps the delivery history and dead letters.
+// Without an explicit engine, deliveries are kept next to the alert
+// instances: in postgres when the alert store uses it.
+//
+// Supported storage engines:
+//   - json: A JSON file at notificationstore.path
+//   - memory: In-memory storage; pending retries are lost on restart
+//   - postgres: The notification_deliveries table; the DSN defaults to the alert store's
+//
+// Parameters:
+//   - cfg: Configuration containing notification and alert store settings
+//
+// Returns:
+//   - notificationstore.NotificationStore: Initialized notification store
+//   - error: If the store cannot be opened or the engine is unsupported
+func InitNotificationStore(cfg *config.Config) (notificationstore.NotificationStore, error) {
+	engine := cfg.NotificationStore.Engine
+	if engine == "" {
+		engine = cfg.AlertStore.Engine
+	}
+
+	utils.Info("Initializing notification store type: %s", engine)
+	switch engine {
+	case "json":
+		return notificationstore.NewJSONNotificationStore(cfg.NotificationStore.Path)
+	case "memory":
+		return notificationstore.NewJSONNotificationStore("")
+	case "postgres":
+		dsn := cfg.NotificationStore.DSN
+		if dsn == "" {
+			dsn = cfg.AlertStore.DSN
+		}
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.Ping(); err != nil {
+			return nil, err
+		}
+		return pgnotificationstore.NewPGNotificationStore(db), nil
+	default:
+		return nil, fmt.Errorf("unsupported notification store type: %s", engine)
+	}
+}
@@ -22,36 +22,65 @@ along with GoSight. If not, see https://www.gnu.org/licenses/.
 package bootstrap
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+
 	"github.com/aaronlmathis/gosight-server/internal/config"
 	"github.com/aaronlmathis/gosight-server/internal/store/routestore"
+	"github.com/aaronlmathis/gosight-server/internal/store/routestore/pgroutestore"
+	_ "github.com/lib/pq"
 )
 
 // InitRouteStore initializes the route store component for the GoSight server.
-// The route store manages routing configurations for data flow, load balancing,
-// and service mesh integration. It stores and retrieves routing rules that
-// determine how requests and data are directed throughout the system.
-//
-// Route store capabilities:
-//   - Service routing and load balancing rules
-//   - Data pipeline routing configurations
-//   - Traffic routing and failover rules
-//   - Service mesh integration settings
-//   - Dynamic routing rule updates
+// The route store holds the notification routes alerts are dispatched
+// through and the inhibition rules applied before dispatch. Routes can be
+// changed at runtime through the routes API; edits to the routes file are
+// picked up without a restart, and a file that fails validation is logged
+// while the previous routes stay active.
 //
-// The route store uses file-based storage for routing configurations,
-// allowing for easy management and version control of routing rules.
+// Currently supported storage engines:
+//   - file: Routes are kept in the YAML routes file (default). API changes
+//     rewrite the file.
+//   - postgres: Routes are shared by every server instance through
+//     PostgreSQL and changes are announced with LISTEN/NOTIFY. The routes
+//     file still holds the inhibition rules and seeds an empty table. The
+//     DSN defaults to the alert store's.
 //
 // Parameters:
-//   - cfg: Configuration containing route store settings including file path
+//   - ctx: Context that bounds watching the routes file and the postgres change listener
+//   - cfg: Configuration containing route store settings including engine and file path
 //
 // Returns:
 //   - *routestore.RouteStore: Initialized route store for routing management
-//   - error: If route store initialization or file access fails
-func InitRouteStore(cfg *config.Config) (*routestore.RouteStore, error) {
-	// Initialize the route store
-	routeStore, err := routestore.NewRouteStore(cfg.RouteStore.Path)
+//   - error: If route store initialization fails or unsupported engine is specified
+func InitRouteStore(ctx context.Context, cfg *config.Config) (*routestore.RouteStore, error) {
+	var (
+		routeStore *routestore.RouteStore
+		err        error
+	)
+	switch cfg.RouteStore.Engine {
+	case "", "file":
+		routeStore, err = routestore.NewRouteStore(cfg.RouteStore.Path)
+	case "postgres":
+		dsn := cfg.RouteStore.DSN
+		if dsn == "" {
+			dsn = cfg.AlertStore.DSN
+		}
+		db, dbErr := sql.Open("postgres", dsn)
+		if dbErr != nil {
+			return nil, dbErr
+		}
+		if dbErr := db.Ping(); dbErr != nil {
+			return nil, dbErr
+		}
+		routeStore, err = routestore.NewBackendRouteStore(ctx, cfg.RouteStore.Path, pgroutestore.NewPGRouteStore(db, dsn))
+	default:
+		return nil, errors.New("unsupported route store engine: " + cfg.RouteStore.Engine)
+	}
 	if err != nil {
 		return nil, err
 	}
+	go routeStore.Watch(ctx)
 	return routeStore, nil
 }
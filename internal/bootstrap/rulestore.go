@@ -22,10 +22,15 @@ along with GoSight. If not, see https://www.gnu.org/licenses/.
 package bootstrap
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 
 	"github.com/aaronlmathis/gosight-server/internal/config"
 	"github.com/aaronlmathis/gosight-server/internal/store/rulestore"
+	"github.com/aaronlmathis/gosight-server/internal/store/rulestore/pgrulestore"
+	_ "github.com/lib/pq"
 )
 
 // InitRuleStore initializes the rule store component for the GoSight server.
@@ -43,25 +48,101 @@ import (
 //
 // Currently supported storage engines:
 //   - yaml: File-based YAML storage for human-readable rule definitions
-//   - memory: In-memory storage for testing and development (commented out)
+//   - json: File-based JSON storage
+//   - memory: In-memory storage for testing and development
+//   - postgres: Rules shared by every server instance through PostgreSQL.
+//     Changes are announced with LISTEN/NOTIFY and picked up by all
+//     instances without a restart. The DSN defaults to the alert store's.
 //
 // The YAML engine allows for easy rule management, version control, and
 // collaborative rule development through standard file-based workflows.
 //
 // Parameters:
+//   - ctx: Context that bounds the postgres change listener
 //   - cfg: Configuration containing rule store settings including engine type and file path
 //
 // Returns:
 //   - rulestore.RuleStore: Initialized rule store interface implementation
 //   - error: If rule store initialization fails or unsupported engine is specified
-func InitRuleStore(cfg *config.Config) (rulestore.RuleStore, error) {
+func InitRuleStore(ctx context.Context, cfg *config.Config) (rulestore.RuleStore, error) {
 	switch cfg.RuleStore.Engine {
 	case "yaml":
 		return rulestore.NewYAMLStore(cfg.RuleStore.Path)
-	//case "memory":
-	//return rulestore.NewMemoryStore(), nil
+	case "json":
+		return rulestore.NewJSONStore(cfg.RuleStore.Path)
+	case "memory":
+		return rulestore.NewMemoryStore(), nil
+	case "postgres":
+		dsn := cfg.RuleStore.DSN
+		if dsn == "" {
+			dsn = cfg.AlertStore.DSN
+		}
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.Ping(); err != nil {
+			return nil, err
+		}
+		store, err := pgrulestore.NewPGRuleStore(db, dsn)
+		if err != nil {
+			return nil, err
+		}
+		go store.Watch(ctx)
+		return store, nil
 	default:
 		return nil, errors.New("unsupported rule store engine: " + cfg.RuleStore.Engine)
 	}
 
 }
+
+// InitRuleRevisionStore initializes the store that keeps the change history
+// of alert rules. A rule store that keeps its own history (postgres) is used
+// as-is; otherwise revisions are appended to rulestore.revisions_path, which
+// defaults to a ".revisions.jsonl" file next to the rules file.
+//
+// Parameters:
+//   - cfg: Configuration containing rule store settings
+//   - rules: The initialized rule store
+//
+// Returns:
+//   - rulestore.RevisionStore: Initialized revision store
+//   - error: If the existing history file cannot be read
+func InitRuleRevisionStore(cfg *config.Config, rules rulestore.RuleStore) (rulestore.RevisionStore, error) {
+	if revisions, ok := rules.(rulestore.RevisionStore); ok {
+		return revisions, nil
+	}
+	path := cfg.RuleStore.RevisionsPath
+	if path == "" {
+		path = rulestore.RevisionsPath(cfg.RuleStore.Path)
+	}
+	return rulestore.NewFileRevisionStore(path)
+}
+
+// ImportRules runs the one-shot import of a YAML or JSON rules file into the
+// configured rule store, e.g. when moving from file-based rules to postgres.
+// Rules that already exist are left untouched.
+//
+// Parameters:
+//   - ctx: Context for the import
+//   - configFlag: Path to the server config file
+//   - path: Rules file to import
+//
+// Returns:
+//   - error: If the store cannot be opened or a rule cannot be imported
+func ImportRules(ctx context.Context, configFlag *string, path string) error {
+	cfg := LoadServerConfig(configFlag)
+
+	ruleStore, err := InitRuleStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	revisions, err := InitRuleRevisionStore(cfg, ruleStore)
+	if err != nil {
+		return err
+	}
+
+	imported, skipped, err := rulestore.ImportRules(ctx, ruleStore, revisions, path, "import")
+	fmt.Printf("Imported %d rules from %s (%d already present)\n", imported, path, skipped)
+	return err
+}
@@ -0,0 +1,287 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// File: gosight-server/internal/bufferengine/batch.go
+// Description: Shared batching core for the buffered stores: bounded memory
+// buffer, overflow policy, retry of failed flushes and disk WAL fallback.
+
+package bufferengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+// Overflow policies applied when a buffer is full because its backing store
+// is failing. The same policy decides what happens to a failed batch when the
+// disk WAL has reached its size cap.
+const (
+	OverflowDropOldest = "drop_oldest" // discard the oldest buffered payloads
+	OverflowDropNewest = "drop_newest" // reject the incoming payload
+	OverflowBlock      = "block"       // block writers until a flush succeeds
+)
+
+var (
+	// ErrBufferFull is returned by writes rejected under OverflowDropNewest.
+	ErrBufferFull = errors.New("buffer full")
+	// ErrBufferClosed is returned by writes after the buffer has been closed.
+	ErrBufferClosed = errors.New("buffer closed")
+)
+
+const (
+	defaultRetryBackoff = 5 * time.Second
+	// maxReplaySegments bounds how much WAL backlog one flush replays, so
+	// catching up after an outage doesn't hold the buffer lock for too long.
+	maxReplaySegments = 64
+)
+
+// Options controls how a buffered store behaves when its backing store fails.
+// The zero value keeps the original behaviour: a failed batch is dropped.
+type Options struct {
+	Overflow         string        // OverflowDropOldest, OverflowDropNewest (default) or OverflowBlock
+	RetryFailedFlush bool          // keep a failed batch in memory and retry it on the next flush
+	RetryBackoff     time.Duration // how long size-triggered flushes wait after a failure
+	WALPath          string        // directory failed batches spill to; empty disables the WAL
+	WALMaxBytes      int64         // size cap of the WAL; 0 means unbounded
+}
+
+// batcher is the buffer shared by the typed buffered stores. Payloads are
+// held in memory up to maxSize and written through write. A failed batch is
+// spilled to the WAL when one is configured, kept in memory when
+// RetryFailedFlush is set, and dropped otherwise. Once the WAL holds data,
+// every later batch goes behind it until it has been replayed, so the
+// backing store sees payloads in the order they arrived.
+type batcher[T any] struct {
+	name    string
+	maxSize int
+	opts    Options
+	write   func([]T) error
+	wal     *wal
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	buffer  []T
+	retryAt time.Time
+	dropped int
+	closed  bool
+}
+
+func newBatcher[T any](name string, maxSize int, opts Options, write func([]T) error) *batcher[T] {
+	if maxSize <= 0 {
+		maxSize = 1
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+	switch opts.Overflow {
+	case OverflowDropOldest, OverflowDropNewest, OverflowBlock:
+	default:
+		opts.Overflow = OverflowDropNewest
+	}
+
+	b := &batcher[T]{
+		name:    name,
+		maxSize: maxSize,
+		opts:    opts,
+		write:   write,
+		buffer:  make([]T, 0, maxSize),
+	}
+	b.cond = sync.NewCond(&b.mu)
+
+	if opts.WALPath != "" {
+		w, err := openWAL(opts.WALPath, opts.WALMaxBytes)
+		if err != nil {
+			utils.Error("Buffer [%s] disk fallback disabled: %v", name, err)
+		} else {
+			b.wal = w
+			if !w.empty() {
+				utils.Info("Buffer [%s] has %d spilled batches to replay from %s", name, len(w.segments), opts.WALPath)
+			}
+		}
+	}
+	return b
+}
+
+// add buffers a payload, applying the overflow policy when the buffer is
+// full, and flushes once the buffer reaches maxSize.
+func (b *batcher[T]) add(item T) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for len(b.buffer) >= b.maxSize {
+		if b.closed {
+			return ErrBufferClosed
+		}
+		if !time.Now().Before(b.retryAt) {
+			if err := b.flushLocked(); err == nil && len(b.buffer) < b.maxSize {
+				continue
+			}
+		}
+		if b.wal != nil {
+			// The store is failing; the disk takes over until it is full.
+			b.spillLocked(false)
+			if len(b.buffer) < b.maxSize {
+				continue
+			}
+		}
+		switch b.opts.Overflow {
+		case OverflowDropOldest:
+			n := copy(b.buffer, b.buffer[1:])
+			b.buffer = b.buffer[:n]
+			b.dropped++
+		case OverflowBlock:
+			b.cond.Wait()
+		default:
+			b.dropped++
+			return ErrBufferFull
+		}
+	}
+	if b.closed {
+		return ErrBufferClosed
+	}
+
+	b.buffer = append(b.buffer, item)
+	if len(b.buffer) >= b.maxSize && !time.Now().Before(b.retryAt) {
+		return b.flushLocked()
+	}
+	return nil
+}
+
+// flush writes out the WAL backlog and the buffer.
+func (b *batcher[T]) flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flushLocked()
+}
+
+// close wakes blocked writers and performs a final flush. Anything that
+// cannot be written is spilled to the WAL for the next start.
+func (b *batcher[T]) close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.cond.Broadcast()
+	return b.flushLocked()
+}
+
+func (b *batcher[T]) flushLocked() error {
+	if b.dropped > 0 {
+		utils.Warn("Buffer [%s] dropped %d payloads on overflow (%s)", b.name, b.dropped, b.opts.Overflow)
+		b.dropped = 0
+	}
+
+	if b.wal != nil && !b.wal.empty() {
+		if err := b.replayLocked(); err != nil {
+			b.retryAt = time.Now().Add(b.opts.RetryBackoff)
+			b.spillLocked(true)
+			return fmt.Errorf("replay %s wal: %w", b.name, err)
+		}
+		if !b.wal.empty() {
+			// Still catching up; queue the buffer behind the backlog.
+			b.spillLocked(false)
+			return nil
+		}
+	}
+
+	if len(b.buffer) == 0 {
+		return nil
+	}
+	utils.Debug("Flushing %d payloads from buffer [%s]", len(b.buffer), b.name)
+	if err := b.write(b.buffer); err != nil {
+		b.retryAt = time.Now().Add(b.opts.RetryBackoff)
+		switch {
+		case b.wal != nil:
+			b.spillLocked(true)
+		case b.opts.RetryFailedFlush:
+			// Keep the batch for the next flush.
+		default:
+			b.resetLocked()
+		}
+		return err
+	}
+	b.retryAt = time.Time{}
+	b.resetLocked()
+	return nil
+}
+
+// replayLocked writes spilled batches back to the store, oldest first.
+func (b *batcher[T]) replayLocked() error {
+	for i := 0; i < maxReplaySegments && !b.wal.empty(); i++ {
+		data, err := b.wal.oldest()
+		if err != nil {
+			return err
+		}
+		var batch []T
+		if err := json.Unmarshal(data, &batch); err != nil {
+			utils.Warn("Buffer [%s] discarding unreadable spilled batch: %v", b.name, err)
+		} else if err := b.write(batch); err != nil {
+			return err
+		}
+		if err := b.wal.removeOldest(); err != nil {
+			return err
+		}
+	}
+	if b.wal.empty() {
+		utils.Info("Buffer [%s] finished replaying spilled batches", b.name)
+	}
+	return nil
+}
+
+// spillLocked moves the buffer into the WAL. When the WAL cannot take it,
+// failed decides what happens: a batch the store rejected is handled by the
+// overflow policy, while a batch that is only queued behind the backlog stays
+// in memory.
+func (b *batcher[T]) spillLocked(failed bool) {
+	if len(b.buffer) == 0 {
+		return
+	}
+	data, err := json.Marshal(b.buffer)
+	if err == nil {
+		var evicted int
+		evicted, err = b.wal.append(data, b.opts.Overflow == OverflowDropOldest)
+		if evicted > 0 {
+			utils.Warn("Buffer [%s] evicted %d spilled batches to stay under the disk cap", b.name, evicted)
+		}
+	}
+	if err == nil {
+		b.resetLocked()
+		return
+	}
+	switch {
+	case !failed, b.opts.Overflow == OverflowBlock:
+		return
+	case b.opts.RetryFailedFlush && !errors.Is(err, ErrWALFull):
+		// The disk is failing rather than full; retry from memory.
+		return
+	}
+	utils.Warn("Buffer [%s] dropped %d payloads that could not be spilled: %v", b.name, len(b.buffer), err)
+	b.resetLocked()
+}
+
+func (b *batcher[T]) resetLocked() {
+	b.buffer = make([]T, 0, b.maxSize)
+	b.cond.Broadcast()
+}
@@ -0,0 +1,180 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package bufferengine
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyStore records the batches written to it and fails while down.
+type flakyStore struct {
+	mu      sync.Mutex
+	down    bool
+	batches [][]int
+}
+
+func (s *flakyStore) write(batch []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return errors.New("store unavailable")
+	}
+	s.batches = append(s.batches, append([]int(nil), batch...))
+	return nil
+}
+
+func (s *flakyStore) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+// written returns every payload written so far, in write order.
+func (s *flakyStore) written() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var all []int
+	for _, b := range s.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+func addAll(t *testing.T, b *batcher[int], items ...int) {
+	for _, item := range items {
+		_ = b.add(item)
+	}
+}
+
+func TestBatcherReplaysSpilledBatchesInOrder(t *testing.T) {
+	store := &flakyStore{down: true}
+	b := newBatcher("test", 2, Options{WALPath: t.TempDir(), RetryBackoff: time.Nanosecond}, store.write)
+
+	addAll(t, b, 1, 2, 3, 4)
+	assert.Error(t, b.flush())
+	addAll(t, b, 5)
+	assert.False(t, b.wal.empty())
+
+	store.setDown(false)
+	require.NoError(t, b.flush())
+	assert.True(t, b.wal.empty())
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, store.written())
+}
+
+func TestBatcherDropOldestEvictsSpilledBatches(t *testing.T) {
+	store := &flakyStore{down: true}
+	// Each spilled batch of two ints is 5 bytes ("[1,2]"), so the cap holds two.
+	b := newBatcher("test", 2, Options{
+		Overflow:     OverflowDropOldest,
+		WALPath:      t.TempDir(),
+		WALMaxBytes:  10,
+		RetryBackoff: time.Nanosecond,
+	}, store.write)
+
+	addAll(t, b, 1, 2, 3, 4, 5, 6)
+	assert.Len(t, b.wal.segments, 2)
+
+	store.setDown(false)
+	require.NoError(t, b.flush())
+	assert.Equal(t, []int{3, 4, 5, 6}, store.written())
+}
+
+func TestBatcherPicksUpSpilledBatchesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	down := &flakyStore{down: true}
+	first := newBatcher("test", 10, Options{WALPath: dir}, down.write)
+	addAll(t, first, 1, 2, 3)
+	assert.Error(t, first.close())
+
+	store := &flakyStore{}
+	second := newBatcher("test", 10, Options{WALPath: dir}, store.write)
+	addAll(t, second, 4)
+	require.NoError(t, second.flush())
+	assert.Equal(t, []int{1, 2, 3, 4}, store.written())
+	assert.True(t, second.wal.empty())
+}
+
+func TestBatcherBlockWakesWriterAfterFlush(t *testing.T) {
+	store := &flakyStore{down: true}
+	b := newBatcher("test", 2, Options{
+		Overflow:         OverflowBlock,
+		RetryFailedFlush: true,
+		RetryBackoff:     time.Hour,
+	}, store.write)
+
+	addAll(t, b, 1, 2)
+	done := make(chan error, 1)
+	go func() { done <- b.add(3) }()
+
+	select {
+	case <-done:
+		t.Fatal("add returned while the buffer was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	store.setDown(false)
+	require.NoError(t, b.flush())
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("blocked writer was not woken by the flush")
+	}
+	require.NoError(t, b.flush())
+	assert.Equal(t, []int{1, 2, 3}, store.written())
+}
+
+func TestBatcherBlockedWriterFailsOnClose(t *testing.T) {
+	store := &flakyStore{down: true}
+	b := newBatcher("test", 1, Options{Overflow: OverflowBlock, RetryFailedFlush: true, RetryBackoff: time.Hour}, store.write)
+
+	addAll(t, b, 1)
+	done := make(chan error, 1)
+	go func() { done <- b.add(2) }()
+	time.Sleep(20 * time.Millisecond)
+
+	_ = b.close()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrBufferClosed)
+	case <-time.After(time.Second):
+		t.Fatal("blocked writer was not woken by close")
+	}
+}
+
+func TestBatcherDefaultsToDropNewest(t *testing.T) {
+	store := &flakyStore{down: true}
+	b := newBatcher("test", 2, Options{RetryFailedFlush: true, RetryBackoff: time.Hour}, store.write)
+
+	addAll(t, b, 1, 2)
+	assert.ErrorIs(t, b.add(3), ErrBufferFull)
+
+	store.setDown(false)
+	require.NoError(t, b.flush())
+	assert.Equal(t, []int{1, 2}, store.written())
+}
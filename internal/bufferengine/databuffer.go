@@ -30,12 +30,10 @@ package bufferengine
 import (
 	"context"
 	"errors"
-	"sync"
 	"time"
 
 	"github.com/aaronlmathis/gosight-server/internal/store/datastore"
 	"github.com/aaronlmathis/gosight-shared/model"
-	"github.com/aaronlmathis/gosight-shared/utils"
 )
 
 // DataStore defines the interface for persistent storage of process monitoring
@@ -71,7 +69,10 @@ type DataStore interface {
 //   - Time-based: Periodic flush based on configurable intervals
 //
 // This design ensures optimal performance while preventing unbounded memory
-// growth and providing predictable data persistence guarantees.
+// growth and providing predictable data persistence guarantees. When the
+// underlying store fails, the Options decide whether the batch is spilled to
+// the disk WAL and replayed later, retried from memory, or dropped, and which
+// overflow policy applies once the buffer is full.
 //
 // Key Features:
 //   - Thread-safe concurrent operations with optimized locking
@@ -85,13 +86,11 @@ type DataStore interface {
 // The implementation provides reliable data persistence while minimizing
 // the performance impact on data collection operations.
 type BufferedDataStore struct {
-	name          string                  // Human-readable identifier for logging
-	underlying    datastore.DataStore     // Persistent storage backend
-	buffer        []*model.ProcessPayload // In-memory payload buffer
-	mu            sync.Mutex              // Synchronization for thread safety
-	maxSize       int                     // Maximum buffer size before flush
-	flushInterval time.Duration           // Time-based flush interval
-	ctx           context.Context         // Context for cancellation and timeout
+	name          string                          // Human-readable identifier for logging
+	underlying    datastore.DataStore             // Persistent storage backend
+	buf           *batcher[*model.ProcessPayload] // In-memory buffer with overflow and WAL handling
+	flushInterval time.Duration                   // Time-based flush interval
+	ctx           context.Context                 // Context for cancellation and timeout
 }
 
 // NewBufferedDataStore creates and initializes a new BufferedDataStore instance
@@ -103,6 +102,7 @@ type BufferedDataStore struct {
 //   - maxSize: Controls memory usage and batch size optimization
 //   - flushInterval: Balances latency vs. throughput requirements
 //   - ctx: Enables graceful shutdown and operation cancellation
+//   - opts: Overflow policy, failed-flush retry and disk fallback
 //
 // Parameters:
 //   - ctx: Context for cancellation and timeout control
@@ -110,18 +110,21 @@ type BufferedDataStore struct {
 //   - store: The underlying persistent storage implementation
 //   - maxSize: Maximum buffer size before automatic flush
 //   - flushInterval: Time interval for periodic flush operations
+//   - opts: How overflow and failed flushes are handled
 //
 // Returns:
 //   - *BufferedDataStore: Configured buffer ready for data operations
-func NewBufferedDataStore(ctx context.Context, name string, store datastore.DataStore, maxSize int, flushInterval time.Duration) *BufferedDataStore {
-	return &BufferedDataStore{
+func NewBufferedDataStore(ctx context.Context, name string, store datastore.DataStore, maxSize int, flushInterval time.Duration, opts Options) *BufferedDataStore {
+	b := &BufferedDataStore{
 		name:          name,
 		underlying:    store,
-		buffer:        make([]*model.ProcessPayload, 0, maxSize),
-		maxSize:       maxSize,
 		flushInterval: flushInterval,
 		ctx:           ctx,
 	}
+	b.buf = newBatcher(name, maxSize, opts, func(batch []*model.ProcessPayload) error {
+		return store.Write(b.ctx, batch)
+	})
+	return b
 }
 
 // Name returns the human-readable identifier for this buffered data store
@@ -172,7 +175,10 @@ func (b *BufferedDataStore) WriteAny(payload interface{}) error {
 //
 // When the buffer reaches capacity, an automatic flush operation is triggered
 // to persist the accumulated data. This approach optimizes both memory usage
-// and storage performance by maintaining predictable batch sizes.
+// and storage performance by maintaining predictable batch sizes. If the
+// buffer is still full because the store is failing, the configured overflow
+// policy drops the oldest payload, rejects this one, or blocks until a flush
+// succeeds.
 //
 // Parameters:
 //   - payload: The process payload to buffer for later persistence
@@ -180,14 +186,7 @@ func (b *BufferedDataStore) WriteAny(payload interface{}) error {
 // Returns:
 //   - error: Any error encountered during buffering or automatic flush
 func (b *BufferedDataStore) Write(payload *model.ProcessPayload) error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	b.buffer = append(b.buffer, payload)
-	if len(b.buffer) >= b.maxSize {
-		return b.flushLocked()
-	}
-	return nil
+	return b.buf.add(payload)
 }
 
 // Flush immediately persists all buffered data to the underlying storage
@@ -196,37 +195,13 @@ func (b *BufferedDataStore) Write(payload *model.ProcessPayload) error {
 // sequences or when immediate durability is required.
 //
 // The operation is thread-safe and can be called concurrently with write
-// operations. It ensures all currently buffered data is safely persisted
-// before returning.
+// operations. Batches previously spilled to disk are replayed first so the
+// store receives payloads in arrival order.
 //
 // Returns:
 //   - error: Any error encountered during the flush operation
 func (b *BufferedDataStore) Flush() error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	return b.flushLocked()
-}
-
-// flushLocked performs the core flush operation while holding the buffer lock.
-// This internal method handles the actual data transfer to the underlying
-// storage system, buffer reset, and error handling. It's designed for optimal
-// performance with minimal lock contention.
-//
-// The method atomically transfers the current buffer contents to avoid data
-// loss during concurrent operations and resets the buffer for continued use.
-// Comprehensive logging provides visibility into flush operations for
-// monitoring and debugging purposes.
-//
-// Returns:
-//   - error: Any error encountered during the persistence operation
-func (b *BufferedDataStore) flushLocked() error {
-	if len(b.buffer) == 0 {
-		return nil
-	}
-	toFlush := b.buffer
-	b.buffer = make([]*model.ProcessPayload, 0, b.maxSize)
-	utils.Debug("Flushing %d process payloads from buffer", len(toFlush))
-	return b.underlying.Write(b.ctx, toFlush)
+	return b.buf.flush()
 }
 
 // Close performs graceful shutdown of the buffered data store, ensuring all
@@ -236,10 +211,12 @@ func (b *BufferedDataStore) flushLocked() error {
 //
 // The close operation guarantees that no buffered data is lost during
 // shutdown by performing a final flush before resource cleanup. This
-// ensures data durability across application restarts and shutdowns.
+// ensures data durability across application restarts and shutdowns. With
+// disk fallback enabled, anything the store rejects is left in the WAL for
+// the next start. Writers blocked on a full buffer are released.
 //
 // Returns:
 //   - error: Any error encountered during final flush operation
 func (b *BufferedDataStore) Close() error {
-	return b.Flush()
+	return b.buf.close()
 }
@@ -26,11 +26,9 @@ package bufferengine
 
 import (
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/aaronlmathis/gosight-shared/model"
-	"github.com/aaronlmathis/gosight-shared/utils"
 )
 
 // LogStore is an interface that defines the methods for writing log entries.
@@ -43,12 +41,11 @@ type LogStore interface {
 // BufferedLogStore is a buffered implementation of the LogStore interface.
 // It buffers log entries in memory and flushes them to the underlying log store
 // when the buffer reaches a certain size or after a specified interval.
+// Failed flushes are handled according to its Options.
 type BufferedLogStore struct {
 	name          string
 	underlying    LogStore
-	buffer        []model.LogPayload
-	mu            sync.Mutex
-	maxSize       int
+	buf           *batcher[model.LogPayload]
 	flushInterval time.Duration
 }
 
@@ -57,13 +54,12 @@ type BufferedLogStore struct {
 // The flush interval determines how often the buffer is flushed to the underlying log store.
 // The maximum size determines when the buffer is flushed.
 // The BufferedLogStore is designed to improve performance by reducing the number of write operations
-// to the underlying log store.
-func NewBufferedLogStore(name string, store LogStore, maxSize int, flushInterval time.Duration) *BufferedLogStore {
+// to the underlying log store. The options control overflow and failed-flush handling.
+func NewBufferedLogStore(name string, store LogStore, maxSize int, flushInterval time.Duration, opts Options) *BufferedLogStore {
 	return &BufferedLogStore{
 		name:          name,
 		underlying:    store,
-		buffer:        make([]model.LogPayload, 0, maxSize),
-		maxSize:       maxSize,
+		buf:           newBatcher(name, maxSize, opts, store.Write),
 		flushInterval: flushInterval,
 	}
 }
@@ -95,47 +91,23 @@ func (b *BufferedLogStore) WriteAny(payload interface{}) error {
 // Write writes a log entry to the BufferedLogStore.
 // It appends the entry to the buffer and checks if the buffer size has reached the maximum.
 // If the buffer size exceeds the maximum, it flushes the buffer to the underlying log store.
-// The Write method is thread-safe and uses a mutex to protect the buffer.
+// When the buffer is full because the store is failing, the overflow policy applies.
 func (b *BufferedLogStore) Write(payload model.LogPayload) error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	b.buffer = append(b.buffer, payload)
-	if len(b.buffer) >= b.maxSize {
-		return b.flushLocked()
-	}
-	return nil
+	return b.buf.add(payload)
 }
 
 // Flush flushes the buffer to the underlying log store.
 // It is called to ensure that all buffered log entries are written to the store.
-// The Flush method is thread-safe and uses a mutex to protect the buffer.
+// Any batches spilled to disk are replayed first, in order.
 // It returns an error if the flush operation fails.
 func (b *BufferedLogStore) Flush() error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	return b.flushLocked()
-}
-
-// flushLocked is a helper method that performs the actual flush operation.
-// It is called when the buffer size exceeds the maximum or when the Flush method is called.
-// It clears the buffer and writes the buffered log entries to the underlying log store.
-// The flushLocked method is thread-safe and uses a mutex to protect the buffer.
-// It returns an error if the flush operation fails.
-func (b *BufferedLogStore) flushLocked() error {
-	if len(b.buffer) == 0 {
-		return nil
-	}
-	toFlush := b.buffer
-	b.buffer = make([]model.LogPayload, 0, b.maxSize)
-	utils.Debug("Flushing %d log payloads from buffer", len(toFlush))
-	return b.underlying.Write(toFlush)
+	return b.buf.flush()
 }
 
 // Close closes the BufferedLogStore and flushes any remaining log entries in the buffer.
 // It is called to ensure that all buffered log entries are written to the underlying log store
-// before the BufferedLogStore is closed. The Close method is thread-safe and uses a mutex
-// to protect the buffer. It returns an error if the flush operation fails.
+// before the BufferedLogStore is closed. Writers blocked on a full buffer are released
+// and further writes are rejected. It returns an error if the flush operation fails.
 func (b *BufferedLogStore) Close() error {
-	return b.Flush()
+	return b.buf.close()
 }
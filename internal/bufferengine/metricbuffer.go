@@ -26,7 +26,6 @@ package bufferengine
 
 import (
 	"errors"
-	"sync"
 	"time"
 
 	"github.com/aaronlmathis/gosight-shared/model"
@@ -35,14 +34,13 @@ import (
 // BufferedMetricStore is a buffered implementation of the MetricStore interface.
 // It buffers metric payloads in memory and flushes them to the underlying metric store
 // when the buffer reaches a certain size or after a specified interval.
-// The buffer is protected by a mutex to ensure thread safety.
+// Failed flushes are handled according to its Options: spilled to the disk WAL,
+// retried from memory, or dropped.
 // The BufferedMetricStore is designed to improve performance by reducing the number of write operations
 type BufferedMetricStore struct {
 	name          string
 	underlying    MetricStore
-	buffer        []model.MetricPayload
-	mu            sync.Mutex
-	maxSize       int
+	buf           *batcher[model.MetricPayload]
 	flushInterval time.Duration
 }
 
@@ -60,13 +58,12 @@ type MetricStore interface {
 // The flush interval determines how often the buffer is flushed to the underlying metric store.
 // The maximum size determines when the buffer is flushed.
 // The BufferedMetricStore is designed to improve performance by reducing the number of write operations
-// to the underlying metric store.
-func NewBufferedMetricStore(name string, store MetricStore, maxSize int, flushInterval time.Duration) *BufferedMetricStore {
+// to the underlying metric store. The options control overflow and failed-flush handling.
+func NewBufferedMetricStore(name string, store MetricStore, maxSize int, flushInterval time.Duration, opts Options) *BufferedMetricStore {
 	return &BufferedMetricStore{
 		name:          name,
 		underlying:    store,
-		buffer:        make([]model.MetricPayload, 0, maxSize),
-		maxSize:       maxSize,
+		buf:           newBatcher(name, maxSize, opts, store.Write),
 		flushInterval: flushInterval,
 	}
 }
@@ -99,48 +96,25 @@ func (b *BufferedMetricStore) WriteAny(payload interface{}) error {
 // Write writes a metric payload to the buffered metric store.
 // It appends the payload to the buffer and checks if the buffer size has reached the maximum.
 // If the buffer size exceeds the maximum, it flushes the buffer to the underlying metric store.
-// The Write method is thread-safe and uses a mutex to protect the buffer.
-// It returns an error if the flush operation fails.
+// When the buffer is full because the store is failing, the overflow policy applies.
+// It returns an error if the payload is rejected or the flush operation fails.
 func (b *BufferedMetricStore) Write(payload model.MetricPayload) error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	b.buffer = append(b.buffer, payload)
-	if len(b.buffer) >= b.maxSize {
-		return b.flushLocked()
-	}
-	return nil
+	return b.buf.add(payload)
 }
 
 // Flush flushes the buffer to the underlying metric store.
 // It is called to ensure that all buffered metric payloads are written to the store.
-// The Flush method is thread-safe and uses a mutex to protect the buffer.
+// Any batches spilled to disk are replayed first, in order.
 // It returns an error if the flush operation fails.
 func (b *BufferedMetricStore) Flush() error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	return b.flushLocked()
-}
-
-// flushLocked flushes the buffer to the underlying metric store.
-// It is called when the buffer size exceeds the maximum size or when the Flush method is called.
-// The flushLocked method is thread-safe and uses a mutex to protect the buffer.
-// It returns an error if the flush operation fails.
-func (b *BufferedMetricStore) flushLocked() error {
-	if len(b.buffer) == 0 {
-		return nil
-	}
-	toFlush := b.buffer
-	b.buffer = make([]model.MetricPayload, 0, b.maxSize)
-	//utils.Debug("Flushing %d metric payloads from buffer", len(toFlush))
-	return b.underlying.Write(toFlush)
+	return b.buf.flush()
 }
 
 // Close closes the BufferedMetricStore and flushes any remaining buffered metric payloads.
 // It is called to ensure that all buffered metric payloads are written to the underlying metric store.
-// The Close method is thread-safe and uses a mutex to protect the buffer.
+// Writers blocked on a full buffer are released and further writes are rejected.
 // It returns an error if the flush operation fails.
 // The Close method is typically called when the application is shutting down
 func (b *BufferedMetricStore) Close() error {
-	return b.Flush()
+	return b.buf.close()
 }
@@ -0,0 +1,182 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// File: gosight-server/internal/bufferengine/wal.go
+// Description: Size-capped on-disk write-ahead log that buffered stores spill
+// failed flushes to and replay from once the backing store recovers.
+
+package bufferengine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrWALFull is returned when a batch does not fit under the WAL's size cap.
+var ErrWALFull = errors.New("wal full")
+
+const walExt = ".wal"
+
+// walSegment is one spilled batch on disk.
+type walSegment struct {
+	seq  uint64
+	size int64
+}
+
+// wal keeps each failed batch in its own segment file, named by a
+// monotonically increasing sequence number so replay order matches spill
+// order. A segment is removed only after its batch has been written to the
+// backing store, so a crash mid-replay can duplicate a batch but never lose
+// one. The wal is not safe for concurrent use; callers hold their own lock.
+type wal struct {
+	dir      string
+	maxBytes int64
+	size     int64
+	nextSeq  uint64
+	segments []walSegment
+}
+
+// openWAL opens the WAL in dir, creating the directory if needed and picking
+// up any segments left behind by a previous run.
+func openWAL(dir string, maxBytes int64) (*wal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create wal dir: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read wal dir: %w", err)
+	}
+
+	w := &wal{dir: dir, maxBytes: maxBytes, nextSeq: 1}
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			// A spill that never made it to rename; the batch was not acknowledged.
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		if e.IsDir() || !strings.HasSuffix(name, walExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, walExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		w.segments = append(w.segments, walSegment{seq: seq, size: info.Size()})
+		w.size += info.Size()
+		if seq >= w.nextSeq {
+			w.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(w.segments, func(i, j int) bool { return w.segments[i].seq < w.segments[j].seq })
+	return w, nil
+}
+
+func (w *wal) path(seq uint64) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%020d%s", seq, walExt))
+}
+
+// empty reports whether there is nothing left to replay.
+func (w *wal) empty() bool {
+	return len(w.segments) == 0
+}
+
+// append writes data as a new segment. When the WAL would exceed its cap it
+// evicts the oldest segments if evict is set, and otherwise returns ErrWALFull.
+// It returns the number of segments evicted to make room.
+func (w *wal) append(data []byte, evict bool) (int, error) {
+	need := int64(len(data))
+	if w.maxBytes > 0 && need > w.maxBytes {
+		return 0, ErrWALFull
+	}
+	evicted := 0
+	for w.maxBytes > 0 && w.size+need > w.maxBytes {
+		if !evict || w.empty() {
+			return evicted, ErrWALFull
+		}
+		if err := w.removeOldest(); err != nil {
+			return evicted, err
+		}
+		evicted++
+	}
+
+	seq := w.nextSeq
+	path := w.path(seq)
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return evicted, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return evicted, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return evicted, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return evicted, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return evicted, err
+	}
+
+	w.nextSeq++
+	w.segments = append(w.segments, walSegment{seq: seq, size: need})
+	w.size += need
+	return evicted, nil
+}
+
+// oldest returns the contents of the oldest segment.
+func (w *wal) oldest() ([]byte, error) {
+	if w.empty() {
+		return nil, nil
+	}
+	return os.ReadFile(w.path(w.segments[0].seq))
+}
+
+// removeOldest deletes the oldest segment once it has been replayed or evicted.
+func (w *wal) removeOldest() error {
+	if w.empty() {
+		return nil
+	}
+	seg := w.segments[0]
+	if err := os.Remove(w.path(seg.seq)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	w.segments = w.segments[1:]
+	w.size -= seg.size
+	return nil
+}
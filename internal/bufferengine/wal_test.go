@@ -0,0 +1,99 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package bufferengine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drain replays every segment of w, oldest first.
+func drain(t *testing.T, w *wal) []string {
+	var out []string
+	for !w.empty() {
+		data, err := w.oldest()
+		require.NoError(t, err)
+		out = append(out, string(data))
+		require.NoError(t, w.removeOldest())
+	}
+	return out
+}
+
+func TestWALReplaysInOrder(t *testing.T) {
+	w, err := openWAL(t.TempDir(), 0)
+	require.NoError(t, err)
+
+	for _, batch := range []string{"one", "two", "three"} {
+		_, err := w.append([]byte(batch), false)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"one", "two", "three"}, drain(t, w))
+	assert.Zero(t, w.size)
+}
+
+func TestWALSizeCap(t *testing.T) {
+	w, err := openWAL(t.TempDir(), 10)
+	require.NoError(t, err)
+
+	_, err = w.append([]byte("aaaa"), false)
+	require.NoError(t, err)
+	_, err = w.append([]byte("bbbb"), false)
+	require.NoError(t, err)
+
+	_, err = w.append([]byte("cccc"), false)
+	assert.ErrorIs(t, err, ErrWALFull, "without eviction a full wal rejects the batch")
+
+	evicted, err := w.append([]byte("cccc"), true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, evicted)
+	assert.LessOrEqual(t, w.size, int64(10))
+
+	_, err = w.append([]byte("a batch larger than the cap"), true)
+	assert.ErrorIs(t, err, ErrWALFull)
+
+	assert.Equal(t, []string{"bbbb", "cccc"}, drain(t, w))
+}
+
+func TestWALPicksUpLeftoverSegments(t *testing.T) {
+	dir := t.TempDir()
+	w, err := openWAL(dir, 0)
+	require.NoError(t, err)
+	for _, batch := range []string{"one", "two"} {
+		_, err := w.append([]byte(batch), false)
+		require.NoError(t, err)
+	}
+	// A spill interrupted before its rename.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000000000000009.wal.tmp"), []byte("partial"), 0o644))
+
+	reopened, err := openWAL(dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("one")+len("two")), reopened.size)
+	assert.NoFileExists(t, filepath.Join(dir, "00000000000000000009.wal.tmp"))
+
+	_, err = reopened.append([]byte("three"), false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "three"}, drain(t, reopened))
+}
@@ -81,6 +81,7 @@ type BufferEngineConfig struct {
 //   - BufferSize: Maximum number of metric entries to buffer before forced flush
 //   - FlushInterval: Time-based flush trigger for ensuring data freshness
 //   - DropOnOverflow: Behavior when buffer capacity is exceeded
+//   - OverflowPolicy: drop_oldest, drop_newest (default) or block
 //   - RetryFailedFlush: Retry policy for failed storage operations
 //   - FlushOnDisconnect: Ensure data persistence during network issues
 //   - FallbackDisk: Disk-based backup when memory buffers are full
@@ -100,6 +101,7 @@ type MetricBufferConfig struct {
 	BufferSize        int              `yaml:"buffer_size"`
 	FlushInterval     time.Duration    `yaml:"flush_interval"`
 	DropOnOverflow    bool             `yaml:"drop_on_overflow"`
+	OverflowPolicy    string           `yaml:"overflow_policy"` // drop_oldest | drop_newest | block; drop_newest when unset
 	RetryFailedFlush  bool             `yaml:"retry_failed_flush"`
 	FlushOnDisconnect bool             `yaml:"flush_on_disconnect"`
 	FallbackDisk      DiskBufferConfig `yaml:"fallback_disk"`
@@ -119,6 +121,7 @@ type MetricBufferConfig struct {
 //   - BufferSize: Maximum number of log entries to buffer
 //   - FlushInterval: Maximum time logs remain in buffer
 //   - DropOnOverflow: Policy for handling buffer overflow
+//   - OverflowPolicy: drop_oldest, drop_newest (default) or block
 //   - RetryFailedFlush: Retry mechanism for storage failures
 //   - FallbackDisk: Disk-based overflow protection
 //
@@ -132,6 +135,7 @@ type LogBufferConfig struct {
 	BufferSize       int              `yaml:"buffer_size"`
 	FlushInterval    time.Duration    `yaml:"flush_interval"`
 	DropOnOverflow   bool             `yaml:"drop_on_overflow"`
+	OverflowPolicy   string           `yaml:"overflow_policy"` // drop_oldest | drop_newest | block; drop_newest when unset
 	RetryFailedFlush bool             `yaml:"retry_failed_flush"`
 	FallbackDisk     DiskBufferConfig `yaml:"fallback_disk"`
 }
@@ -151,6 +155,7 @@ type LogBufferConfig struct {
 //   - BufferSize: Maximum entries before forced flush
 //   - FlushInterval: Time-based flush frequency
 //   - DropOnOverflow: Overflow handling strategy
+//   - OverflowPolicy: drop_oldest, drop_newest (default) or block
 //   - RetryFailedFlush: Error recovery mechanism
 //   - FlushOnDisconnect: Network resilience feature
 //   - FallbackDisk: Persistent overflow storage
@@ -163,6 +168,7 @@ type DataBufferConfig struct {
 	BufferSize        int              `yaml:"buffer_size"`
 	FlushInterval     time.Duration    `yaml:"flush_interval"`
 	DropOnOverflow    bool             `yaml:"drop_on_overflow"`
+	OverflowPolicy    string           `yaml:"overflow_policy"` // drop_oldest | drop_newest | block; drop_newest when unset
 	RetryFailedFlush  bool             `yaml:"retry_failed_flush"`
 	FlushOnDisconnect bool             `yaml:"flush_on_disconnect"`
 	FallbackDisk      DiskBufferConfig `yaml:"fallback_disk"`
@@ -250,16 +256,18 @@ type AlertBufferConfig struct {
 //   - MaxDiskSizeMB: Maximum disk space allocation in megabytes
 //
 // Operational behavior:
-//   - Activates when memory buffers reach capacity
-//   - Uses sequential file writes for optimal I/O performance
-//   - Automatically rotates files when size limits are reached
-//   - Provides data recovery during system restarts
+//   - Activates when a flush to the backing store fails
+//   - Each failed batch is written to its own segment file under Path
+//   - Segments are replayed in order once the store recovers, before newer data
+//   - At the size cap the overflow policy applies: drop_oldest evicts the
+//     oldest segments, drop_newest drops the batch, block keeps it in memory
+//   - Segments left on disk are replayed after a restart
 //
 // Performance considerations:
 //   - Disk I/O is slower than memory but provides durability
 //   - Sequential writes minimize disk seek time
 //   - Size limits prevent disk space exhaustion
-//   - File rotation maintains manageable file sizes
+//   - Segments are fsynced before a batch is acknowledged
 //
 // Example configuration:
 //
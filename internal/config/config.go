@@ -85,6 +85,7 @@ type Config struct {
 		TemplateDir   string   `yaml:"template_dir"`
 		DefaultTitle  string   `yaml:"default_title"`
 		AuthProviders []string `yaml:"auth_providers"`
+		BaseURL       string   `yaml:"base_url"` // external URL of the web console, used for links in notifications
 	} `yaml:"web"`
 
 	TLS struct {
@@ -149,18 +150,73 @@ type Config struct {
 	} `yaml:"alertstore"`
 
 	EventStore struct {
-		Engine string `yaml:"engine"`        // "memory", "json", or "postgres"
-		Path   string `yaml:"path"`          // optional path for JSON file
-		DSN    string `yaml:"dsn,omitempty"` // optional DSN for PostgreSQL
+		Engine          string        `yaml:"engine"`                     // "memory", "json", "local", or "postgres"
+		Path            string        `yaml:"path"`                       // JSON file, or directory of the local engine's segments
+		DSN             string        `yaml:"dsn,omitempty"`              // optional DSN for PostgreSQL
+		Retention       time.Duration `yaml:"retention,omitempty"`        // delete events older than this; 0 keeps them forever
+		SegmentSize     int64         `yaml:"segment_size,omitempty"`     // local: bytes per segment file; defaults to 64 MiB
+		SegmentDuration time.Duration `yaml:"segment_duration,omitempty"` // local: start a new segment after this long; defaults to 24h
 	} `yaml:"eventstore"`
 
 	RuleStore struct {
-		Engine string `yaml:"engine"` // "memory", "json", or "postgres"
-		Path   string `yaml:"path"`   // optional path for JSON file
+		Engine        string `yaml:"engine"`                   // "memory", "json", or "postgres"
+		Path          string `yaml:"path"`                     // optional path for JSON file
+		RevisionsPath string `yaml:"revisions_path,omitempty"` // rule change history; defaults next to path
+		DSN           string `yaml:"dsn,omitempty"`            // optional DSN for PostgreSQL; defaults to alertstore.dsn
 	} `yaml:"rulestore"`
 
+	// SMTP configures the server used by "email" route actions.
+	SMTP struct {
+		Host               string        `yaml:"host"`
+		Port               int           `yaml:"port"`                 // defaults to 587, or 465 with implicit TLS
+		Username           string        `yaml:"username"`             // optional; enables PLAIN auth
+		Password           string        `yaml:"password"`             // optional
+		From               string        `yaml:"from"`                 // sender address
+		TLS                string        `yaml:"tls"`                  // "starttls" (default), "tls" for implicit TLS, or "none"
+		InsecureSkipVerify bool          `yaml:"insecure_skip_verify"` // skip certificate verification (testing only)
+		RateLimit          int           `yaml:"rate_limit"`           // max emails per recipient per rate_window; defaults to 20
+		RateWindow         time.Duration `yaml:"rate_window"`          // defaults to 1h
+	} `yaml:"smtp"`
+
+	SilenceStore struct {
+		Engine string `yaml:"engine"`         // "json", "memory", or "postgres"; defaults to postgres when the alert store is
+		Path   string `yaml:"path,omitempty"` // path for JSON file
+		DSN    string `yaml:"dsn,omitempty"`  // optional DSN for PostgreSQL; defaults to alertstore.dsn
+	} `yaml:"silencestore"`
+
+	// NotificationStore persists the notification queue and delivery history.
+	NotificationStore struct {
+		Engine string `yaml:"engine"`         // "json", "memory", or "postgres"; defaults to postgres when the alert store is
+		Path   string `yaml:"path,omitempty"` // path for JSON file
+		DSN    string `yaml:"dsn,omitempty"`  // optional DSN for PostgreSQL; defaults to alertstore.dsn
+	} `yaml:"notificationstore"`
+
+	// Notifications sets how failed notifications are retried. Route actions
+	// can override the retry settings.
+	Notifications struct {
+		MaxAttempts int           `yaml:"max_attempts"` // attempts before a notification is dead-lettered; defaults to 5
+		Backoff     time.Duration `yaml:"backoff"`      // delay before the first retry, doubled per attempt; defaults to 30s
+		MaxBackoff  time.Duration `yaml:"max_backoff"`  // longest delay between retries; defaults to 30m
+		Retention   time.Duration `yaml:"retention"`    // how long delivered and dead notifications are kept; defaults to 168h
+	} `yaml:"notifications"`
+
+	// EventSinks publish every emitted event, and optionally alert state
+	// changes, to external systems.
+	EventSinks []EventSinkConfig `yaml:"event_sinks"`
+
+	// Incidents groups related events into incidents and sets where they
+	// are stored.
+	Incidents struct {
+		Engine string        `yaml:"engine"`           // "json", "memory", or "postgres"; defaults to postgres when the alert store is
+		Path   string        `yaml:"path,omitempty"`   // path for JSON file
+		DSN    string        `yaml:"dsn,omitempty"`    // optional DSN for PostgreSQL; defaults to alertstore.dsn
+		Window time.Duration `yaml:"window,omitempty"` // events on a host this close together join one incident; defaults to 5m
+	} `yaml:"incidents"`
+
 	RouteStore struct {
-		Path string `yaml:"path"` // path for YAML file
+		Engine string `yaml:"engine"` // file (default) or postgres
+		Path   string `yaml:"path"`   // path for YAML file; with postgres it holds the inhibit rules and seeds an empty table
+		DSN    string `yaml:"dsn"`    // postgres only; defaults to alertstore.dsn
 	} `yaml:"routestore"`
 
 	BufferEngine BufferEngineConfig `yaml:"buffer_engine"`
@@ -253,6 +309,61 @@ type SyslogCollectionConfig struct {
 	DefaultIPLimit int  `yaml:"default_ip_limit"`
 }
 
+// EventSinkConfig configures one outbound event sink. Every emitted event,
+// and with "alerts" in publish every alert state change, is queued for the
+// sink and retried with backoff until the sink acknowledges it, so
+// subscribers receive each record at least once. Each record is a JSON
+// envelope {"kind": "event"|"alert", "key", "time", "data"}.
+//
+// Supported types:
+//   - http: POSTs batches as NDJSON, one envelope per line, to url
+//   - nats: publishes each record to subject on the servers in url; with
+//     jetstream it waits for the stream's acknowledgement
+//   - kafka: produces to topic on the brokers, keyed by endpoint, with acks=all
+//
+// With spool_dir set, queued records are kept on disk and survive a restart.
+//
+// Example configuration:
+//
+//	event_sinks:
+//	  - name: siem
+//	    type: http
+//	    url: https://siem.example.com/ingest
+//	    headers:
+//	      Authorization: Bearer token
+//	    publish: [events, alerts]
+//	    spool_dir: /var/lib/gosight/sinks
+//	  - name: bus
+//	    type: kafka
+//	    brokers: [kafka-1:9092, kafka-2:9092]
+//	    topic: gosight.events
+type EventSinkConfig struct {
+	Name    string   `yaml:"name"`    // identifies the sink in logs and its spool file
+	Type    string   `yaml:"type"`    // "http", "nats", or "kafka"
+	Publish []string `yaml:"publish"` // "events" and/or "alerts"; defaults to events
+
+	URL     string            `yaml:"url,omitempty"`     // http: endpoint; nats: comma-separated nats:// or tls:// server URLs
+	Headers map[string]string `yaml:"headers,omitempty"` // http only
+
+	Subject   string `yaml:"subject,omitempty"`   // nats only
+	JetStream bool   `yaml:"jetstream,omitempty"` // nats: wait for a JetStream acknowledgement of every record
+	Token     string `yaml:"token,omitempty"`     // nats: auth token
+
+	Brokers []string `yaml:"brokers,omitempty"` // kafka: bootstrap brokers as host:port
+	Topic   string   `yaml:"topic,omitempty"`   // kafka only
+
+	Username           string `yaml:"username,omitempty"` // nats: user; kafka is unauthenticated
+	Password           string `yaml:"password,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"` // skip TLS certificate verification (testing only)
+
+	BatchSize  int           `yaml:"batch_size,omitempty"`  // records per publish; defaults to 100
+	QueueSize  int           `yaml:"queue_size,omitempty"`  // records held in memory; without a spool new ones are dropped beyond it; defaults to 10000
+	SpoolDir   string        `yaml:"spool_dir,omitempty"`   // keep the queue, and rejected records, on disk in this directory
+	Timeout    time.Duration `yaml:"timeout,omitempty"`     // per publish; defaults to 10s
+	Backoff    time.Duration `yaml:"backoff,omitempty"`     // delay before the first retry, doubled per failure; defaults to 1s
+	MaxBackoff time.Duration `yaml:"max_backoff,omitempty"` // defaults to 1m
+}
+
 // GoogleConfig represents the configuration for Google OAuth2 authentication.
 // This configuration enables Single Sign-On (SSO) authentication through Google's
 // OAuth2 service, allowing users to authenticate using their Google accounts.
@@ -23,7 +23,8 @@ along with GoSight. If not, see https://www.gnu.org/licenses/.
 
 // Package dispatcher provides functionality to manage and dispatch events
 // to various actions based on defined routes. It allows for flexible event handling
-// through the use of webhooks and scripts, enabling integration with external systems
+// through the use of webhooks, scripts, SMTP email and native Slack, Microsoft Teams, PagerDuty
+// and Opsgenie integrations, enabling integration with external systems
 // and custom processing of events.
 package dispatcher
 
@@ -31,105 +32,283 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"net/http"
+	"fmt"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/notificationstore"
 	"github.com/aaronlmathis/gosight-shared/model"
 	"github.com/aaronlmathis/gosight-shared/utils"
 )
 
+// scriptTimeout bounds how long a script action may run.
+const scriptTimeout = time.Minute
+
 // Dispatcher is responsible for managing and dispatching events to various actions based on defined routes.
 type Dispatcher struct {
-	Routes map[string]model.ActionRoute
+	routesLock sync.RWMutex
+	routes     map[string]alertmodel.Route
+
+	lock   sync.Mutex
+	groups map[string]*group // key: route ID and group_by label values
+
+	mailer  *Mailer    // sends email actions; nil when SMTP isn't configured
+	rules   RuleLookup // resolves rule IDs for notification templates
+	baseURL string     // web console URL used for alert links
+
+	store    notificationstore.NotificationStore // delivery queue and history; nil sends once without retries
+	retry    RetrySettings
+	inflight map[string]bool // delivery IDs being attempted
+	queue    chan string     // IDs of new deliveries for Run's first attempt
 }
 
 // NewDispatcher initializes a new Dispatcher with the provided route map.
-// The route map should contain action IDs as keys and ActionRoute structs as values.
-// The ActionRoute struct contains the match filter and a list of actions to be executed.
-func NewDispatcher(routeMap map[string]model.ActionRoute) *Dispatcher {
-	return &Dispatcher{Routes: routeMap}
+// The route map should contain action IDs as keys and Route structs as values.
+// The Route struct contains the match filter and a list of actions to be executed.
+func NewDispatcher(routeMap map[string]alertmodel.Route) *Dispatcher {
+	return &Dispatcher{
+		routes:   routeMap,
+		groups:   make(map[string]*group),
+		inflight: make(map[string]bool),
+		queue:    make(chan string, queueSize),
+	}
+}
+
+// SetRoutes replaces the routes events are dispatched to. Groups already
+// collecting events keep the settings of the route they were opened for.
+func (d *Dispatcher) SetRoutes(routeMap map[string]alertmodel.Route) {
+	d.routesLock.Lock()
+	defer d.routesLock.Unlock()
+
+	d.routes = routeMap
+}
+
+// Route returns the route with the given ID.
+func (d *Dispatcher) Route(id string) (alertmodel.Route, bool) {
+	d.routesLock.RLock()
+	defer d.routesLock.RUnlock()
+
+	r, ok := d.routes[id]
+	return r, ok
+}
+
+// SetMailer sets the Mailer used by email actions.
+func (d *Dispatcher) SetMailer(m *Mailer) {
+	d.mailer = m
+}
+
+// SetTemplateContext sets how notification templates resolve an alert's rule
+// and the base URL of links back to the alert.
+func (d *Dispatcher) SetTemplateContext(rules RuleLookup, baseURL string) {
+	d.rules = rules
+	d.baseURL = baseURL
 }
 
 // Dispatch processes an event against all routes and triggers matching actions.
+// Routes with group_by collect the event into a group notification instead.
 func (d *Dispatcher) Dispatch(ctx context.Context, event model.EventEntry) {
-	for _, route := range d.Routes {
+	d.routesLock.RLock()
+	routes := d.routes
+	d.routesLock.RUnlock()
+
+	for _, route := range routes {
 		if !matchRoute(route.Match, event) {
 			continue
 		}
 		utils.Debug("Dispatching event:" + event.Message)
-		for _, action := range route.Actions {
-			go d.ExecuteAction(ctx, action, event)
+		for _, r := range resolveRoute(route, event) {
+			d.handle(ctx, r, event)
 		}
 	}
 }
 
-// TriggerActionByID looks up a route by ID and executes its actions.
+// TriggerActionByID looks up a route by ID and executes its actions, or
+// those of its child routes that match the event.
 func (d *Dispatcher) TriggerActionByID(ctx context.Context, actionID string, event model.EventEntry) {
-	route, ok := d.Routes[actionID]
+	route, ok := d.Route(actionID)
 	if !ok {
 		utils.Warn("🚫 No route found for action ID: %s", actionID)
 		return
 	}
+	for _, r := range resolveRoute(route, event) {
+		d.handle(ctx, r, event)
+	}
+}
 
+// handle sends an event to a route's actions, or adds it to the route's
+// group when it has group_by. It never waits on a receiver: with a delivery
+// store the actions are queued for Run, otherwise they are sent in the
+// background.
+func (d *Dispatcher) handle(ctx context.Context, route alertmodel.Route, e model.EventEntry) {
+	if len(route.GroupBy) > 0 {
+		d.enqueue(ctx, route, e)
+		return
+	}
 	for _, action := range route.Actions {
-		d.ExecuteAction(ctx, action, event)
+		if d.store == nil {
+			go d.execute(context.WithoutCancel(ctx), route.ID, action, e)
+		} else {
+			d.execute(ctx, route.ID, action, e)
+		}
 	}
 }
 
-// matchRoute checks if the event matches the route's filter criteria.
-// It compares the event's level, rule ID, and tags against the filter.
-// If all criteria match, it returns true; otherwise, false.
-func matchRoute(f model.MatchFilter, e model.EventEntry) bool {
-	if f.Level != "" && f.Level != e.Level {
-		return false
+// resolveRoute returns the routes that handle an event matched by route:
+// the first matching child, and the ones after it while the matching
+// children have continue set, resolved the same way, or route itself when
+// no child matches. Children inherit route's grouping settings.
+func resolveRoute(route alertmodel.Route, e model.EventEntry) []alertmodel.Route {
+	var targets []alertmodel.Route
+	for _, child := range route.Routes {
+		if !matchRoute(child.Match, e) {
+			continue
+		}
+		if len(child.GroupBy) == 0 {
+			child.GroupBy = route.GroupBy
+		}
+		if child.GroupWait == "" {
+			child.GroupWait = route.GroupWait
+		}
+		if child.GroupInterval == "" {
+			child.GroupInterval = route.GroupInterval
+		}
+		targets = append(targets, resolveRoute(child, e)...)
+		if !child.Continue {
+			break
+		}
+	}
+	if len(targets) == 0 {
+		return []alertmodel.Route{route}
 	}
-	if f.RuleID != "" && f.RuleID != e.Meta["rule_id"] {
-		return false
+	return targets
+}
+
+// matchRoute checks if the event matches the route's filter criteria: its
+// level, rule ID, category, source, scope, target and endpoint ID, its meta
+// tags and the label matchers. Unset criteria match any event.
+func matchRoute(f alertmodel.RouteMatch, e model.EventEntry) bool {
+	for _, c := range []struct{ want, got string }{
+		{f.Level, e.Level},
+		{f.RuleID, e.Meta["rule_id"]},
+		{f.Category, e.Category},
+		{f.Source, e.Source},
+		{f.Scope, e.Scope},
+		{f.Target, e.Target},
+		{f.EndpointID, e.EndpointID},
+	} {
+		if c.want != "" && c.want != c.got {
+			return false
+		}
 	}
 	for k, v := range f.Tags {
 		if e.Meta[k] != v {
 			return false
 		}
 	}
+	for _, m := range f.Matchers {
+		if !m.Matches(eventLabel(e, m.Name)) {
+			return false
+		}
+	}
 	return true
 }
 
 // ExecuteAction executes the action specified in the route.
-// It determines the action type (webhook or script) and calls the appropriate function.
-func (d *Dispatcher) ExecuteAction(ctx context.Context, a model.ActionSpec, e model.EventEntry) {
+// It renders the action's templates for the event and hands the result to the
+// delivery queue, whose worker makes the first attempt and retries failures.
+// Without a delivery store the action is sent once, before ExecuteAction returns.
+func (d *Dispatcher) ExecuteAction(ctx context.Context, a alertmodel.ActionSpec, e model.EventEntry) {
+	d.execute(ctx, "", a, e)
+}
+
+// execute renders an action for an event of the given route and submits it
+// for delivery.
+func (d *Dispatcher) execute(ctx context.Context, routeID string, a alertmodel.ActionSpec, e model.EventEntry) {
+	n := notificationFor(e)
+	a, err := d.renderAction(ctx, a, n)
+	if err != nil {
+		utils.Warn("Failed to render %s action: %v", a.Type, err)
+		return
+	}
+	d.submit(ctx, routeID, a, alertmodel.PayloadEvent, e, n)
+}
+
+// send makes one attempt at a delivery. It determines the action type
+// (webhook, script, email, or one of the slack, msteams, pagerduty and
+// opsgenie integrations) and calls the appropriate function.
+func (d *Dispatcher) send(ctx context.Context, del alertmodel.Delivery) error {
+	ctx = context.WithoutCancel(ctx)
+	a := del.Action
+
+	var v interface{}
+	var n Notification
+	if del.Kind == alertmodel.PayloadGroup {
+		if err := json.Unmarshal(del.Payload, &n); err != nil {
+			return fmt.Errorf("decode notification: %w", err)
+		}
+		v = n
+	} else {
+		var e model.EventEntry
+		if err := json.Unmarshal(del.Payload, &e); err != nil {
+			return fmt.Errorf("decode event: %w", err)
+		}
+		v, n = e, notificationFor(e)
+	}
+
 	switch strings.ToLower(a.Type) {
 	case "webhook":
-		executeWebhook(a, e)
+		return executeWebhook(ctx, a, v)
 	case "script":
-		executeScript(a, e)
-	default:
-		// unknown type
+		return executeScript(ctx, a, v)
+	case "email":
+		return d.sendEmail(ctx, a)
 	}
+	ok, err := executeIntegration(ctx, a, n)
+	if !ok {
+		return fmt.Errorf("unknown action type: %s", a.Type)
+	}
+	return err
 }
 
-// executeWebhook sends a POST request to the specified URL with the event data as JSON.
+// executeWebhook sends a POST request to the specified URL with the rendered
+// body template, or the event or group notification as JSON when the action has no body.
 // It sets the content type to application/json and includes any additional headers specified in the action.
-func executeWebhook(a model.ActionSpec, e model.EventEntry) {
-	payload, _ := json.Marshal(e)
-	req, _ := http.NewRequest("POST", a.URL, bytes.NewBuffer(payload))
-	req.Header.Set("Content-Type", "application/json")
+func executeWebhook(ctx context.Context, a alertmodel.ActionSpec, v interface{}) error {
+	return post(ctx, a.URL, a.Headers, actionPayload(a, v))
+}
 
-	for k, v := range a.Headers {
-		req.Header.Set(k, v)
-	}
+// executeScript runs a script with the event or group notification as input.
+// It uses the command and arguments specified in the action's Command and Args fields.
+// The rendered body template, or the data as JSON, is passed to the script via standard input.
+// The script fails if it exits non-zero or runs longer than scriptTimeout.
+func executeScript(ctx context.Context, a alertmodel.ActionSpec, v interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, scriptTimeout)
+	defer cancel()
 
-	client := &http.Client{}
-	client.Do(req) // ignore error here, but should log
+	cmd := exec.CommandContext(ctx, a.Command, a.Args...)
+	cmd.Stdin = bytes.NewReader(actionPayload(a, v))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if len(msg) > 512 {
+			msg = msg[:512]
+		}
+		if msg != "" {
+			return fmt.Errorf("%s: %w: %s", a.Command, err, msg)
+		}
+		return fmt.Errorf("%s: %w", a.Command, err)
+	}
+	return nil
 }
 
-// executeScript runs a script with the event data as input.
-// It uses the command and arguments specified in the action's Command and Args fields.
-// The event data is passed to the script via standard input.
-// The script is expected to handle the input and perform the necessary actions.
-func executeScript(a model.ActionSpec, e model.EventEntry) {
-	payload, _ := json.Marshal(e)
-	cmd := exec.Command(a.Command, a.Args...)
-	cmd.Stdin = bytes.NewReader(payload)
-	cmd.Run() // ignore error here, but should log
+// actionPayload returns the action's rendered body, or v as JSON.
+func actionPayload(a alertmodel.ActionSpec, v interface{}) []byte {
+	if a.Body != "" {
+		return []byte(a.Body)
+	}
+	payload, _ := json.Marshal(v)
+	return payload
 }
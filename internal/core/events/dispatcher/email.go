@@ -0,0 +1,339 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultEmailRateLimit  = 20
+	defaultEmailRateWindow = time.Hour
+	smtpTimeout            = 30 * time.Second
+)
+
+// Default templates of the email action, used when the action doesn't set
+// its own subject, text_body or html_body.
+const (
+	defaultEmailSubject = `{{ if eq (len .Alerts) 1 }}[{{ if eq .Status "resolved" }}RESOLVED{{ else }}FIRING{{ end }}] {{ end }}{{ .Title }}`
+
+	defaultEmailText = `{{ range .Alerts }}{{ if eq .Status "resolved" }}RESOLVED{{ else }}FIRING{{ end }}: {{ .Message }}
+{{ with .Rule.Name }}Rule:     {{ . }}
+{{ end }}Level:    {{ .Level }}
+Target:   {{ .Target }}
+{{ with .Meta.hostname }}Host:     {{ . }}
+{{ end }}{{ with .Value }}Value:    {{ . }}
+{{ end }}Fired at: {{ .FiredAt.Format "2006-01-02 15:04:05 MST" }}
+{{ with .Link }}Details:  {{ . }}
+{{ end }}
+{{ end }}`
+
+	defaultEmailHTML = `<html><body style="font-family: sans-serif; font-size: 14px;">
+<h2>{{ .Title }}</h2>
+{{ range .Alerts }}<table cellpadding="4" style="border-collapse: collapse; margin-bottom: 16px;">
+<tr><th colspan="2" align="left" style="color: {{ if eq .Status "resolved" }}#2eb886{{ else }}#d00000{{ end }};">{{ if eq .Status "resolved" }}RESOLVED{{ else }}FIRING{{ end }}: {{ .Message }}</th></tr>
+{{ with .Rule.Name }}<tr><td>Rule</td><td>{{ . }}</td></tr>
+{{ end }}<tr><td>Level</td><td>{{ .Level }}</td></tr>
+<tr><td>Target</td><td>{{ .Target }}</td></tr>
+{{ with .Meta.hostname }}<tr><td>Host</td><td>{{ . }}</td></tr>
+{{ end }}{{ with .Value }}<tr><td>Value</td><td>{{ . }}</td></tr>
+{{ end }}<tr><td>Fired at</td><td>{{ .FiredAt.Format "2006-01-02 15:04:05 MST" }}</td></tr>
+{{ with .Link }}<tr><td colspan="2"><a href="{{ . }}">View alert</a></td></tr>
+{{ end }}</table>
+{{ end }}</body></html>`
+)
+
+// ErrRateLimited is returned when every recipient of an email is over
+// their rate limit, so the delivery is retried after its backoff, or
+// dead-lettered, instead of being recorded as delivered.
+var ErrRateLimited = errors.New("email rate limit reached for every recipient")
+
+// SMTPSettings configures the Mailer used by email actions. TLS is
+// "starttls" (the default), "tls" for implicit TLS or "none".
+type SMTPSettings struct {
+	Host               string
+	Port               int
+	Username           string
+	Password           string
+	From               string
+	TLS                string
+	InsecureSkipVerify bool
+	RateLimit          int           // emails per recipient per RateWindow; negative disables the limit
+	RateWindow         time.Duration // length of the rate limit window
+}
+
+// Mailer sends email notifications over SMTP. Each recipient gets at most
+// RateLimit emails per RateWindow; further notifications to that address
+// are dropped until the window moves on, so a flapping rule can't flood an
+// inbox.
+type Mailer struct {
+	settings SMTPSettings
+
+	lock sync.Mutex
+	sent map[string][]time.Time // key: recipient address
+}
+
+// NewMailer returns a Mailer for the given settings, filling in the default
+// port, TLS mode and rate limit.
+func NewMailer(s SMTPSettings) *Mailer {
+	s.TLS = strings.ToLower(s.TLS)
+	if s.TLS == "" {
+		s.TLS = "starttls"
+	}
+	if s.Port == 0 {
+		switch s.TLS {
+		case "tls":
+			s.Port = 465
+		case "none":
+			s.Port = 25
+		default:
+			s.Port = 587
+		}
+	}
+	if s.RateLimit == 0 {
+		s.RateLimit = defaultEmailRateLimit
+	}
+	if s.RateWindow <= 0 {
+		s.RateWindow = defaultEmailRateWindow
+	}
+	return &Mailer{
+		settings: s,
+		sent:     make(map[string][]time.Time),
+	}
+}
+
+// sendEmail mails a notification to the action's recipients. The action's
+// subject and bodies have already been rendered by renderAction.
+func (d *Dispatcher) sendEmail(ctx context.Context, a alertmodel.ActionSpec) error {
+	if d.mailer == nil {
+		return fmt.Errorf("smtp is not configured")
+	}
+	if len(a.To) == 0 {
+		return fmt.Errorf("email action needs at least one to address")
+	}
+	return d.mailer.Send(context.WithoutCancel(ctx), a.To, strings.TrimSpace(a.Subject), a.TextBody, a.HTMLBody)
+}
+
+// Send mails a multipart/alternative message with a plain text and an HTML
+// body. Recipients over their rate limit are skipped; when that leaves none,
+// Send returns ErrRateLimited. Only a successful send counts against the
+// recipients' limits.
+func (m *Mailer) Send(ctx context.Context, to []string, subject, text, html string) error {
+	now := time.Now()
+	var rcpts []string
+	for _, addr := range to {
+		if m.allow(addr, now) {
+			rcpts = append(rcpts, addr)
+		} else {
+			utils.Warn("Email to %s dropped: rate limit of %d per %s reached", addr, m.settings.RateLimit, m.settings.RateWindow)
+		}
+	}
+	if len(rcpts) == 0 {
+		return ErrRateLimited
+	}
+
+	msg, err := buildMessage(m.settings.From, rcpts, subject, text, html, now)
+	if err != nil {
+		return err
+	}
+	if err := m.deliver(ctx, rcpts, msg); err != nil {
+		return err
+	}
+	m.count(rcpts, now)
+	return nil
+}
+
+// allow reports whether another email may be sent to addr within its rate
+// limit.
+func (m *Mailer) allow(addr string, now time.Time) bool {
+	if m.settings.RateLimit < 0 {
+		return true
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.window(strings.ToLower(addr), now)) < m.settings.RateLimit
+}
+
+// count records an email sent to each of the addresses at now.
+func (m *Mailer) count(addrs []string, now time.Time) {
+	if m.settings.RateLimit < 0 {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, addr := range addrs {
+		key := strings.ToLower(addr)
+		m.sent[key] = append(m.window(key, now), now)
+	}
+}
+
+// window drops the sends to an address that fell out of the rate window
+// and returns the rest. The caller must hold the lock.
+func (m *Mailer) window(key string, now time.Time) []time.Time {
+	cutoff := now.Add(-m.settings.RateWindow)
+	sent := m.sent[key]
+	i := 0
+	for i < len(sent) && !sent[i].After(cutoff) {
+		i++
+	}
+	sent = sent[i:]
+	if len(sent) == 0 {
+		delete(m.sent, key)
+		return nil
+	}
+	m.sent[key] = sent
+	return sent
+}
+
+// deliver hands the message to the SMTP server, upgrading the connection
+// with STARTTLS or dialing TLS directly as configured.
+func (m *Mailer) deliver(ctx context.Context, rcpts []string, msg []byte) error {
+	s := m.settings
+	if s.Host == "" {
+		return fmt.Errorf("smtp host is not set")
+	}
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	tlsConfig := &tls.Config{ServerName: s.Host, InsecureSkipVerify: s.InsecureSkipVerify}
+
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	var conn net.Conn
+	var err error
+	if s.TLS == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if s.TLS == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s does not support STARTTLS", addr)
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	from := s.From
+	if addr, err := mail.ParseAddress(s.From); err == nil {
+		from = addr.Address
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range rcpts {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("recipient %s: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMessage assembles a multipart/alternative email with quoted-printable
+// text and HTML parts.
+func buildMessage(from string, to []string, subject, text, html string, now time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range [][2]string{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", html},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part[0]},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part[1])); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	for _, h := range [][2]string{
+		{"From", from},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("UTF-8", subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", "<" + uuid.NewString() + "@gosight>"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + mw.Boundary()},
+	} {
+		msg.WriteString(h[0] + ": " + h[1] + "\r\n")
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,149 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package dispatcher
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStandIn is a plain SMTP server that accepts every message and counts
+// them.
+type smtpStandIn struct {
+	net.Listener
+	messages atomic.Int32
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpStandIn{Listener: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 stand-in ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 stand-in")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+			}
+			s.messages.Add(1)
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *smtpStandIn) port() int {
+	return s.Addr().(*net.TCPAddr).Port
+}
+
+func newTestMailer(port, limit int) *Mailer {
+	return NewMailer(SMTPSettings{
+		Host:       "127.0.0.1",
+		Port:       port,
+		From:       "gosight@example.com",
+		TLS:        "none",
+		RateLimit:  limit,
+		RateWindow: time.Hour,
+	})
+}
+
+func TestMailerRateLimitsPerRecipient(t *testing.T) {
+	srv := newSMTPStandIn(t)
+	m := newTestMailer(srv.port(), 2)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, m.Send(ctx, []string{"ops@example.com"}, "s", "t", "h"))
+	}
+	err := m.Send(ctx, []string{"OPS@example.com"}, "s", "t", "h")
+	assert.ErrorIs(t, err, ErrRateLimited, "addresses are counted case-insensitively")
+	assert.EqualValues(t, 2, srv.messages.Load())
+
+	// A recipient with room left still gets the email.
+	require.NoError(t, m.Send(ctx, []string{"ops@example.com", "dev@example.com"}, "s", "t", "h"))
+	assert.EqualValues(t, 3, srv.messages.Load())
+}
+
+func TestMailerCountsOnlySentEmails(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	m := newTestMailer(closed, 1)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		err := m.Send(ctx, []string{"ops@example.com"}, "s", "t", "h")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrRateLimited, "attempt "+strconv.Itoa(i+1))
+	}
+	assert.Empty(t, m.sent)
+
+	srv := newSMTPStandIn(t)
+	m.settings.Port = srv.port()
+	require.NoError(t, m.Send(ctx, []string{"ops@example.com"}, "s", "t", "h"))
+	assert.ErrorIs(t, m.Send(ctx, []string{"ops@example.com"}, "s", "t", "h"), ErrRateLimited)
+}
@@ -0,0 +1,236 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package dispatcher
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+const (
+	defaultGroupWait     = 30 * time.Second
+	defaultGroupInterval = 5 * time.Minute
+)
+
+// Notification is the payload sent to a grouped route's actions. It lists
+// every alert of the group that is still firing, point-in-time alerts (logs,
+// events) received since the last notification, and the alerts that
+// resolved since then.
+type Notification struct {
+	RouteID     string             `json:"route_id"`
+	GroupKey    string             `json:"group_key"`
+	GroupLabels map[string]string  `json:"group_labels"`
+	Status      string             `json:"status"` // "firing" while any member fires, otherwise "resolved"
+	Timestamp   time.Time          `json:"timestamp"`
+	Firing      []model.EventEntry `json:"firing"`
+	Resolved    []model.EventEntry `json:"resolved"`
+}
+
+// group collects the events of one route and group_by label set between
+// notifications.
+type group struct {
+	key       string
+	route     alertmodel.Route
+	labels    map[string]string
+	firing    map[string]model.EventEntry // key: rule ID and target
+	resolved  map[string]model.EventEntry
+	once      []model.EventEntry // point-in-time alerts, sent once
+	timer     *time.Timer
+	lastFlush time.Time
+}
+
+// enqueue adds an event to its group and schedules the group's next
+// notification: group_wait after a new group is created, otherwise
+// group_interval after the previous notification.
+func (d *Dispatcher) enqueue(ctx context.Context, route alertmodel.Route, e model.EventEntry) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	labels := make(map[string]string, len(route.GroupBy))
+	parts := make([]string, 0, len(route.GroupBy))
+	for _, name := range route.GroupBy {
+		labels[name] = eventLabel(e, name)
+		parts = append(parts, name+"="+labels[name])
+	}
+	key := route.ID + "|" + strings.Join(parts, ",")
+
+	g := d.groups[key]
+	if g == nil {
+		g = &group{
+			key:      key,
+			labels:   labels,
+			firing:   make(map[string]model.EventEntry),
+			resolved: make(map[string]model.EventEntry),
+		}
+		d.groups[key] = g
+	}
+	g.route = route
+
+	member := e.Meta["rule_id"] + "|" + e.Target
+	switch e.Meta["alert_state"] {
+	case alertmodel.StateFiring:
+		delete(g.resolved, member)
+		g.firing[member] = e
+	case alertmodel.StateResolved:
+		delete(g.firing, member)
+		g.resolved[member] = e
+	default:
+		g.once = append(g.once, e)
+	}
+
+	if g.timer != nil {
+		return
+	}
+	wait := alertmodel.ParseDuration(route.GroupWait)
+	if wait <= 0 {
+		wait = defaultGroupWait
+	}
+	if !g.lastFlush.IsZero() {
+		interval := alertmodel.ParseDuration(route.GroupInterval)
+		if interval <= 0 {
+			interval = defaultGroupInterval
+		}
+		wait = time.Until(g.lastFlush.Add(interval))
+	}
+	flushCtx := context.WithoutCancel(ctx)
+	g.timer = time.AfterFunc(wait, func() { d.flush(flushCtx, key) })
+}
+
+// Withdraw removes an alert of a rule and target from the groups it is
+// firing in without announcing a resolve, for rules that don't notify on
+// resolve. A group left with nothing to send is dropped, so its next alert
+// waits group_wait again.
+func (d *Dispatcher) Withdraw(ruleID, target string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	member := ruleID + "|" + target
+	for key, g := range d.groups {
+		if _, ok := g.firing[member]; !ok {
+			continue
+		}
+		delete(g.firing, member)
+		if len(g.firing) == 0 && len(g.resolved) == 0 && len(g.once) == 0 {
+			if g.timer != nil {
+				g.timer.Stop()
+			}
+			delete(d.groups, key)
+		}
+	}
+}
+
+// flush sends the group's notification to the route's actions. A group
+// with nothing left firing is dropped, so its next alert waits group_wait
+// again.
+func (d *Dispatcher) flush(ctx context.Context, key string) {
+	d.lock.Lock()
+	g := d.groups[key]
+	if g == nil {
+		d.lock.Unlock()
+		return
+	}
+
+	now := time.Now().UTC()
+	n := Notification{
+		RouteID:     g.route.ID,
+		GroupKey:    g.key,
+		GroupLabels: g.labels,
+		Status:      alertmodel.StateResolved,
+		Timestamp:   now,
+		Firing:      sortedEvents(g.firing),
+		Resolved:    sortedEvents(g.resolved),
+	}
+	n.Firing = append(n.Firing, g.once...)
+	if len(n.Firing) > 0 {
+		n.Status = alertmodel.StateFiring
+	}
+
+	g.timer = nil
+	g.lastFlush = now
+	g.once = nil
+	g.resolved = make(map[string]model.EventEntry)
+	if len(g.firing) == 0 {
+		delete(d.groups, key)
+	}
+	actions := g.route.Actions
+	d.lock.Unlock()
+
+	if len(n.Firing) == 0 && len(n.Resolved) == 0 {
+		return
+	}
+	for _, action := range actions {
+		d.ExecuteGroup(ctx, action, n)
+	}
+}
+
+// ExecuteGroup renders an action's templates for a group notification and
+// hands the result to the delivery queue.
+func (d *Dispatcher) ExecuteGroup(ctx context.Context, a alertmodel.ActionSpec, n Notification) {
+	a, err := d.renderAction(ctx, a, n)
+	if err != nil {
+		utils.Warn("Failed to render %s action: %v", a.Type, err)
+		return
+	}
+	d.submit(ctx, n.RouteID, a, alertmodel.PayloadGroup, n, n)
+}
+
+// eventLabel returns the value of a group_by label for an event: one of its
+// meta labels, or the event's level, category, source, scope, target or
+// endpoint_id.
+func eventLabel(e model.EventEntry, name string) string {
+	if v, ok := e.Meta[name]; ok {
+		return v
+	}
+	switch name {
+	case "level":
+		return e.Level
+	case "category":
+		return e.Category
+	case "source":
+		return e.Source
+	case "scope":
+		return e.Scope
+	case "target":
+		return e.Target
+	case "endpoint_id":
+		return e.EndpointID
+	}
+	return ""
+}
+
+// sortedEvents returns the events of a member map, oldest first.
+func sortedEvents(members map[string]model.EventEntry) []model.EventEntry {
+	list := make([]model.EventEntry, 0, len(members))
+	for _, e := range members {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Timestamp.Before(list[j].Timestamp)
+	})
+	return list
+}
@@ -0,0 +1,175 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package dispatcher
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// groupedRoute sends one notification per level, quickly enough for tests.
+func groupedRoute(url string) alertmodel.Route {
+	return alertmodel.Route{
+		ID:            "ops",
+		GroupBy:       []string{"level"},
+		GroupWait:     "20ms",
+		GroupInterval: "100ms",
+		Actions:       []alertmodel.ActionSpec{{Type: "webhook", URL: url}},
+	}
+}
+
+func ruleEvent(ruleID, state string) model.EventEntry {
+	return model.EventEntry{
+		Timestamp: time.Now().UTC(),
+		Level:     "critical",
+		Category:  "alert",
+		Message:   ruleID + " " + state,
+		Scope:     "endpoint",
+		Target:    "host-1",
+		Meta:      map[string]string{"rule_id": ruleID, "alert_state": state},
+	}
+}
+
+// members returns the rule IDs of one list of a group notification.
+func members(body map[string]interface{}, list string) []string {
+	var ids []string
+	entries, _ := body[list].([]interface{})
+	for _, e := range entries {
+		meta := e.(map[string]interface{})["meta"].(map[string]interface{})
+		ids = append(ids, meta["rule_id"].(string))
+	}
+	return ids
+}
+
+// waitForNotifications waits until the stand-in has received n requests.
+func waitForNotifications(t *testing.T, srv *standIn, n int) []recordedRequest {
+	require.Eventually(t, func() bool { return len(srv.received()) >= n }, 2*time.Second, 5*time.Millisecond)
+	return srv.received()
+}
+
+func TestWithdrawDropsGroupMemberWithoutResolve(t *testing.T) {
+	srv := newStandIn(t, http.StatusOK)
+	d := NewDispatcher(map[string]alertmodel.Route{"ops": groupedRoute(srv.URL)})
+	ctx := context.Background()
+
+	d.Dispatch(ctx, ruleEvent("disk", alertmodel.StateFiring))
+	d.Dispatch(ctx, ruleEvent("cpu", alertmodel.StateFiring))
+	reqs := waitForNotifications(t, srv, 1)
+	assert.Equal(t, []string{"disk", "cpu"}, members(reqs[0].Body, "firing"))
+
+	d.Withdraw("disk", "host-1")
+	d.Dispatch(ctx, ruleEvent("mem", alertmodel.StateFiring))
+	reqs = waitForNotifications(t, srv, 2)
+	assert.Equal(t, []string{"cpu", "mem"}, members(reqs[1].Body, "firing"))
+	assert.Empty(t, members(reqs[1].Body, "resolved"))
+
+	// Withdrawing the last members drops the group.
+	d.Withdraw("cpu", "host-1")
+	d.Withdraw("mem", "host-1")
+	d.lock.Lock()
+	assert.Empty(t, d.groups)
+	d.lock.Unlock()
+}
+
+func TestGroupWaitBatchesAlertsPerGroup(t *testing.T) {
+	srv := newStandIn(t, http.StatusOK)
+	d := NewDispatcher(map[string]alertmodel.Route{"ops": groupedRoute(srv.URL)})
+	ctx := context.Background()
+
+	warning := ruleEvent("swap", alertmodel.StateFiring)
+	warning.Level = "warning"
+	d.Dispatch(ctx, ruleEvent("disk", alertmodel.StateFiring))
+	d.Dispatch(ctx, warning)
+	d.Dispatch(ctx, ruleEvent("cpu", alertmodel.StateFiring))
+	assert.Empty(t, srv.received(), "nothing is sent before group_wait")
+
+	reqs := waitForNotifications(t, srv, 2)
+	byLevel := make(map[string]map[string]interface{})
+	for _, r := range reqs {
+		labels := r.Body["group_labels"].(map[string]interface{})
+		byLevel[labels["level"].(string)] = r.Body
+	}
+	require.Len(t, byLevel, 2)
+	assert.Equal(t, []string{"disk", "cpu"}, members(byLevel["critical"], "firing"))
+	assert.Equal(t, "firing", byLevel["critical"]["status"])
+	assert.Equal(t, "ops|level=critical", byLevel["critical"]["group_key"])
+	assert.Equal(t, []string{"swap"}, members(byLevel["warning"], "firing"))
+}
+
+func TestGroupIntervalSpacesNotifications(t *testing.T) {
+	srv := newStandIn(t, http.StatusOK)
+	d := NewDispatcher(map[string]alertmodel.Route{"ops": groupedRoute(srv.URL)})
+	ctx := context.Background()
+
+	d.Dispatch(ctx, ruleEvent("disk", alertmodel.StateFiring))
+	waitForNotifications(t, srv, 1)
+	first := time.Now()
+
+	d.Dispatch(ctx, ruleEvent("cpu", alertmodel.StateFiring))
+	reqs := waitForNotifications(t, srv, 2)
+	assert.GreaterOrEqual(t, time.Since(first), 70*time.Millisecond, "a changed group waits group_interval, not group_wait")
+	assert.Equal(t, []string{"disk", "cpu"}, members(reqs[1].Body, "firing"), "alerts still firing are repeated")
+}
+
+func TestGroupedResolve(t *testing.T) {
+	srv := newStandIn(t, http.StatusOK)
+	d := NewDispatcher(map[string]alertmodel.Route{"ops": groupedRoute(srv.URL)})
+	ctx := context.Background()
+
+	d.Dispatch(ctx, ruleEvent("disk", alertmodel.StateFiring))
+	d.Dispatch(ctx, ruleEvent("cpu", alertmodel.StateFiring))
+	waitForNotifications(t, srv, 1)
+
+	d.Dispatch(ctx, ruleEvent("disk", alertmodel.StateResolved))
+	reqs := waitForNotifications(t, srv, 2)
+	assert.Equal(t, "firing", reqs[1].Body["status"])
+	assert.Equal(t, []string{"cpu"}, members(reqs[1].Body, "firing"))
+	assert.Equal(t, []string{"disk"}, members(reqs[1].Body, "resolved"))
+
+	// Once the last member resolves the notification is resolved and the
+	// group is dropped.
+	d.Dispatch(ctx, ruleEvent("cpu", alertmodel.StateResolved))
+	reqs = waitForNotifications(t, srv, 3)
+	assert.Equal(t, "resolved", reqs[2].Body["status"])
+	assert.Empty(t, members(reqs[2].Body, "firing"))
+	assert.Equal(t, []string{"cpu"}, members(reqs[2].Body, "resolved"))
+	d.lock.Lock()
+	assert.Empty(t, d.groups)
+	d.lock.Unlock()
+
+	// A resolve followed by a refire before the next notification is sent as
+	// firing only.
+	d.Dispatch(ctx, ruleEvent("mem", alertmodel.StateFiring))
+	waitForNotifications(t, srv, 4)
+	d.Dispatch(ctx, ruleEvent("mem", alertmodel.StateResolved))
+	d.Dispatch(ctx, ruleEvent("mem", alertmodel.StateFiring))
+	reqs = waitForNotifications(t, srv, 5)
+	assert.Equal(t, []string{"mem"}, members(reqs[4].Body, "firing"))
+	assert.Empty(t, members(reqs[4].Body, "resolved"))
+}
@@ -0,0 +1,388 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+)
+
+const (
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	opsgenieAlertsURL  = "https://api.opsgenie.com/v2/alerts"
+
+	// opsgenieMessageLimit is the longest alert message, in characters,
+	// Opsgenie accepts.
+	opsgenieMessageLimit = 130
+)
+
+// httpClient is used for webhooks and the vendor integrations.
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// notificationFor wraps a single event in a Notification so the vendor
+// formatters handle single and grouped alerts alike. The group key is the
+// alert instance ID, which lets PagerDuty and Opsgenie close the incident
+// when the alert resolves.
+func notificationFor(e model.EventEntry) Notification {
+	key := e.Meta["alert_id"]
+	if key == "" {
+		key = e.Meta["rule_id"] + "|" + e.Target
+	}
+	n := Notification{
+		GroupKey:  key,
+		Status:    alertmodel.StateFiring,
+		Timestamp: e.Timestamp,
+	}
+	if e.Meta["alert_state"] == alertmodel.StateResolved {
+		n.Status = alertmodel.StateResolved
+		n.Resolved = []model.EventEntry{e}
+	} else {
+		n.Firing = []model.EventEntry{e}
+	}
+	return n
+}
+
+// executeIntegration sends a notification to a vendor action type. It
+// reports whether the type is a vendor integration. Delivery is not tied to
+// the caller's context so a finished request doesn't abort it.
+func executeIntegration(ctx context.Context, a alertmodel.ActionSpec, n Notification) (bool, error) {
+	ctx = context.WithoutCancel(ctx)
+	switch strings.ToLower(a.Type) {
+	case "slack":
+		return true, sendSlack(ctx, a, n)
+	case "msteams":
+		return true, sendTeams(ctx, a, n)
+	case "pagerduty":
+		return true, sendPagerDuty(ctx, a, n)
+	case "opsgenie":
+		return true, sendOpsgenie(ctx, a, n)
+	}
+	return false, nil
+}
+
+// sendSlack posts the notification to a Slack incoming webhook as an
+// attachment colored by status and level.
+func sendSlack(ctx context.Context, a alertmodel.ActionSpec, n Notification) error {
+	var lines []string
+	for _, e := range n.Firing {
+		lines = append(lines, "• "+e.Message+" ("+e.Target+")")
+	}
+	for _, e := range n.Resolved {
+		lines = append(lines, "• ✅ "+e.Message+" ("+e.Target+")")
+	}
+
+	fields := []map[string]interface{}{}
+	for _, f := range notificationFields(n) {
+		fields = append(fields, map[string]interface{}{"title": f[0], "value": f[1], "short": true})
+	}
+	payload := map[string]interface{}{
+		"text": actionTitle(a, n),
+		"attachments": []map[string]interface{}{{
+			"color":  notificationColor(n),
+			"text":   orDefault(a.Body, strings.Join(lines, "\n")),
+			"fields": fields,
+			"ts":     n.Timestamp.Unix(),
+		}},
+	}
+	if a.Channel != "" {
+		payload["channel"] = a.Channel
+	}
+	return postJSON(ctx, a.URL, a.Headers, payload)
+}
+
+// sendTeams posts the notification to a Microsoft Teams incoming webhook as
+// a MessageCard.
+func sendTeams(ctx context.Context, a alertmodel.ActionSpec, n Notification) error {
+	facts := []map[string]string{}
+	for _, f := range notificationFields(n) {
+		facts = append(facts, map[string]string{"name": f[0], "value": f[1]})
+	}
+	var lines []string
+	for _, e := range n.Firing {
+		lines = append(lines, "- "+e.Message+" ("+e.Target+")")
+	}
+	for _, e := range n.Resolved {
+		lines = append(lines, "- Resolved: "+e.Message+" ("+e.Target+")")
+	}
+
+	payload := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    actionTitle(a, n),
+		"themeColor": strings.TrimPrefix(notificationColor(n), "#"),
+		"title":      actionTitle(a, n),
+		"sections": []map[string]interface{}{{
+			"facts": facts,
+			"text":  orDefault(a.Body, strings.Join(lines, "\n\n")),
+		}},
+	}
+	return postJSON(ctx, a.URL, a.Headers, payload)
+}
+
+// sendPagerDuty sends a PagerDuty Events v2 trigger, or a resolve once
+// nothing in the notification is firing. The dedup key is the alert ID (or
+// group key), so the resolve closes the incident the trigger opened.
+func sendPagerDuty(ctx context.Context, a alertmodel.ActionSpec, n Notification) error {
+	if a.RoutingKey == "" {
+		return fmt.Errorf("pagerduty action needs a routing_key")
+	}
+	endpoint := a.URL
+	if endpoint == "" {
+		endpoint = pagerDutyEventsURL
+	}
+
+	body := map[string]interface{}{
+		"routing_key": a.RoutingKey,
+		"dedup_key":   n.GroupKey,
+	}
+	if n.Status == alertmodel.StateResolved {
+		body["event_action"] = "resolve"
+		return postJSON(ctx, endpoint, a.Headers, body)
+	}
+
+	first := n.Firing[0]
+	details := map[string]interface{}{}
+	for _, f := range notificationFields(n) {
+		details[f[0]] = f[1]
+	}
+	if len(n.Firing) > 1 {
+		var members []string
+		for _, e := range n.Firing {
+			members = append(members, e.Message+" ("+e.Target+")")
+		}
+		details["firing"] = members
+	}
+	if a.Body != "" {
+		details["body"] = a.Body
+	}
+	body["event_action"] = "trigger"
+	body["payload"] = map[string]interface{}{
+		"summary":        actionTitle(a, n),
+		"source":         first.Target,
+		"severity":       pagerDutySeverity(first.Level),
+		"timestamp":      first.Timestamp.UTC().Format(time.RFC3339),
+		"component":      first.Source,
+		"group":          first.Scope,
+		"class":          first.Category,
+		"custom_details": details,
+	}
+	return postJSON(ctx, endpoint, a.Headers, body)
+}
+
+// sendOpsgenie creates an Opsgenie alert aliased to the alert ID (or group
+// key), or closes it once nothing in the notification is firing.
+func sendOpsgenie(ctx context.Context, a alertmodel.ActionSpec, n Notification) error {
+	if a.APIKey == "" {
+		return fmt.Errorf("opsgenie action needs an api_key")
+	}
+	endpoint := a.URL
+	if endpoint == "" {
+		endpoint = opsgenieAlertsURL
+	}
+	headers := map[string]string{"Authorization": "GenieKey " + a.APIKey}
+	for k, v := range a.Headers {
+		headers[k] = v
+	}
+
+	if n.Status == alertmodel.StateResolved {
+		closeURL := strings.TrimSuffix(endpoint, "/") + "/" + url.PathEscape(n.GroupKey) + "/close?identifierType=alias"
+		return postJSON(ctx, closeURL, headers, map[string]string{
+			"source": "gosight",
+			"note":   actionTitle(a, n),
+		})
+	}
+
+	first := n.Firing[0]
+	message := actionTitle(a, n)
+	if r := []rune(message); len(r) > opsgenieMessageLimit {
+		message = string(r[:opsgenieMessageLimit])
+	}
+	var lines []string
+	for _, e := range n.Firing {
+		lines = append(lines, e.Message+" ("+e.Target+")")
+	}
+	details := map[string]string{}
+	for _, f := range notificationFields(n) {
+		details[f[0]] = f[1]
+	}
+	return postJSON(ctx, endpoint, headers, map[string]interface{}{
+		"message":     message,
+		"alias":       n.GroupKey,
+		"description": orDefault(a.Body, strings.Join(lines, "\n")),
+		"priority":    opsgeniePriority(first.Level),
+		"source":      "gosight",
+		"entity":      first.Target,
+		"tags":        []string{first.Level, first.Category},
+		"details":     details,
+	})
+}
+
+// actionTitle is the action's rendered subject, or the notification title.
+func actionTitle(a alertmodel.ActionSpec, n Notification) string {
+	return orDefault(a.Subject, notificationTitle(n))
+}
+
+// notificationTitle is the one-line summary of a notification: the alert's
+// message, or the status, size and labels of a group.
+func notificationTitle(n Notification) string {
+	if len(n.Firing)+len(n.Resolved) == 1 {
+		if len(n.Firing) == 1 {
+			return n.Firing[0].Message
+		}
+		return n.Resolved[0].Message
+	}
+	var labels []string
+	for k, v := range n.GroupLabels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	title := fmt.Sprintf("[%s:%d]", strings.ToUpper(n.Status), len(n.Firing))
+	if len(n.Resolved) > 0 {
+		title += fmt.Sprintf(" (%d resolved)", len(n.Resolved))
+	}
+	if len(labels) > 0 {
+		title += " " + strings.Join(labels, " ")
+	}
+	return title
+}
+
+// notificationFields returns name/value pairs describing a notification:
+// the rule, level and target of a single alert, or the group labels.
+func notificationFields(n Notification) [][2]string {
+	if len(n.GroupLabels) > 0 {
+		var names []string
+		for k := range n.GroupLabels {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		fields := make([][2]string, 0, len(names))
+		for _, k := range names {
+			fields = append(fields, [2]string{k, n.GroupLabels[k]})
+		}
+		return fields
+	}
+
+	var e model.EventEntry
+	if len(n.Firing) > 0 {
+		e = n.Firing[0]
+	} else if len(n.Resolved) > 0 {
+		e = n.Resolved[0]
+	}
+	var fields [][2]string
+	for _, f := range [][2]string{
+		{"rule_id", e.Meta["rule_id"]},
+		{"level", e.Level},
+		{"scope", e.Scope},
+		{"target", e.Target},
+	} {
+		if f[1] != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// notificationColor picks the attachment color for a notification.
+func notificationColor(n Notification) string {
+	if n.Status == alertmodel.StateResolved {
+		return "#2eb886"
+	}
+	switch strings.ToLower(n.Firing[0].Level) {
+	case "critical", "error":
+		return "#d00000"
+	case "warning":
+		return "#f2c744"
+	default:
+		return "#439fe0"
+	}
+}
+
+// pagerDutySeverity maps an alert level to a PagerDuty severity.
+func pagerDutySeverity(level string) string {
+	switch strings.ToLower(level) {
+	case "critical", "error", "warning":
+		return strings.ToLower(level)
+	default:
+		return "info"
+	}
+}
+
+// opsgeniePriority maps an alert level to an Opsgenie priority.
+func opsgeniePriority(level string) string {
+	switch strings.ToLower(level) {
+	case "critical":
+		return "P1"
+	case "error":
+		return "P2"
+	case "warning":
+		return "P3"
+	default:
+		return "P5"
+	}
+}
+
+// postJSON sends v as JSON to endpoint and fails on a non-2xx response.
+func postJSON(ctx context.Context, endpoint string, headers map[string]string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return post(ctx, endpoint, headers, payload)
+}
+
+// post sends a JSON payload to endpoint and fails on a non-2xx response.
+// Headers may override the content type.
+func post(ctx context.Context, endpoint string, headers map[string]string, payload []byte) error {
+	if endpoint == "" {
+		return fmt.Errorf("action has no url")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, val := range headers {
+		req.Header.Set(k, val)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned %s: %s", endpoint, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedRequest is one request received by a vendor stand-in.
+type recordedRequest struct {
+	Path   string
+	Query  string
+	Header http.Header
+	Body   map[string]interface{}
+}
+
+// standIn is a local HTTP server that records the requests it receives.
+type standIn struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+func newStandIn(t *testing.T, status int) *standIn {
+	s := &standIn{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		s.mu.Lock()
+		s.requests = append(s.requests, recordedRequest{
+			Path:   r.URL.Path,
+			Query:  r.URL.RawQuery,
+			Header: r.Header.Clone(),
+			Body:   body,
+		})
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *standIn) received() []recordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]recordedRequest(nil), s.requests...)
+}
+
+func alertEvent(state string) model.EventEntry {
+	return model.EventEntry{
+		Timestamp: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		Level:     "critical",
+		Category:  "alert",
+		Message:   "CPU usage above 90%",
+		Source:    "system.cpu.usage_percent",
+		Scope:     "endpoint",
+		Target:    "host-abc123",
+		Meta: map[string]string{
+			"rule_id":     "high-cpu",
+			"alert_id":    "3f1c6a52-8d7e-4b0a-9c1d-2e5f7a9b0c11",
+			"alert_state": state,
+		},
+	}
+}
+
+func TestSlackAction(t *testing.T) {
+	srv := newStandIn(t, http.StatusOK)
+	d := NewDispatcher(nil)
+
+	d.ExecuteAction(context.Background(), alertmodel.ActionSpec{Type: "slack", URL: srv.URL, Channel: "#ops"}, alertEvent(alertmodel.StateFiring))
+
+	reqs := srv.received()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "CPU usage above 90%", reqs[0].Body["text"])
+	assert.Equal(t, "#ops", reqs[0].Body["channel"])
+	attachments := reqs[0].Body["attachments"].([]interface{})
+	require.Len(t, attachments, 1)
+	assert.Equal(t, "#d00000", attachments[0].(map[string]interface{})["color"])
+}
+
+func TestTeamsAction(t *testing.T) {
+	srv := newStandIn(t, http.StatusOK)
+	d := NewDispatcher(nil)
+
+	d.ExecuteAction(context.Background(), alertmodel.ActionSpec{Type: "msteams", URL: srv.URL}, alertEvent(alertmodel.StateResolved))
+
+	reqs := srv.received()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "MessageCard", reqs[0].Body["@type"])
+	assert.Equal(t, "2eb886", reqs[0].Body["themeColor"])
+	assert.Equal(t, "CPU usage above 90%", reqs[0].Body["title"])
+}
+
+func TestPagerDutyTriggerAndResolveShareDedupKey(t *testing.T) {
+	srv := newStandIn(t, http.StatusAccepted)
+	d := NewDispatcher(nil)
+	action := alertmodel.ActionSpec{Type: "pagerduty", URL: srv.URL, RoutingKey: "routing-key"}
+
+	d.ExecuteAction(context.Background(), action, alertEvent(alertmodel.StateFiring))
+	d.ExecuteAction(context.Background(), action, alertEvent(alertmodel.StateResolved))
+
+	reqs := srv.received()
+	require.Len(t, reqs, 2)
+
+	trigger, resolve := reqs[0].Body, reqs[1].Body
+	assert.Equal(t, "trigger", trigger["event_action"])
+	assert.Equal(t, "routing-key", trigger["routing_key"])
+	assert.Equal(t, "3f1c6a52-8d7e-4b0a-9c1d-2e5f7a9b0c11", trigger["dedup_key"])
+	payload := trigger["payload"].(map[string]interface{})
+	assert.Equal(t, "critical", payload["severity"])
+	assert.Equal(t, "host-abc123", payload["source"])
+
+	assert.Equal(t, "resolve", resolve["event_action"])
+	assert.Equal(t, trigger["dedup_key"], resolve["dedup_key"])
+	assert.NotContains(t, resolve, "payload")
+}
+
+func TestPagerDutyRequiresRoutingKey(t *testing.T) {
+	srv := newStandIn(t, http.StatusAccepted)
+
+	ok, err := executeIntegration(context.Background(), alertmodel.ActionSpec{Type: "pagerduty", URL: srv.URL}, notificationFor(alertEvent(alertmodel.StateFiring)))
+	assert.True(t, ok)
+	assert.Error(t, err)
+	assert.Empty(t, srv.received())
+}
+
+func TestOpsgenieCreateAndClose(t *testing.T) {
+	srv := newStandIn(t, http.StatusAccepted)
+	d := NewDispatcher(nil)
+	action := alertmodel.ActionSpec{Type: "opsgenie", URL: srv.URL + "/v2/alerts", APIKey: "genie"}
+
+	d.ExecuteAction(context.Background(), action, alertEvent(alertmodel.StateFiring))
+	d.ExecuteAction(context.Background(), action, alertEvent(alertmodel.StateResolved))
+
+	reqs := srv.received()
+	require.Len(t, reqs, 2)
+
+	create := reqs[0]
+	assert.Equal(t, "/v2/alerts", create.Path)
+	assert.Equal(t, "GenieKey genie", create.Header.Get("Authorization"))
+	assert.Equal(t, "3f1c6a52-8d7e-4b0a-9c1d-2e5f7a9b0c11", create.Body["alias"])
+	assert.Equal(t, "P1", create.Body["priority"])
+
+	closeReq := reqs[1]
+	assert.Equal(t, "/v2/alerts/3f1c6a52-8d7e-4b0a-9c1d-2e5f7a9b0c11/close", closeReq.Path)
+	assert.Equal(t, "identifierType=alias", closeReq.Query)
+	assert.Equal(t, "GenieKey genie", closeReq.Header.Get("Authorization"))
+}
+
+func TestOpsgenieTruncatesMessageByCharacter(t *testing.T) {
+	srv := newStandIn(t, http.StatusAccepted)
+	e := alertEvent(alertmodel.StateFiring)
+	e.Message = strings.Repeat("é", opsgenieMessageLimit+10)
+
+	ok, err := executeIntegration(context.Background(), alertmodel.ActionSpec{Type: "opsgenie", URL: srv.URL, APIKey: "genie"}, notificationFor(e))
+	require.True(t, ok)
+	require.NoError(t, err)
+
+	reqs := srv.received()
+	require.Len(t, reqs, 1)
+	message := reqs[0].Body["message"].(string)
+	assert.True(t, utf8.ValidString(message))
+	assert.Equal(t, strings.Repeat("é", opsgenieMessageLimit), message)
+}
+
+func TestIntegrationReportsHTTPErrors(t *testing.T) {
+	srv := newStandIn(t, http.StatusBadRequest)
+
+	ok, err := executeIntegration(context.Background(), alertmodel.ActionSpec{Type: "slack", URL: srv.URL}, notificationFor(alertEvent(alertmodel.StateFiring)))
+	assert.True(t, ok)
+	assert.Error(t, err)
+}
+
+func TestGroupedPagerDutyUsesGroupKey(t *testing.T) {
+	srv := newStandIn(t, http.StatusAccepted)
+	n := Notification{
+		RouteID:     "page-oncall",
+		GroupKey:    "page-oncall|rule_id=high-cpu",
+		GroupLabels: map[string]string{"rule_id": "high-cpu"},
+		Status:      alertmodel.StateFiring,
+		Firing:      []model.EventEntry{alertEvent(alertmodel.StateFiring), alertEvent(alertmodel.StateFiring)},
+	}
+
+	ok, err := executeIntegration(context.Background(), alertmodel.ActionSpec{Type: "pagerduty", URL: srv.URL, RoutingKey: "rk"}, n)
+	require.True(t, ok)
+	require.NoError(t, err)
+
+	reqs := srv.received()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "page-oncall|rule_id=high-cpu", reqs[0].Body["dedup_key"])
+	payload := reqs[0].Body["payload"].(map[string]interface{})
+	assert.Equal(t, "[FIRING:2] rule_id=high-cpu", payload["summary"])
+}
+
+func TestRouteTestResolvesIncidents(t *testing.T) {
+	pagerDuty := newStandIn(t, http.StatusAccepted)
+	opsgenie := newStandIn(t, http.StatusAccepted)
+	slack := newStandIn(t, http.StatusOK)
+	route := alertmodel.Route{
+		ID: "oncall",
+		Actions: []alertmodel.ActionSpec{
+			{Type: "pagerduty", URL: pagerDuty.URL, RoutingKey: "rk"},
+			{Type: "opsgenie", URL: opsgenie.URL + "/v2/alerts", APIKey: "genie"},
+			{Type: "slack", URL: slack.URL},
+		},
+	}
+
+	results := NewDispatcher(nil).TestRoute(context.Background(), route)
+	require.Len(t, results, 3)
+	for _, r := range results {
+		assert.Empty(t, r.Error, r.Type)
+	}
+
+	pd := pagerDuty.received()
+	require.Len(t, pd, 2)
+	assert.Equal(t, "trigger", pd[0].Body["event_action"])
+	assert.Equal(t, "resolve", pd[1].Body["event_action"])
+	assert.Equal(t, pd[0].Body["dedup_key"], pd[1].Body["dedup_key"])
+
+	og := opsgenie.received()
+	require.Len(t, og, 2)
+	alias := og[0].Body["alias"].(string)
+	assert.Equal(t, "/v2/alerts/"+alias+"/close", og[1].Path)
+	assert.Equal(t, "identifierType=alias", og[1].Query)
+
+	assert.Len(t, slack.received(), 1)
+}
+
+func TestRouteTestSkipsResolveWhenTriggerFails(t *testing.T) {
+	srv := newStandIn(t, http.StatusBadRequest)
+	route := alertmodel.Route{
+		ID:      "oncall",
+		Actions: []alertmodel.ActionSpec{{Type: "pagerduty", URL: srv.URL, RoutingKey: "rk"}},
+	}
+
+	results := NewDispatcher(nil).TestRoute(context.Background(), route)
+	require.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].Error)
+	require.Len(t, srv.received(), 1)
+	assert.Equal(t, "trigger", srv.received()[0].Body["event_action"])
+}
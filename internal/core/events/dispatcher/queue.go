@@ -0,0 +1,368 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/notificationstore"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultBackoff     = 30 * time.Second
+	defaultMaxBackoff  = 30 * time.Minute
+	defaultRetention   = 7 * 24 * time.Hour
+
+	retryPollInterval = 5 * time.Second
+	retryBatchSize    = 100
+	pruneInterval     = time.Hour
+
+	// queueSize bounds the new deliveries waiting for their first attempt.
+	// Deliveries that don't fit are persisted and picked up by the next poll.
+	queueSize = 1024
+)
+
+var (
+	// ErrDeliveryInFlight is returned when a delivery is retried while an
+	// attempt is already running.
+	ErrDeliveryInFlight = errors.New("delivery is being sent")
+
+	// ErrAlreadyDelivered is returned when a delivered notification is
+	// retried.
+	ErrAlreadyDelivered = errors.New("notification was already delivered")
+
+	// ErrNoDeliveryStore is returned when deliveries aren't persisted.
+	ErrNoDeliveryStore = errors.New("notification store is not configured")
+)
+
+// RetrySettings are the server-wide defaults for retrying failed
+// deliveries. Route actions can override them with a retry policy.
+type RetrySettings struct {
+	MaxAttempts int
+	Backoff     time.Duration // delay before the first retry, doubled per attempt
+	MaxBackoff  time.Duration
+	Retention   time.Duration // how long delivered and dead deliveries are kept
+}
+
+// SetDeliveryStore makes notification delivery durable: every notification
+// is recorded in the store with its attempts, failed ones are retried with
+// exponential backoff by Run, and those that run out of attempts are kept as
+// dead letters.
+func (d *Dispatcher) SetDeliveryStore(store notificationstore.NotificationStore, retry RetrySettings) {
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = defaultMaxAttempts
+	}
+	if retry.Backoff <= 0 {
+		retry.Backoff = defaultBackoff
+	}
+	if retry.MaxBackoff <= 0 {
+		retry.MaxBackoff = defaultMaxBackoff
+	}
+	if retry.Retention <= 0 {
+		retry.Retention = defaultRetention
+	}
+	d.store = store
+	d.retry = retry
+}
+
+// Run makes the first attempt at new deliveries, retries due ones, including
+// those left pending by a restart, and prunes old ones. Each attempt runs in
+// its own goroutine so a slow receiver holds up nothing else. It blocks until
+// the context is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d.store == nil {
+		return
+	}
+	ticker := time.NewTicker(retryPollInterval)
+	defer ticker.Stop()
+
+	utils.Info("[notifications] delivery worker started")
+	var lastPrune time.Time
+	for {
+		select {
+		case id := <-d.queue:
+			if d.claim(id) {
+				go d.attemptDue(ctx, id, time.Now().UTC())
+			}
+		case now := <-ticker.C:
+			d.retryDue(ctx, now.UTC())
+			if now.Sub(lastPrune) >= pruneInterval {
+				d.prune(ctx, now.UTC())
+				lastPrune = now
+			}
+		case <-ctx.Done():
+			utils.Info("[notifications] delivery worker stopped")
+			return
+		}
+	}
+}
+
+// Retry sends a pending, retrying or dead delivery again right away, with a
+// fresh set of attempts, and returns the updated delivery.
+func (d *Dispatcher) Retry(ctx context.Context, id string) (alertmodel.Delivery, error) {
+	if d.store == nil {
+		return alertmodel.Delivery{}, ErrNoDeliveryStore
+	}
+	del, err := d.store.GetDelivery(ctx, id)
+	if err != nil {
+		return alertmodel.Delivery{}, err
+	}
+	if del.Status == alertmodel.DeliveryDelivered {
+		return del, ErrAlreadyDelivered
+	}
+	if !d.claim(id) {
+		return del, ErrDeliveryInFlight
+	}
+	defer d.release(id)
+
+	del.MaxAttempts = len(del.Attempts) + d.policy(del.Action).MaxAttempts
+	d.attempt(ctx, &del)
+	return del, nil
+}
+
+// submit records a delivery of a rendered action and queues it for Run's
+// first attempt, so it returns without waiting on the receiver. Without a
+// store the notification is sent once, synchronously.
+func (d *Dispatcher) submit(ctx context.Context, routeID string, a alertmodel.ActionSpec, kind string, payload interface{}, n Notification) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		utils.Warn("Failed to encode %s notification: %v", a.Type, err)
+		return
+	}
+	now := time.Now().UTC()
+	del := alertmodel.Delivery{
+		ID:          uuid.NewString(),
+		RouteID:     routeID,
+		GroupKey:    n.GroupKey,
+		Action:      a,
+		Kind:        kind,
+		Payload:     raw,
+		Status:      alertmodel.DeliveryPending,
+		MaxAttempts: d.policy(a).MaxAttempts,
+		NextAttempt: now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if members := append(append([]model.EventEntry{}, n.Firing...), n.Resolved...); len(members) > 0 {
+		del.RuleID = members[0].Meta["rule_id"]
+		if len(members) == 1 {
+			del.AlertID = members[0].Meta["alert_id"]
+		}
+	}
+
+	if d.store == nil {
+		if err := d.send(ctx, del); err != nil {
+			utils.Warn("Failed to send %s notification: %v", a.Type, err)
+		}
+		return
+	}
+
+	if err := d.store.SaveDelivery(context.WithoutCancel(ctx), del); err != nil {
+		// Run couldn't load the delivery, so it is sent from memory.
+		utils.Warn("Failed to record %s notification %s, sending it unrecorded: %v", a.Type, del.ID, err)
+		if d.claim(del.ID) {
+			go d.sendUnsaved(context.WithoutCancel(ctx), del)
+		}
+		return
+	}
+	select {
+	case d.queue <- del.ID:
+	default:
+		utils.Warn("Notification queue full, %s notification %s waits for the next poll", a.Type, del.ID)
+	}
+}
+
+// sendUnsaved attempts a claimed delivery the store couldn't record and
+// releases the claim. Failed attempts are retried from memory until the
+// delivery is sent, dead, or recorded so Run can retry it.
+func (d *Dispatcher) sendUnsaved(ctx context.Context, del alertmodel.Delivery) {
+	defer d.release(del.ID)
+
+	for {
+		if err := d.attempt(ctx, &del); err == nil || del.Status != alertmodel.DeliveryRetrying {
+			return
+		}
+		select {
+		case <-time.After(time.Until(del.NextAttempt)):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// attempt sends a delivery once, records the attempt and schedules the next
+// one, or dead-letters the delivery when it's out of attempts. It returns
+// the error recording the delivery, if any. The caller must have claimed
+// the delivery.
+func (d *Dispatcher) attempt(ctx context.Context, del *alertmodel.Delivery) error {
+	start := time.Now()
+	err := d.send(ctx, *del)
+	now := time.Now().UTC()
+
+	at := alertmodel.DeliveryAttempt{
+		Attempt:    len(del.Attempts) + 1,
+		Timestamp:  start.UTC(),
+		DurationMs: now.Sub(start).Milliseconds(),
+	}
+	if err != nil {
+		at.Error = err.Error()
+	}
+	del.Attempts = append(del.Attempts, at)
+	del.UpdatedAt = now
+	del.LastError = at.Error
+
+	switch {
+	case err == nil:
+		del.Status = alertmodel.DeliveryDelivered
+		del.DeliveredAt = &now
+		del.NextAttempt = time.Time{}
+	case at.Attempt >= del.MaxAttempts:
+		del.Status = alertmodel.DeliveryDead
+		del.NextAttempt = time.Time{}
+		utils.Warn("Notification %s (%s) failed %d times, moved to dead letters: %v", del.ID, del.Action.Type, at.Attempt, err)
+	default:
+		del.Status = alertmodel.DeliveryRetrying
+		del.NextAttempt = now.Add(d.backoff(del.Action, at.Attempt))
+		utils.Warn("Notification %s (%s) failed, attempt %d of %d, retrying at %s: %v",
+			del.ID, del.Action.Type, at.Attempt, del.MaxAttempts, del.NextAttempt.Format(time.RFC3339), err)
+	}
+
+	if d.store == nil {
+		return nil
+	}
+	if err := d.store.SaveDelivery(context.WithoutCancel(ctx), *del); err != nil {
+		utils.Warn("Failed to record notification %s: %v", del.ID, err)
+		return err
+	}
+	return nil
+}
+
+// retryDue attempts the deliveries that are due, each in its own goroutine.
+func (d *Dispatcher) retryDue(ctx context.Context, now time.Time) {
+	due, err := d.store.DueDeliveries(ctx, now, retryBatchSize)
+	if err != nil {
+		utils.Warn("Failed to load due notifications: %v", err)
+		return
+	}
+	for _, del := range due {
+		if !d.claim(del.ID) {
+			continue
+		}
+		go d.attemptDue(ctx, del.ID, now)
+	}
+}
+
+// attemptDue attempts a claimed delivery if it is still due at now and
+// releases the claim. The delivery is reloaded first, since another attempt
+// may have sent it after it was queued or listed as due.
+func (d *Dispatcher) attemptDue(ctx context.Context, id string, now time.Time) {
+	defer d.release(id)
+
+	del, err := d.store.GetDelivery(ctx, id)
+	if err != nil || !isDue(del, now) {
+		return
+	}
+	d.attempt(ctx, &del)
+}
+
+// isDue reports whether a delivery is waiting for an attempt at now.
+func isDue(del alertmodel.Delivery, now time.Time) bool {
+	if del.Status != alertmodel.DeliveryPending && del.Status != alertmodel.DeliveryRetrying {
+		return false
+	}
+	return !del.NextAttempt.After(now)
+}
+
+// prune removes delivered and dead deliveries older than the retention.
+func (d *Dispatcher) prune(ctx context.Context, now time.Time) {
+	n, err := d.store.PruneDeliveries(ctx, now.Add(-d.retry.Retention))
+	if err != nil {
+		utils.Warn("Failed to prune notification history: %v", err)
+	} else if n > 0 {
+		utils.Debug("Pruned %d notifications", n)
+	}
+}
+
+// claim marks a delivery as being attempted. It reports false when another
+// attempt is already running.
+func (d *Dispatcher) claim(id string) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.inflight[id] {
+		return false
+	}
+	d.inflight[id] = true
+	return true
+}
+
+// release clears the claim on a delivery.
+func (d *Dispatcher) release(id string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.inflight, id)
+}
+
+// policy returns the retry settings of an action: the server defaults with
+// the action's retry policy applied.
+func (d *Dispatcher) policy(a alertmodel.ActionSpec) RetrySettings {
+	p := d.retry
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 1
+	}
+	if a.Retry == nil {
+		return p
+	}
+	if a.Retry.MaxAttempts > 0 {
+		p.MaxAttempts = a.Retry.MaxAttempts
+	}
+	if b := alertmodel.ParseDuration(a.Retry.Backoff); b > 0 {
+		p.Backoff = b
+	}
+	if b := alertmodel.ParseDuration(a.Retry.MaxBackoff); b > 0 {
+		p.MaxBackoff = b
+	}
+	return p
+}
+
+// backoff returns the delay after the given failed attempt: the action's
+// backoff doubled for each earlier attempt, capped at its max backoff.
+func (d *Dispatcher) backoff(a alertmodel.ActionSpec, attempt int) time.Duration {
+	p := d.policy(a)
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
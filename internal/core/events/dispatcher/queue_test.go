@@ -0,0 +1,311 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/notificationstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingReceiver is a webhook receiver that holds every request until the
+// test ends.
+func blockingReceiver(t *testing.T) *httptest.Server {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(func() {
+		close(release)
+		srv.Close()
+	})
+	return srv
+}
+
+func newQueueDispatcher(t *testing.T, routes ...alertmodel.Route) (*Dispatcher, *notificationstore.JSONNotificationStore) {
+	store, err := notificationstore.NewJSONNotificationStore("")
+	require.NoError(t, err)
+
+	routeMap := make(map[string]alertmodel.Route)
+	for _, r := range routes {
+		routeMap[r.ID] = r
+	}
+	d := NewDispatcher(routeMap)
+	d.SetDeliveryStore(store, RetrySettings{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	return d, store
+}
+
+func deliveries(t *testing.T, store notificationstore.NotificationStore) []alertmodel.Delivery {
+	list, err := store.ListDeliveries(context.Background(), notificationstore.DeliveryQuery{})
+	require.NoError(t, err)
+	return list
+}
+
+func TestTriggerActionByIDQueuesFirstAttempt(t *testing.T) {
+	srv := newStandIn(t, http.StatusOK)
+	d, store := newQueueDispatcher(t, alertmodel.Route{
+		ID:      "ops",
+		Actions: []alertmodel.ActionSpec{{Type: "webhook", URL: srv.URL}},
+	})
+
+	d.TriggerActionByID(context.Background(), "ops", alertEvent(alertmodel.StateFiring))
+
+	list := deliveries(t, store)
+	require.Len(t, list, 1)
+	assert.Equal(t, alertmodel.DeliveryPending, list[0].Status)
+	assert.Empty(t, srv.received(), "nothing is sent before the worker runs")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	require.Eventually(t, func() bool {
+		del, err := store.GetDelivery(ctx, list[0].ID)
+		return err == nil && del.Status == alertmodel.DeliveryDelivered
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Len(t, srv.received(), 1)
+}
+
+func TestTriggerActionByIDDoesNotWaitForReceiver(t *testing.T) {
+	srv := blockingReceiver(t)
+	route := alertmodel.Route{
+		ID:      "ops",
+		Actions: []alertmodel.ActionSpec{{Type: "webhook", URL: srv.URL}},
+	}
+
+	queued, _ := newQueueDispatcher(t, route)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go queued.Run(ctx)
+
+	direct := NewDispatcher(map[string]alertmodel.Route{"ops": route})
+
+	for name, d := range map[string]*Dispatcher{"with store": queued, "without store": direct} {
+		done := make(chan struct{})
+		go func() {
+			d.TriggerActionByID(ctx, "ops", alertEvent(alertmodel.StateFiring))
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("%s: TriggerActionByID waited for the receiver", name)
+		}
+	}
+}
+
+// flakyReceiver is a webhook receiver that fails the first failures
+// requests with a 500 and accepts the rest.
+type flakyReceiver struct {
+	*httptest.Server
+	calls atomic.Int32
+}
+
+func newFlakyReceiver(t *testing.T, failures int) *flakyReceiver {
+	r := &flakyReceiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if int(r.calls.Add(1)) <= failures {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// queueOne triggers the ops route and returns its one recorded delivery.
+func queueOne(t *testing.T, d *Dispatcher, store notificationstore.NotificationStore) alertmodel.Delivery {
+	d.TriggerActionByID(context.Background(), "ops", alertEvent(alertmodel.StateFiring))
+	list := deliveries(t, store)
+	require.Len(t, list, 1)
+	return list[0]
+}
+
+// waitForAttempts waits until a delivery has n attempts recorded and no
+// attempt running, and returns it.
+func waitForAttempts(t *testing.T, d *Dispatcher, store notificationstore.NotificationStore, id string, n int) alertmodel.Delivery {
+	var del alertmodel.Delivery
+	require.Eventually(t, func() bool {
+		d.lock.Lock()
+		running := d.inflight[id]
+		d.lock.Unlock()
+		var err error
+		del, err = store.GetDelivery(context.Background(), id)
+		return err == nil && len(del.Attempts) == n && !running
+	}, 2*time.Second, 5*time.Millisecond)
+	return del
+}
+
+func TestFailedDeliveryIsRetriedAfterBackoff(t *testing.T) {
+	srv := newFlakyReceiver(t, 1)
+	d, store := newQueueDispatcher(t, alertmodel.Route{
+		ID:      "ops",
+		Actions: []alertmodel.ActionSpec{{Type: "webhook", URL: srv.URL, Retry: &alertmodel.RetryPolicy{Backoff: "1m", MaxBackoff: "10m"}}},
+	})
+	ctx := context.Background()
+	del := queueOne(t, d, store)
+
+	start := time.Now().UTC()
+	d.retryDue(ctx, start)
+	del = waitForAttempts(t, d, store, del.ID, 1)
+	assert.Equal(t, alertmodel.DeliveryRetrying, del.Status)
+	assert.Contains(t, del.LastError, "500")
+	assert.WithinDuration(t, start.Add(time.Minute), del.NextAttempt, 5*time.Second, "the action's backoff applies")
+
+	// Not due yet.
+	d.retryDue(ctx, start.Add(30*time.Second))
+	time.Sleep(20 * time.Millisecond)
+	assert.EqualValues(t, 1, srv.calls.Load())
+
+	d.retryDue(ctx, start.Add(2*time.Minute))
+	del = waitForAttempts(t, d, store, del.ID, 2)
+	assert.Equal(t, alertmodel.DeliveryDelivered, del.Status)
+	assert.NotNil(t, del.DeliveredAt)
+	assert.True(t, del.NextAttempt.IsZero())
+}
+
+func TestBackoffDoublesUpToMax(t *testing.T) {
+	d := NewDispatcher(nil)
+	d.SetDeliveryStore(nil, RetrySettings{Backoff: time.Second, MaxBackoff: 5 * time.Second})
+	a := alertmodel.ActionSpec{Type: "webhook"}
+	var got []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		got = append(got, d.backoff(a, attempt))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, got)
+
+	a.Retry = &alertmodel.RetryPolicy{MaxAttempts: 2, Backoff: "10s"}
+	assert.Equal(t, 2, d.policy(a).MaxAttempts)
+	assert.Equal(t, 5*time.Second, d.backoff(a, 1), "a backoff larger than the max is capped")
+}
+
+func TestDeliveryIsDeadLetteredAfterMaxAttempts(t *testing.T) {
+	srv := newFlakyReceiver(t, 3)
+	d, store := newQueueDispatcher(t, alertmodel.Route{
+		ID:      "ops",
+		Actions: []alertmodel.ActionSpec{{Type: "webhook", URL: srv.URL}},
+	})
+	ctx := context.Background()
+	del := queueOne(t, d, store)
+
+	for n := 1; n <= 3; n++ {
+		d.retryDue(ctx, time.Now().UTC().Add(time.Second))
+		del = waitForAttempts(t, d, store, del.ID, n)
+	}
+	assert.Equal(t, alertmodel.DeliveryDead, del.Status)
+	assert.True(t, del.NextAttempt.IsZero())
+	assert.NotEmpty(t, del.LastError)
+
+	// Dead letters are not retried on their own.
+	d.retryDue(ctx, time.Now().UTC().Add(time.Hour))
+	time.Sleep(20 * time.Millisecond)
+	assert.EqualValues(t, 3, srv.calls.Load())
+
+	// A manual retry gets a fresh set of attempts.
+	del, err := d.Retry(ctx, del.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alertmodel.DeliveryDelivered, del.Status)
+	assert.Equal(t, 6, del.MaxAttempts)
+	assert.Len(t, del.Attempts, 4)
+
+	_, err = d.Retry(ctx, del.ID)
+	assert.ErrorIs(t, err, ErrAlreadyDelivered)
+}
+
+func TestPendingDeliveryIsSentAfterRestart(t *testing.T) {
+	srv := newStandIn(t, http.StatusOK)
+	route := alertmodel.Route{
+		ID:      "ops",
+		Actions: []alertmodel.ActionSpec{{Type: "webhook", URL: srv.URL}},
+	}
+	// The first dispatcher records the delivery but stops before sending it.
+	stopped, store := newQueueDispatcher(t, route)
+	del := queueOne(t, stopped, store)
+
+	d := NewDispatcher(map[string]alertmodel.Route{"ops": route})
+	d.SetDeliveryStore(store, RetrySettings{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	d.retryDue(context.Background(), time.Now().UTC())
+	del = waitForAttempts(t, d, store, del.ID, 1)
+	assert.Equal(t, alertmodel.DeliveryDelivered, del.Status)
+	assert.Len(t, srv.received(), 1)
+}
+
+func TestQueuedDeliveryIsSentOnce(t *testing.T) {
+	srv := newStandIn(t, http.StatusOK)
+	d, store := newQueueDispatcher(t, alertmodel.Route{
+		ID:      "ops",
+		Actions: []alertmodel.ActionSpec{{Type: "webhook", URL: srv.URL}},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	del := queueOne(t, d, store)
+
+	// The poll and the queue both see the new delivery; only one sends it.
+	d.retryDue(ctx, time.Now().UTC())
+	go d.Run(ctx)
+	waitForAttempts(t, d, store, del.ID, 1)
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, srv.received(), 1)
+}
+
+// failingStore is a notification store whose saves fail.
+type failingStore struct {
+	*notificationstore.JSONNotificationStore
+}
+
+func (failingStore) SaveDelivery(ctx context.Context, del alertmodel.Delivery) error {
+	return errors.New("disk full")
+}
+
+func TestUnrecordedDeliveryIsSentFromMemory(t *testing.T) {
+	srv := newFlakyReceiver(t, 1)
+	store, err := notificationstore.NewJSONNotificationStore("")
+	require.NoError(t, err)
+	d := NewDispatcher(map[string]alertmodel.Route{"ops": {
+		ID:      "ops",
+		Actions: []alertmodel.ActionSpec{{Type: "webhook", URL: srv.URL}},
+	}})
+	d.SetDeliveryStore(failingStore{store}, RetrySettings{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	// Run isn't started: the delivery never reaches the queue, and the
+	// failed first attempt is retried from memory.
+	d.TriggerActionByID(context.Background(), "ops", alertEvent(alertmodel.StateFiring))
+	require.Eventually(t, func() bool { return srv.calls.Load() == 2 }, 2*time.Second, 5*time.Millisecond)
+	assert.Empty(t, d.queue)
+	require.Eventually(t, func() bool {
+		d.lock.Lock()
+		defer d.lock.Unlock()
+		return len(d.inflight) == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.EqualValues(t, 2, srv.calls.Load())
+}
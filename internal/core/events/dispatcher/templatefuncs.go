@@ -0,0 +1,150 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package dispatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// templateFuncs are the helpers available to notification templates, named
+// and ordered like their sprig counterparts so templates written for other
+// alerting tools mostly carry over:
+//
+//	{{ .Alert.Message | upper }}          {{ .Alert.Meta.env | default "prod" }}
+//	{{ .Alert.FiredAt | date "15:04" }}   {{ .Alert.FiredAt | ago }}
+//	{{ toJson .Alert.Meta }}              {{ .Title | trunc 80 }}
+var templateFuncs = map[string]interface{}{
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      titleCase,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"join":       joinList,
+	"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+	"trunc":      truncate,
+	"indent":     func(n int, s string) string { return indent(n, s) },
+	"nindent":    func(n int, s string) string { return "\n" + indent(n, s) },
+	"quote":      func(v interface{}) string { return fmt.Sprintf("%q", fmt.Sprint(v)) },
+	"squote":     func(v interface{}) string { return "'" + fmt.Sprint(v) + "'" },
+	"default":    defaultValue,
+	"empty":      isEmpty,
+	"coalesce":   coalesce,
+	"toJson":     toJSON,
+	"toPrettyJson": func(v interface{}) (string, error) {
+		b, err := json.MarshalIndent(v, "", "  ")
+		return string(b), err
+	},
+	"now":  time.Now,
+	"date": func(layout string, t time.Time) string { return t.Format(layout) },
+	"ago": func(t time.Time) string {
+		return time.Since(t).Round(time.Second).String()
+	},
+}
+
+// titleCase upper-cases the first letter of each word.
+func titleCase(s string) string {
+	r := []rune(s)
+	for i := range r {
+		if i == 0 || unicode.IsSpace(r[i-1]) {
+			r[i] = unicode.ToTitle(r[i])
+		}
+	}
+	return string(r)
+}
+
+// joinList joins the elements of a slice with sep.
+func joinList(sep string, v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Sprint(v)
+	}
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	return strings.Join(parts, sep)
+}
+
+// truncate shortens s to at most n runes.
+func truncate(n int, s string) string {
+	r := []rune(s)
+	if n < 0 || len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// indent prefixes every line of s with n spaces.
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// defaultValue returns given, or def when given is empty.
+func defaultValue(def interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || isEmpty(given[0]) {
+		return def
+	}
+	return given[0]
+}
+
+// coalesce returns the first non-empty argument.
+func coalesce(v ...interface{}) interface{} {
+	for _, x := range v {
+		if !isEmpty(x) {
+			return x
+		}
+	}
+	return nil
+}
+
+// isEmpty reports whether v is nil or the zero value of its type, or an
+// empty slice or map.
+func isEmpty(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return true
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return rv.IsZero()
+}
+
+// toJSON encodes v as JSON.
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
@@ -0,0 +1,459 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+)
+
+// sampleRule and sampleEvent are the alert templates are validated and
+// previewed against when no real alert is given.
+var sampleRule = alertmodel.AlertRule{
+	ID:          "sample-high-cpu",
+	Name:        "CPU Usage Over 90%",
+	Description: "Alert if CPU usage is too high",
+	Message:     "CPU usage is above 90%",
+	Level:       "critical",
+	Enabled:     true,
+	Type:        "metric",
+	Scope:       alertmodel.Scope{Namespace: "system", SubNamespace: "cpu", Metric: "usage_percent"},
+	Expression:  alertmodel.Expression{Operator: ">", Value: 90},
+	Actions:     []string{"notify-email"},
+}
+
+func sampleEvent(now time.Time) model.EventEntry {
+	return model.EventEntry{
+		Timestamp:  now,
+		Level:      sampleRule.Level,
+		Category:   "alert",
+		Message:    sampleRule.Message,
+		Source:     "system.cpu.usage_percent",
+		Scope:      "endpoint",
+		Target:     "host-abc123",
+		EndpointID: "host-abc123",
+		Meta: map[string]string{
+			"rule_id":     sampleRule.ID,
+			"alert_id":    "00000000-0000-0000-0000-000000000000",
+			"alert_state": alertmodel.StateFiring,
+			"value":       "93.5",
+			"fired_at":    now.Add(-5 * time.Minute).Format(time.RFC3339),
+			"endpoint_id": "host-abc123",
+			"hostname":    "web-01",
+			"env":         "prod",
+		},
+	}
+}
+
+// RuleLookup returns the rule an alert was raised by, so templates can
+// reference it.
+type RuleLookup func(ctx context.Context, id string) (alertmodel.AlertRule, error)
+
+// AlertData is one alert as seen by notification templates.
+type AlertData struct {
+	ID         string
+	RuleID     string
+	Rule       alertmodel.AlertRule // empty when the rule can't be found
+	Status     string               // firing or resolved
+	Level      string
+	Message    string
+	Source     string
+	Scope      string
+	Target     string
+	EndpointID string
+	Category   string
+	Value      string
+	FiredAt    time.Time
+	Meta       map[string]string // endpoint meta and labels the alert was raised with
+	Link       string            // the alert in the web console; empty without web.base_url
+}
+
+// TemplateData is the data notification templates are executed with. A
+// single alert is a notification with one member; Alert returns it.
+type TemplateData struct {
+	Status      string
+	Title       string
+	GroupLabels map[string]string
+	Alerts      []AlertData // firing alerts first, then resolved ones
+	Firing      []AlertData
+	Resolved    []AlertData
+}
+
+// Alert returns the first alert of the notification.
+func (t TemplateData) Alert() AlertData {
+	if len(t.Alerts) == 0 {
+		return AlertData{}
+	}
+	return t.Alerts[0]
+}
+
+// templateData builds the template data for a notification, looking up each
+// alert's rule and linking it to the web console.
+func (d *Dispatcher) templateData(ctx context.Context, n Notification) TemplateData {
+	data := TemplateData{
+		Status:      n.Status,
+		Title:       notificationTitle(n),
+		GroupLabels: n.GroupLabels,
+	}
+	rules := make(map[string]alertmodel.AlertRule)
+	for _, e := range n.Firing {
+		data.Firing = append(data.Firing, d.alertData(ctx, e, alertmodel.StateFiring, rules))
+	}
+	for _, e := range n.Resolved {
+		data.Resolved = append(data.Resolved, d.alertData(ctx, e, alertmodel.StateResolved, rules))
+	}
+	data.Alerts = append(append([]AlertData{}, data.Firing...), data.Resolved...)
+	return data
+}
+
+// alertData converts an alert event for templates. rules caches the rules
+// looked up for the notification.
+func (d *Dispatcher) alertData(ctx context.Context, e model.EventEntry, status string, rules map[string]alertmodel.AlertRule) AlertData {
+	a := AlertData{
+		ID:         e.Meta["alert_id"],
+		RuleID:     e.Meta["rule_id"],
+		Status:     status,
+		Level:      e.Level,
+		Message:    e.Message,
+		Source:     e.Source,
+		Scope:      e.Scope,
+		Target:     e.Target,
+		EndpointID: e.EndpointID,
+		Category:   e.Category,
+		Value:      e.Meta["value"],
+		FiredAt:    e.Timestamp,
+		Meta:       e.Meta,
+	}
+	if a.EndpointID == "" {
+		a.EndpointID = e.Meta["endpoint_id"]
+	}
+	if t, err := time.Parse(time.RFC3339, e.Meta["fired_at"]); err == nil {
+		a.FiredAt = t
+	}
+	if a.ID != "" && d.baseURL != "" {
+		a.Link = strings.TrimSuffix(d.baseURL, "/") + "/alerts/" + a.ID
+	}
+	if a.RuleID != "" && d.rules != nil {
+		rule, ok := rules[a.RuleID]
+		if !ok {
+			rule, _ = d.rules(ctx, a.RuleID)
+			rules[a.RuleID] = rule
+		}
+		a.Rule = rule
+	}
+	return a
+}
+
+// AlertEvent rebuilds the notification event of a stored alert instance the
+// way the alert manager dispatches it, so templates can be previewed against
+// real alerts.
+func AlertEvent(inst *model.AlertInstance) model.EventEntry {
+	meta := make(map[string]string, len(inst.Labels)+6)
+	for k, v := range inst.Labels {
+		meta[k] = v
+	}
+	meta["rule_id"] = inst.RuleID
+	meta["alert_id"] = inst.ID
+	meta["value"] = strconv.FormatFloat(inst.LastValue, 'f', -1, 64)
+	meta["fired_at"] = inst.FirstFired.Format(time.RFC3339)
+	meta["alert_state"] = alertmodel.StateFiring
+	if inst.State == alertmodel.StateResolved {
+		meta["alert_state"] = alertmodel.StateResolved
+	}
+	if inst.EndpointID != "" {
+		meta["endpoint_id"] = inst.EndpointID
+	}
+	return model.EventEntry{
+		Timestamp:  inst.LastFired,
+		Level:      inst.Level,
+		Category:   "alert",
+		Message:    inst.Message,
+		Scope:      inst.Scope,
+		Target:     inst.Target,
+		EndpointID: inst.EndpointID,
+		Meta:       meta,
+	}
+}
+
+// Preview renders a notification template against an alert event, or
+// against a sample alert when e is nil. html selects html/template, which
+// escapes the alert's fields as the email action's html_body does.
+func (d *Dispatcher) Preview(ctx context.Context, text string, html bool, e *model.EventEntry) (string, error) {
+	var data TemplateData
+	if e == nil {
+		data = d.sampleData(time.Now())
+	} else {
+		data = d.templateData(ctx, notificationFor(*e))
+	}
+	if html {
+		return renderHTML("preview", text, data)
+	}
+	return renderText("preview", text, data)
+}
+
+// ValidateAction checks that an action has a known type and a valid retry
+// policy, and that its templates parse and execute against a sample alert, so
+// mistakes such as misspelled fields surface when routes are loaded rather
+// than when an alert fires.
+func ValidateAction(a alertmodel.ActionSpec) error {
+	switch strings.ToLower(a.Type) {
+	case "webhook", "script", "email", "slack", "msteams", "pagerduty", "opsgenie":
+	default:
+		return fmt.Errorf("unknown action type %q", a.Type)
+	}
+	if r := a.Retry; r != nil {
+		for _, v := range []string{r.Backoff, r.MaxBackoff} {
+			if v == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+				return fmt.Errorf("retry: invalid duration %q", v)
+			}
+		}
+	}
+
+	data := (&Dispatcher{}).sampleData(time.Now())
+	check := func(name, text string, html bool) error {
+		var err error
+		if html {
+			_, err = renderHTML(name, text, data)
+		} else {
+			_, err = renderText(name, text, data)
+		}
+		return err
+	}
+
+	for _, f := range []struct {
+		name string
+		text string
+		html bool
+	}{
+		{"subject", a.Subject, false},
+		{"body", a.Body, false},
+		{"text_body", a.TextBody, false},
+		{"html_body", a.HTMLBody, true},
+	} {
+		if err := check(f.name, f.text, f.html); err != nil {
+			return err
+		}
+	}
+	for k, v := range a.Headers {
+		if err := check("header "+k, v, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderAction returns the action with its templates executed for the
+// notification: subject, body and header values, plus the text and HTML
+// bodies of email actions, whose empty fields get the built-in templates.
+// Fields without template actions are left as they are.
+func (d *Dispatcher) renderAction(ctx context.Context, a alertmodel.ActionSpec, n Notification) (alertmodel.ActionSpec, error) {
+	if strings.EqualFold(a.Type, "email") {
+		a.Subject = orDefault(a.Subject, defaultEmailSubject)
+		a.TextBody = orDefault(a.TextBody, orDefault(a.Body, defaultEmailText))
+		a.HTMLBody = orDefault(a.HTMLBody, defaultEmailHTML)
+	}
+
+	var data *TemplateData
+	render := func(name, text string, html bool) (string, error) {
+		if !strings.Contains(text, "{{") {
+			return text, nil
+		}
+		if data == nil {
+			td := d.templateData(ctx, n)
+			data = &td
+		}
+		if html {
+			return renderHTML(name, text, *data)
+		}
+		return renderText(name, text, *data)
+	}
+
+	var err error
+	if a.Subject, err = render("subject", a.Subject, false); err != nil {
+		return a, err
+	}
+	if a.Body, err = render("body", a.Body, false); err != nil {
+		return a, err
+	}
+	if a.TextBody, err = render("text_body", a.TextBody, false); err != nil {
+		return a, err
+	}
+	if a.HTMLBody, err = render("html_body", a.HTMLBody, true); err != nil {
+		return a, err
+	}
+	if len(a.Headers) > 0 {
+		headers := make(map[string]string, len(a.Headers))
+		for k, v := range a.Headers {
+			if headers[k], err = render("header "+k, v, false); err != nil {
+				return a, err
+			}
+		}
+		a.Headers = headers
+	}
+	return a, nil
+}
+
+// sampleData is the template data of a notification for the sample alert.
+func (d *Dispatcher) sampleData(now time.Time) TemplateData {
+	sample := &Dispatcher{
+		rules: func(context.Context, string) (alertmodel.AlertRule, error) {
+			return sampleRule, nil
+		},
+		baseURL: d.baseURL,
+	}
+	if sample.baseURL == "" {
+		sample.baseURL = "https://gosight.example.com"
+	}
+	return sample.templateData(context.Background(), notificationFor(sampleEvent(now)))
+}
+
+// renderText executes a text/template against the notification data.
+func renderText(name, text string, data TemplateData) (string, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderHTML executes an html/template against the notification data, so
+// alert messages and labels are escaped.
+func renderHTML(name, text string, data TemplateData) (string, error) {
+	t, err := htmltemplate.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// orDefault returns s, or def when s is empty.
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// ActionResult is the outcome of one action of a route test.
+type ActionResult struct {
+	RouteID string `json:"route_id"`
+	Index   int    `json:"index"`
+	Type    string `json:"type"`
+	Error   string `json:"error,omitempty"`
+}
+
+// TestRoute sends a synthetic alert through each action of a route and of
+// its child routes once, so credentials and templates can be checked
+// without waiting for a real alert. The test bypasses matching, grouping
+// and the delivery queue and is not retried; each action's error, if any,
+// is returned in its result. PagerDuty and Opsgenie actions are sent the
+// matching resolve right after the test alert, so the test doesn't leave an
+// incident open.
+func (d *Dispatcher) TestRoute(ctx context.Context, route alertmodel.Route) []ActionResult {
+	e := sampleEvent(time.Now())
+	e.Message = "Test notification for route " + route.ID
+	e.Meta["test"] = "true"
+	n := notificationFor(e)
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return []ActionResult{{RouteID: route.ID, Error: err.Error()}}
+	}
+
+	// The resolve carries the same alert ID, so it closes the incident the
+	// test alert opened under that dedup key or alias.
+	resolved := e
+	resolved.Meta = make(map[string]string, len(e.Meta))
+	for k, v := range e.Meta {
+		resolved.Meta[k] = v
+	}
+	resolved.Meta["alert_state"] = alertmodel.StateResolved
+	rawResolved, err := json.Marshal(resolved)
+	if err != nil {
+		return []ActionResult{{RouteID: route.ID, Error: err.Error()}}
+	}
+
+	var results []ActionResult
+	var walk func(r alertmodel.Route)
+	walk = func(r alertmodel.Route) {
+		for i, a := range r.Actions {
+			del := alertmodel.Delivery{
+				RouteID: r.ID,
+				Action:  a,
+				Kind:    alertmodel.PayloadEvent,
+				Payload: raw,
+			}
+			var err error
+			if del.Action, err = d.renderAction(ctx, a, n); err == nil {
+				err = d.send(ctx, del)
+			}
+			if err == nil && opensIncident(a.Type) {
+				resolve := del
+				resolve.Payload = rawResolved
+				if err = d.send(ctx, resolve); err != nil {
+					err = fmt.Errorf("test incident was opened but not resolved: %w", err)
+				}
+			}
+			if err != nil {
+				// Mask webhook URLs in the error as the delivery history does.
+				del.LastError = err.Error()
+				del = del.Redacted()
+			}
+			results = append(results, ActionResult{RouteID: r.ID, Index: i, Type: a.Type, Error: del.LastError})
+		}
+		for _, child := range r.Routes {
+			walk(child)
+		}
+	}
+	walk(route)
+	return results
+}
+
+// opensIncident reports whether an action type opens an incident that stays
+// open until it is resolved.
+func opensIncident(actionType string) bool {
+	switch strings.ToLower(actionType) {
+	case "pagerduty", "opsgenie":
+		return true
+	}
+	return false
+}
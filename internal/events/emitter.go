@@ -26,6 +26,7 @@ package events
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/aaronlmathis/gosight-server/internal/store/eventstore"
@@ -37,8 +38,65 @@ import (
 // Emitter is an event emitter that stores events in an event store.
 // It provides a method to emit events with various attributes such as level, category, message, source, and metadata.
 type Emitter struct {
-	Store eventstore.EventStore
-	hub   *websocket.EventsHub
+	Store     eventstore.EventStore
+	hub       *websocket.EventsHub
+	lock      sync.RWMutex
+	listeners []*listenerQueue
+}
+
+// Listener receives every event passed to Emit, such as the rules engine
+// evaluating event rules. Listeners are called asynchronously so they may
+// emit events or raise alerts of their own. Each listener gets the events
+// one at a time, in the order they were emitted.
+type Listener interface {
+	OnEvent(ctx context.Context, event model.EventEntry)
+}
+
+// queuedEvent is an emitted event waiting for a listener.
+type queuedEvent struct {
+	ctx   context.Context
+	event model.EventEntry
+}
+
+// listenerQueue holds the events a listener hasn't handled yet and passes
+// them to it from a single goroutine. The queue is unbounded so Emit never
+// waits on a slow listener, nor on a listener emitting from OnEvent.
+type listenerQueue struct {
+	listener Listener
+	lock     sync.Mutex
+	pending  []queuedEvent
+	wake     chan struct{}
+}
+
+// push queues an event for the listener.
+func (q *listenerQueue) push(ctx context.Context, event model.EventEntry) {
+	q.lock.Lock()
+	q.pending = append(q.pending, queuedEvent{ctx: ctx, event: event})
+	q.lock.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run passes the queued events to the listener in order. It never returns.
+func (q *listenerQueue) run() {
+	for range q.wake {
+		for {
+			q.lock.Lock()
+			if len(q.pending) == 0 {
+				q.lock.Unlock()
+				break
+			}
+			next := q.pending[0]
+			q.pending[0] = queuedEvent{}
+			q.pending = q.pending[1:]
+			q.lock.Unlock()
+
+			q.listener.OnEvent(next.ctx, next.event)
+		}
+	}
 }
 
 // NewEmitter creates a new Emitter instance with the provided event store.
@@ -63,4 +121,20 @@ func (e *Emitter) Emit(ctx context.Context, event model.EventEntry) {
 		e.hub.Broadcast(event)
 	}
 	e.Store.AddEvent(ctx, event)
+
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	for _, q := range e.listeners {
+		q.push(context.WithoutCancel(ctx), event)
+	}
+}
+
+// AddListener registers a listener that is notified of every emitted event.
+func (e *Emitter) AddListener(l Listener) {
+	q := &listenerQueue{listener: l, wake: make(chan struct{}, 1)}
+	go q.run()
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.listeners = append(e.listeners, q)
 }
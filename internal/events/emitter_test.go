@@ -0,0 +1,106 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package events
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/store/eventstore"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingListener records the messages of the events it receives. When
+// echo is set it emits a follow-up event for each one, as the rules engine
+// does when an event rule fires.
+type recordingListener struct {
+	emitter *Emitter
+	echo    bool
+	lock    sync.Mutex
+	got     []string
+}
+
+func (l *recordingListener) OnEvent(ctx context.Context, e model.EventEntry) {
+	// Hold each event a little so later ones pile up behind it.
+	time.Sleep(time.Microsecond)
+	l.lock.Lock()
+	l.got = append(l.got, e.Message)
+	l.lock.Unlock()
+	if l.echo && e.Source != "echo" {
+		l.emitter.Emit(ctx, model.EventEntry{Message: "echo " + e.Message, Source: "echo"})
+	}
+}
+
+func (l *recordingListener) messages() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]string(nil), l.got...)
+}
+
+func newTestEmitter(t *testing.T) *Emitter {
+	store, err := eventstore.NewJSONEventStore("")
+	require.NoError(t, err)
+	return NewEmitter(store, nil)
+}
+
+func TestListenersReceiveEventsInOrder(t *testing.T) {
+	e := newTestEmitter(t)
+	first, second := &recordingListener{}, &recordingListener{}
+	e.AddListener(first)
+	e.AddListener(second)
+
+	var want []string
+	for i := 0; i < 200; i++ {
+		status := "Offline"
+		if i%2 == 1 {
+			status = "Online"
+		}
+		msg := strconv.Itoa(i) + " " + status
+		want = append(want, msg)
+		e.Emit(context.Background(), model.EventEntry{Message: msg})
+	}
+
+	for _, l := range []*recordingListener{first, second} {
+		require.Eventually(t, func() bool { return len(l.messages()) == len(want) }, 2*time.Second, 5*time.Millisecond)
+		assert.Equal(t, want, l.messages())
+	}
+}
+
+func TestListenerMayEmitFromOnEvent(t *testing.T) {
+	e := newTestEmitter(t)
+	l := &recordingListener{emitter: e, echo: true}
+	e.AddListener(l)
+
+	e.Emit(context.Background(), model.EventEntry{Message: "a"})
+	e.Emit(context.Background(), model.EventEntry{Message: "b"})
+
+	require.Eventually(t, func() bool { return len(l.messages()) == 4 }, 2*time.Second, 5*time.Millisecond)
+	got := l.messages()
+	assert.ElementsMatch(t, []string{"a", "b", "echo a", "echo b"}, got)
+	assert.Equal(t, "a", got[0])
+	assert.Equal(t, "echo b", got[3])
+}
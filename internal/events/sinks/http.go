@@ -0,0 +1,113 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HTTPSettings configures an HTTPSink.
+type HTTPSettings struct {
+	URL                string
+	Headers            map[string]string // added to every request; may override the content type
+	InsecureSkipVerify bool
+}
+
+// HTTPSink POSTs each batch of records to a URL as NDJSON, one record
+// envelope per line. Any 2xx response acknowledges the whole batch; other 4xx
+// responses than 408 and 429 reject it.
+type HTTPSink struct {
+	settings HTTPSettings
+	client   *http.Client
+}
+
+// NewHTTPSink creates an HTTPSink.
+func NewHTTPSink(settings HTTPSettings) (*HTTPSink, error) {
+	u, err := url.Parse(settings.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid http sink url %q", settings.URL)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if settings.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &HTTPSink{
+		settings: settings,
+		client:   &http.Client{Transport: transport},
+	}, nil
+}
+
+// Publish sends the records in one request. The publisher's timeout bounds it.
+func (s *HTTPSink) Publish(ctx context.Context, records []Record) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.settings.URL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	for k, v := range s.settings.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("%s returned %s: %s", s.settings.URL, resp.Status, strings.TrimSpace(string(msg)))
+		if rejected(resp.StatusCode) {
+			return Permanent(err)
+		}
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// rejected reports whether a status means the endpoint will never accept the
+// request as sent. Timeouts and rate limiting are worth retrying.
+func rejected(status int) bool {
+	return status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+// Close releases idle connections.
+func (s *HTTPSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
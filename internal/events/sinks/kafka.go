@@ -0,0 +1,329 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package sinks
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// KafkaSettings configures a KafkaSink.
+type KafkaSettings struct {
+	Brokers  []string // bootstrap brokers as host:port
+	Topic    string
+	ClientID string // defaults to gosight-server
+}
+
+// KafkaSink produces records to a Kafka topic with acks=all, speaking the
+// Kafka wire protocol directly. Records are keyed by their Record.Key and
+// spread over the topic's partitions by an FNV-1a hash of the key, so events
+// about one endpoint stay in order on one partition. Partition leaders are
+// looked up with a metadata request, which also creates the topic when the
+// brokers allow auto-creation, and again after any error.
+// A KafkaSink is used by a single publisher and is not safe for concurrent use.
+type KafkaSink struct {
+	settings KafkaSettings
+	leaders  []string // partition -> leader host:port; nil until metadata is loaded
+	conns    map[string]net.Conn
+	corrID   int32
+}
+
+// NewKafkaSink creates a KafkaSink. It connects on the first publish.
+func NewKafkaSink(settings KafkaSettings) (*KafkaSink, error) {
+	if len(settings.Brokers) == 0 {
+		return nil, errors.New("kafka sink needs at least one broker")
+	}
+	if settings.Topic == "" {
+		return nil, errors.New("kafka sink needs a topic")
+	}
+	if settings.ClientID == "" {
+		settings.ClientID = "gosight-server"
+	}
+	return &KafkaSink{settings: settings, conns: make(map[string]net.Conn)}, nil
+}
+
+// Publish produces the records, one request per partition leader, and
+// returns once every partition has acknowledged its records. On error the
+// connections and partition leaders are dropped and loaded again on the next
+// publish; partitions that did succeed receive their records twice.
+func (s *KafkaSink) Publish(ctx context.Context, records []Record) error {
+	err := s.publish(ctx, records)
+	if err != nil {
+		s.reset()
+	}
+	return err
+}
+
+func (s *KafkaSink) publish(ctx context.Context, records []Record) error {
+	if s.leaders == nil {
+		if err := s.loadMetadata(ctx); err != nil {
+			return err
+		}
+	}
+
+	byPartition := make(map[int32][]kafkaMessage)
+	for _, rec := range records {
+		value, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		p := s.partition(rec.Key)
+		byPartition[p] = append(byPartition[p], kafkaMessage{Key: []byte(rec.Key), Value: value, Time: rec.Time})
+	}
+
+	byLeader := make(map[string][]int32)
+	for p := range byPartition {
+		leader := s.leaders[p]
+		byLeader[leader] = append(byLeader[leader], p)
+	}
+	for leader, partitions := range byLeader {
+		if err := s.produce(ctx, leader, partitions, byPartition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partition picks the partition for a record key.
+func (s *KafkaSink) partition(key string) int32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int32(h.Sum32() % uint32(len(s.leaders)))
+}
+
+// produce sends one Produce request with a record batch per partition.
+func (s *KafkaSink) produce(ctx context.Context, leader string, partitions []int32, msgs map[int32][]kafkaMessage) error {
+	var req kafkaEncoder
+	req.nullString() // transactional ID
+	req.int16(-1)    // acks: all in-sync replicas
+	req.int32(int32(timeoutMillis(ctx)))
+	req.int32(1)
+	req.string(s.settings.Topic)
+	req.int32(int32(len(partitions)))
+	for _, p := range partitions {
+		req.int32(p)
+		req.bytes(encodeRecordBatch(msgs[p]))
+	}
+
+	resp, err := s.roundTrip(ctx, leader, kafkaProduceKey, kafkaProduceVersion, req.b)
+	if err != nil {
+		return err
+	}
+
+	acked := 0
+	for topics := resp.int32(); topics > 0 && resp.err == nil; topics-- {
+		resp.string()
+		for n := resp.int32(); n > 0 && resp.err == nil; n-- {
+			p := resp.int32()
+			code := resp.int16()
+			resp.int64() // base offset
+			resp.int64() // log append time
+			if resp.err == nil && code != 0 {
+				err := fmt.Errorf("kafka: produce to %s partition %d: error code %d", s.settings.Topic, p, code)
+				if kafkaRejectCodes[code] {
+					return Permanent(err)
+				}
+				return err
+			}
+			acked++
+		}
+	}
+	if resp.err != nil {
+		return resp.err
+	}
+	if acked != len(partitions) {
+		return fmt.Errorf("kafka: %s acknowledged %d of %d partitions", leader, acked, len(partitions))
+	}
+	return nil
+}
+
+// loadMetadata looks up the leader of every partition of the topic, asking
+// the bootstrap brokers in turn.
+func (s *KafkaSink) loadMetadata(ctx context.Context) error {
+	var req kafkaEncoder
+	req.int32(1)
+	req.string(s.settings.Topic)
+	req.int8(1) // allow auto topic creation
+
+	var lastErr error
+	for _, broker := range s.settings.Brokers {
+		resp, err := s.roundTrip(ctx, broker, kafkaMetadataKey, kafkaMetadataVersion, req.b)
+		if err != nil {
+			lastErr = err
+			s.closeConn(broker)
+			continue
+		}
+		leaders, err := s.parseMetadata(resp)
+		if err != nil {
+			return err
+		}
+		s.leaders = leaders
+		return nil
+	}
+	return lastErr
+}
+
+func (s *KafkaSink) parseMetadata(resp *kafkaDecoder) ([]string, error) {
+	resp.int32() // throttle time
+	brokers := make(map[int32]string)
+	for n := resp.int32(); n > 0 && resp.err == nil; n-- {
+		id := resp.int32()
+		host := resp.string()
+		port := resp.int32()
+		resp.string() // rack
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	resp.string() // cluster ID
+	resp.int32()  // controller ID
+
+	var leaders []string
+	for n := resp.int32(); n > 0 && resp.err == nil; n-- {
+		code := resp.int16()
+		name := resp.string()
+		resp.int8() // is internal
+		partitions := make(map[int32]int32)
+		for p := resp.int32(); p > 0 && resp.err == nil; p-- {
+			resp.int16() // partition error; a missing leader is caught below
+			index := resp.int32()
+			partitions[index] = resp.int32()
+			for i := 0; i < 2; i++ { // replicas, then in-sync replicas
+				for r := resp.int32(); r > 0 && resp.err == nil; r-- {
+					resp.int32()
+				}
+			}
+		}
+		if resp.err != nil || name != s.settings.Topic {
+			continue
+		}
+		if code != 0 {
+			return nil, fmt.Errorf("kafka: metadata for %s: error code %d", name, code)
+		}
+		leaders = make([]string, len(partitions))
+		for index, leader := range partitions {
+			addr, ok := brokers[leader]
+			if !ok || index < 0 || int(index) >= len(leaders) {
+				return nil, fmt.Errorf("kafka: %s partition %d has no leader", name, index)
+			}
+			leaders[index] = addr
+		}
+	}
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	if len(leaders) == 0 {
+		return nil, fmt.Errorf("kafka: topic %s has no partitions", s.settings.Topic)
+	}
+	return leaders, nil
+}
+
+// roundTrip sends a request to a broker and returns the response body after
+// its correlation ID.
+func (s *KafkaSink) roundTrip(ctx context.Context, broker string, key, version int16, body []byte) (*kafkaDecoder, error) {
+	conn, err := s.conn(ctx, broker)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Time{})
+	}
+
+	s.corrID++
+	var req kafkaEncoder
+	req.int32(0) // size, filled in below
+	req.int16(key)
+	req.int16(version)
+	req.int32(s.corrID)
+	req.string(s.settings.ClientID)
+	req.b = append(req.b, body...)
+	binary.BigEndian.PutUint32(req.b, uint32(len(req.b)-4))
+	if _, err := conn.Write(req.b); err != nil {
+		return nil, err
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	d := &kafkaDecoder{b: resp}
+	if id := d.int32(); d.err == nil && id != s.corrID {
+		return nil, fmt.Errorf("kafka: %s answered request %d, expected %d", broker, id, s.corrID)
+	}
+	return d, d.err
+}
+
+func (s *KafkaSink) conn(ctx context.Context, broker string) (net.Conn, error) {
+	if c, ok := s.conns[broker]; ok {
+		return c, nil
+	}
+	var d net.Dialer
+	c, err := d.DialContext(ctx, "tcp", broker)
+	if err != nil {
+		return nil, err
+	}
+	s.conns[broker] = c
+	return c, nil
+}
+
+func (s *KafkaSink) closeConn(broker string) {
+	if c, ok := s.conns[broker]; ok {
+		c.Close()
+		delete(s.conns, broker)
+	}
+}
+
+func (s *KafkaSink) reset() {
+	for broker := range s.conns {
+		s.closeConn(broker)
+	}
+	s.leaders = nil
+}
+
+// Close closes the broker connections.
+func (s *KafkaSink) Close() error {
+	s.reset()
+	return nil
+}
+
+// timeoutMillis is how long the broker may wait for replicas: the time left
+// before ctx's deadline, or 10s.
+func timeoutMillis(ctx context.Context) int64 {
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline).Milliseconds(); left > 0 {
+			return left
+		}
+	}
+	return 10000
+}
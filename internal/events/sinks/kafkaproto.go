@@ -0,0 +1,208 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package sinks
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"time"
+)
+
+// Kafka API keys and the versions the sink speaks. Produce v3 is the oldest
+// version current brokers accept and the first to carry record batches;
+// Metadata v4 adds allow_auto_topic_creation.
+const (
+	kafkaProduceKey      = 0
+	kafkaProduceVersion  = 3
+	kafkaMetadataKey     = 3
+	kafkaMetadataVersion = 4
+)
+
+// Produce error codes for records the broker will never accept:
+// MESSAGE_TOO_LARGE, RECORD_LIST_TOO_LARGE and INVALID_RECORD.
+var kafkaRejectCodes = map[int16]bool{10: true, 18: true, 87: true}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+var errKafkaShort = errors.New("kafka: truncated response")
+
+// kafkaEncoder builds a Kafka request in the classic (non-flexible) encoding.
+type kafkaEncoder struct {
+	b []byte
+}
+
+func (e *kafkaEncoder) int8(v int8)   { e.b = append(e.b, byte(v)) }
+func (e *kafkaEncoder) int16(v int16) { e.b = binary.BigEndian.AppendUint16(e.b, uint16(v)) }
+func (e *kafkaEncoder) int32(v int32) { e.b = binary.BigEndian.AppendUint32(e.b, uint32(v)) }
+func (e *kafkaEncoder) int64(v int64) { e.b = binary.BigEndian.AppendUint64(e.b, uint64(v)) }
+
+func (e *kafkaEncoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.b = append(e.b, s...)
+}
+
+func (e *kafkaEncoder) nullString() { e.int16(-1) }
+
+func (e *kafkaEncoder) bytes(b []byte) {
+	e.int32(int32(len(b)))
+	e.b = append(e.b, b...)
+}
+
+func (e *kafkaEncoder) varint(v int64) { e.b = binary.AppendVarint(e.b, v) }
+
+// varBytes writes a length-prefixed field of a record; nil is encoded as -1.
+func (e *kafkaEncoder) varBytes(b []byte) {
+	if b == nil {
+		e.varint(-1)
+		return
+	}
+	e.varint(int64(len(b)))
+	e.b = append(e.b, b...)
+}
+
+// kafkaDecoder reads a Kafka response. The first short read sets err and
+// every later read returns zero values.
+type kafkaDecoder struct {
+	b   []byte
+	err error
+}
+
+func (d *kafkaDecoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.b) < n {
+		d.err = errKafkaShort
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *kafkaDecoder) int8() int8 {
+	if b := d.take(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if b := d.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if b := d.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if b := d.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+func (d *kafkaDecoder) bytes() []byte {
+	n := d.int32()
+	if n < 0 {
+		return nil
+	}
+	return d.take(int(n))
+}
+
+func (d *kafkaDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.err = errKafkaShort
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+// kafkaMessage is one record in a record batch.
+type kafkaMessage struct {
+	Key   []byte
+	Value []byte
+	Time  time.Time
+}
+
+// encodeRecordBatch encodes messages as an uncompressed, non-transactional
+// record batch (magic 2) with offsets and timestamps relative to the first.
+func encodeRecordBatch(msgs []kafkaMessage) []byte {
+	first := msgs[0].Time
+	maxTS := first
+	var records kafkaEncoder
+	for i, m := range msgs {
+		if m.Time.After(maxTS) {
+			maxTS = m.Time
+		}
+		var r kafkaEncoder
+		r.int8(0) // attributes
+		r.varint(m.Time.Sub(first).Milliseconds())
+		r.varint(int64(i))
+		r.varBytes(m.Key)
+		r.varBytes(m.Value)
+		r.varint(0) // headers
+		records.varint(int64(len(r.b)))
+		records.b = append(records.b, r.b...)
+	}
+
+	// Everything from attributes on is covered by the CRC.
+	var body kafkaEncoder
+	body.int16(0) // attributes: no compression, create time
+	body.int32(int32(len(msgs) - 1))
+	body.int64(first.UnixMilli())
+	body.int64(maxTS.UnixMilli())
+	body.int64(-1) // producer ID
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.int32(int32(len(msgs)))
+	body.b = append(body.b, records.b...)
+
+	var batch kafkaEncoder
+	batch.int64(0)                              // base offset; assigned by the broker
+	batch.int32(int32(4 + 1 + 4 + len(body.b))) // length of everything after this field
+	batch.int32(-1)                             // partition leader epoch
+	batch.int8(2)                               // magic
+	batch.int32(int32(crc32.Checksum(body.b, castagnoli)))
+	batch.b = append(batch.b, body.b...)
+	return batch.b
+}
@@ -0,0 +1,362 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package sinks
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NATSSettings configures a NATSSink.
+type NATSSettings struct {
+	URLs               []string // nats://host:port or tls://host:port; credentials may be given in the URL
+	Subject            string
+	JetStream          bool // wait for the stream's acknowledgement of every record
+	Username           string
+	Password           string
+	Token              string
+	InsecureSkipVerify bool
+}
+
+// NATSSink publishes every record to a NATS subject over the plain NATS
+// client protocol. Without JetStream a batch is acknowledged once the server
+// answers a PING sent after it, which means the server has received it; with
+// JetStream every record must be acknowledged by the stream storing it.
+// A NATSSink is used by a single publisher and is not safe for concurrent use.
+type NATSSink struct {
+	settings NATSSettings
+	servers  []*url.URL
+	next     int // server to try first on the next connect
+
+	conn  net.Conn
+	r     *bufio.Reader
+	w     *bufio.Writer
+	inbox string // JetStream acknowledgements arrive on inbox.<seq>
+	seq   uint64
+
+	maxPayload int // largest message the server accepts, from its INFO
+}
+
+// NewNATSSink creates a NATSSink. It connects on the first publish.
+func NewNATSSink(settings NATSSettings) (*NATSSink, error) {
+	if settings.Subject == "" {
+		return nil, errors.New("nats sink needs a subject")
+	}
+	if len(settings.URLs) == 0 {
+		settings.URLs = []string{"nats://127.0.0.1:4222"}
+	}
+	s := &NATSSink{settings: settings}
+	for _, raw := range settings.URLs {
+		if !strings.Contains(raw, "://") {
+			raw = "nats://" + raw
+		}
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || (u.Scheme != "nats" && u.Scheme != "tls") || u.Hostname() == "" {
+			return nil, fmt.Errorf("invalid nats url %q", raw)
+		}
+		if u.Port() == "" {
+			u.Host = net.JoinHostPort(u.Hostname(), "4222")
+		}
+		s.servers = append(s.servers, u)
+	}
+	return s, nil
+}
+
+// Publish sends the records and waits for the server, or with JetStream the
+// stream, to acknowledge them. On any error the connection is dropped and the
+// next publish reconnects, trying the next server first.
+func (s *NATSSink) Publish(ctx context.Context, records []Record) error {
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetDeadline(deadline)
+	} else {
+		s.conn.SetDeadline(time.Time{})
+	}
+
+	err := s.publish(records)
+	if err != nil {
+		s.reset()
+		s.next = (s.next + 1) % len(s.servers)
+	}
+	return err
+}
+
+func (s *NATSSink) publish(records []Record) error {
+	waiting := make(map[string]bool, len(records))
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		if s.maxPayload > 0 && len(data) > s.maxPayload {
+			return Permanent(fmt.Errorf("nats: %d byte record exceeds the server's max_payload of %d", len(data), s.maxPayload))
+		}
+		if s.settings.JetStream {
+			s.seq++
+			reply := s.inbox + "." + strconv.FormatUint(s.seq, 10)
+			waiting[reply] = true
+			fmt.Fprintf(s.w, "PUB %s %s %d\r\n", s.settings.Subject, reply, len(data))
+		} else {
+			fmt.Fprintf(s.w, "PUB %s %d\r\n", s.settings.Subject, len(data))
+		}
+		s.w.Write(data)
+		s.w.WriteString("\r\n")
+	}
+	if !s.settings.JetStream {
+		s.w.WriteString("PING\r\n")
+	}
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+
+	if !s.settings.JetStream {
+		return s.waitPong()
+	}
+	for len(waiting) > 0 {
+		subject, payload, err := s.readMsg()
+		if err != nil {
+			return err
+		}
+		if !waiting[subject] {
+			continue // a late acknowledgement of an earlier, failed batch
+		}
+		var ack struct {
+			Stream string `json:"stream"`
+			Error  *struct {
+				Code        int    `json:"code"`
+				Description string `json:"description"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(payload, &ack); err != nil {
+			return fmt.Errorf("nats: invalid jetstream ack: %w", err)
+		}
+		if ack.Error != nil {
+			return fmt.Errorf("nats: jetstream: %s (%d)", ack.Error.Description, ack.Error.Code)
+		}
+		delete(waiting, subject)
+	}
+	return nil
+}
+
+// connect dials the servers in turn, upgrading to TLS for tls:// URLs or
+// when the server requires it, and authenticates.
+func (s *NATSSink) connect(ctx context.Context) error {
+	var lastErr error
+	for i := range s.servers {
+		u := s.servers[(s.next+i)%len(s.servers)]
+		if err := s.dial(ctx, u); err != nil {
+			lastErr = fmt.Errorf("nats %s: %w", u.Host, err)
+			s.reset()
+			continue
+		}
+		s.next = (s.next + i) % len(s.servers)
+		return nil
+	}
+	s.next = (s.next + 1) % len(s.servers)
+	return lastErr
+}
+
+func (s *NATSSink) dial(ctx context.Context, u *url.URL) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	s.conn = conn
+	s.r = bufio.NewReader(conn)
+
+	line, err := s.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected greeting %q", line)
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+		MaxPayload  int  `json:"max_payload"`
+	}
+	if err := json.Unmarshal([]byte(line[len("INFO "):]), &info); err != nil {
+		return fmt.Errorf("invalid INFO: %w", err)
+	}
+	s.maxPayload = info.MaxPayload
+	if u.Scheme == "tls" || info.TLSRequired {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: s.settings.InsecureSkipVerify})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return err
+		}
+		s.conn = tlsConn
+		s.r = bufio.NewReader(tlsConn)
+	}
+	s.w = bufio.NewWriter(s.conn)
+
+	opts := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"lang":     "go",
+		"version":  "gosight",
+		"name":     "gosight-server",
+		"protocol": 1,
+	}
+	user, pass, token := s.settings.Username, s.settings.Password, s.settings.Token
+	if u.User != nil {
+		if p, ok := u.User.Password(); ok {
+			user, pass = u.User.Username(), p
+		} else {
+			token = u.User.Username()
+		}
+	}
+	if user != "" {
+		opts["user"], opts["pass"] = user, pass
+	}
+	if token != "" {
+		opts["auth_token"] = token
+	}
+	connect, _ := json.Marshal(opts)
+	fmt.Fprintf(s.w, "CONNECT %s\r\nPING\r\n", connect)
+	if s.settings.JetStream {
+		s.inbox = "_INBOX." + randomID()
+		fmt.Fprintf(s.w, "SUB %s.* 1\r\n", s.inbox)
+	}
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return s.waitPong()
+}
+
+// waitPong reads until the server's PONG, answering its PINGs.
+func (s *NATSSink) waitPong() error {
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if err := s.pong(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats: %s", strings.TrimSpace(line[len("-ERR"):]))
+		case strings.HasPrefix(line, "MSG "):
+			if err := s.skipPayload(line); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readMsg reads until the next MSG and returns its subject and payload.
+func (s *NATSSink) readMsg() (string, []byte, error) {
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return "", nil, err
+		}
+		switch {
+		case line == "PING":
+			if err := s.pong(); err != nil {
+				return "", nil, err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return "", nil, fmt.Errorf("nats: %s", strings.TrimSpace(line[len("-ERR"):]))
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			fields := strings.Fields(line)
+			if len(fields) < 4 {
+				return "", nil, fmt.Errorf("nats: malformed %q", line)
+			}
+			n, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return "", nil, fmt.Errorf("nats: malformed %q", line)
+			}
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(s.r, payload); err != nil {
+				return "", nil, err
+			}
+			return fields[1], payload[:n], nil
+		}
+	}
+}
+
+func (s *NATSSink) skipPayload(line string) error {
+	fields := strings.Fields(line)
+	n, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return fmt.Errorf("nats: malformed %q", line)
+	}
+	_, err = s.r.Discard(n + 2)
+	return err
+}
+
+func (s *NATSSink) pong() error {
+	s.w.WriteString("PONG\r\n")
+	return s.w.Flush()
+}
+
+func (s *NATSSink) readLine() (string, error) {
+	line, err := s.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (s *NATSSink) reset() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn, s.r, s.w = nil, nil, nil
+}
+
+// Close closes the connection.
+func (s *NATSSink) Close() error {
+	s.reset()
+	return nil
+}
+
+func randomID() string {
+	b := make([]byte, 11)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,320 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+// PublisherSettings controls which records a sink receives and how they are
+// queued and retried.
+type PublisherSettings struct {
+	Name       string        // identifies the sink in logs and names its spool file
+	Events     bool          // publish emitted events
+	Alerts     bool          // publish alert changes
+	BatchSize  int           // records per publish; defaults to 100
+	QueueSize  int           // records held in memory; without a spool new ones are dropped beyond it; defaults to 10000
+	SpoolDir   string        // keep the queue on disk in this directory, bounded only by the disk; empty keeps it in memory
+	Timeout    time.Duration // per publish; defaults to 10s
+	Backoff    time.Duration // delay before the first retry, doubled per failure; defaults to 1s
+	MaxBackoff time.Duration // longest delay between retries; defaults to 1m
+}
+
+// Publisher queues records for one sink and publishes them in order,
+// retrying a failed batch with exponential backoff until the sink accepts it.
+// Records the sink rejects outright are dead-lettered instead.
+type Publisher struct {
+	settings PublisherSettings
+	sink     Sink
+	lock     sync.Mutex
+	queue    []Record
+	spool    *spool
+	backlog  int // spooled records behind the queue, not yet read back into memory
+	dropped  int
+	wake     chan struct{}
+}
+
+// NewPublisher creates a Publisher for sink. With a spool directory, records
+// left in the spool by a previous run are queued again.
+func NewPublisher(sink Sink, settings PublisherSettings) (*Publisher, error) {
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 100
+	}
+	if settings.QueueSize <= 0 {
+		settings.QueueSize = 10000
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = 10 * time.Second
+	}
+	if settings.Backoff <= 0 {
+		settings.Backoff = time.Second
+	}
+	if settings.MaxBackoff <= 0 {
+		settings.MaxBackoff = time.Minute
+	}
+	if settings.MaxBackoff < settings.Backoff {
+		settings.MaxBackoff = settings.Backoff
+	}
+
+	p := &Publisher{
+		settings: settings,
+		sink:     sink,
+		wake:     make(chan struct{}, 1),
+	}
+	if settings.SpoolDir != "" {
+		if err := os.MkdirAll(settings.SpoolDir, 0755); err != nil {
+			return nil, err
+		}
+		sp, pending, backlog, err := openSpool(filepath.Join(settings.SpoolDir, settings.Name+".spool"), settings.QueueSize)
+		if err != nil {
+			return nil, fmt.Errorf("open spool of sink %s: %w", settings.Name, err)
+		}
+		if n := len(pending) + backlog; n > 0 {
+			utils.Info("Event sink %s: %d undelivered records from the spool", settings.Name, n)
+		}
+		p.spool = sp
+		p.queue = pending
+		p.backlog = backlog
+	}
+	return p, nil
+}
+
+// Enqueue adds a record to the queue if the sink publishes its kind. Without
+// a spool the record is dropped and counted when the queue is full; with one
+// it waits on disk until the queue has room for it.
+func (p *Publisher) Enqueue(rec Record) {
+	if (rec.Kind == KindEvent && !p.settings.Events) || (rec.Kind == KindAlert && !p.settings.Alerts) {
+		return
+	}
+
+	p.lock.Lock()
+	full := p.backlog > 0 || len(p.queue) >= p.settings.QueueSize
+	switch {
+	case p.spool != nil && full:
+		if err := p.spool.append(rec, false); err != nil {
+			p.drop(err)
+		} else {
+			p.backlog++
+		}
+	case full:
+		p.drop(nil)
+	default:
+		if p.spool != nil {
+			if err := p.spool.append(rec, true); err != nil {
+				utils.Warn("Event sink %s: failed to spool record: %v", p.settings.Name, err)
+			}
+		}
+		p.queue = append(p.queue, rec)
+	}
+	p.lock.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// drop counts a record that could not be queued. The caller holds the lock.
+func (p *Publisher) drop(err error) {
+	p.dropped++
+	if p.dropped != 1 && p.dropped%1000 != 0 {
+		return
+	}
+	if err != nil {
+		utils.Warn("Event sink %s: failed to spool record, %d records dropped: %v", p.settings.Name, p.dropped, err)
+	} else {
+		utils.Warn("Event sink %s: queue full, %d records dropped", p.settings.Name, p.dropped)
+	}
+}
+
+// Pending returns the number of queued records, including those waiting in
+// the spool.
+func (p *Publisher) Pending() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.queue) + p.backlog
+}
+
+// Run publishes queued records until ctx is canceled, then closes the sink.
+// Records still queued stay in the spool for the next run. When the sink
+// rejects a batch, its records are published one at a time so that only the
+// rejected ones are dead-lettered.
+func (p *Publisher) Run(ctx context.Context) {
+	defer p.close()
+
+	backoff := p.settings.Backoff
+	isolate := 0 // records left to publish on their own after a rejected batch
+	for {
+		size := p.settings.BatchSize
+		if isolate > 0 {
+			size = 1
+		}
+		batch := p.next(size)
+		if len(batch) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.wake:
+			}
+			continue
+		}
+
+		pubCtx, cancel := context.WithTimeout(ctx, p.settings.Timeout)
+		err := p.sink.Publish(pubCtx, batch)
+		cancel()
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			return
+		case IsPermanent(err) && len(batch) > 1:
+			isolate = len(batch)
+			continue
+		case IsPermanent(err):
+			p.deadLetter(batch, err)
+		default:
+			utils.Warn("Event sink %s: publishing %d records failed, retrying in %s: %v", p.settings.Name, len(batch), backoff, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > p.settings.MaxBackoff {
+				backoff = p.settings.MaxBackoff
+			}
+			continue
+		}
+		backoff = p.settings.Backoff
+		if isolate > 0 {
+			isolate--
+		}
+		p.ack(len(batch))
+	}
+}
+
+// next returns up to size of the oldest queued records, syncing the spool
+// first so nothing is published that would be lost in a crash.
+func (p *Publisher) next(size int) []Record {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.queue) == 0 && p.spool != nil {
+		p.refill() // after a failed read in ack
+	}
+	n := len(p.queue)
+	if n > size {
+		n = size
+	}
+	if n > 0 && p.spool != nil {
+		if err := p.spool.sync(); err != nil {
+			utils.Warn("Event sink %s: failed to sync spool: %v", p.settings.Name, err)
+		}
+	}
+	return append([]Record(nil), p.queue[:n]...)
+}
+
+// deadRecord is a rejected record as written to the dead-letter file.
+type deadRecord struct {
+	Record
+	Error string `json:"error"`
+}
+
+// deadLetter sets aside records the sink rejected. With a spool directory
+// they are appended to <name>.dead there; otherwise they are only logged.
+func (p *Publisher) deadLetter(records []Record, reason error) {
+	if p.settings.SpoolDir == "" {
+		utils.Warn("Event sink %s: dropping %d rejected records: %v", p.settings.Name, len(records), reason)
+		return
+	}
+	path := filepath.Join(p.settings.SpoolDir, p.settings.Name+".dead")
+	utils.Warn("Event sink %s: moving %d rejected records to %s: %v", p.settings.Name, len(records), path, reason)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		utils.Warn("Event sink %s: failed to open dead-letter file: %v", p.settings.Name, err)
+		return
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	for _, rec := range records {
+		if err := enc.Encode(deadRecord{Record: rec, Error: reason.Error()}); err != nil {
+			utils.Warn("Event sink %s: failed to write dead-letter file: %v", p.settings.Name, err)
+			return
+		}
+	}
+}
+
+// ack removes the first n records, which the sink has accepted.
+func (p *Publisher) ack(n int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.queue = p.queue[n:]
+	if len(p.queue) == 0 {
+		p.queue = nil
+	}
+	if p.spool == nil {
+		return
+	}
+	p.refill()
+	if err := p.spool.ack(n, p.queue); err != nil {
+		utils.Warn("Event sink %s: failed to update spool: %v", p.settings.Name, err)
+	}
+}
+
+// refill reads records waiting in the spool into the queue as far as it has
+// room. The caller holds the lock.
+func (p *Publisher) refill() {
+	room := p.settings.QueueSize - len(p.queue)
+	if p.backlog == 0 || room <= 0 {
+		return
+	}
+	more, read, err := p.spool.load(min(room, p.backlog))
+	if err != nil {
+		utils.Warn("Event sink %s: failed to read spool: %v", p.settings.Name, err)
+	}
+	if skipped := read - len(more); skipped > 0 {
+		utils.Warn("Event sink %s: skipped %d unreadable spooled records", p.settings.Name, skipped)
+	}
+	p.queue = append(p.queue, more...)
+	p.backlog -= read
+}
+
+func (p *Publisher) close() {
+	if err := p.sink.Close(); err != nil {
+		utils.Warn("Event sink %s: close failed: %v", p.settings.Name, err)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.spool != nil {
+		if err := p.spool.close(); err != nil {
+			utils.Warn("Event sink %s: failed to close spool: %v", p.settings.Name, err)
+		}
+	}
+}
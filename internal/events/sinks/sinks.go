@@ -0,0 +1,153 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package sinks publishes emitted events, and optionally alert state
+// changes, to external systems: an HTTP endpoint taking NDJSON, a NATS
+// subject or a Kafka topic. Every sink has its own queue, kept on disk when a
+// spool directory is set, and records leave the queue only once the sink has
+// acknowledged them, so subscribers receive each record at least once.
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+// Record kinds.
+const (
+	KindEvent = "event"
+	KindAlert = "alert"
+)
+
+// Record is the envelope published to a sink. Data holds an event entry for
+// KindEvent and an AlertChange for KindAlert.
+type Record struct {
+	Kind string          `json:"kind"`
+	Key  string          `json:"key,omitempty"` // endpoint the record is about, or its ID; the Kafka message key
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+// AlertChange is published for every change to an alert instance: a state
+// transition, or an acknowledgement, assignment or manual resolve.
+type AlertChange struct {
+	Alert  model.AlertInstance      `json:"alert"`
+	Change alertmodel.TimelineEntry `json:"change"`
+}
+
+// Sink delivers batches of records to an external system. Publish returns
+// nil only once every record in the batch has been accepted; on error the
+// whole batch is published again, so subscribers may see duplicates. An
+// error wrapped with Permanent means the sink rejected the batch and would
+// reject it again, so it is not retried.
+type Sink interface {
+	Publish(ctx context.Context, records []Record) error
+	Close() error
+}
+
+// permanentError marks a publish error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err to tell the publisher the records were rejected, for
+// example by a 4xx response or for being larger than the broker accepts.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err, or an error it wraps, came from Permanent.
+func IsPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+// Sinks fans emitted events and alert changes out to the configured sinks.
+// It is an events.Listener and an alerts.StateListener.
+type Sinks struct {
+	publishers []*Publisher
+}
+
+// New creates a Sinks publishing to the given publishers.
+func New(publishers ...*Publisher) *Sinks {
+	return &Sinks{publishers: publishers}
+}
+
+// Len returns the number of configured sinks.
+func (s *Sinks) Len() int {
+	return len(s.publishers)
+}
+
+// OnEvent queues an emitted event for every sink publishing events.
+func (s *Sinks) OnEvent(ctx context.Context, e model.EventEntry) {
+	key := e.EndpointID
+	if key == "" {
+		key = e.ID
+	}
+	s.enqueue(KindEvent, key, e.Timestamp, e)
+}
+
+// OnAlertChange queues an alert change for every sink publishing alerts.
+func (s *Sinks) OnAlertChange(ctx context.Context, inst model.AlertInstance, entry alertmodel.TimelineEntry) {
+	key := inst.EndpointID
+	if key == "" {
+		key = inst.ID
+	}
+	s.enqueue(KindAlert, key, entry.Timestamp, AlertChange{Alert: inst, Change: entry})
+}
+
+func (s *Sinks) enqueue(kind, key string, ts time.Time, v any) {
+	if len(s.publishers) == 0 {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		utils.Warn("Failed to encode %s for event sinks: %v", kind, err)
+		return
+	}
+	rec := Record{Kind: kind, Key: key, Time: ts, Data: data}
+	for _, p := range s.publishers {
+		p.Enqueue(rec)
+	}
+}
+
+// Run publishes queued records until ctx is canceled, then closes the sinks.
+func (s *Sinks) Run(ctx context.Context) {
+	done := make(chan struct{})
+	for _, p := range s.publishers {
+		go func(p *Publisher) {
+			p.Run(ctx)
+			done <- struct{}{}
+		}(p)
+	}
+	for range s.publishers {
+		<-done
+	}
+}
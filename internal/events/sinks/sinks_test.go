@@ -0,0 +1,759 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package sinks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// received collects the records a stand-in has accepted.
+type received struct {
+	lock    sync.Mutex
+	records []Record
+}
+
+func (r *received) add(recs ...Record) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.records = append(r.records, recs...)
+}
+
+// eventIDs returns the distinct IDs of the received events in arrival order.
+func (r *received) eventIDs(t *testing.T) []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var ids []string
+	seen := make(map[string]bool)
+	for _, rec := range r.records {
+		if rec.Kind != KindEvent {
+			continue
+		}
+		var e model.EventEntry
+		require.NoError(t, json.Unmarshal(rec.Data, &e))
+		if !seen[e.ID] {
+			seen[e.ID] = true
+			ids = append(ids, e.ID)
+		}
+	}
+	return ids
+}
+
+func (r *received) kinds() map[string]int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	kinds := make(map[string]int)
+	for _, rec := range r.records {
+		kinds[rec.Kind]++
+	}
+	return kinds
+}
+
+func testEvent(id string) model.EventEntry {
+	return model.EventEntry{
+		ID:         id,
+		Timestamp:  time.Now().UTC(),
+		Level:      "warning",
+		Category:   "system",
+		Message:    "Agent web-1 changed status to Offline",
+		EndpointID: "host-web-1",
+	}
+}
+
+func fastSettings(name string) PublisherSettings {
+	return PublisherSettings{Name: name, Events: true, Backoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond, Timeout: 2 * time.Second}
+}
+
+// runPublisher runs p until the test ends.
+func runPublisher(t *testing.T, p *Publisher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestHTTPSinkRetriesUntilAccepted(t *testing.T) {
+	var got received
+	var calls int
+	var lock sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		calls++
+		first := calls == 1
+		lock.Unlock()
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if first {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var rec Record
+			require.NoError(t, json.Unmarshal(sc.Bytes(), &rec))
+			got.add(rec)
+		}
+	}))
+	defer srv.Close()
+
+	sink, err := NewHTTPSink(HTTPSettings{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer secret"}})
+	require.NoError(t, err)
+	p, err := NewPublisher(sink, fastSettings("siem"))
+	require.NoError(t, err)
+	s := New(p)
+
+	for i := 0; i < 3; i++ {
+		s.OnEvent(context.Background(), testEvent(fmt.Sprintf("e%d", i)))
+	}
+	s.OnAlertChange(context.Background(), model.AlertInstance{ID: "a1"}, alertmodel.TimelineEntry{Action: alertmodel.StateFiring})
+	runPublisher(t, p)
+
+	require.Eventually(t, func() bool { return len(got.eventIDs(t)) == 3 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"e0", "e1", "e2"}, got.eventIDs(t))
+	assert.Zero(t, got.kinds()[KindAlert], "alerts are only published when asked for")
+	assert.Equal(t, 0, p.Pending())
+}
+
+// flakySink fails until it is told to accept.
+type flakySink struct {
+	received
+	accept bool
+}
+
+func (f *flakySink) Publish(ctx context.Context, records []Record) error {
+	f.lock.Lock()
+	accept := f.accept
+	f.lock.Unlock()
+	if !accept {
+		return errors.New("unavailable")
+	}
+	f.add(records...)
+	return nil
+}
+
+func (f *flakySink) Close() error { return nil }
+
+func TestSpoolRedeliversAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	settings := fastSettings("bus")
+	settings.Alerts = true
+	settings.SpoolDir = dir
+
+	// The sink is down for the whole first run.
+	down := &flakySink{}
+	p, err := NewPublisher(down, settings)
+	require.NoError(t, err)
+	s := New(p)
+	s.OnEvent(context.Background(), testEvent("e1"))
+	s.OnAlertChange(context.Background(), model.AlertInstance{ID: "a1", EndpointID: "host-web-1"}, alertmodel.TimelineEntry{AlertID: "a1", Action: alertmodel.StateFiring})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+	assert.Empty(t, down.records)
+
+	// A new publisher on the same spool delivers what the first could not.
+	up := &flakySink{accept: true}
+	p, err = NewPublisher(up, settings)
+	require.NoError(t, err)
+	assert.Equal(t, 2, p.Pending())
+	runPublisher(t, p)
+	require.Eventually(t, func() bool { return p.Pending() == 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"e1"}, up.eventIDs(t))
+	assert.Equal(t, 1, up.kinds()[KindAlert])
+
+	// Once delivered, nothing is replayed.
+	again, err := NewPublisher(&flakySink{}, settings)
+	require.NoError(t, err)
+	assert.Equal(t, 0, again.Pending())
+}
+
+func TestSpoolCutsTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	sp, pending, _, err := openSpool(dir+"/s.spool", 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	require.NoError(t, sp.append(Record{Kind: KindEvent, Key: "k", Data: json.RawMessage(`{"id":"e1"}`)}, true))
+	require.NoError(t, sp.sync())
+	_, err = sp.f.WriteString(`{"kind":"event","da`)
+	require.NoError(t, err)
+	require.NoError(t, sp.close())
+
+	sp, pending, _, err = openSpool(dir+"/s.spool", 10)
+	require.NoError(t, err)
+	defer sp.close()
+	require.Len(t, pending, 1)
+	assert.JSONEq(t, `{"id":"e1"}`, string(pending[0].Data))
+}
+
+func TestSpoolHoldsQueueBeyondMemory(t *testing.T) {
+	dir := t.TempDir()
+	settings := fastSettings("bus")
+	settings.SpoolDir = dir
+	settings.QueueSize = 3
+	settings.BatchSize = 2
+
+	down := &flakySink{}
+	p, err := NewPublisher(down, settings)
+	require.NoError(t, err)
+	s := New(p)
+	var want []string
+	for i := 0; i < 10; i++ {
+		s.OnEvent(context.Background(), testEvent(fmt.Sprintf("e%d", i)))
+		want = append(want, fmt.Sprintf("e%d", i))
+	}
+	assert.Equal(t, 10, p.Pending(), "a spooled queue is not cut at the queue size")
+	assert.Len(t, p.queue, 3, "only the queue size is held in memory")
+	p.close()
+
+	// After a restart the backlog is still on disk and drains in order.
+	up := &flakySink{accept: true}
+	p, err = NewPublisher(up, settings)
+	require.NoError(t, err)
+	assert.Equal(t, 10, p.Pending())
+	assert.Len(t, p.queue, 3)
+	runPublisher(t, p)
+	require.Eventually(t, func() bool { return p.Pending() == 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, want, up.eventIDs(t))
+}
+
+func TestSpoolRewriteKeepsRecordsOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	sp, _, _, err := openSpool(dir+"/s.spool", 2)
+	require.NoError(t, err)
+	var held []Record
+	for i := 0; i < 5; i++ {
+		rec := Record{Kind: KindEvent, Key: strconv.Itoa(i), Data: json.RawMessage(`{}`)}
+		require.NoError(t, sp.append(rec, i < 2))
+		if i < 2 {
+			held = append(held, rec)
+		}
+	}
+	sp.acked = 1
+	require.NoError(t, sp.rewrite(held[1:]))
+	require.NoError(t, sp.close())
+
+	sp, pending, backlog, err := openSpool(dir+"/s.spool", 2)
+	require.NoError(t, err)
+	defer sp.close()
+	require.Len(t, pending, 2)
+	assert.Equal(t, "1", pending[0].Key)
+	assert.Equal(t, "2", pending[1].Key)
+	assert.Equal(t, 2, backlog)
+	more, read, err := sp.load(5)
+	require.NoError(t, err)
+	assert.Equal(t, 2, read)
+	require.Len(t, more, 2)
+	assert.Equal(t, "3", more[0].Key)
+	assert.Equal(t, "4", more[1].Key)
+}
+
+// deadLetters reads the records a publisher set aside in dir.
+func deadLetters(t *testing.T, dir, name string) []deadRecord {
+	data, err := os.ReadFile(filepath.Join(dir, name+".dead"))
+	require.NoError(t, err)
+	var out []deadRecord
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var rec deadRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestHTTPSinkDeadLettersRejectedRecords(t *testing.T) {
+	var got received
+	var lock sync.Mutex
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		calls++
+		first := calls == 1
+		lock.Unlock()
+		if first {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		var recs []Record
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var rec Record
+			require.NoError(t, json.Unmarshal(sc.Bytes(), &rec))
+			if strings.Contains(string(rec.Data), "bad") {
+				http.Error(w, "invalid record", http.StatusBadRequest)
+				return
+			}
+			recs = append(recs, rec)
+		}
+		got.add(recs...)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	sink, err := NewHTTPSink(HTTPSettings{URL: srv.URL})
+	require.NoError(t, err)
+	settings := fastSettings("siem")
+	settings.SpoolDir = dir
+	p, err := NewPublisher(sink, settings)
+	require.NoError(t, err)
+	s := New(p)
+	for _, id := range []string{"e0", "bad", "e2"} {
+		s.OnEvent(context.Background(), testEvent(id))
+	}
+	runPublisher(t, p)
+
+	require.Eventually(t, func() bool { return p.Pending() == 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"e0", "e2"}, got.eventIDs(t), "a 429 is retried and the rest of a rejected batch delivered")
+	dead := deadLetters(t, dir, "siem")
+	require.Len(t, dead, 1)
+	assert.Contains(t, string(dead[0].Data), `"bad"`)
+	assert.Contains(t, dead[0].Error, "400")
+}
+
+func TestRejectedStatus(t *testing.T) {
+	for status, want := range map[int]bool{
+		http.StatusBadRequest:            true,
+		http.StatusUnauthorized:          true,
+		http.StatusRequestEntityTooLarge: true,
+		http.StatusRequestTimeout:        false,
+		http.StatusTooManyRequests:       false,
+		http.StatusBadGateway:            false,
+	} {
+		assert.Equal(t, want, rejected(status), "status %d", status)
+	}
+}
+
+// natsStandIn is a minimal NATS server. With jetstream it acknowledges every
+// message that has a reply subject as a stream would.
+type natsStandIn struct {
+	received
+	ln        net.Listener
+	jetstream bool
+	failFirst bool // acknowledge the first message with an error
+	acks      int
+}
+
+func newNATSStandIn(t *testing.T, jetstream bool) *natsStandIn {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &natsStandIn{ln: ln, jetstream: jetstream}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(t, conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *natsStandIn) serve(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"stand-in\",\"max_payload\":1048576}\r\n")
+	sid := ""
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "CONNECT":
+			var opts map[string]any
+			assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "CONNECT ")), &opts))
+			assert.Equal(t, "nats-user", opts["user"])
+		case "PING":
+			io.WriteString(conn, "PONG\r\n")
+		case "SUB":
+			sid = fields[len(fields)-1]
+		case "PUB":
+			n, _ := strconv.Atoi(fields[len(fields)-1])
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			assert.Equal(t, "gosight.events", fields[1])
+			var rec Record
+			assert.NoError(t, json.Unmarshal(payload[:n], &rec))
+			if !s.jetstream || len(fields) != 4 {
+				s.add(rec)
+				continue
+			}
+			s.lock.Lock()
+			s.acks++
+			fail := s.failFirst && s.acks == 1
+			s.lock.Unlock()
+			ack := `{"stream":"EVENTS","seq":1}`
+			if fail {
+				ack = `{"error":{"code":503,"description":"no stream leader"}}`
+			} else {
+				s.add(rec)
+			}
+			fmt.Fprintf(conn, "MSG %s %s %d\r\n%s\r\n", fields[2], sid, len(ack), ack)
+		}
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	for _, jetstream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("jetstream=%v", jetstream), func(t *testing.T) {
+			srv := newNATSStandIn(t, jetstream)
+			srv.failFirst = jetstream
+
+			sink, err := NewNATSSink(NATSSettings{
+				URLs:      []string{"nats://" + srv.ln.Addr().String()},
+				Subject:   "gosight.events",
+				JetStream: jetstream,
+				Username:  "nats-user",
+				Password:  "nats-pass",
+			})
+			require.NoError(t, err)
+			p, err := NewPublisher(sink, fastSettings("nats"))
+			require.NoError(t, err)
+			s := New(p)
+			for i := 0; i < 5; i++ {
+				s.OnEvent(context.Background(), testEvent(fmt.Sprintf("e%d", i)))
+			}
+			runPublisher(t, p)
+
+			require.Eventually(t, func() bool { return p.Pending() == 0 }, 2*time.Second, 10*time.Millisecond)
+			// A failed batch is published again whole, so order and
+			// uniqueness are only guaranteed without retries.
+			assert.ElementsMatch(t, []string{"e0", "e1", "e2", "e3", "e4"}, srv.eventIDs(t))
+		})
+	}
+}
+
+// kafkaStandIn is a single Kafka broker leading every partition of a topic.
+// It answers Metadata v4 and Produce v3 requests.
+type kafkaStandIn struct {
+	received
+	ln         net.Listener
+	topic      string
+	partitions int32
+	failFirst  bool // fail the first produce with NOT_LEADER_OR_FOLLOWER
+	maxMessage int  // answer MESSAGE_TOO_LARGE for a partition holding a larger record
+	produces   int
+	keys       map[string]int32 // record key -> partition it was produced to
+}
+
+func newKafkaStandIn(t *testing.T, topic string, partitions int32) *kafkaStandIn {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	k := &kafkaStandIn{ln: ln, topic: topic, partitions: partitions, keys: make(map[string]int32)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go k.serve(t, conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return k
+}
+
+func (k *kafkaStandIn) serve(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		buf := make([]byte, int(size[0])<<24|int(size[1])<<16|int(size[2])<<8|int(size[3]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		req := &kafkaDecoder{b: buf}
+		key, version, corrID := req.int16(), req.int16(), req.int32()
+		assert.Equal(t, "gosight-server", req.string())
+
+		var resp kafkaEncoder
+		resp.int32(0)
+		resp.int32(corrID)
+		switch key {
+		case kafkaMetadataKey:
+			assert.EqualValues(t, kafkaMetadataVersion, version)
+			k.metadata(&resp)
+		case kafkaProduceKey:
+			assert.EqualValues(t, kafkaProduceVersion, version)
+			k.produce(t, req, &resp)
+		default:
+			t.Errorf("unexpected api key %d", key)
+			return
+		}
+		n := len(resp.b) - 4
+		resp.b[0], resp.b[1], resp.b[2], resp.b[3] = byte(n>>24), byte(n>>16), byte(n>>8), byte(n)
+		if _, err := conn.Write(resp.b); err != nil {
+			return
+		}
+	}
+}
+
+func (k *kafkaStandIn) metadata(resp *kafkaEncoder) {
+	host, port, _ := net.SplitHostPort(k.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	resp.int32(0) // throttle
+	resp.int32(1) // brokers
+	resp.int32(7)
+	resp.string(host)
+	resp.int32(int32(p))
+	resp.nullString() // rack
+	resp.nullString() // cluster ID
+	resp.int32(7)     // controller
+	resp.int32(1)     // topics
+	resp.int16(0)
+	resp.string(k.topic)
+	resp.int8(0)
+	resp.int32(k.partitions)
+	for i := int32(0); i < k.partitions; i++ {
+		resp.int16(0)
+		resp.int32(i)
+		resp.int32(7) // leader
+		resp.int32(1) // replicas
+		resp.int32(7)
+		resp.int32(1) // isr
+		resp.int32(7)
+	}
+}
+
+func (k *kafkaStandIn) produce(t *testing.T, req *kafkaDecoder, resp *kafkaEncoder) {
+	req.string() // transactional ID
+	assert.EqualValues(t, -1, req.int16(), "acks=all")
+	req.int32() // timeout
+
+	k.lock.Lock()
+	k.produces++
+	fail := k.failFirst && k.produces == 1
+	k.lock.Unlock()
+
+	var partitions []int32
+	codes := make(map[int32]int16)
+	require.EqualValues(t, 1, req.int32())
+	assert.Equal(t, k.topic, req.string())
+	for n := req.int32(); n > 0; n-- {
+		p := req.int32()
+		partitions = append(partitions, p)
+		msgs, err := decodeRecordBatches(req.bytes())
+		require.NoError(t, err)
+		if fail {
+			codes[p] = 6 // NOT_LEADER_OR_FOLLOWER
+			continue
+		}
+		for _, m := range msgs {
+			if k.maxMessage > 0 && len(m.Value) > k.maxMessage {
+				codes[p] = 10 // MESSAGE_TOO_LARGE
+			}
+		}
+		if codes[p] != 0 {
+			continue
+		}
+		for _, m := range msgs {
+			var rec Record
+			require.NoError(t, json.Unmarshal(m.Value, &rec))
+			assert.Equal(t, rec.Key, string(m.Key))
+			k.add(rec)
+			k.lock.Lock()
+			k.keys[string(m.Key)] = p
+			k.lock.Unlock()
+		}
+	}
+	require.NoError(t, req.err)
+
+	resp.int32(1)
+	resp.string(k.topic)
+	resp.int32(int32(len(partitions)))
+	for _, p := range partitions {
+		resp.int32(p)
+		resp.int16(codes[p])
+		resp.int64(0)
+		resp.int64(-1)
+	}
+	resp.int32(0) // throttle
+}
+
+func TestKafkaSink(t *testing.T) {
+	broker := newKafkaStandIn(t, "gosight.events", 3)
+	broker.failFirst = true
+
+	sink, err := NewKafkaSink(KafkaSettings{Brokers: []string{broker.ln.Addr().String()}, Topic: "gosight.events"})
+	require.NoError(t, err)
+	settings := fastSettings("kafka")
+	settings.Alerts = true
+	p, err := NewPublisher(sink, settings)
+	require.NoError(t, err)
+	s := New(p)
+
+	var want []string
+	for i := 0; i < 6; i++ {
+		e := testEvent(fmt.Sprintf("e%d", i))
+		e.EndpointID = fmt.Sprintf("host-%d", i%3)
+		s.OnEvent(context.Background(), e)
+		want = append(want, e.ID)
+	}
+	s.OnAlertChange(context.Background(), model.AlertInstance{ID: "a1", EndpointID: "host-0"}, alertmodel.TimelineEntry{AlertID: "a1", Action: alertmodel.StateResolved})
+	runPublisher(t, p)
+
+	require.Eventually(t, func() bool { return p.Pending() == 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, want, broker.eventIDs(t))
+	assert.Equal(t, 1, broker.kinds()[KindAlert])
+	broker.lock.Lock()
+	defer broker.lock.Unlock()
+	assert.Len(t, broker.keys, 3)
+	for key, p := range broker.keys {
+		assert.Equal(t, sink.partition(key), p, "records with one key share a partition")
+	}
+}
+
+func TestKafkaSinkDeadLettersOversizedRecords(t *testing.T) {
+	broker := newKafkaStandIn(t, "gosight.events", 1)
+	broker.maxMessage = 1024
+
+	dir := t.TempDir()
+	sink, err := NewKafkaSink(KafkaSettings{Brokers: []string{broker.ln.Addr().String()}, Topic: "gosight.events"})
+	require.NoError(t, err)
+	settings := fastSettings("kafka")
+	settings.SpoolDir = dir
+	p, err := NewPublisher(sink, settings)
+	require.NoError(t, err)
+	s := New(p)
+
+	big := testEvent("big")
+	big.Message = strings.Repeat("x", 2048)
+	s.OnEvent(context.Background(), testEvent("e0"))
+	s.OnEvent(context.Background(), big)
+	s.OnEvent(context.Background(), testEvent("e2"))
+	runPublisher(t, p)
+
+	require.Eventually(t, func() bool { return p.Pending() == 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"e0", "e2"}, broker.eventIDs(t))
+	dead := deadLetters(t, dir, "kafka")
+	require.Len(t, dead, 1)
+	assert.Contains(t, dead[0].Error, "error code 10")
+}
+
+func TestRecordBatchRoundTrip(t *testing.T) {
+	now := time.UnixMilli(time.Now().UnixMilli())
+	msgs := []kafkaMessage{
+		{Key: []byte("host-1"), Value: []byte(`{"a":1}`), Time: now},
+		{Key: nil, Value: []byte(`{"b":2}`), Time: now.Add(1500 * time.Millisecond)},
+	}
+	batch := encodeRecordBatch(msgs)
+	got, err := decodeRecordBatches(batch)
+	require.NoError(t, err)
+	assert.Equal(t, msgs, got)
+
+	batch[len(batch)-1] ^= 0xff
+	_, err = decodeRecordBatches(batch)
+	assert.Error(t, err)
+}
+
+// decodeRecordBatches reads the messages of one or more record batches,
+// checking their CRCs.
+func decodeRecordBatches(b []byte) ([]kafkaMessage, error) {
+	var msgs []kafkaMessage
+	d := &kafkaDecoder{b: b}
+	for len(d.b) > 0 && d.err == nil {
+		d.int64() // base offset
+		length := d.int32()
+		rest := &kafkaDecoder{b: d.take(int(length))}
+		rest.int32() // partition leader epoch
+		if magic := rest.int8(); magic != 2 {
+			return nil, errors.New("kafka: unsupported record batch version")
+		}
+		crc := uint32(rest.int32())
+		if rest.err == nil && crc32.Checksum(rest.b, castagnoli) != crc {
+			return nil, errors.New("kafka: record batch crc mismatch")
+		}
+		rest.int16() // attributes
+		rest.int32() // last offset delta
+		first := rest.int64()
+		rest.int64() // max timestamp
+		rest.int64() // producer ID
+		rest.int16() // producer epoch
+		rest.int32() // base sequence
+		n := rest.int32()
+		for i := int32(0); i < n && rest.err == nil; i++ {
+			r := &kafkaDecoder{b: rest.take(int(rest.varint()))}
+			r.int8()
+			delta := r.varint()
+			r.varint() // offset delta
+			m := kafkaMessage{Key: r.varBytes(), Value: r.varBytes(), Time: time.UnixMilli(first + delta)}
+			if r.err != nil {
+				return nil, r.err
+			}
+			msgs = append(msgs, m)
+		}
+		if rest.err != nil {
+			return nil, rest.err
+		}
+	}
+	return msgs, d.err
+}
+
+func (d *kafkaDecoder) varBytes() []byte {
+	n := d.varint()
+	if n < 0 {
+		return nil
+	}
+	return d.take(int(n))
+}
@@ -0,0 +1,258 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package sinks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// compactAfter is how many delivered records a spool holds before it is
+// rewritten with only the undelivered ones.
+const compactAfter = 10000
+
+// spool keeps a publisher's queue on disk. Records are appended to a JSON
+// Lines file and the number of them already delivered is kept in a sidecar
+// .offset file. The publisher holds only the oldest undelivered records in
+// memory; the rest stay on disk until load reads them. The file is truncated
+// whenever the queue drains and rewritten once enough delivered records pile
+// up at its head.
+type spool struct {
+	path    string
+	f       *os.File
+	w       *bufio.Writer
+	total   int   // records in the file
+	acked   int   // records at the head of the file already delivered
+	readOff int64 // end of the last record held in memory; later ones are only on disk
+}
+
+// openSpool opens or creates the spool at path and returns up to limit of
+// the records not yet delivered, along with how many more are left on disk.
+// A partly written last line, left by a crash, is cut off.
+func openSpool(path string, limit int) (*spool, []Record, int, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	acked := 0
+	if data, err := os.ReadFile(path + ".offset"); err == nil {
+		acked, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+	}
+	if acked < 0 {
+		acked = 0
+	}
+
+	var records []Record
+	var good, readOff int64
+	total, backlog := 0, 0
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, nil, 0, err
+		}
+		var rec Record
+		if json.Unmarshal(bytes.TrimSpace(line), &rec) != nil {
+			break
+		}
+		good += int64(len(line))
+		switch {
+		case total < acked:
+			readOff = good
+		case len(records) < limit:
+			records = append(records, rec)
+			readOff = good
+		default:
+			backlog++
+		}
+		total++
+	}
+	if err := f.Truncate(good); err != nil {
+		f.Close()
+		return nil, nil, 0, err
+	}
+	if _, err := f.Seek(good, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, 0, err
+	}
+	if acked > total {
+		acked = total
+	}
+
+	s := &spool{
+		path:    path,
+		f:       f,
+		w:       bufio.NewWriter(f),
+		total:   total,
+		acked:   acked,
+		readOff: readOff,
+	}
+	return s, records, backlog, nil
+}
+
+// append writes a record to the end of the spool. It is on disk after the
+// next sync. held says whether the publisher also keeps it in memory, which
+// it may only do while no older record is left on disk alone.
+func (s *spool) append(rec Record, held bool) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+	s.total++
+	if held {
+		s.readOff += int64(len(data))
+	}
+	return nil
+}
+
+// load reads up to n of the records held only on disk, oldest first. It
+// returns how many lines it consumed, which includes any it could not decode
+// and skipped.
+func (s *spool) load(n int) ([]Record, int, error) {
+	if err := s.w.Flush(); err != nil {
+		return nil, 0, err
+	}
+	var records []Record
+	read := 0
+	r := bufio.NewReader(io.NewSectionReader(s.f, s.readOff, math.MaxInt64-s.readOff))
+	for read < n {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return records, read, err
+		}
+		s.readOff += int64(len(line))
+		read++
+		var rec Record
+		if json.Unmarshal(bytes.TrimSpace(line), &rec) == nil {
+			records = append(records, rec)
+		}
+	}
+	return records, read, nil
+}
+
+func (s *spool) sync() error {
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+// ack marks the next n records delivered. pending holds the records still
+// queued in memory; when the spool is rewritten they are kept along with the
+// records only on disk.
+func (s *spool) ack(n int, pending []Record) error {
+	s.acked += n
+	if s.acked >= s.total || s.acked >= compactAfter {
+		return s.rewrite(pending)
+	}
+	return writeFileAtomic(s.path+".offset", []byte(strconv.Itoa(s.acked)))
+}
+
+// rewrite replaces the spool with the pending records, followed by those
+// only on disk, and resets the offset. The offset is removed before the new
+// file is renamed into place, so a crash in between replays delivered records
+// rather than losing undelivered ones.
+func (s *spool) rewrite(pending []Record) error {
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	var readOff int64
+	for _, rec := range pending {
+		data, err := json.Marshal(rec)
+		if err == nil {
+			data = append(data, '\n')
+			_, err = w.Write(data)
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+		readOff += int64(len(data))
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(s.f, s.readOff, math.MaxInt64-s.readOff)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := os.Remove(s.path + ".offset"); err != nil && !os.IsNotExist(err) {
+		f.Close()
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		f.Close()
+		return err
+	}
+
+	s.f.Close()
+	s.f = f
+	s.w = bufio.NewWriter(f)
+	s.total, s.acked, s.readOff = s.total-s.acked, 0, readOff
+	return nil
+}
+
+func (s *spool) close() error {
+	if err := s.sync(); err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
+
+// writeFileAtomic replaces path with data through a temporary file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,354 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package incidents correlates emitted events into incidents. Events on the
+// same host or target that arrive within a time window are grouped together,
+// an alert's resolve event joins the incident its firing event opened, and
+// container-down events join the incident of their agent going offline.
+package incidents
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/incidentstore"
+	"github.com/aaronlmathis/gosight-server/internal/tracker"
+	"github.com/aaronlmathis/gosight-server/internal/websocket"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/google/uuid"
+)
+
+// DefaultWindow is how close together events must be to join the same
+// incident, and how long an incident with nothing outstanding stays open.
+const DefaultWindow = 5 * time.Minute
+
+// maxTimeline caps the events kept on an incident's timeline; the oldest
+// are dropped first. EventCount still counts every event.
+const maxTimeline = 500
+
+// Correlator is an events.Listener that groups events into incidents.
+// An incident stays open while any alert it contains is firing or any of its
+// agents or containers is down, and resolves once they have all recovered, or
+// after a window without events when nothing was outstanding.
+type Correlator struct {
+	lock   sync.Mutex
+	store  incidentstore.IncidentStore
+	hub    *websocket.EventsHub
+	window time.Duration
+	open   map[string]*alertmodel.Incident // key: incident ID
+}
+
+// NewCorrelator creates a Correlator that saves incidents to store and
+// announces new ones on hub. Open incidents are loaded from the store so
+// events after a restart keep joining them.
+func NewCorrelator(ctx context.Context, store incidentstore.IncidentStore, hub *websocket.EventsHub, window time.Duration) (*Correlator, error) {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	c := &Correlator{
+		store:  store,
+		hub:    hub,
+		window: window,
+		open:   make(map[string]*alertmodel.Incident),
+	}
+
+	open, err := store.ListIncidents(ctx, alertmodel.IncidentFilter{Status: alertmodel.IncidentOpen})
+	if err != nil {
+		return nil, err
+	}
+	for i := range open {
+		c.open[open[i].ID] = &open[i]
+	}
+	return c, nil
+}
+
+// OnEvent adds the event to the incident it correlates with, opening a new
+// incident for warning and worse events, firing alerts and down agents or
+// containers that match none.
+func (c *Correlator) OnEvent(ctx context.Context, e model.EventEntry) {
+	host := hostKey(e)
+	if host == "" {
+		return
+	}
+	alertID, alertState := e.Meta["alert_id"], e.Meta["alert_state"]
+	target, down, lifecycle := lifecycleState(e)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	inc := c.find(host, alertID, target, e.Timestamp)
+	created := false
+	if inc == nil {
+		if !opens(e, alertState, down) {
+			return
+		}
+		inc = &alertmodel.Incident{
+			ID:        uuid.NewString(),
+			Title:     e.Message,
+			Status:    alertmodel.IncidentOpen,
+			Level:     e.Level,
+			HostID:    host,
+			StartedAt: e.Timestamp,
+		}
+		c.open[inc.ID] = inc
+		created = true
+	}
+
+	recovered := false
+	if alertID != "" {
+		inc.AlertIDs = appendUnique(inc.AlertIDs, alertID)
+		switch alertState {
+		case alertmodel.StateFiring:
+			inc.Active = appendUnique(inc.Active, "alert:"+alertID)
+		case alertmodel.StateResolved:
+			inc.Active, recovered = remove(inc.Active, "alert:"+alertID)
+		}
+	}
+	if lifecycle {
+		if down {
+			inc.Active = appendUnique(inc.Active, "down:"+target)
+		} else {
+			inc.Active, recovered = remove(inc.Active, "down:"+target)
+		}
+	}
+	addEvent(inc, e)
+
+	if recovered && len(inc.Active) == 0 {
+		c.resolve(inc, e.Timestamp, "")
+	}
+	if err := c.store.UpsertIncident(ctx, *inc); err != nil {
+		utils.Warn("Failed to save incident %s: %v", inc.ID, err)
+	}
+	if created && c.hub != nil {
+		utils.Info("Opened incident %s: %s", inc.ID, inc.Title)
+		c.hub.AnnounceIncident(*inc)
+	}
+}
+
+// find returns the open incident the event belongs to: the one holding its
+// alert, the one its target went down in, or one on the same host that was
+// updated within the window.
+func (c *Correlator) find(host, alertID, target string, ts time.Time) *alertmodel.Incident {
+	var recent *alertmodel.Incident
+	for _, inc := range c.open {
+		if alertID != "" && containsString(inc.AlertIDs, alertID) {
+			return inc
+		}
+		if target != "" && containsString(inc.Active, "down:"+target) {
+			return inc
+		}
+		if inc.HostID == host && ts.Sub(inc.UpdatedAt) <= c.window {
+			if recent == nil || inc.UpdatedAt.After(recent.UpdatedAt) {
+				recent = inc
+			}
+		}
+	}
+	return recent
+}
+
+// resolve closes the incident and forgets it. The caller must hold the lock.
+func (c *Correlator) resolve(inc *alertmodel.Incident, at time.Time, actor string) {
+	inc.Status = alertmodel.IncidentResolved
+	inc.ResolvedAt = at
+	inc.ResolvedBy = actor
+	delete(c.open, inc.ID)
+}
+
+// Resolve closes an incident by hand, whether or not anything in it is still
+// outstanding. Later events on the same host open a new incident.
+func (c *Correlator) Resolve(ctx context.Context, id, actor string) (alertmodel.Incident, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	inc, ok := c.open[id]
+	if !ok {
+		stored, err := c.store.GetIncident(ctx, id)
+		if err != nil {
+			return alertmodel.Incident{}, err
+		}
+		if stored.Status == alertmodel.IncidentResolved {
+			return stored, nil
+		}
+		inc = &stored
+	}
+	c.resolve(inc, time.Now().UTC(), actor)
+	return *inc, c.store.UpsertIncident(ctx, *inc)
+}
+
+// Run resolves open incidents that have nothing outstanding once they have
+// had no events for the window. It blocks until ctx is canceled.
+func (c *Correlator) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			c.sweep(ctx, now)
+		}
+	}
+}
+
+// sweep resolves the quiet incidents.
+func (c *Correlator) sweep(ctx context.Context, now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, inc := range c.open {
+		if len(inc.Active) > 0 || now.Sub(inc.UpdatedAt) <= c.window {
+			continue
+		}
+		c.resolve(inc, inc.UpdatedAt, "")
+		if err := c.store.UpsertIncident(ctx, *inc); err != nil {
+			utils.Warn("Failed to save incident %s: %v", inc.ID, err)
+		}
+	}
+}
+
+// addEvent appends the event to the incident's timeline and widens its
+// level, endpoints and targets.
+func addEvent(inc *alertmodel.Incident, e model.EventEntry) {
+	inc.Timeline = append(inc.Timeline, alertmodel.IncidentEvent{
+		EventID:    e.ID,
+		Timestamp:  e.Timestamp,
+		Level:      e.Level,
+		Category:   e.Category,
+		Source:     e.Source,
+		Scope:      e.Scope,
+		Target:     e.Target,
+		EndpointID: endpointID(e),
+		Message:    e.Message,
+	})
+	if len(inc.Timeline) > maxTimeline {
+		inc.Timeline = inc.Timeline[len(inc.Timeline)-maxTimeline:]
+	}
+	inc.EventCount++
+
+	if severity(e.Level) > severity(inc.Level) {
+		inc.Level = e.Level
+	}
+	if eid := endpointID(e); eid != "" {
+		inc.EndpointIDs = appendUnique(inc.EndpointIDs, eid)
+	}
+	if e.Target != "" {
+		inc.Targets = appendUnique(inc.Targets, e.Target)
+	}
+	if e.Timestamp.After(inc.UpdatedAt) {
+		inc.UpdatedAt = e.Timestamp
+	}
+}
+
+// opens reports whether an event that matches no open incident starts one.
+func opens(e model.EventEntry, alertState string, down bool) bool {
+	if alertState == alertmodel.StateResolved {
+		return false
+	}
+	return down || alertState == alertmodel.StateFiring || severity(e.Level) >= severity("warning")
+}
+
+// hostKey is what events are correlated on: the host an agent or container
+// runs on, so an agent and its containers share incidents, falling back to
+// the endpoint or target.
+func hostKey(e model.EventEntry) string {
+	if hid := e.Meta["host_id"]; hid != "" {
+		return hid
+	}
+	if eid := endpointID(e); eid != "" {
+		return eid
+	}
+	return e.Target
+}
+
+func endpointID(e model.EventEntry) string {
+	if e.EndpointID != "" {
+		return e.EndpointID
+	}
+	return e.Meta["endpoint_id"]
+}
+
+// lifecycleState reads an agent or container lifecycle event from the
+// endpoint tracker by the status it records in the event's meta. It returns
+// the agent or container, whether it went down or came back, and false for
+// events that are neither.
+func lifecycleState(e model.EventEntry) (target string, down bool, ok bool) {
+	if e.Source != "agent.lifecycle" && e.Source != "container.lifecycle" {
+		return "", false, false
+	}
+	switch status := e.Meta["status"]; {
+	case tracker.IsDownStatus(status):
+		return e.Target, true, true
+	case tracker.IsUpStatus(status):
+		return e.Target, false, true
+	}
+	return "", false, false
+}
+
+// severity ranks event levels; unknown levels rank with info.
+func severity(level string) int {
+	switch strings.ToLower(level) {
+	case "critical":
+		return 4
+	case "error":
+		return 3
+	case "warning", "warn":
+		return 2
+	case "debug":
+		return 0
+	}
+	return 1
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(list []string, s string) []string {
+	if containsString(list, s) {
+		return list
+	}
+	return append(list, s)
+}
+
+// remove drops s from list and reports whether it was there. It returns a
+// new slice because saved copies of the incident share the old one.
+func remove(list []string, s string) ([]string, bool) {
+	if !containsString(list, s) {
+		return list, false
+	}
+	out := make([]string, 0, len(list)-1)
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out, true
+}
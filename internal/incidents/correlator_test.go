@@ -0,0 +1,182 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package incidents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/incidentstore"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestCorrelator(t *testing.T) (*Correlator, *incidentstore.JSONIncidentStore) {
+	store, err := incidentstore.NewJSONIncidentStore("")
+	require.NoError(t, err)
+	c, err := NewCorrelator(context.Background(), store, nil, 5*time.Minute)
+	require.NoError(t, err)
+	return c, store
+}
+
+func hostEvent(id, level string, at time.Time) model.EventEntry {
+	return model.EventEntry{
+		ID:         id,
+		Timestamp:  at,
+		Level:      level,
+		Category:   "system",
+		Message:    "event " + id,
+		Source:     "test",
+		Scope:      "endpoint",
+		Target:     "host-1",
+		EndpointID: "host-1",
+		Meta:       map[string]string{"host_id": "h1"},
+	}
+}
+
+func alertEvent(id, alertID, state string, at time.Time) model.EventEntry {
+	e := hostEvent(id, "warning", at)
+	e.Category = "alert"
+	e.Meta["alert_id"] = alertID
+	e.Meta["alert_state"] = state
+	return e
+}
+
+func lifecycleEvent(id, source, target, status string, at time.Time) model.EventEntry {
+	level := "info"
+	if status == "Offline" {
+		level = "warning"
+	}
+	return model.EventEntry{
+		ID:        id,
+		Timestamp: at,
+		Level:     level,
+		Category:  "system",
+		// Worded unlike the tracker's messages: only the meta status counts.
+		Message:    target + " heartbeat update",
+		Source:     source,
+		Scope:      "endpoint",
+		Target:     target,
+		EndpointID: "host-1",
+		Meta:       map[string]string{"host_id": "h1", "status": status},
+	}
+}
+
+func listIncidents(t *testing.T, store *incidentstore.JSONIncidentStore) []alertmodel.Incident {
+	list, err := store.ListIncidents(context.Background(), alertmodel.IncidentFilter{})
+	require.NoError(t, err)
+	return list
+}
+
+func TestCorrelatorGroupsWithinWindow(t *testing.T) {
+	c, store := newTestCorrelator(t)
+	ctx := context.Background()
+
+	c.OnEvent(ctx, hostEvent("e1", "warning", t0))
+	c.OnEvent(ctx, hostEvent("e2", "info", t0.Add(2*time.Minute)))
+	c.OnEvent(ctx, hostEvent("e3", "critical", t0.Add(4*time.Minute)))
+
+	list := listIncidents(t, store)
+	require.Len(t, list, 1)
+	assert.Equal(t, 3, list[0].EventCount)
+	assert.Equal(t, "critical", list[0].Level)
+
+	// Past the window the host's next warning opens a new incident.
+	c.OnEvent(ctx, hostEvent("e4", "warning", t0.Add(10*time.Minute)))
+	assert.Len(t, listIncidents(t, store), 2)
+
+	// An info event alone opens nothing.
+	other := hostEvent("e5", "info", t0)
+	other.Meta["host_id"] = "h2"
+	c.OnEvent(ctx, other)
+	assert.Len(t, listIncidents(t, store), 2)
+}
+
+func TestCorrelatorQuietIncidentResolvesAfterWindow(t *testing.T) {
+	c, store := newTestCorrelator(t)
+	ctx := context.Background()
+
+	c.OnEvent(ctx, hostEvent("e1", "warning", t0))
+	c.sweep(ctx, t0.Add(3*time.Minute))
+	assert.Equal(t, alertmodel.IncidentOpen, listIncidents(t, store)[0].Status)
+
+	c.sweep(ctx, t0.Add(6*time.Minute))
+	assert.Equal(t, alertmodel.IncidentResolved, listIncidents(t, store)[0].Status)
+}
+
+func TestCorrelatorFiringThenResolveCloses(t *testing.T) {
+	c, store := newTestCorrelator(t)
+	ctx := context.Background()
+
+	c.OnEvent(ctx, alertEvent("e1", "a1", alertmodel.StateFiring, t0))
+	c.OnEvent(ctx, alertEvent("e2", "a2", alertmodel.StateFiring, t0.Add(time.Minute)))
+
+	// The resolve arrives long after the window but still joins by alert ID.
+	c.OnEvent(ctx, alertEvent("e3", "a1", alertmodel.StateResolved, t0.Add(30*time.Minute)))
+	list := listIncidents(t, store)
+	require.Len(t, list, 1)
+	assert.Equal(t, alertmodel.IncidentOpen, list[0].Status)
+	assert.Equal(t, []string{"alert:a2"}, list[0].Active)
+
+	c.OnEvent(ctx, alertEvent("e4", "a2", alertmodel.StateResolved, t0.Add(31*time.Minute)))
+	list = listIncidents(t, store)
+	require.Len(t, list, 1)
+	assert.Equal(t, alertmodel.IncidentResolved, list[0].Status)
+	assert.ElementsMatch(t, []string{"a1", "a2"}, list[0].AlertIDs)
+
+	// A resolve for an alert no incident holds doesn't open one.
+	c.OnEvent(ctx, alertEvent("e5", "a3", alertmodel.StateResolved, t0.Add(2*time.Hour)))
+	assert.Len(t, listIncidents(t, store), 1)
+}
+
+func TestCorrelatorContainerDownJoinsAgentOffline(t *testing.T) {
+	c, store := newTestCorrelator(t)
+	ctx := context.Background()
+
+	c.OnEvent(ctx, lifecycleEvent("e1", "agent.lifecycle", "agent-1", "Offline", t0))
+	c.OnEvent(ctx, lifecycleEvent("e2", "container.lifecycle", "ctr-1", "Offline", t0.Add(time.Minute)))
+
+	list := listIncidents(t, store)
+	require.Len(t, list, 1)
+	assert.ElementsMatch(t, []string{"down:agent-1", "down:ctr-1"}, list[0].Active)
+	assert.ElementsMatch(t, []string{"agent-1", "ctr-1"}, list[0].Targets)
+
+	// Idle is neither down nor up.
+	c.OnEvent(ctx, lifecycleEvent("e3", "agent.lifecycle", "agent-1", "Idle", t0.Add(20*time.Minute)))
+	assert.Len(t, listIncidents(t, store)[0].Active, 2)
+
+	// Recoveries join by target after the window and close the incident once
+	// both are back.
+	c.OnEvent(ctx, lifecycleEvent("e4", "agent.lifecycle", "agent-1", "Online", t0.Add(30*time.Minute)))
+	assert.Equal(t, alertmodel.IncidentOpen, listIncidents(t, store)[0].Status)
+	c.OnEvent(ctx, lifecycleEvent("e5", "container.lifecycle", "ctr-1", "Running", t0.Add(31*time.Minute)))
+
+	list = listIncidents(t, store)
+	require.Len(t, list, 1)
+	assert.Equal(t, alertmodel.IncidentResolved, list[0].Status)
+	assert.Empty(t, list[0].Active)
+}
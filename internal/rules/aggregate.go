@@ -0,0 +1,228 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+)
+
+// defaultWindowEvalInterval is how often windowed rules are evaluated when
+// they don't set an eval_interval of their own.
+const defaultWindowEvalInterval = 30 * time.Second
+
+// absentLookbackFactor controls how far back, in multiples of the window,
+// the scheduler looks for endpoints that used to report a metric when
+// evaluating an absent() rule without explicit endpoint IDs.
+const absentLookbackFactor = 5
+
+// maxWindowPoints caps the number of points requested per series when a
+// windowed rule doesn't set a step.
+const maxWindowPoints = 60
+
+// sample is a single timestamped value of a series inside a rule window.
+type sample struct {
+	ts    time.Time
+	value float64
+}
+
+// isWindowed reports whether the rule reduces a window of samples rather
+// than checking the latest value.
+func isWindowed(rule alertmodel.AlertRule) bool {
+	return rule.Type == "metric" && alertmodel.ParseDuration(rule.Scope.Window) > 0
+}
+
+// ruleEvalInterval returns how often the Scheduler should evaluate the rule,
+// or 0 when the rule is evaluated inline as payloads arrive.
+func ruleEvalInterval(rule alertmodel.AlertRule) time.Duration {
+	if interval := alertmodel.ParseDuration(rule.Options.EvalInterval); interval > 0 {
+		return interval
+	}
+	if isWindowed(rule) {
+		return defaultWindowEvalInterval
+	}
+	return 0
+}
+
+// windowStep returns the query resolution for a windowed rule. Without an
+// explicit step the window is split into at most maxWindowPoints points,
+// never finer than one second.
+func windowStep(rule alertmodel.AlertRule, window time.Duration) time.Duration {
+	if step := alertmodel.ParseDuration(rule.Scope.Step); step > 0 {
+		return step
+	}
+	step := (window / maxWindowPoints).Truncate(time.Second)
+	if step < time.Second {
+		step = time.Second
+	}
+	return step
+}
+
+// aggregateWindow reduces the samples of one endpoint to a single value
+// using the rule's function. Samples are grouped per series so counter
+// functions (rate, increase) never mix resets from different series; their
+// per-series results are summed. It returns false when there is nothing to
+// aggregate.
+func aggregateWindow(fn string, series map[string][]sample, window time.Duration) (float64, bool) {
+	fn = strings.ToLower(fn)
+
+	switch fn {
+	case "rate", "increase":
+		total := 0.0
+		found := false
+		for _, samples := range series {
+			if len(samples) < 2 {
+				continue
+			}
+			inc := counterIncrease(samples)
+			if fn == "rate" {
+				inc /= window.Seconds()
+			}
+			total += inc
+			found = true
+		}
+		return total, found
+	}
+
+	var all []sample
+	for _, samples := range series {
+		all = append(all, samples...)
+	}
+	if len(all) == 0 {
+		return 0, false
+	}
+
+	switch fn {
+	case "", "avg":
+		sum := 0.0
+		for _, s := range all {
+			sum += s.value
+		}
+		return sum / float64(len(all)), true
+	case "min":
+		min := math.Inf(1)
+		for _, s := range all {
+			min = math.Min(min, s.value)
+		}
+		return min, true
+	case "max":
+		max := math.Inf(-1)
+		for _, s := range all {
+			max = math.Max(max, s.value)
+		}
+		return max, true
+	case "sum":
+		sum := 0.0
+		for _, s := range all {
+			sum += s.value
+		}
+		return sum, true
+	case "count":
+		return float64(len(all)), true
+	case "last":
+		last := all[0]
+		for _, s := range all[1:] {
+			if s.ts.After(last.ts) {
+				last = s
+			}
+		}
+		return last.value, true
+	}
+
+	p, err := alertmodel.Percentile(fn)
+	if err != nil {
+		return 0, false
+	}
+	values := make([]float64, len(all))
+	for i, s := range all {
+		values[i] = s.value
+	}
+	return percentile(values, p), true
+}
+
+// counterIncrease returns how much a counter grew across the samples,
+// treating any decrease as a reset to zero.
+func counterIncrease(samples []sample) float64 {
+	sort.Slice(samples, func(i, j int) bool { return samples[i].ts.Before(samples[j].ts) })
+	inc := 0.0
+	for i := 1; i < len(samples); i++ {
+		delta := samples[i].value - samples[i-1].value
+		if delta < 0 {
+			delta = samples[i].value
+		}
+		inc += delta
+	}
+	return inc
+}
+
+// percentile returns the p-th percentile of values using linear
+// interpolation between the closest ranks.
+func percentile(values []float64, p float64) float64 {
+	sort.Float64s(values)
+	if len(values) == 1 {
+		return values[0]
+	}
+	rank := p / 100 * float64(len(values)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	frac := rank - float64(lo)
+	return values[lo] + (values[hi]-values[lo])*frac
+}
+
+// seriesKey identifies a single series by its sorted label set.
+func seriesKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// windowMetric wraps an aggregated value in a single-point gauge metric so
+// it can go through the same expression check and state transitions as a
+// pushed sample.
+func windowMetric(rule alertmodel.AlertRule, value float64, ts time.Time, labels map[string]string) model.Metric {
+	return model.Metric{
+		Namespace:    rule.Scope.Namespace,
+		SubNamespace: rule.Scope.SubNamespace,
+		Name:         rule.Scope.Metric,
+		DataType:     "gauge",
+		DataPoints: []model.DataPoint{{
+			Value:      value,
+			Timestamp:  ts,
+			Attributes: labels,
+		}},
+	}
+}
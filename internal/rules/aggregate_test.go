@@ -0,0 +1,175 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var windowStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// samples returns one sample per value, a minute apart.
+func samples(values ...float64) []sample {
+	list := make([]sample, len(values))
+	for i, v := range values {
+		list[i] = sample{ts: windowStart.Add(time.Duration(i) * time.Minute), value: v}
+	}
+	return list
+}
+
+func TestAggregateWindow(t *testing.T) {
+	one := map[string][]sample{"a": samples(4, 1, 7, 2)}
+	two := map[string][]sample{"a": samples(1, 2), "b": samples(3, 10)}
+	counter := map[string][]sample{"a": samples(10, 20, 5, 15)}
+
+	tests := []struct {
+		fn     string
+		series map[string][]sample
+		want   float64
+		ok     bool
+	}{
+		{"", one, 3.5, true},
+		{"avg", one, 3.5, true},
+		{"MIN", one, 1, true},
+		{"max", one, 7, true},
+		{"sum", one, 14, true},
+		{"count", one, 4, true},
+		{"last", one, 2, true},
+		{"avg", two, 4, true},
+		{"max", two, 10, true},
+		{"p50", one, 3, true},
+		{"p100", one, 7, true},
+		{"increase", counter, 25, true},
+		{"rate", counter, 25.0 / 300, true},
+		{"increase", two, 8, true},
+		{"increase", map[string][]sample{"a": samples(5)}, 0, false},
+		{"avg", map[string][]sample{}, 0, false},
+		{"median", one, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fn, func(t *testing.T) {
+			got, ok := aggregateWindow(tt.fn, tt.series, 5*time.Minute)
+			assert.Equal(t, tt.ok, ok)
+			assert.InDelta(t, tt.want, got, 1e-9)
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		values []float64
+		p      float64
+		want   float64
+	}{
+		{[]float64{42}, 99, 42},
+		{[]float64{1, 2, 3, 4, 5}, 50, 3},
+		{[]float64{5, 1, 4, 2, 3}, 0, 1},
+		{[]float64{1, 2, 3, 4, 5}, 100, 5},
+		{[]float64{1, 2, 3, 4, 5}, 90, 4.6},
+		{[]float64{10, 20}, 25, 12.5},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, percentile(tt.values, tt.p), 1e-9, "p%v of %v", tt.p, tt.values)
+	}
+}
+
+func TestCounterIncrease(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []sample
+		want    float64
+	}{
+		{"steady", samples(0, 10, 20, 30), 30},
+		{"flat", samples(7, 7, 7), 0},
+		{"reset", samples(100, 120, 5, 25), 45},
+		{"reset to zero", samples(50, 0, 10), 10},
+		{"unsorted", []sample{
+			{ts: windowStart.Add(2 * time.Minute), value: 20},
+			{ts: windowStart, value: 0},
+			{ts: windowStart.Add(time.Minute), value: 10},
+		}, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, counterIncrease(tt.samples), 1e-9)
+		})
+	}
+}
+
+func TestAbsentRule(t *testing.T) {
+	ctx := context.Background()
+	rule := cpuRule
+	rule.ID = "cpu-absent"
+	rule.Scope.Window = "5m"
+	rule.Expression = alertmodel.Expression{Function: "absent"}
+
+	tests := []struct {
+		name     string
+		explicit []string
+		rows     func(now time.Time) []time.Time // sample times of host-1
+		firing   []string
+	}{
+		{
+			name:   "reporting",
+			rows:   func(now time.Time) []time.Time { return []time.Time{now.Add(-time.Minute)} },
+			firing: nil,
+		},
+		{
+			name:   "stopped within the lookback",
+			rows:   func(now time.Time) []time.Time { return []time.Time{now.Add(-10 * time.Minute)} },
+			firing: []string{"host-1"},
+		},
+		{
+			name:   "beyond the lookback",
+			rows:   func(now time.Time) []time.Time { return []time.Time{now.Add(-time.Hour)} },
+			firing: nil,
+		},
+		{
+			name:     "explicit endpoint never reported",
+			explicit: []string{"host-1", "host-2"},
+			rows:     func(now time.Time) []time.Time { return []time.Time{now.Add(-time.Minute)} },
+			firing:   []string{"host-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := rule
+			r.Match.EndpointIDs = tt.explicit
+			s, metrics := newTestScheduler(t, r)
+			now := time.Now().UTC()
+			var rows []model.MetricRow
+			for _, at := range tt.rows(now) {
+				rows = append(rows, cpuRow("host-1", 50, at))
+			}
+			metrics.set(rows...)
+
+			s.evaluateWindow(ctx, r, now)
+			assert.ElementsMatch(t, tt.firing, firingEndpoints(s.evaluator, r.ID))
+		})
+	}
+}
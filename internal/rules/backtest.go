@@ -0,0 +1,370 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/logstore"
+	"github.com/aaronlmathis/gosight-server/internal/store/metricstore"
+	"github.com/aaronlmathis/gosight-shared/model"
+)
+
+// maxBacktestSteps bounds how many evaluations a single backtest may run
+// per endpoint, so a tiny step over a long range can't stall the server.
+const maxBacktestSteps = 10000
+
+// backtestLogLimit is the most log entries a backtest replays.
+const backtestLogLimit = 10000
+
+// defaultBacktestStep is the query resolution for metric rules and the
+// evaluation tick for log rate rules when the request doesn't set one.
+const defaultBacktestStep = time.Minute
+
+// ErrBacktestUnsupported is returned for rule types that can't be replayed
+// from stored data.
+var ErrBacktestUnsupported = errors.New("backtesting is supported for metric and log rules only")
+
+// ErrInvalidBacktest is wrapped by errors caused by the request itself,
+// such as an empty range or too small a step.
+var ErrInvalidBacktest = errors.New("invalid backtest")
+
+// BacktestInterval is one period during which the rule would have fired.
+// ResolvedAt is nil when the rule was still firing at the end of the range.
+type BacktestInterval struct {
+	FiredAt    time.Time  `json:"fired_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	Value      float64    `json:"value"`
+}
+
+// BacktestResult lists, per endpoint, when a rule would have fired and
+// resolved over the tested range.
+type BacktestResult struct {
+	RuleID      string                        `json:"rule_id"`
+	Start       time.Time                     `json:"start"`
+	End         time.Time                     `json:"end"`
+	Step        string                        `json:"step"`
+	Evaluations int                           `json:"evaluations"`
+	Endpoints   map[string][]BacktestInterval `json:"endpoints"`
+	Truncated   bool                          `json:"truncated,omitempty"`
+}
+
+// Backtester replays historical metrics or logs through the evaluator's
+// state machine to show how a rule would have behaved. It never touches the
+// alert store, the alert manager or the dispatcher.
+type Backtester struct {
+	metrics metricstore.MetricStore
+	logs    logstore.LogStore
+}
+
+// NewBacktester creates a Backtester reading from the given stores.
+func NewBacktester(metrics metricstore.MetricStore, logs logstore.LogStore) *Backtester {
+	return &Backtester{metrics: metrics, logs: logs}
+}
+
+// backtestRun holds the state of a single replay: a private evaluator that
+// only its transitions are used from, and the intervals recorded so far.
+type backtestRun struct {
+	rule      alertmodel.AlertRule
+	evaluator *Evaluator
+	result    *BacktestResult
+	open      map[string]int // endpointID -> index of the open interval
+}
+
+// Run replays the rule between start and end. A zero step picks a default
+// suited to the rule type.
+func (b *Backtester) Run(rule alertmodel.AlertRule, start, end time.Time, step time.Duration) (*BacktestResult, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("%w: end must be after start", ErrInvalidBacktest)
+	}
+
+	run := &backtestRun{
+		rule: rule,
+		evaluator: &Evaluator{
+			history:   make(map[string][]model.Metric),
+			firing:    make(map[string]bool),
+			resolved:  make(map[string]time.Time),
+			hits:      make(map[string]map[string]conditionHit),
+			lastFired: make(map[string]time.Time),
+			rates:     make(map[string]*logRate),
+			metas:     make(map[string]*model.Meta),
+		},
+		result: &BacktestResult{
+			RuleID:    rule.ID,
+			Start:     start,
+			End:       end,
+			Endpoints: make(map[string][]BacktestInterval),
+		},
+		open: make(map[string]int),
+	}
+
+	var err error
+	switch {
+	case isWindowed(rule):
+		err = b.replayWindow(run, start, end, step)
+	case rule.Type == "metric":
+		err = b.replayMetric(run, start, end, step)
+	case rule.Type == "log":
+		err = b.replayLogs(run, start, end, step)
+	default:
+		err = ErrBacktestUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	return run.result, nil
+}
+
+// observe feeds one evaluation result through the evaluator's transitions
+// and records the firing and resolve points.
+func (r *backtestRun) observe(endpointID string, metric model.Metric, breached bool) {
+	r.result.Evaluations++
+	state, changed := r.evaluator.transition(r.rule.ID+"|"+endpointID, r.rule, metric, breached)
+	if !changed {
+		return
+	}
+	at := sampleTime(&metric)
+	switch state {
+	case alertmodel.StateFiring:
+		r.result.Endpoints[endpointID] = append(r.result.Endpoints[endpointID], BacktestInterval{
+			FiredAt: at,
+			Value:   getMetricValue(&metric),
+		})
+		r.open[endpointID] = len(r.result.Endpoints[endpointID]) - 1
+	case alertmodel.StateOK:
+		if idx, ok := r.open[endpointID]; ok {
+			r.result.Endpoints[endpointID][idx].ResolvedAt = &at
+			delete(r.open, endpointID)
+		}
+	}
+}
+
+// checkSteps rejects ranges that would need more than maxBacktestSteps
+// evaluations at the given step.
+func checkSteps(start, end time.Time, step time.Duration) error {
+	if step <= 0 {
+		return fmt.Errorf("%w: step must be positive", ErrInvalidBacktest)
+	}
+	if end.Sub(start)/step > maxBacktestSteps {
+		return fmt.Errorf("%w: range too large for step %s (max %d steps)", ErrInvalidBacktest, step, maxBacktestSteps)
+	}
+	return nil
+}
+
+// replayMetric replays a plain metric rule sample by sample.
+func (b *Backtester) replayMetric(run *backtestRun, start, end time.Time, step time.Duration) error {
+	if step == 0 {
+		step = defaultBacktestStep
+	}
+	if err := checkSteps(start, end, step); err != nil {
+		return err
+	}
+	run.result.Step = step.String()
+
+	rows, err := b.metrics.QueryMultiRange([]string{ruleMetricName(run.rule)}, start, end, step.String(), ruleFilters(run.rule))
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Timestamp < rows[j].Timestamp })
+
+	for _, row := range rows {
+		meta := metaFromLabels(row.Labels)
+		if !ruleMatchLabels(run.rule.Match, meta) {
+			continue
+		}
+		metric := metricFromRow(run.rule, row)
+		run.observe(meta.EndpointID, metric, evaluateExpression(run.rule.Expression, &metric))
+	}
+	return nil
+}
+
+// replayWindow replays a windowed metric rule at its evaluation interval,
+// reducing the samples of each window the same way the Scheduler does.
+func (b *Backtester) replayWindow(run *backtestRun, start, end time.Time, step time.Duration) error {
+	fn := strings.ToLower(run.rule.Expression.Function)
+	if !alertmodel.ValidFunction(fn) {
+		return fmt.Errorf("%w: unknown function %q", ErrInvalidBacktest, run.rule.Expression.Function)
+	}
+	interval := step
+	if interval == 0 {
+		interval = ruleEvalInterval(run.rule)
+	}
+	if err := checkSteps(start, end, interval); err != nil {
+		return err
+	}
+	run.result.Step = interval.String()
+
+	window := alertmodel.ParseDuration(run.rule.Scope.Window)
+	resolution := windowStep(run.rule, window)
+	rows, err := b.metrics.QueryMultiRange([]string{ruleMetricName(run.rule)}, start.Add(-window), end, resolution.String(), ruleFilters(run.rule))
+	if err != nil {
+		return err
+	}
+
+	// endpointID -> series -> samples, and the labels each endpoint reported
+	samples := make(map[string]map[string][]sample)
+	labels := make(map[string]map[string]string)
+	for _, row := range rows {
+		meta := metaFromLabels(row.Labels)
+		if !ruleMatchLabels(run.rule.Match, meta) {
+			continue
+		}
+		if samples[meta.EndpointID] == nil {
+			samples[meta.EndpointID] = make(map[string][]sample)
+			labels[meta.EndpointID] = meta.Labels
+		}
+		key := seriesKey(row.Labels)
+		samples[meta.EndpointID][key] = append(samples[meta.EndpointID][key], sample{
+			ts:    time.UnixMilli(row.Timestamp).UTC(),
+			value: row.Value,
+		})
+	}
+	if fn == "absent" {
+		for _, id := range run.rule.Match.EndpointIDs {
+			if samples[id] == nil {
+				samples[id] = make(map[string][]sample)
+			}
+		}
+	}
+
+	for t := start; !t.After(end); t = t.Add(interval) {
+		from := t.Add(-window)
+		for id, series := range samples {
+			inWindow := make(map[string][]sample, len(series))
+			count := 0
+			for key, all := range series {
+				for _, s := range all {
+					if s.ts.After(from) && !s.ts.After(t) {
+						inWindow[key] = append(inWindow[key], s)
+						count++
+					}
+				}
+			}
+
+			if fn == "absent" {
+				metric := windowMetric(run.rule, float64(count), t, labels[id])
+				run.observe(id, metric, count == 0)
+				continue
+			}
+			value, ok := aggregateWindow(fn, inWindow, window)
+			if !ok {
+				continue
+			}
+			metric := windowMetric(run.rule, value, t, labels[id])
+			run.observe(id, metric, evaluateExpression(run.rule.Expression, &metric))
+		}
+	}
+	return nil
+}
+
+// replayLogs replays a log rule against stored logs. Plain log rules fire
+// once per matching line; rate rules count matches per endpoint and are
+// evaluated every step so they resolve once the rate drops.
+func (b *Backtester) replayLogs(run *backtestRun, start, end time.Time, step time.Duration) error {
+	filter := model.LogFilter{
+		Start:    start,
+		End:      end,
+		Category: run.rule.Match.Category,
+		Source:   run.rule.Match.Source,
+		Limit:    backtestLogLimit,
+		Order:    "asc",
+	}
+	if len(run.rule.Match.EndpointIDs) == 1 {
+		filter.EndpointID = run.rule.Match.EndpointIDs[0]
+	}
+	logs, err := b.logs.GetLogs(filter)
+	if err != nil {
+		return err
+	}
+	run.result.Truncated = len(logs) >= backtestLogLimit
+	sort.SliceStable(logs, func(i, j int) bool { return logs[i].Timestamp.Before(logs[j].Timestamp) })
+
+	matches := func(log model.LogEntry) (*model.Meta, bool) {
+		meta := log.Meta
+		if meta == nil {
+			meta = &model.Meta{}
+		}
+		if !ruleMatchLabels(run.rule.Match, meta) {
+			return nil, false
+		}
+		if run.rule.Match.Category != "" && run.rule.Match.Category != log.Category {
+			return nil, false
+		}
+		if run.rule.Match.Source != "" && run.rule.Match.Source != log.Source {
+			return nil, false
+		}
+		return meta, evaluateLogExpression(run.rule.Expression, log)
+	}
+
+	if run.rule.Threshold == nil {
+		for _, log := range logs {
+			meta, ok := matches(log)
+			if !ok {
+				continue
+			}
+			at := log.Timestamp
+			run.result.Evaluations++
+			run.result.Endpoints[meta.EndpointID] = append(run.result.Endpoints[meta.EndpointID], BacktestInterval{
+				FiredAt:    at,
+				ResolvedAt: &at,
+			})
+		}
+		return nil
+	}
+
+	if step == 0 {
+		step = defaultBacktestStep
+	}
+	if err := checkSteps(start, end, step); err != nil {
+		return err
+	}
+	run.result.Step = step.String()
+
+	window := alertmodel.ParseDuration(run.rule.Threshold.Window)
+	counters := make(map[string]*slidingCounter)
+	labels := make(map[string]map[string]string)
+	next := 0
+	for t := start; !t.After(end); t = t.Add(step) {
+		for ; next < len(logs) && !logs[next].Timestamp.After(t); next++ {
+			meta, ok := matches(logs[next])
+			if !ok {
+				continue
+			}
+			if counters[meta.EndpointID] == nil {
+				counters[meta.EndpointID] = newSlidingCounter(window)
+				labels[meta.EndpointID] = meta.Labels
+			}
+			counters[meta.EndpointID].add(logs[next].Timestamp)
+		}
+		for id, counter := range counters {
+			count := counter.count(t)
+			metric := windowMetric(run.rule, float64(count), t, labels[id])
+			run.observe(id, metric, count > run.rule.Threshold.Count)
+		}
+	}
+	return nil
+}
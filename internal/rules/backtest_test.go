@@ -0,0 +1,239 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/logstore"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var backtestStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeLogs serves stored logs to the backtester, filtered by time range.
+type fakeLogs struct {
+	logstore.LogStore
+	entries []model.LogEntry
+}
+
+func (f *fakeLogs) GetLogs(filter model.LogFilter) ([]model.LogEntry, error) {
+	var out []model.LogEntry
+	for _, e := range f.entries {
+		if !e.Timestamp.Before(filter.Start) && !e.Timestamp.After(filter.End) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// minutes returns one cpu row per minute from backtestStart for host-1.
+func minutes(values ...float64) []model.MetricRow {
+	rows := make([]model.MetricRow, len(values))
+	for i, v := range values {
+		rows[i] = cpuRow("host-1", v, backtestStart.Add(time.Duration(i)*time.Minute))
+	}
+	return rows
+}
+
+// interval builds the expected BacktestInterval from minute offsets; a
+// negative resolved offset means the interval is still open.
+func interval(fired, resolved int, value float64) BacktestInterval {
+	in := BacktestInterval{FiredAt: backtestStart.Add(time.Duration(fired) * time.Minute), Value: value}
+	if resolved >= 0 {
+		at := backtestStart.Add(time.Duration(resolved) * time.Minute)
+		in.ResolvedAt = &at
+	}
+	return in
+}
+
+func TestBacktestMetricRules(t *testing.T) {
+	pending := cpuRule
+	pending.Options.For = "2m"
+	windowed := cpuRule
+	windowed.Scope.Window = "2m"
+	windowed.Expression.Function = "avg"
+	windowed.Options.EvalInterval = "1m"
+	absent := cpuRule
+	absent.Scope.Window = "2m"
+	absent.Expression = alertmodel.Expression{Function: "absent"}
+	absent.Match.EndpointIDs = []string{"host-2"}
+	absent.Options.EvalInterval = "1m"
+
+	tests := []struct {
+		name        string
+		rule        alertmodel.AlertRule
+		rows        []model.MetricRow
+		end         time.Duration
+		evaluations int
+		want        map[string][]BacktestInterval
+	}{
+		{
+			name:        "fires and resolves per sample",
+			rule:        cpuRule,
+			rows:        minutes(50, 95, 97, 40, 96, 30),
+			end:         5 * time.Minute,
+			evaluations: 6,
+			want: map[string][]BacktestInterval{
+				"host-1": {interval(1, 3, 95), interval(4, 5, 96)},
+			},
+		},
+		{
+			name:        "still firing at the end",
+			rule:        cpuRule,
+			rows:        minutes(50, 95),
+			end:         5 * time.Minute,
+			evaluations: 2,
+			want:        map[string][]BacktestInterval{"host-1": {interval(1, -1, 95)}},
+		},
+		{
+			name:        "for holds the alert pending",
+			rule:        pending,
+			rows:        minutes(95, 95, 40, 95, 95, 95, 40),
+			end:         6 * time.Minute,
+			evaluations: 7,
+			want:        map[string][]BacktestInterval{"host-1": {interval(5, 6, 95)}},
+		},
+		{
+			name: "window average",
+			rule: windowed,
+			// (100+100)/2 first breaches at 2m, (100+40)/2 drops out at 4m
+			rows:        minutes(50, 100, 100, 100, 40, 40),
+			end:         5 * time.Minute,
+			evaluations: 6,
+			want:        map[string][]BacktestInterval{"host-1": {interval(2, 4, 100)}},
+		},
+		{
+			name:        "absent endpoint",
+			rule:        absent,
+			rows:        minutes(50, 50, 50),
+			end:         2 * time.Minute,
+			evaluations: 3,
+			want:        map[string][]BacktestInterval{"host-2": {interval(0, -1, 0)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &fakeMetrics{}
+			metrics.set(tt.rows...)
+			result, err := NewBacktester(metrics, nil).Run(tt.rule, backtestStart, backtestStart.Add(tt.end), 0)
+			require.NoError(t, err)
+			assert.Equal(t, tt.rule.ID, result.RuleID)
+			assert.Equal(t, "1m0s", result.Step)
+			assert.Equal(t, tt.evaluations, result.Evaluations)
+			assert.Equal(t, tt.want, result.Endpoints)
+		})
+	}
+}
+
+func TestBacktestLogRules(t *testing.T) {
+	errorRule := alertmodel.AlertRule{
+		ID:         "errors",
+		Type:       "log",
+		Enabled:    true,
+		Expression: alertmodel.Expression{Field: "level", Operator: "=", Value: "error"},
+	}
+	burst := errorRule
+	burst.Threshold = &alertmodel.Threshold{Count: 2, Window: "1m"}
+
+	meta := &model.Meta{EndpointID: "host-1"}
+	logAt := func(level string, seconds int) model.LogEntry {
+		return model.LogEntry{
+			Timestamp: backtestStart.Add(time.Duration(seconds) * time.Second),
+			Level:     level,
+			Message:   level,
+			Meta:      meta,
+		}
+	}
+	logs := &fakeLogs{entries: []model.LogEntry{
+		logAt("info", 5),
+		logAt("error", 10),
+		logAt("error", 20),
+		logAt("error", 30),
+		logAt("info", 40),
+		logAt("error", 75),
+	}}
+	at := func(seconds int) *time.Time {
+		ts := backtestStart.Add(time.Duration(seconds) * time.Second)
+		return &ts
+	}
+
+	t.Run("each matching line", func(t *testing.T) {
+		result, err := NewBacktester(nil, logs).Run(errorRule, backtestStart, backtestStart.Add(5*time.Minute), 0)
+		require.NoError(t, err)
+		assert.Equal(t, 4, result.Evaluations)
+		assert.Equal(t, []BacktestInterval{
+			{FiredAt: *at(10), ResolvedAt: at(10)},
+			{FiredAt: *at(20), ResolvedAt: at(20)},
+			{FiredAt: *at(30), ResolvedAt: at(30)},
+			{FiredAt: *at(75), ResolvedAt: at(75)},
+		}, result.Endpoints["host-1"])
+	})
+
+	t.Run("rate over the threshold", func(t *testing.T) {
+		// Three errors within the first minute breach the threshold of two
+		// at 30s. At 90s only two are left in the window, which is no
+		// longer over the threshold.
+		result, err := NewBacktester(nil, logs).Run(burst, backtestStart, backtestStart.Add(3*time.Minute), 30*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "30s", result.Step)
+		require.Len(t, result.Endpoints["host-1"], 1)
+		got := result.Endpoints["host-1"][0]
+		assert.Equal(t, *at(30), got.FiredAt)
+		assert.Equal(t, 3.0, got.Value)
+		assert.Equal(t, at(90), got.ResolvedAt)
+	})
+}
+
+func TestBacktestRejectsInvalidRequests(t *testing.T) {
+	unknownFn := cpuRule
+	unknownFn.Scope.Window = "5m"
+	unknownFn.Expression.Function = "median"
+	event := alertmodel.AlertRule{ID: "login", Type: "event"}
+	end := backtestStart.Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		rule       alertmodel.AlertRule
+		start, end time.Time
+		step       time.Duration
+		want       error
+	}{
+		{"end before start", cpuRule, end, backtestStart, 0, ErrInvalidBacktest},
+		{"empty range", cpuRule, backtestStart, backtestStart, 0, ErrInvalidBacktest},
+		{"negative step", cpuRule, backtestStart, end, -time.Minute, ErrInvalidBacktest},
+		{"too many steps", cpuRule, backtestStart, end, time.Millisecond, ErrInvalidBacktest},
+		{"unknown function", unknownFn, backtestStart, end, 0, ErrInvalidBacktest},
+		{"event rule", event, backtestStart, end, 0, ErrBacktestUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &fakeMetrics{}
+			_, err := NewBacktester(metrics, &fakeLogs{}).Run(tt.rule, tt.start, tt.end, tt.step)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+)
+
+// defaultCompositeWindow is how long a condition counts as held after it
+// was last observed when a composite rule doesn't set a window.
+const defaultCompositeWindow = 5 * time.Minute
+
+// conditionHit records when a composite condition last held and why.
+type conditionHit struct {
+	at     time.Time
+	detail string
+}
+
+// conditionName returns the name a condition is reported under.
+func conditionName(i int, cond alertmodel.Condition) string {
+	if cond.Name != "" {
+		return cond.Name
+	}
+	if cond.RuleID != "" {
+		return cond.RuleID
+	}
+	return fmt.Sprintf("condition_%d", i+1)
+}
+
+// compositeWindow returns how long a condition hit stays valid for the rule.
+func compositeWindow(rule alertmodel.AlertRule) time.Duration {
+	if w := alertmodel.ParseDuration(rule.Composite.Window); w > 0 {
+		return w
+	}
+	return defaultCompositeWindow
+}
+
+// recordHit marks a composite condition as held for the endpoint.
+func (e *Evaluator) recordHit(key, name string, at time.Time, detail string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	hits := e.hits[key]
+	if hits == nil {
+		hits = make(map[string]conditionHit)
+		e.hits[key] = hits
+	}
+	if prev, ok := hits[name]; ok && prev.at.After(at) {
+		return
+	}
+	hits[name] = conditionHit{at: at, detail: detail}
+}
+
+// compositeMetricHits checks the metric conditions of a composite rule
+// against a pushed payload.
+func (e *Evaluator) compositeMetricHits(rule alertmodel.AlertRule, metrics []model.Metric, meta *model.Meta) {
+	key := rule.ID + "|" + meta.EndpointID
+	for i, cond := range rule.Composite.Conditions {
+		if cond.Type != "metric" || cond.RuleID != "" {
+			continue
+		}
+		if !ruleMatchLabels(cond.Match, meta) {
+			continue
+		}
+		name := ruleMetricName(alertmodel.AlertRule{Scope: cond.Scope})
+		for j := range metrics {
+			m := &metrics[j]
+			full := strings.ToLower(fmt.Sprintf("%s.%s.%s", m.Namespace, m.SubNamespace, m.Name))
+			if full != name {
+				continue
+			}
+			if evaluateExpression(cond.Expression, m) {
+				detail := fmt.Sprintf("%s %s %v (value %g)", name, cond.Expression.Operator, cond.Expression.Value, getMetricValue(m))
+				e.recordHit(key, conditionName(i, cond), sampleTime(m), detail)
+			}
+			break
+		}
+	}
+}
+
+// compositeLogHits checks the log conditions of a composite rule against a
+// pushed log entry.
+func (e *Evaluator) compositeLogHits(rule alertmodel.AlertRule, log model.LogEntry, meta *model.Meta) {
+	key := rule.ID + "|" + meta.EndpointID
+	for i, cond := range rule.Composite.Conditions {
+		if cond.Type != "log" || cond.RuleID != "" {
+			continue
+		}
+		if !ruleMatchLabels(cond.Match, meta) {
+			continue
+		}
+		if cond.Match.Category != "" && cond.Match.Category != log.Category {
+			continue
+		}
+		if cond.Match.Source != "" && cond.Match.Source != log.Source {
+			continue
+		}
+		if evaluateLogExpression(cond.Expression, log) {
+			at := log.Timestamp
+			if at.IsZero() {
+				at = time.Now().UTC()
+			}
+			e.recordHit(key, conditionName(i, cond), at, "log: "+log.Message)
+		}
+	}
+}
+
+// evaluateComposite combines the condition hits of a composite rule for an
+// endpoint and drives the rule through the usual pending/firing/resolved
+// transitions. The alert instance carries a "condition.<name>" label for
+// every condition that contributed.
+func (e *Evaluator) evaluateComposite(ctx context.Context, rule alertmodel.AlertRule, meta *model.Meta, now time.Time) {
+	key := rule.ID + "|" + meta.EndpointID
+	window := compositeWindow(rule)
+
+	e.lock.Lock()
+	hits := e.hits[key]
+	held := make(map[string]string)
+	for i, cond := range rule.Composite.Conditions {
+		name := conditionName(i, cond)
+		if cond.RuleID != "" {
+			refKey := cond.RuleID + "|" + meta.EndpointID
+			if e.firing[refKey] {
+				held[name] = "rule " + cond.RuleID + " firing"
+				continue
+			}
+			if at, ok := e.lastFired[refKey]; ok && now.Sub(at) <= window {
+				held[name] = "rule " + cond.RuleID + " fired at " + at.Format(time.RFC3339)
+			}
+			continue
+		}
+		if hit, ok := hits[name]; ok {
+			if now.Sub(hit.at) > window {
+				delete(hits, name)
+				continue
+			}
+			held[name] = hit.detail
+		}
+	}
+	e.lock.Unlock()
+
+	var breached bool
+	switch strings.ToLower(rule.Composite.Operator) {
+	case "or":
+		breached = len(held) > 0
+	default:
+		breached = len(rule.Composite.Conditions) > 0 && len(held) == len(rule.Composite.Conditions)
+	}
+
+	metric := windowMetric(rule, float64(len(held)), now, meta.Labels)
+	state, changed := e.transition(key, rule, metric, breached)
+	if !changed && state != alertmodel.StateFiring {
+		return
+	}
+
+	withConditions := *meta
+	withConditions.Labels = make(map[string]string, len(meta.Labels)+len(held))
+	for k, v := range meta.Labels {
+		withConditions.Labels[k] = v
+	}
+	for name, detail := range held {
+		withConditions.Labels["condition."+name] = detail
+	}
+	e.AlertMgr.HandleState(ctx, rule, &withConditions, float64(len(held)), state)
+}
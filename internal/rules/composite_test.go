@@ -0,0 +1,142 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	cpuCondition = alertmodel.Condition{
+		Name:       "cpu",
+		Type:       "metric",
+		Scope:      cpuRule.Scope,
+		Expression: alertmodel.Expression{Operator: ">", Value: 90},
+	}
+	oomCondition = alertmodel.Condition{
+		Name:       "oom",
+		Type:       "log",
+		Expression: alertmodel.Expression{Field: "message", Operator: "contains", Value: "OOM"},
+	}
+)
+
+func compositeRule(operator string, conditions ...alertmodel.Condition) alertmodel.AlertRule {
+	return alertmodel.AlertRule{
+		ID:        "cpu-and-oom",
+		Type:      "composite",
+		Enabled:   true,
+		Level:     "critical",
+		Message:   "CPU and OOM",
+		Composite: &alertmodel.Composite{Operator: operator, Window: "5m", Conditions: conditions},
+	}
+}
+
+func oomLog(at time.Time) []model.LogEntry {
+	return []model.LogEntry{{Timestamp: at, Message: "OOM killer invoked", Level: "error"}}
+}
+
+func TestCompositeOperators(t *testing.T) {
+	tests := []struct {
+		operator string
+		cpu      float64
+		oom      bool
+		firing   bool
+	}{
+		{"and", 97, true, true},
+		{"and", 97, false, false},
+		{"and", 10, true, false},
+		{"AND", 97, true, true},
+		{"or", 97, false, true},
+		{"or", 10, true, true},
+		{"or", 97, true, true},
+		{"or", 10, false, false},
+	}
+	for _, tt := range tests {
+		name := tt.operator
+		if tt.cpu > 90 {
+			name += " cpu"
+		}
+		if tt.oom {
+			name += " oom"
+		}
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			rule := compositeRule(tt.operator, cpuCondition, oomCondition)
+			e, _, _ := newTestEvaluator(t, rule)
+			meta := &model.Meta{EndpointID: "host-1"}
+
+			e.EvaluateMetric(ctx, cpuSample(tt.cpu), meta)
+			if tt.oom {
+				e.EvaluateLogs(ctx, oomLog(time.Now().UTC()), meta)
+			}
+
+			active := e.AlertMgr.ListActive()
+			if !tt.firing {
+				assert.Empty(t, active)
+				return
+			}
+			require.Len(t, active, 1)
+			assert.Equal(t, rule.ID, active[0].RuleID)
+		})
+	}
+}
+
+func TestCompositeConditionsExpireAfterWindow(t *testing.T) {
+	ctx := context.Background()
+	rule := compositeRule("and", cpuCondition, oomCondition)
+	e, _, _ := newTestEvaluator(t, rule)
+	meta := &model.Meta{EndpointID: "host-1"}
+
+	stale := cpuSample(97)
+	stale[0].DataPoints[0].Timestamp = time.Now().UTC().Add(-10 * time.Minute)
+	e.EvaluateMetric(ctx, stale, meta)
+	e.EvaluateLogs(ctx, oomLog(time.Now().UTC()), meta)
+	assert.Empty(t, e.AlertMgr.ListActive(), "the CPU breach is older than the window")
+
+	e.EvaluateMetric(ctx, cpuSample(97), meta)
+	assert.Len(t, e.AlertMgr.ListActive(), 1)
+}
+
+func TestCompositeReferencesFiringRule(t *testing.T) {
+	ctx := context.Background()
+	rule := compositeRule("and", alertmodel.Condition{RuleID: cpuRule.ID}, oomCondition)
+	e, _, _ := newTestEvaluator(t, cpuRule, rule)
+	meta := &model.Meta{EndpointID: "host-1"}
+
+	e.EvaluateLogs(ctx, oomLog(time.Now().UTC()), meta)
+	assert.Empty(t, e.AlertMgr.ListActive())
+
+	e.EvaluateMetric(ctx, cpuSample(97), meta)
+	rules := make(map[string]bool)
+	for _, a := range e.AlertMgr.ListActive() {
+		rules[a.RuleID] = true
+	}
+	assert.Equal(t, map[string]bool{cpuRule.ID: true, rule.ID: true}, rules,
+		"the referenced rule fires while the log condition still holds")
+}
@@ -26,10 +26,12 @@ package rules
 import (
 	"context"
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
 	"github.com/aaronlmathis/gosight-server/internal/alerts"
 	"github.com/aaronlmathis/gosight-server/internal/store/rulestore"
 	"github.com/aaronlmathis/gosight-shared/model"
@@ -42,11 +44,29 @@ import (
 // for each rule and endpoint combination, allowing it to track the state
 // of alerts over time. The firing map is used to track which rules are currently
 // firing for each endpoint, preventing duplicate alerts from being emitted.
+//
+// history holds the samples of the current breach for each rule and endpoint:
+// the first sample marks when the rule went pending and the last one when the
+// expression last held. Together with the rule's "for" and "keep_firing_for"
+// options they drive the pending -> firing -> resolved transitions.
+//
+// hits and lastFired feed composite rules: hits holds when each inline
+// condition of a composite rule last held, lastFired when a rule last fired,
+// so conditions referencing other rules can be checked within a window.
+// rates holds the sliding counters of log rules with a threshold. metas
+// holds the metadata of each open breach, so the Scheduler can re-evaluate
+// endpoints that dropped out of its query results.
 type Evaluator struct {
-	store    rulestore.RuleStore
-	AlertMgr *alerts.Manager
-	history  map[string][]model.Metric
-	firing   map[string]bool // ruleID + endpointID
+	store     rulestore.RuleStore
+	AlertMgr  *alerts.Manager
+	lock      sync.Mutex
+	history   map[string][]model.Metric          // ruleID + endpointID
+	firing    map[string]bool                    // ruleID + endpointID
+	resolved  map[string]time.Time               // ruleID + endpointID, for cooldown
+	hits      map[string]map[string]conditionHit // composite ruleID + endpointID -> condition
+	lastFired map[string]time.Time               // ruleID + endpointID
+	rates     map[string]*logRate                // ruleID + endpointID
+	metas     map[string]*model.Meta             // ruleID + endpointID, while history is kept
 }
 
 // NewEvaluator creates a new Evaluator instance.
@@ -58,10 +78,15 @@ type Evaluator struct {
 
 func NewEvaluator(store rulestore.RuleStore, alertMgr *alerts.Manager) *Evaluator {
 	return &Evaluator{
-		store:    store,
-		AlertMgr: alertMgr,
-		history:  make(map[string][]model.Metric),
-		firing:   make(map[string]bool),
+		store:     store,
+		AlertMgr:  alertMgr,
+		history:   make(map[string][]model.Metric),
+		firing:    make(map[string]bool),
+		resolved:  make(map[string]time.Time),
+		hits:      make(map[string]map[string]conditionHit),
+		lastFired: make(map[string]time.Time),
+		rates:     make(map[string]*logRate),
+		metas:     make(map[string]*model.Meta),
 	}
 }
 
@@ -79,12 +104,21 @@ func (e *Evaluator) EvaluateMetric(ctx context.Context, metrics []model.Metric,
 		return
 	}
 
+	var composites []alertmodel.AlertRule
 	for _, rule := range activeRules {
 
 		if !rule.Enabled {
 			continue
 		}
 
+		if rule.Type == "composite" && rule.Composite != nil {
+			if ruleMatchLabels(rule.Match, meta) {
+				e.compositeMetricHits(rule, metrics, meta)
+				composites = append(composites, rule)
+			}
+			continue
+		}
+
 		if rule.Type != "metric" {
 			continue
 		}
@@ -93,7 +127,13 @@ func (e *Evaluator) EvaluateMetric(ctx context.Context, metrics []model.Metric,
 			continue
 		}
 
-		metricName := fmt.Sprintf("%s.%s.%s", rule.Scope.Namespace, rule.Scope.SubNamespace, rule.Scope.Metric)
+		// Rules with an eval_interval or a window are evaluated by the
+		// Scheduler against the metric store, not on every pushed payload.
+		if ruleEvalInterval(rule) > 0 {
+			continue
+		}
+
+		metricName := ruleMetricName(rule)
 
 		var matched *model.Metric
 		for _, m := range metrics {
@@ -109,25 +149,111 @@ func (e *Evaluator) EvaluateMetric(ctx context.Context, metrics []model.Metric,
 			continue
 		}
 
-		// Use helper function to extract value from DataPoints
-		matchedValue := getMetricValue(matched)
-		firing := evaluateExpression(rule.Expression, matched)
-		key := rule.ID + "|" + meta.EndpointID
+		e.evaluateMetricRule(ctx, rule, matched, meta)
+	}
 
-		if firing {
-			if !e.firing[key] {
-				e.firing[key] = true
-				// Use extracted value instead of matched.Value
-				e.AlertMgr.HandleState(ctx, rule, meta, matchedValue, true)
-			}
-		} else {
-			if e.firing[key] {
-				delete(e.firing, key)
-				// Use extracted value instead of matched.Value
-				e.AlertMgr.HandleState(ctx, rule, meta, matchedValue, false)
+	// Composite rules are evaluated last so conditions referencing rules
+	// that fired on this payload see them firing.
+	now := time.Now().UTC()
+	for _, rule := range composites {
+		e.evaluateComposite(ctx, rule, meta, now)
+	}
+}
+
+// evaluateMetricRule checks a single metric sample against a rule and
+// forwards any resulting state change to the alert manager.
+func (e *Evaluator) evaluateMetricRule(ctx context.Context, rule alertmodel.AlertRule, matched *model.Metric, meta *model.Meta) {
+	// Use helper function to extract value from DataPoints
+	e.applyBreach(ctx, rule, matched, meta, evaluateExpression(rule.Expression, matched))
+}
+
+// applyBreach records whether the rule's condition held for the sample and
+// forwards any resulting state change to the alert manager.
+func (e *Evaluator) applyBreach(ctx context.Context, rule alertmodel.AlertRule, matched *model.Metric, meta *model.Meta, breached bool) {
+	key := rule.ID + "|" + meta.EndpointID
+
+	state, changed := e.transition(key, rule, *matched, breached)
+	e.lock.Lock()
+	if len(e.history[key]) > 0 {
+		e.metas[key] = meta
+	} else {
+		delete(e.metas, key)
+	}
+	e.lock.Unlock()
+	if changed && state == alertmodel.StateFiring {
+		e.markFired(key, sampleTime(matched))
+	}
+	// Firing is reported on every evaluation so the manager can send repeat
+	// notifications and notice when a silence ends.
+	if changed || state == alertmodel.StateFiring {
+		e.AlertMgr.HandleState(ctx, rule, meta, getMetricValue(matched), state)
+	}
+}
+
+// markFired records when a rule last fired for an endpoint.
+func (e *Evaluator) markFired(key string, at time.Time) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.lastFired[key] = at
+}
+
+// transition records the evaluation result for a rule and endpoint and
+// returns the resulting alert state and whether it changed.
+//
+// A breach starts pending and is kept in history. Once the breach has lasted
+// for the rule's "for" duration the rule fires. When the expression stops
+// holding, a firing rule keeps firing until "keep_firing_for" has passed since
+// the last breaching sample, then resolves. A pending rule simply goes back to
+// ok. After a resolve, breaches are ignored until the rule's cooldown has
+// passed. Sample timestamps are used as the clock so agents that batch or
+// delay pushes are measured by when the value was observed.
+func (e *Evaluator) transition(key string, rule alertmodel.AlertRule, m model.Metric, breached bool) (string, bool) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	now := sampleTime(&m)
+	hist := e.history[key]
+
+	if breached {
+		if len(hist) == 0 {
+			cooldown := alertmodel.ParseDuration(rule.Options.Cooldown)
+			if resolvedAt, ok := e.resolved[key]; ok && now.Sub(resolvedAt) < cooldown {
+				return alertmodel.StateOK, false
 			}
 		}
+		if len(hist) > 1 {
+			// Only the start of the breach and the latest sample matter.
+			hist = hist[:1]
+		}
+		hist = append(hist, m)
+		e.history[key] = hist
+
+		if e.firing[key] {
+			return alertmodel.StateFiring, false
+		}
+
+		forDur := alertmodel.ParseDuration(rule.Options.For)
+		if now.Sub(sampleTime(&hist[0])) >= forDur {
+			e.firing[key] = true
+			return alertmodel.StateFiring, true
+		}
+		return alertmodel.StatePending, len(hist) == 1
 	}
+
+	if len(hist) == 0 {
+		return alertmodel.StateOK, false
+	}
+
+	if e.firing[key] {
+		keep := alertmodel.ParseDuration(rule.Options.KeepFiringFor)
+		if keep > 0 && now.Sub(sampleTime(&hist[len(hist)-1])) < keep {
+			return alertmodel.StateFiring, false
+		}
+		delete(e.firing, key)
+		e.resolved[key] = now
+	}
+	delete(e.history, key)
+	return alertmodel.StateOK, true
 }
 
 // EvaluateLogs processes the given logs and metadata,
@@ -137,6 +263,9 @@ func (e *Evaluator) EvaluateMetric(ctx context.Context, metrics []model.Metric,
 // The logs are expected to be in the format of model.LogEntry,
 // and the metadata is expected to be in the format of model.Meta.
 // Logs are point-in-time events, so they are always evaluated immediately.
+// Rules with a threshold count matching lines per endpoint instead of firing
+// on each one; the Scheduler keeps re-evaluating their counters so they
+// resolve when the lines stop.
 func (e *Evaluator) EvaluateLogs(ctx context.Context, logs []model.LogEntry, meta *model.Meta) {
 	activeRules, err := e.store.GetActiveRules(ctx)
 	if err != nil {
@@ -144,12 +273,22 @@ func (e *Evaluator) EvaluateLogs(ctx context.Context, logs []model.LogEntry, met
 		return
 	}
 
+	touched := make(map[string]bool)
 	for _, log := range logs {
+		if log.Meta == nil {
+			log.Meta = meta
+		}
 		for _, rule := range activeRules {
 			utils.Debug("Evaluating log rule: %s", rule.ID)
 			if !rule.Enabled {
 				continue
 			}
+			if rule.Type == "composite" && rule.Composite != nil {
+				if ruleMatchLabels(rule.Match, meta) {
+					e.compositeLogHits(rule, log, meta)
+				}
+				continue
+			}
 			if rule.Type != "log" {
 				continue
 			}
@@ -163,44 +302,32 @@ func (e *Evaluator) EvaluateLogs(ctx context.Context, logs []model.LogEntry, met
 				continue
 			}
 			firing := evaluateLogExpression(rule.Expression, log)
+			if firing && rule.Threshold != nil {
+				touched[e.countLogMatch(rule, meta, time.Now().UTC())] = true
+				continue
+			}
 			if firing {
+				e.markFired(rule.ID+"|"+meta.EndpointID, time.Now().UTC())
 				e.AlertMgr.HandleLogState(ctx, rule, meta, log, true)
 			}
 		}
 	}
-}
 
-// evaluateLogExpression evaluates the log entry against the rule's expression.
-func evaluateLogExpression(expr model.Expression, log model.LogEntry) bool {
-	val := ""
-	if expr.Datatype == "level" {
-		val = log.Level
-	} else if expr.Datatype == "message" {
-		val = log.Message
-	} else {
-		val = log.Source
+	// Rate rules and composite rules are re-evaluated once per batch,
+	// after every log in it has been counted.
+	now := time.Now().UTC()
+	for key := range touched {
+		e.evaluateLogRate(ctx, key, now)
 	}
-
-	switch expr.Operator {
-	case "contains":
-		return strings.Contains(val, toString(expr.Value))
-	case "=", "==":
-		return val == toString(expr.Value)
-	case "!=":
-		return val != toString(expr.Value)
-	case "regex":
-		re, err := regexp.Compile(toString(expr.Value))
-		if err != nil {
-			return false
+	for _, rule := range activeRules {
+		if rule.Enabled && rule.Type == "composite" && rule.Composite != nil && ruleMatchLabels(rule.Match, meta) {
+			e.evaluateComposite(ctx, rule, meta, now)
 		}
-		return re.MatchString(val)
-	default:
-		return false
 	}
 }
 
-// evaluateLogExpression evaluates the log entry against the rule's expression.
-func evaluateExpression(expr model.Expression, m *model.Metric) bool {
+// evaluateExpression evaluates the metric against the rule's expression.
+func evaluateExpression(expr alertmodel.Expression, m *model.Metric) bool {
 	// Extract value using helper function instead of m.Value
 	metricValue := getMetricValue(m)
 
@@ -220,7 +347,7 @@ func evaluateExpression(expr model.Expression, m *model.Metric) bool {
 	case "contains":
 		return strings.Contains(toString(metricValue), toString(expr.Value))
 	case "regex":
-		re, err := regexp.Compile(toString(expr.Value))
+		re, err := compileRegex(toString(expr.Value))
 		if err != nil {
 			return false
 		}
@@ -232,7 +359,7 @@ func evaluateExpression(expr model.Expression, m *model.Metric) bool {
 
 // ruleMatchLabels checks if the rule's match labels match the given metadata labels.
 
-func ruleMatchLabels(match model.MatchCriteria, meta *model.Meta) bool {
+func ruleMatchLabels(match alertmodel.MatchCriteria, meta *model.Meta) bool {
 	if len(match.EndpointIDs) > 0 {
 		found := false
 		for _, id := range match.EndpointIDs {
@@ -254,6 +381,12 @@ func ruleMatchLabels(match model.MatchCriteria, meta *model.Meta) bool {
 	return true
 }
 
+// ruleMetricName returns the fully qualified metric name a rule targets,
+// e.g. "system.cpu.usage_percent".
+func ruleMetricName(rule alertmodel.AlertRule) string {
+	return strings.ToLower(fmt.Sprintf("%s.%s.%s", rule.Scope.Namespace, rule.Scope.SubNamespace, rule.Scope.Metric))
+}
+
 // toFloat converts various types to float64.
 // It handles float64, int, and string types.
 func toFloat(v interface{}) float64 {
@@ -286,6 +419,15 @@ func toString(v interface{}) string {
 	}
 }
 
+// sampleTime returns the observation time of a metric, falling back to
+// the current time when the data point carries no timestamp.
+func sampleTime(metric *model.Metric) time.Time {
+	if len(metric.DataPoints) > 0 && !metric.DataPoints[0].Timestamp.IsZero() {
+		return metric.DataPoints[0].Timestamp
+	}
+	return time.Now().UTC()
+}
+
 // getMetricValue extracts the first data point value from a metric
 func getMetricValue(metric *model.Metric) float64 {
 	if len(metric.DataPoints) == 0 {
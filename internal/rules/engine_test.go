@@ -0,0 +1,137 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// sampleAt returns a single-point gauge observed at t.
+func sampleAt(t time.Time, value float64) model.Metric {
+	return model.Metric{
+		DataType:   "gauge",
+		DataPoints: []model.DataPoint{{Timestamp: t, Value: value}},
+	}
+}
+
+// step is one evaluation fed through Evaluator.transition.
+type step struct {
+	at       time.Duration // since the start of the test
+	breached bool
+	state    string
+	changed  bool
+}
+
+func runSteps(t *testing.T, rule alertmodel.AlertRule, steps []step) {
+	e := &Evaluator{
+		history:  make(map[string][]model.Metric),
+		firing:   make(map[string]bool),
+		resolved: make(map[string]time.Time),
+	}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, s := range steps {
+		state, changed := e.transition("rule|host-1", rule, sampleAt(start.Add(s.at), 0), s.breached)
+		assert.Equal(t, s.state, state, "state at %s", s.at)
+		assert.Equal(t, s.changed, changed, "changed at %s", s.at)
+	}
+}
+
+func TestTransition(t *testing.T) {
+	rule := func(opts alertmodel.Options) alertmodel.AlertRule {
+		return alertmodel.AlertRule{ID: "rule", Options: opts}
+	}
+
+	tests := []struct {
+		name  string
+		rule  alertmodel.AlertRule
+		steps []step
+	}{
+		{
+			name: "fires and resolves",
+			rule: rule(alertmodel.Options{}),
+			steps: []step{
+				{0, true, alertmodel.StateFiring, true},
+				{time.Minute, true, alertmodel.StateFiring, false},
+				{2 * time.Minute, false, alertmodel.StateOK, true},
+				{3 * time.Minute, false, alertmodel.StateOK, false},
+			},
+		},
+		{
+			name: "for holds the alert pending",
+			rule: rule(alertmodel.Options{For: "2m"}),
+			steps: []step{
+				{0, true, alertmodel.StatePending, true},
+				{time.Minute, true, alertmodel.StatePending, false},
+				{2 * time.Minute, true, alertmodel.StateFiring, true},
+			},
+		},
+		{
+			name: "pending clears without firing",
+			rule: rule(alertmodel.Options{For: "2m"}),
+			steps: []step{
+				{0, true, alertmodel.StatePending, true},
+				{time.Minute, false, alertmodel.StateOK, true},
+				{2 * time.Minute, true, alertmodel.StatePending, true},
+			},
+		},
+		{
+			name: "keep_firing_for delays the resolve",
+			rule: rule(alertmodel.Options{KeepFiringFor: "5m"}),
+			steps: []step{
+				{0, true, alertmodel.StateFiring, true},
+				{time.Minute, false, alertmodel.StateFiring, false},
+				{4 * time.Minute, false, alertmodel.StateFiring, false},
+				{5 * time.Minute, false, alertmodel.StateOK, true},
+			},
+		},
+		{
+			name: "cooldown ignores breaches after a resolve",
+			rule: rule(alertmodel.Options{Cooldown: "10m"}),
+			steps: []step{
+				{0, true, alertmodel.StateFiring, true},
+				{time.Minute, false, alertmodel.StateOK, true},
+				{2 * time.Minute, true, alertmodel.StateOK, false},
+				{10 * time.Minute, true, alertmodel.StateOK, false},
+				{11 * time.Minute, true, alertmodel.StateFiring, true},
+			},
+		},
+		{
+			name: "cooldown doesn't apply to a cleared pending alert",
+			rule: rule(alertmodel.Options{For: "2m", Cooldown: "10m"}),
+			steps: []step{
+				{0, true, alertmodel.StatePending, true},
+				{time.Minute, false, alertmodel.StateOK, true},
+				{2 * time.Minute, true, alertmodel.StatePending, true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteps(t, tt.rule, tt.steps)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"context"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+// OnEvent implements events.Listener so every emitted event is checked
+// against the active event rules.
+func (e *Evaluator) OnEvent(ctx context.Context, event model.EventEntry) {
+	e.EvaluateEvent(ctx, event)
+}
+
+// EvaluateEvent checks an emitted event against rules of type "event".
+// Lifecycle events reporting an agent or container back up first resolve the
+// open event alerts on it.
+// Events are point-in-time, so a matching rule fires once per event, subject
+// to the rule's cooldown per target. Events raised by a rule are never fed
+// back into the same rule, and alert events only reach rules that explicitly
+// match their category, so alerts cannot trigger each other in a loop.
+func (e *Evaluator) EvaluateEvent(ctx context.Context, event model.EventEntry) {
+	e.AlertMgr.HandleEventRecovery(ctx, event)
+
+	activeRules, err := e.store.GetActiveRules(ctx)
+	if err != nil {
+		utils.Error("Failed to fetch active rules: %v", err)
+		return
+	}
+
+	for _, rule := range activeRules {
+		if !rule.Enabled || rule.Type != "event" {
+			continue
+		}
+		if ruleID := event.Meta["rule_id"]; ruleID != "" {
+			if ruleID == rule.ID || rule.Match.Category != event.Category {
+				continue
+			}
+		}
+		if !eventMatches(rule.Match, event) {
+			continue
+		}
+		if rule.Expression.Operator != "" && !evaluateEventExpression(rule.Expression, event) {
+			continue
+		}
+
+		target := event.Target
+		if target == "" {
+			target = event.EndpointID
+		}
+		key := rule.ID + "|" + target
+		if !e.claimEventFire(key, rule, event.Timestamp) {
+			continue
+		}
+
+		meta := &model.Meta{
+			EndpointID: event.EndpointID,
+			Labels:     make(map[string]string, len(event.Meta)),
+		}
+		for k, v := range event.Meta {
+			meta.Labels[k] = v
+		}
+		e.AlertMgr.HandleEventState(ctx, rule, meta, event)
+	}
+}
+
+// claimEventFire records that an event rule fired for a target, unless it
+// already fired within the rule's cooldown.
+func (e *Evaluator) claimEventFire(key string, rule alertmodel.AlertRule, at time.Time) bool {
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+	cooldown := alertmodel.ParseDuration(rule.Options.Cooldown)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if last, ok := e.lastFired[key]; ok && at.Sub(last) < cooldown {
+		return false
+	}
+	e.lastFired[key] = at
+	return true
+}
+
+// eventMatches checks the rule's match criteria against an event. Labels
+// are matched against the event's meta.
+func eventMatches(match alertmodel.MatchCriteria, event model.EventEntry) bool {
+	if len(match.EndpointIDs) > 0 {
+		found := false
+		for _, id := range match.EndpointIDs {
+			if id == event.EndpointID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for k, v := range match.Labels {
+		if event.Meta[k] != v {
+			return false
+		}
+	}
+	if match.Category != "" && match.Category != event.Category {
+		return false
+	}
+	if match.Source != "" && match.Source != event.Source {
+		return false
+	}
+	if match.Scope != "" && match.Scope != event.Scope {
+		return false
+	}
+	if match.Target != "" && match.Target != event.Target {
+		return false
+	}
+	return true
+}
+
+// evaluateEventExpression evaluates the event against the rule's expression.
+// The datatype selects the field to check and defaults to the message.
+func evaluateEventExpression(expr alertmodel.Expression, event model.EventEntry) bool {
+	var val string
+	switch expr.Datatype {
+	case "level":
+		val = event.Level
+	case "type":
+		val = event.Type
+	case "category":
+		val = event.Category
+	case "source":
+		val = event.Source
+	case "scope":
+		val = event.Scope
+	case "target":
+		val = event.Target
+	default:
+		val = event.Message
+	}
+	return matchString(expr, val)
+}
@@ -0,0 +1,238 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+)
+
+// regexCache holds compiled rule patterns so hot paths don't recompile the
+// same regex for every log line.
+var regexCache sync.Map // pattern -> *regexp.Regexp
+
+// compileRegex returns the compiled pattern, compiling it once.
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(pattern, re)
+	return re, nil
+}
+
+// matchString applies an expression to a string value. Besides contains,
+// =, != and regex (and their negations) it supports ordered comparisons
+// when both sides are numeric, and in/not_in against a list of values.
+// Equality falls back to numeric comparison so "500" equals 500.
+func matchString(expr alertmodel.Expression, val string) bool {
+	switch strings.ToLower(expr.Operator) {
+	case "contains":
+		return strings.Contains(val, alertmodel.FormatValue(expr.Value))
+	case "not_contains":
+		return !strings.Contains(val, alertmodel.FormatValue(expr.Value))
+	case "=", "==":
+		return valuesEqual(val, expr.Value)
+	case "!=":
+		return !valuesEqual(val, expr.Value)
+	case "regex", "not_regex":
+		re, err := compileRegex(alertmodel.FormatValue(expr.Value))
+		if err != nil {
+			return false
+		}
+		return re.MatchString(val) == (strings.ToLower(expr.Operator) == "regex")
+	case ">", "<", ">=", "<=":
+		got, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return false
+		}
+		want, ok := alertmodel.ParseNumber(expr.Value)
+		if !ok {
+			return false
+		}
+		switch expr.Operator {
+		case ">":
+			return got > want
+		case "<":
+			return got < want
+		case ">=":
+			return got >= want
+		default:
+			return got <= want
+		}
+	case "in", "not_in":
+		found := false
+		for _, item := range alertmodel.ValueList(expr.Value) {
+			if valuesEqual(val, item) {
+				found = true
+				break
+			}
+		}
+		return found == (strings.ToLower(expr.Operator) == "in")
+	default:
+		return false
+	}
+}
+
+// valuesEqual compares a field value to an expression value, numerically
+// when both parse as numbers.
+func valuesEqual(val string, want interface{}) bool {
+	if w, ok := alertmodel.ParseNumber(want); ok {
+		if got, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil {
+			return got == w
+		}
+	}
+	return val == alertmodel.FormatValue(want)
+}
+
+// evaluateLogExpression evaluates the log entry against the rule's expression.
+// The field is taken from expr.Field; rules without one keep the original
+// behaviour of selecting level, message or source through the datatype.
+// The "exists" operator only checks that the field is present.
+func evaluateLogExpression(expr alertmodel.Expression, log model.LogEntry) bool {
+	path := expr.Field
+	if path == "" {
+		switch expr.Datatype {
+		case "level":
+			path = "level"
+		case "message":
+			path = "message"
+		default:
+			path = "source"
+		}
+	}
+
+	val, ok := logFieldValue(log, path)
+	if strings.ToLower(expr.Operator) == "exists" {
+		return ok
+	}
+	if !ok {
+		return false
+	}
+	return matchString(expr, val)
+}
+
+// logFieldValue resolves a dotted path against a log entry. Top-level names
+// map to LogEntry fields; "fields.", "labels.", "attributes." and "meta."
+// prefixes look up keys in the corresponding maps. Attribute keys may
+// themselves contain dots, so the longest literal key is preferred before
+// descending into nested maps.
+func logFieldValue(log model.LogEntry, path string) (string, bool) {
+	prefix, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		switch strings.ToLower(path) {
+		case "message":
+			return log.Message, true
+		case "body":
+			return log.Body, true
+		case "level":
+			return log.Level, true
+		case "source":
+			return log.Source, true
+		case "category":
+			return log.Category, true
+		case "name":
+			return log.Name, true
+		case "severity_text":
+			return log.SeverityText, true
+		case "severity_number":
+			return strconv.Itoa(int(log.SeverityNumber)), true
+		case "trace_id":
+			return log.TraceID, true
+		case "span_id":
+			return log.SpanID, true
+		case "pid":
+			return strconv.Itoa(log.PID), true
+		}
+		return "", false
+	}
+
+	switch strings.ToLower(prefix) {
+	case "fields":
+		v, ok := log.Fields[rest]
+		return v, ok
+	case "labels":
+		v, ok := log.Labels[rest]
+		return v, ok
+	case "attributes":
+		v, ok := lookupAttribute(log.Attributes, rest)
+		if !ok {
+			return "", false
+		}
+		return alertmodel.FormatValue(v), true
+	case "meta":
+		return metaFieldValue(log.Meta, rest)
+	}
+	return "", false
+}
+
+// lookupAttribute finds a possibly nested attribute by dotted path.
+func lookupAttribute(attrs map[string]interface{}, path string) (interface{}, bool) {
+	if attrs == nil {
+		return nil, false
+	}
+	if v, ok := attrs[path]; ok {
+		return v, true
+	}
+	for i := len(path) - 1; i > 0; i-- {
+		if path[i] != '.' {
+			continue
+		}
+		if sub, ok := attrs[path[:i]].(map[string]interface{}); ok {
+			if v, ok := lookupAttribute(sub, path[i+1:]); ok {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// metaFieldValue resolves identity fields of a log's meta, falling back to
+// its labels ("meta.labels.env" and "meta.env" are equivalent).
+func metaFieldValue(meta *model.Meta, path string) (string, bool) {
+	if meta == nil {
+		return "", false
+	}
+	switch strings.ToLower(path) {
+	case "endpoint_id":
+		return meta.EndpointID, true
+	case "agent_id":
+		return meta.AgentID, true
+	case "host_id":
+		return meta.HostID, true
+	case "hostname":
+		return meta.Hostname, true
+	case "container_id":
+		return meta.ContainerID, true
+	}
+	path = strings.TrimPrefix(path, "labels.")
+	v, ok := meta.Labels[path]
+	return v, ok
+}
@@ -0,0 +1,147 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testLog = model.LogEntry{
+	Message:        "request failed with status 503",
+	Level:          "error",
+	Source:         "nginx",
+	Category:       "http",
+	SeverityNumber: 17,
+	PID:            4242,
+	Fields:         map[string]string{"status": "503", "path": "/api/v1/orders"},
+	Labels:         map[string]string{"env": "prod"},
+	Attributes: map[string]interface{}{
+		"http.status_code": 503,
+		"latency_ms":       1250.5,
+		"user":             map[string]interface{}{"id": "u-17", "tier": "gold"},
+	},
+	Meta: &model.Meta{EndpointID: "host-1", Hostname: "web-1", Labels: map[string]string{"region": "eu"}},
+}
+
+func TestLogFieldPaths(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"message", "request failed with status 503", true},
+		{"LEVEL", "error", true},
+		{"category", "http", true},
+		{"severity_number", "17", true},
+		{"pid", "4242", true},
+		{"fields.status", "503", true},
+		{"fields.missing", "", false},
+		{"labels.env", "prod", true},
+		{"attributes.http.status_code", "503", true},
+		{"attributes.latency_ms", "1250.5", true},
+		{"attributes.user.tier", "gold", true},
+		{"attributes.user.email", "", false},
+		{"meta.endpoint_id", "host-1", true},
+		{"meta.hostname", "web-1", true},
+		{"meta.region", "eu", true},
+		{"meta.labels.region", "eu", true},
+		{"unknown", "", false},
+		{"unknown.key", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := logFieldValue(testLog, tt.path)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLogExpressionOperators(t *testing.T) {
+	expr := func(field, op string, value interface{}) alertmodel.Expression {
+		return alertmodel.Expression{Field: field, Operator: op, Value: value}
+	}
+
+	tests := []struct {
+		name string
+		expr alertmodel.Expression
+		want bool
+	}{
+		{"contains", expr("message", "contains", "failed"), true},
+		{"not_contains", expr("message", "not_contains", "failed"), false},
+		{"equal string", expr("level", "=", "error"), true},
+		{"not equal", expr("level", "!=", "error"), false},
+		{"equal numeric", expr("fields.status", "==", 503), true},
+		{"equal numeric string", expr("attributes.http.status_code", "=", "503.0"), true},
+		{"greater", expr("attributes.latency_ms", ">", 1000), true},
+		{"greater or equal", expr("fields.status", ">=", "503"), true},
+		{"less", expr("fields.status", "<", 500), false},
+		{"less or equal", expr("severity_number", "<=", 17), true},
+		{"non-numeric field", expr("level", ">", 1), false},
+		{"non-numeric value", expr("fields.status", ">", "high"), false},
+		{"in list", expr("fields.status", "in", []interface{}{500, 502, 503}), true},
+		{"in string list", expr("level", "in", "warn, error"), true},
+		{"not in", expr("fields.status", "not_in", []string{"500", "502"}), true},
+		{"not in excluded", expr("labels.env", "not_in", "prod,staging"), false},
+		{"regex", expr("fields.path", "regex", `^/api/v\d+/`), true},
+		{"regex no match", expr("fields.path", "regex", `^/admin`), false},
+		{"not_regex", expr("fields.path", "not_regex", `^/admin`), true},
+		{"bad regex", expr("message", "regex", "("), false},
+		{"exists", expr("attributes.user.id", "exists", nil), true},
+		{"exists missing", expr("attributes.user.email", "exists", nil), false},
+		{"missing field", expr("fields.missing", "=", ""), false},
+		{"unknown operator", expr("message", "like", "failed"), false},
+		{"datatype fallback", alertmodel.Expression{Datatype: "level", Operator: "=", Value: "error"}, true},
+		{"source by default", alertmodel.Expression{Operator: "=", Value: "nginx"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, evaluateLogExpression(tt.expr, testLog))
+		})
+	}
+}
+
+func TestLogRuleUsesFieldPath(t *testing.T) {
+	ctx := context.Background()
+	rule := alertmodel.AlertRule{
+		ID:         "slow-orders",
+		Type:       "log",
+		Enabled:    true,
+		Message:    "Slow order requests",
+		Expression: alertmodel.Expression{Field: "attributes.latency_ms", Operator: ">", Value: 1000},
+	}
+	e, _, _ := newTestEvaluator(t, rule)
+	meta := &model.Meta{EndpointID: "host-1"}
+
+	fast := testLog
+	fast.Attributes = map[string]interface{}{"latency_ms": 20}
+	e.EvaluateLogs(ctx, []model.LogEntry{fast}, meta)
+	assert.Empty(t, e.AlertMgr.ListActive())
+
+	e.EvaluateLogs(ctx, []model.LogEntry{testLog}, meta)
+	assert.Len(t, e.AlertMgr.ListActive(), 1)
+}
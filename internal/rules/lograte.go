@@ -0,0 +1,165 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"context"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+)
+
+// counterBuckets is how many buckets a sliding window is split into. Counts
+// are exact to within one bucket width.
+const counterBuckets = 60
+
+// bucket counts the events that fell into one slice of a sliding window.
+type bucket struct {
+	start time.Time
+	count int
+}
+
+// slidingCounter counts events over a sliding window using fixed-width
+// buckets, so memory stays constant however many lines match.
+type slidingCounter struct {
+	window  time.Duration
+	width   time.Duration
+	buckets []bucket
+}
+
+// newSlidingCounter creates a counter for the given window.
+func newSlidingCounter(window time.Duration) *slidingCounter {
+	width := window / counterBuckets
+	if width <= 0 {
+		width = window
+	}
+	return &slidingCounter{window: window, width: width}
+}
+
+// add records an event at the given time.
+func (c *slidingCounter) add(at time.Time) {
+	start := at.Truncate(c.width)
+	if n := len(c.buckets); n > 0 && !c.buckets[n-1].start.Before(start) {
+		c.buckets[n-1].count++
+		return
+	}
+	c.buckets = append(c.buckets, bucket{start: start, count: 1})
+}
+
+// count returns the number of events within the window ending at now,
+// dropping buckets that fell out of it.
+func (c *slidingCounter) count(now time.Time) int {
+	cutoff := now.Add(-c.window)
+	drop := 0
+	for drop < len(c.buckets) && !c.buckets[drop].start.Add(c.width).After(cutoff) {
+		drop++
+	}
+	c.buckets = c.buckets[drop:]
+
+	total := 0
+	for _, b := range c.buckets {
+		total += b.count
+	}
+	return total
+}
+
+// logRate is the sliding counter of a log rate rule for one endpoint,
+// together with what is needed to re-evaluate it when no logs arrive.
+type logRate struct {
+	rule    alertmodel.AlertRule
+	meta    *model.Meta
+	counter *slidingCounter
+}
+
+// countLogMatch records a matching line for a log rate rule.
+func (e *Evaluator) countLogMatch(rule alertmodel.AlertRule, meta *model.Meta, at time.Time) string {
+	key := rule.ID + "|" + meta.EndpointID
+	window := alertmodel.ParseDuration(rule.Threshold.Window)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	rate := e.rates[key]
+	if rate == nil || rate.counter.window != window {
+		rate = &logRate{counter: newSlidingCounter(window)}
+		e.rates[key] = rate
+	}
+	rate.rule = rule
+	rate.meta = meta
+	rate.counter.add(at)
+	return key
+}
+
+// evaluateLogRate compares the current count of a log rate rule against its
+// threshold and drives the alert through the usual state transitions, so the
+// alert resolves once the rate drops back below the threshold.
+func (e *Evaluator) evaluateLogRate(ctx context.Context, key string, now time.Time) {
+	e.lock.Lock()
+	rate := e.rates[key]
+	if rate == nil {
+		e.lock.Unlock()
+		return
+	}
+	rule, meta := rate.rule, rate.meta
+	count := rate.counter.count(now)
+	idle := count == 0
+	e.lock.Unlock()
+
+	metric := windowMetric(rule, float64(count), now, meta.Labels)
+	e.applyBreach(ctx, rule, &metric, meta, count > rule.Threshold.Count)
+
+	if idle {
+		e.lock.Lock()
+		if !e.firing[key] && len(e.history[key]) == 0 {
+			delete(e.rates, key)
+		}
+		e.lock.Unlock()
+	}
+}
+
+// sweepLogRates re-evaluates every log rate counter so alerts resolve even
+// when an endpoint stops sending matching lines altogether. Counters of
+// rules that are no longer active are dropped.
+func (e *Evaluator) sweepLogRates(ctx context.Context, activeRules []alertmodel.AlertRule, now time.Time) {
+	active := make(map[string]bool, len(activeRules))
+	for _, rule := range activeRules {
+		if rule.Threshold != nil {
+			active[rule.ID] = true
+		}
+	}
+
+	e.lock.Lock()
+	keys := make([]string, 0, len(e.rates))
+	for key, rate := range e.rates {
+		if !active[rate.rule.ID] {
+			delete(e.rates, key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	e.lock.Unlock()
+
+	for _, key := range keys {
+		e.evaluateLogRate(ctx, key, now)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingCounter(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		events []time.Duration // since start
+		at     time.Duration
+		want   int
+	}{
+		{"empty", nil, 0, 0},
+		{"all inside", []time.Duration{0, 10 * time.Second, 30 * time.Second}, 59 * time.Second, 3},
+		{"same bucket", []time.Duration{0, 100 * time.Millisecond, 900 * time.Millisecond}, time.Second, 3},
+		{"oldest slides out", []time.Duration{0, 30 * time.Second, 45 * time.Second}, 61 * time.Second, 2},
+		{"all slide out", []time.Duration{0, 30 * time.Second}, 2 * time.Minute, 0},
+		// Buckets are a second wide, so an event stays counted until its
+		// whole bucket has left the window.
+		{"bucket still inside", []time.Duration{10 * time.Second}, 70 * time.Second, 1},
+		{"bucket left the window", []time.Duration{10 * time.Second}, 71 * time.Second, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newSlidingCounter(time.Minute)
+			for _, ev := range tt.events {
+				c.add(start.Add(ev))
+			}
+			assert.Equal(t, tt.want, c.count(start.Add(tt.at)))
+		})
+	}
+}
+
+func TestSlidingCounterDropsOldBuckets(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newSlidingCounter(time.Minute)
+	for i := 0; i < 10*counterBuckets; i++ {
+		c.add(start.Add(time.Duration(i) * time.Second))
+	}
+	end := start.Add(10 * time.Duration(counterBuckets) * time.Second)
+	assert.Equal(t, counterBuckets, c.count(end))
+	assert.LessOrEqual(t, len(c.buckets), counterBuckets+1)
+}
+
+func TestLogRateRule(t *testing.T) {
+	ctx := context.Background()
+	rule := alertmodel.AlertRule{
+		ID:         "error-burst",
+		Type:       "log",
+		Enabled:    true,
+		Message:    "More than 3 errors a minute",
+		Expression: alertmodel.Expression{Field: "level", Operator: "=", Value: "error"},
+		Threshold:  &alertmodel.Threshold{Count: 3, Window: "1m"},
+	}
+	e, ruleStore, _ := newTestEvaluator(t, rule)
+	meta := &model.Meta{EndpointID: "host-1"}
+	start := time.Now().UTC()
+
+	var key string
+	for i := 0; i < 3; i++ {
+		key = e.countLogMatch(rule, meta, start.Add(time.Duration(i)*time.Second))
+	}
+	e.evaluateLogRate(ctx, key, start.Add(3*time.Second))
+	assert.Empty(t, e.AlertMgr.ListActive(), "3 lines don't exceed the threshold")
+
+	e.countLogMatch(rule, meta, start.Add(4*time.Second))
+	e.evaluateLogRate(ctx, key, start.Add(5*time.Second))
+	require.Len(t, e.AlertMgr.ListActive(), 1)
+
+	// No more lines: the sweep resolves the alert once they slide out, and
+	// forgets the idle counter.
+	active, err := ruleStore.GetActiveRules(ctx)
+	require.NoError(t, err)
+	e.sweepLogRates(ctx, active, start.Add(30*time.Second))
+	assert.Len(t, e.AlertMgr.ListActive(), 1)
+	e.sweepLogRates(ctx, active, start.Add(2*time.Minute))
+	assert.Empty(t, e.AlertMgr.ListActive())
+	e.lock.Lock()
+	assert.Empty(t, e.rates)
+	e.lock.Unlock()
+}
+
+func TestSweepDropsCountersOfInactiveRules(t *testing.T) {
+	rule := alertmodel.AlertRule{ID: "error-burst", Type: "log", Threshold: &alertmodel.Threshold{Count: 3, Window: "1m"}}
+	e, _, _ := newTestEvaluator(t)
+	e.countLogMatch(rule, &model.Meta{EndpointID: "host-1"}, time.Now().UTC())
+
+	e.sweepLogRates(context.Background(), nil, time.Now().UTC())
+	e.lock.Lock()
+	assert.Empty(t, e.rates)
+	e.lock.Unlock()
+}
@@ -0,0 +1,100 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+// RetireRule forgets the evaluation state of a rule that was disabled or
+// deleted and resolves its open alerts, so it leaves nothing firing behind
+// and starts from a clean slate if it is enabled or restored again.
+func (e *Evaluator) RetireRule(ctx context.Context, ruleID, actor, comment string) {
+	prefix := ruleID + "|"
+
+	e.lock.Lock()
+	forgetKeys(e.history, prefix)
+	forgetKeys(e.firing, prefix)
+	forgetKeys(e.resolved, prefix)
+	forgetKeys(e.hits, prefix)
+	forgetKeys(e.lastFired, prefix)
+	forgetKeys(e.rates, prefix)
+	forgetKeys(e.metas, prefix)
+	e.lock.Unlock()
+
+	e.AlertMgr.ResolveRule(ctx, ruleID, actor, comment)
+}
+
+// retireInactiveRules retires the rules that still have evaluation state or
+// open alerts but are no longer active, such as rules disabled in the rule
+// file or deleted through another server. Rules are listed after the state
+// is collected, so a rule created in the meantime is never retired.
+func (e *Evaluator) retireInactiveRules(ctx context.Context) {
+	ids := e.AlertMgr.RuleIDs()
+	e.lock.Lock()
+	for _, keys := range []map[string]bool{keyRules(e.history), keyRules(e.firing), keyRules(e.lastFired), keyRules(e.rates)} {
+		for id := range keys {
+			ids[id] = true
+		}
+	}
+	e.lock.Unlock()
+	if len(ids) == 0 {
+		return
+	}
+
+	activeRules, err := e.store.GetActiveRules(ctx)
+	if err != nil {
+		utils.Error("Failed to fetch active rules: %v", err)
+		return
+	}
+	for _, rule := range activeRules {
+		delete(ids, rule.ID)
+	}
+	for id := range ids {
+		utils.Info("Retiring alert state of inactive rule %s", id)
+		e.RetireRule(ctx, id, "", "rule is no longer active")
+	}
+}
+
+// forgetKeys deletes the entries of a ruleID|... keyed map that start with
+// prefix.
+func forgetKeys[V any](m map[string]V, prefix string) {
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			delete(m, k)
+		}
+	}
+}
+
+// keyRules returns the rule IDs of a ruleID|... keyed map.
+func keyRules[V any](m map[string]V) map[string]bool {
+	ids := make(map[string]bool)
+	for k := range m {
+		if id, _, ok := strings.Cut(k, "|"); ok {
+			ids[id] = true
+		}
+	}
+	return ids
+}
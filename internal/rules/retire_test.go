@@ -0,0 +1,152 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/alerts"
+	"github.com/aaronlmathis/gosight-server/internal/core/events/dispatcher"
+	"github.com/aaronlmathis/gosight-server/internal/events"
+	"github.com/aaronlmathis/gosight-server/internal/store/alertstore"
+	"github.com/aaronlmathis/gosight-server/internal/store/eventstore"
+	"github.com/aaronlmathis/gosight-server/internal/store/rulestore"
+	"github.com/aaronlmathis/gosight-server/internal/websocket"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memAlertStore keeps the latest state of each alert. Methods the manager
+// doesn't use panic through the nil embedded interface.
+type memAlertStore struct {
+	alertstore.AlertStore
+	mu     sync.Mutex
+	alerts map[string]model.AlertInstance
+}
+
+func (s *memAlertStore) UpsertAlert(ctx context.Context, a *model.AlertInstance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts[a.ID] = *a
+	return nil
+}
+
+func (s *memAlertStore) ResolveAlert(ctx context.Context, ruleID, target string, resolvedAt time.Time) error {
+	return nil
+}
+
+func (s *memAlertStore) AddTimelineEntry(ctx context.Context, e alertmodel.TimelineEntry) error {
+	return nil
+}
+
+func newTestEvaluator(t *testing.T, rules ...alertmodel.AlertRule) (*Evaluator, *rulestore.MemoryRuleStore, *memAlertStore) {
+	es, err := eventstore.NewJSONEventStore("")
+	require.NoError(t, err)
+	alertStore := &memAlertStore{alerts: make(map[string]model.AlertInstance)}
+	mgr := alerts.NewManager(
+		events.NewEmitter(es, nil),
+		dispatcher.NewDispatcher(map[string]alertmodel.Route{}),
+		alertStore,
+		nil,
+		websocket.NewAlertsHub(nil),
+	)
+
+	ruleStore := rulestore.NewMemoryStore()
+	for _, r := range rules {
+		require.NoError(t, ruleStore.AddRule(context.Background(), r))
+	}
+	return NewEvaluator(ruleStore, mgr), ruleStore, alertStore
+}
+
+var cpuRule = alertmodel.AlertRule{
+	ID:         "high-cpu",
+	Type:       "metric",
+	Enabled:    true,
+	Level:      "critical",
+	Message:    "CPU above 90%",
+	Scope:      alertmodel.Scope{Namespace: "system", SubNamespace: "cpu", Metric: "usage_percent"},
+	Expression: alertmodel.Expression{Operator: ">", Value: 90},
+}
+
+func cpuSample(value float64) []model.Metric {
+	return []model.Metric{{
+		Namespace:    "System",
+		SubNamespace: "CPU",
+		Name:         "usage_percent",
+		DataType:     "gauge",
+		DataPoints:   []model.DataPoint{{Timestamp: time.Now().UTC(), Value: value}},
+	}}
+}
+
+func TestRetireRuleResolvesAlertsAndForgetsState(t *testing.T) {
+	ctx := context.Background()
+	e, _, alertStore := newTestEvaluator(t, cpuRule)
+	meta := &model.Meta{EndpointID: "host-1"}
+
+	e.EvaluateMetric(ctx, cpuSample(97), meta)
+	require.Len(t, e.AlertMgr.ListActive(), 1)
+	alert := e.AlertMgr.ListActive()[0]
+
+	e.RetireRule(ctx, cpuRule.ID, "alice", "rule disabled")
+
+	assert.Empty(t, e.AlertMgr.ListActive())
+	assert.Equal(t, alertmodel.StateResolved, alertStore.alerts[alert.ID].State)
+	e.lock.Lock()
+	assert.Empty(t, e.history)
+	assert.Empty(t, e.firing)
+	assert.Empty(t, e.lastFired)
+	e.lock.Unlock()
+
+	// Re-enabled, the rule starts over with a new alert.
+	e.EvaluateMetric(ctx, cpuSample(97), meta)
+	require.Len(t, e.AlertMgr.ListActive(), 1)
+	assert.NotEqual(t, alert.ID, e.AlertMgr.ListActive()[0].ID)
+}
+
+func TestInactiveRulesAreRetired(t *testing.T) {
+	ctx := context.Background()
+	other := cpuRule
+	other.ID = "very-high-cpu"
+	other.Expression.Value = 95
+	e, ruleStore, _ := newTestEvaluator(t, cpuRule, other)
+
+	e.EvaluateMetric(ctx, cpuSample(97), &model.Meta{EndpointID: "host-1"})
+	require.Len(t, e.AlertMgr.ListActive(), 2)
+
+	// Disabled behind the API's back, e.g. in the rule file.
+	disabled := cpuRule
+	disabled.Enabled = false
+	require.NoError(t, ruleStore.UpdateRule(ctx, disabled))
+	e.retireInactiveRules(ctx)
+
+	active := e.AlertMgr.ListActive()
+	require.Len(t, active, 1)
+	assert.Equal(t, "very-high-cpu", active[0].RuleID)
+	e.lock.Lock()
+	assert.Equal(t, map[string]bool{"very-high-cpu": true}, keyRules(e.firing))
+	e.lock.Unlock()
+}
@@ -0,0 +1,315 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/metricstore"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+// schedulerTick is how often the scheduler checks which rules are due.
+// It bounds the precision of eval_interval, not the evaluation rate.
+const schedulerTick = time.Second
+
+// Scheduler evaluates rules that declare an eval_interval on their own
+// schedule, querying the metric store for the latest values instead of
+// waiting for agent payloads. This keeps the cost of evaluating those rules
+// independent of how often agents push. Windowed rules (scope.window) need
+// the store's history, so they are always scheduled. Other rules without an
+// eval_interval are still evaluated inline by the Evaluator.
+type Scheduler struct {
+	ctx       context.Context
+	evaluator *Evaluator
+	metrics   metricstore.MetricStore
+
+	lock    sync.Mutex
+	lastRun map[string]time.Time // ruleID -> last evaluation
+}
+
+// NewScheduler creates a new Scheduler that feeds query results through
+// the given Evaluator.
+func NewScheduler(ctx context.Context, evaluator *Evaluator, metrics metricstore.MetricStore) *Scheduler {
+	return &Scheduler{
+		ctx:       ctx,
+		evaluator: evaluator,
+		metrics:   metrics,
+		lastRun:   make(map[string]time.Time),
+	}
+}
+
+// Run starts the scheduler and blocks until the context is cancelled.
+func (s *Scheduler) Run() {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	utils.Info("[rules] scheduler started")
+	for {
+		select {
+		case now := <-ticker.C:
+			s.runDue(now.UTC())
+		case <-s.ctx.Done():
+			utils.Info("[rules] scheduler stopped")
+			return
+		}
+	}
+}
+
+// runDue evaluates every active rule whose eval_interval has elapsed
+// since its last run.
+func (s *Scheduler) runDue(now time.Time) {
+	activeRules, err := s.evaluator.store.GetActiveRules(s.ctx)
+	if err != nil {
+		utils.Error("Failed to fetch active rules: %v", err)
+		return
+	}
+
+	seen := make(map[string]bool, len(activeRules))
+	for _, rule := range activeRules {
+		interval := ruleEvalInterval(rule)
+		if interval == 0 {
+			continue
+		}
+		seen[rule.ID] = true
+
+		s.lock.Lock()
+		due := now.Sub(s.lastRun[rule.ID]) >= interval
+		if due {
+			s.lastRun[rule.ID] = now
+		}
+		s.lock.Unlock()
+
+		if due {
+			s.EvaluateRule(s.ctx, rule)
+		}
+	}
+
+	s.evaluator.sweepLogRates(s.ctx, activeRules, now)
+
+	// Forget rules that were deleted, disabled or lost their interval.
+	s.lock.Lock()
+	for id := range s.lastRun {
+		if !seen[id] {
+			delete(s.lastRun, id)
+		}
+	}
+	s.lock.Unlock()
+
+	s.evaluator.retireInactiveRules(s.ctx)
+}
+
+// EvaluateRule runs a single scheduled evaluation of the rule against the
+// metric store. Each returned series is treated as one endpoint. Endpoints
+// with an open breach that the query no longer returns are evaluated as not
+// breached; see resolveMissing.
+func (s *Scheduler) EvaluateRule(ctx context.Context, rule alertmodel.AlertRule) {
+	switch {
+	case isWindowed(rule):
+		s.evaluateWindow(ctx, rule, time.Now().UTC())
+	case rule.Type == "metric":
+		rows, err := s.metrics.QueryInstant(ruleMetricName(rule), ruleFilters(rule))
+		if err != nil {
+			utils.Warn("Scheduled evaluation of rule %s failed: %v", rule.ID, err)
+			return
+		}
+		evaluated := make(map[string]bool, len(rows))
+		for _, row := range rows {
+			meta := metaFromLabels(row.Labels)
+			if !ruleMatchLabels(rule.Match, meta) {
+				continue
+			}
+			metric := metricFromRow(rule, row)
+			s.evaluator.evaluateMetricRule(ctx, rule, &metric, meta)
+			evaluated[meta.EndpointID] = true
+		}
+		s.resolveMissing(ctx, rule, evaluated, time.Now().UTC())
+	default:
+		utils.Debug("Rule %s of type %s has an eval_interval but is evaluated inline", rule.ID, rule.Type)
+	}
+}
+
+// evaluateWindow queries the rule's window from the metric store, reduces
+// each endpoint's samples with the rule's function and feeds the result
+// through the evaluator. absent() rules breach for every expected endpoint
+// without samples in the window; expected endpoints are the rule's explicit
+// endpoint IDs, or else those that reported the metric in a longer lookback.
+func (s *Scheduler) evaluateWindow(ctx context.Context, rule alertmodel.AlertRule, now time.Time) {
+	fn := strings.ToLower(rule.Expression.Function)
+	if !alertmodel.ValidFunction(fn) {
+		utils.Warn("Rule %s has unknown function %q", rule.ID, rule.Expression.Function)
+		return
+	}
+
+	window := alertmodel.ParseDuration(rule.Scope.Window)
+	step := windowStep(rule, window)
+	windowStart := now.Add(-window)
+	queryStart := windowStart
+	if fn == "absent" {
+		queryStart = now.Add(-window * absentLookbackFactor)
+	}
+
+	rows, err := s.metrics.QueryMultiRange([]string{ruleMetricName(rule)}, queryStart, now, step.String(), ruleFilters(rule))
+	if err != nil {
+		utils.Warn("Scheduled evaluation of rule %s failed: %v", rule.ID, err)
+		return
+	}
+
+	// endpointID -> series -> samples inside the window
+	inWindow := make(map[string]map[string][]sample)
+	metas := make(map[string]*model.Meta)
+	for _, row := range rows {
+		meta := metaFromLabels(row.Labels)
+		if !ruleMatchLabels(rule.Match, meta) {
+			continue
+		}
+		if _, ok := metas[meta.EndpointID]; !ok {
+			metas[meta.EndpointID] = meta
+			inWindow[meta.EndpointID] = make(map[string][]sample)
+		}
+		ts := time.UnixMilli(row.Timestamp).UTC()
+		if ts.Before(windowStart) {
+			continue
+		}
+		key := seriesKey(row.Labels)
+		inWindow[meta.EndpointID][key] = append(inWindow[meta.EndpointID][key], sample{ts: ts, value: row.Value})
+	}
+
+	if fn == "absent" {
+		for _, id := range rule.Match.EndpointIDs {
+			if _, ok := metas[id]; !ok {
+				metas[id] = &model.Meta{EndpointID: id, Labels: map[string]string{}}
+			}
+		}
+		evaluated := make(map[string]bool, len(metas))
+		for id, meta := range metas {
+			count := 0
+			for _, samples := range inWindow[id] {
+				count += len(samples)
+			}
+			metric := windowMetric(rule, float64(count), now, meta.Labels)
+			s.evaluator.applyBreach(ctx, rule, &metric, meta, count == 0)
+			evaluated[id] = true
+		}
+		s.resolveMissing(ctx, rule, evaluated, now)
+		return
+	}
+
+	evaluated := make(map[string]bool, len(inWindow))
+	for id, series := range inWindow {
+		value, ok := aggregateWindow(fn, series, window)
+		if !ok {
+			continue
+		}
+		metric := windowMetric(rule, value, now, metas[id].Labels)
+		s.evaluator.evaluateMetricRule(ctx, rule, &metric, metas[id])
+		evaluated[id] = true
+	}
+	s.resolveMissing(ctx, rule, evaluated, now)
+}
+
+// resolveMissing evaluates the open breaches of a rule on endpoints that
+// weren't evaluated in the latest run, such as an endpoint that stopped
+// reporting, as no longer breached. Their pending alerts clear and firing
+// ones resolve once keep_firing_for has passed, as if the condition had
+// stopped holding.
+func (s *Scheduler) resolveMissing(ctx context.Context, rule alertmodel.AlertRule, evaluated map[string]bool, now time.Time) {
+	prefix := rule.ID + "|"
+	missing := make(map[string]*model.Meta)
+	values := make(map[string]float64)
+
+	s.evaluator.lock.Lock()
+	for key, meta := range s.evaluator.metas {
+		id, ok := strings.CutPrefix(key, prefix)
+		if !ok || evaluated[id] {
+			continue
+		}
+		missing[id] = meta
+		if hist := s.evaluator.history[key]; len(hist) > 0 {
+			values[id] = getMetricValue(&hist[len(hist)-1])
+		}
+	}
+	s.evaluator.lock.Unlock()
+
+	for id, meta := range missing {
+		metric := windowMetric(rule, values[id], now, meta.Labels)
+		s.evaluator.applyBreach(ctx, rule, &metric, meta, false)
+	}
+}
+
+// ruleFilters converts a rule's match criteria into metric store label filters.
+// Multiple endpoint IDs cannot be expressed as an exact label match, so they
+// are left to ruleMatchLabels on the returned series.
+func ruleFilters(rule alertmodel.AlertRule) map[string]string {
+	filters := make(map[string]string, len(rule.Match.Labels)+1)
+	for k, v := range rule.Match.Labels {
+		filters[k] = v
+	}
+	if len(rule.Match.EndpointIDs) == 1 {
+		filters["endpoint_id"] = rule.Match.EndpointIDs[0]
+	}
+	return filters
+}
+
+// metaFromLabels rebuilds the identifying parts of model.Meta from the
+// labels stored alongside a series, so alerts raised from store queries
+// carry the same scope, target and labels as those raised from payloads.
+func metaFromLabels(labels map[string]string) *model.Meta {
+	meta := &model.Meta{
+		AgentID:     labels["agent_id"],
+		HostID:      labels["host_id"],
+		EndpointID:  labels["endpoint_id"],
+		Hostname:    labels["hostname"],
+		ContainerID: labels["container_id"],
+		Labels:      make(map[string]string, len(labels)),
+	}
+	for k, v := range labels {
+		if k == "__name__" {
+			continue
+		}
+		meta.Labels[k] = v
+	}
+	return meta
+}
+
+// metricFromRow wraps a store row in a single-point gauge metric for the rule.
+func metricFromRow(rule alertmodel.AlertRule, row model.MetricRow) model.Metric {
+	dp := model.DataPoint{
+		Value:      row.Value,
+		Attributes: row.Labels,
+	}
+	if row.Timestamp > 0 {
+		dp.Timestamp = time.UnixMilli(row.Timestamp).UTC()
+	}
+	return model.Metric{
+		Namespace:    rule.Scope.Namespace,
+		SubNamespace: rule.Scope.SubNamespace,
+		Name:         rule.Scope.Metric,
+		DataType:     "gauge",
+		DataPoints:   []model.DataPoint{dp},
+	}
+}
@@ -0,0 +1,224 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rules
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/metricstore"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMetrics serves the rows a test sets as the metric store's contents.
+// Methods the scheduler doesn't use panic through the nil embedded
+// interface.
+type fakeMetrics struct {
+	metricstore.MetricStore
+	mu      sync.Mutex
+	rows    []model.MetricRow
+	queries int
+}
+
+func (f *fakeMetrics) set(rows ...model.MetricRow) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rows = rows
+}
+
+func (f *fakeMetrics) QueryInstant(metric string, filters map[string]string) ([]model.MetricRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries++
+	return append([]model.MetricRow(nil), f.rows...), nil
+}
+
+func (f *fakeMetrics) QueryMultiRange(metrics []string, start, end time.Time, step string, filters map[string]string) ([]model.MetricRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries++
+	var rows []model.MetricRow
+	for _, r := range f.rows {
+		ts := time.UnixMilli(r.Timestamp)
+		if !ts.Before(start) && !ts.After(end) {
+			rows = append(rows, r)
+		}
+	}
+	return rows, nil
+}
+
+func cpuRow(endpointID string, value float64, at time.Time) model.MetricRow {
+	return model.MetricRow{
+		Value:     value,
+		Timestamp: at.UnixMilli(),
+		Labels:    map[string]string{"endpoint_id": endpointID},
+	}
+}
+
+func newTestScheduler(t *testing.T, rules ...alertmodel.AlertRule) (*Scheduler, *fakeMetrics) {
+	e, _, _ := newTestEvaluator(t, rules...)
+	metrics := &fakeMetrics{}
+	return NewScheduler(context.Background(), e, metrics), metrics
+}
+
+// firingEndpoints returns the endpoints a rule has open alerts on.
+func firingEndpoints(e *Evaluator, ruleID string) []string {
+	var ids []string
+	for _, a := range e.AlertMgr.ListActive() {
+		if a.RuleID == ruleID {
+			ids = append(ids, a.Target)
+		}
+	}
+	return ids
+}
+
+func TestScheduledRuleResolvesEndpointsMissingFromResults(t *testing.T) {
+	ctx := context.Background()
+	instant := cpuRule
+	instant.Options.EvalInterval = "1m"
+	windowed := cpuRule
+	windowed.Scope.Window = "5m"
+	windowed.Expression.Function = "avg"
+	kept := instant
+	kept.Options.KeepFiringFor = "1h"
+
+	tests := []struct {
+		name      string
+		rule      alertmodel.AlertRule
+		stillOpen bool
+	}{
+		{"instant query", instant, false},
+		{"window query", windowed, false},
+		{"keep_firing_for holds", kept, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, metrics := newTestScheduler(t, tt.rule)
+			now := time.Now().UTC()
+			metrics.set(cpuRow("host-1", 97, now), cpuRow("host-2", 97, now))
+			s.EvaluateRule(ctx, tt.rule)
+			require.ElementsMatch(t, []string{"host-1", "host-2"}, firingEndpoints(s.evaluator, tt.rule.ID))
+
+			// host-1 stops reporting.
+			metrics.set(cpuRow("host-2", 97, now))
+			s.EvaluateRule(ctx, tt.rule)
+			if tt.stillOpen {
+				assert.ElementsMatch(t, []string{"host-1", "host-2"}, firingEndpoints(s.evaluator, tt.rule.ID))
+				return
+			}
+			assert.Equal(t, []string{"host-2"}, firingEndpoints(s.evaluator, tt.rule.ID))
+			s.evaluator.lock.Lock()
+			assert.NotContains(t, s.evaluator.history, tt.rule.ID+"|host-1")
+			assert.NotContains(t, s.evaluator.metas, tt.rule.ID+"|host-1")
+			s.evaluator.lock.Unlock()
+		})
+	}
+}
+
+func TestScheduledRuleClearsPendingEndpointMissingFromResults(t *testing.T) {
+	ctx := context.Background()
+	rule := cpuRule
+	rule.Options.EvalInterval = "1m"
+	rule.Options.For = "10m"
+	s, metrics := newTestScheduler(t, rule)
+
+	metrics.set(cpuRow("host-1", 97, time.Now().UTC()))
+	s.EvaluateRule(ctx, rule)
+	s.evaluator.lock.Lock()
+	require.Len(t, s.evaluator.history[rule.ID+"|host-1"], 1, "pending")
+	s.evaluator.lock.Unlock()
+
+	metrics.set()
+	s.EvaluateRule(ctx, rule)
+	s.evaluator.lock.Lock()
+	assert.Empty(t, s.evaluator.history)
+	s.evaluator.lock.Unlock()
+}
+
+func TestRuleEvalInterval(t *testing.T) {
+	windowed := cpuRule
+	windowed.Scope.Window = "5m"
+	explicit := windowed
+	explicit.Options.EvalInterval = "2m"
+	scheduled := cpuRule
+	scheduled.Options.EvalInterval = "15s"
+
+	tests := []struct {
+		name string
+		rule alertmodel.AlertRule
+		want time.Duration
+	}{
+		{"inline", cpuRule, 0},
+		{"eval_interval", scheduled, 15 * time.Second},
+		{"window default", windowed, defaultWindowEvalInterval},
+		{"window with eval_interval", explicit, 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ruleEvalInterval(tt.rule))
+		})
+	}
+}
+
+func TestSchedulerRunsRulesOnTheirInterval(t *testing.T) {
+	rule := cpuRule
+	rule.Options.EvalInterval = "1m"
+	inline := cpuRule
+	inline.ID = "inline-cpu"
+	s, metrics := newTestScheduler(t, rule, inline)
+	metrics.set(cpuRow("host-1", 97, time.Now().UTC()))
+
+	start := time.Now().UTC()
+	tests := []struct {
+		at      time.Duration
+		queries int
+	}{
+		{0, 1},
+		{30 * time.Second, 1},
+		{59 * time.Second, 1},
+		{60 * time.Second, 2},
+		{90 * time.Second, 2},
+		{2 * time.Minute, 3},
+	}
+	for _, tt := range tests {
+		s.runDue(start.Add(tt.at))
+		metrics.mu.Lock()
+		assert.Equal(t, tt.queries, metrics.queries, "after %s", tt.at)
+		metrics.mu.Unlock()
+	}
+	assert.Equal(t, []string{"host-1"}, firingEndpoints(s.evaluator, rule.ID))
+	assert.Empty(t, firingEndpoints(s.evaluator, inline.ID), "rules without an interval aren't scheduled")
+}
+
+func TestPushedMetricsSkipScheduledRules(t *testing.T) {
+	rule := cpuRule
+	rule.Options.EvalInterval = "1m"
+	e, _, _ := newTestEvaluator(t, rule)
+
+	e.EvaluateMetric(context.Background(), cpuSample(97), &model.Meta{EndpointID: "host-1"})
+	assert.Empty(t, e.AlertMgr.ListActive())
+}
@@ -26,11 +26,13 @@ import (
 	"context"
 	"time"
 
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
 	"github.com/aaronlmathis/gosight-shared/model"
 )
 
 // AlertStore is an interface for managing alert instances in the database.
-// It provides methods to upsert, resolve, and list active and historical alerts.
+// It provides methods to upsert, resolve, and list active and historical alerts,
+// and to keep the timeline of state changes of each alert.
 // The interface is designed to be implemented by different database backends,
 // allowing for flexibility in the storage solution used by the application.
 
@@ -43,4 +45,6 @@ type AlertStore interface {
 	ListAlertsFiltered(ctx context.Context, q model.AlertQuery) ([]model.AlertInstance, error)
 	CountAlertsFiltered(ctx context.Context, q model.AlertQuery) (int, error)
 	GetByID(ctx context.Context, id string) (model.AlertInstance, error)
+	AddTimelineEntry(ctx context.Context, e alertmodel.TimelineEntry) error
+	ListTimeline(ctx context.Context, alertID string) ([]alertmodel.TimelineEntry, error)
 }
@@ -38,6 +38,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
 	"github.com/aaronlmathis/gosight-shared/model"
 	"github.com/google/uuid"
 )
@@ -226,7 +227,7 @@ func (s *PGAlertStore) ResolveAlert(ctx context.Context, ruleID, target string,
 		UPDATE alerts SET
 			state = 'resolved',
 			resolved_at = $1
-		WHERE rule_id = $2 AND target = $3 AND state = 'firing'
+		WHERE rule_id = $2 AND target = $3 AND state IN ('firing', 'silenced', 'inhibited')
 	`, resolvedAt, ruleID, target)
 	return err
 }
@@ -238,7 +239,7 @@ func (s *PGAlertStore) ListActiveAlerts(ctx context.Context) ([]model.AlertInsta
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT id, rule_id, state, previous, scope, target, first_fired, last_fired,
 			last_ok, resolved_at, last_value, level, message, labels
-		FROM alerts WHERE state = 'firing'
+		FROM alerts WHERE state IN ('firing', 'silenced', 'inhibited')
 	`)
 	if err != nil {
 		return nil, err
@@ -412,3 +413,35 @@ func (s *PGAlertStore) CountAlertsFiltered(ctx context.Context, q model.AlertQue
 	err := s.db.QueryRowContext(ctx, query, args...).Scan(&count)
 	return count, err
 }
+
+// AddTimelineEntry appends an entry to an alert's timeline.
+func (s *PGAlertStore) AddTimelineEntry(ctx context.Context, e alertmodel.TimelineEntry) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO alert_timeline (alert_id, timestamp, action, state, actor, assignee, comment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, e.AlertID, e.Timestamp, e.Action, e.State, e.Actor, e.Assignee, e.Comment)
+	return err
+}
+
+// ListTimeline returns the timeline of an alert, oldest entry first.
+func (s *PGAlertStore) ListTimeline(ctx context.Context, alertID string) ([]alertmodel.TimelineEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT alert_id, timestamp, action, state, actor, assignee, comment
+		FROM alert_timeline WHERE alert_id = $1
+		ORDER BY timestamp, id
+	`, alertID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []alertmodel.TimelineEntry
+	for rows.Next() {
+		var e alertmodel.TimelineEntry
+		if err := rows.Scan(&e.AlertID, &e.Timestamp, &e.Action, &e.State, &e.Actor, &e.Assignee, &e.Comment); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
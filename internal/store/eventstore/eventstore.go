@@ -26,17 +26,31 @@ package eventstore
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/aaronlmathis/gosight-shared/model"
 )
 
+var (
+	// ErrEventNotFound is returned when no event has the requested ID.
+	ErrEventNotFound = errors.New("event not found")
+
+	// ErrInvalidCursor is returned for a cursor that wasn't issued by
+	// QueryEvents.
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
 // EventStore is an interface for storing and retrieving events.
-// It provides methods to add events and get recent events.
+// It provides methods to add events, get recent events, page through and
+// search events, look them up by ID and prune old ones.
 // The implementation of this interface should handle the actual storage
 // and retrieval of events, whether it's in memory, a database, or any other
 // storage mechanism.
-
 type EventStore interface {
 	AddEvent(ctx context.Context, e model.EventEntry) error
 	GetRecentEvents(ctx context.Context, filter model.EventFilter) ([]model.EventEntry, error)
+	QueryEvents(ctx context.Context, q EventQuery) (EventPage, error)
+	GetByID(ctx context.Context, id string) (model.EventEntry, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
 }
@@ -1,27 +1,36 @@
 package eventstore
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/google/uuid"
 )
 
 // JSONEventStore is a file-backed implementation of the EventStore interface.
-// It stores events in a JSON file and loads them into memory on startup.
-// The events are stored in a slice, and the maximum number of events is limited
-// to a specified value. When the limit is reached, the oldest event is dropped
-// to make room for the new one. The store is thread-safe and uses a read-write
-// mutex to ensure that multiple goroutines can read from the store concurrently,
-// while only one goroutine can write to the store at a time.
-
+// It stores events in a JSON Lines file, one event per line, and loads them
+// into memory on startup. New events are appended to the file, so adding an
+// event doesn't rewrite it; the file is only rewritten when DeleteOlderThan
+// removes events. Files in the earlier format, a single JSON array, are
+// converted on load. With an empty path events are kept in memory only.
+// The store is thread-safe and uses a read-write mutex to ensure that
+// multiple goroutines can read from the store concurrently, while only one
+// goroutine can write to the store at a time.
 type JSONEventStore struct {
 	path string
 	lock sync.RWMutex
 	data []model.EventEntry
+	file *os.File // append handle; nil when in memory only
 }
 
 // NewJSONEventStore creates a new JSONEventStore instance.
@@ -32,8 +41,17 @@ func NewJSONEventStore(path string) (*JSONEventStore, error) {
 		path: path,
 		data: []model.EventEntry{},
 	}
-
-	_ = s.load()
+	if path == "" {
+		return s, nil
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	s.file = f
 	return s, nil
 }
 
@@ -50,48 +68,199 @@ func (s *JSONEventStore) GetByID(ctx context.Context, id string) (model.EventEnt
 	}
 
 	// Return error if event is not found
-	return model.EventEntry{}, fmt.Errorf("event with ID %s not found", id)
+	return model.EventEntry{}, ErrEventNotFound
 }
 
-// load reads the events from the JSON file and unmarshals them into the data slice.
-// If the file does not exist, it returns nil without an error.
+// load reads the events from the file. A missing file is not an error and
+// lines that don't decode, such as one cut short by a crash, are skipped.
+// A file holding a JSON array is converted to JSON Lines.
 func (s *JSONEventStore) load() error {
-	f, err := os.ReadFile(s.path)
+	raw, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
 	if err != nil {
-		return nil // okay if not found
+		return err
 	}
-	_ = json.Unmarshal(f, &s.data)
-	return nil
+
+	trimmed := bytes.TrimSpace(raw)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &s.data); err != nil {
+			return err
+		}
+		for i := range s.data {
+			if s.data[i].ID == "" {
+				s.data[i].ID = uuid.NewString()
+			}
+		}
+		utils.Info("Converting event store %s to JSON Lines", s.path)
+		return s.rewrite()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e model.EventEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			utils.Warn("Skipping unreadable event in %s: %v", s.path, err)
+			continue
+		}
+		s.data = append(s.data, e)
+	}
+	return scanner.Err()
 }
 
-// save writes the events to the JSON file.
-// It marshals the data slice into JSON format and writes it to the file.
-func (s *JSONEventStore) save() {
-	data, _ := json.MarshalIndent(s.data, "", "  ")
-	_ = os.WriteFile(s.path, data, 0644)
+// rewrite replaces the file with the events in memory, atomically through
+// a temporary file, and reopens the append handle. The caller must hold the
+// write lock, or own the store exclusively.
+func (s *JSONEventStore) rewrite() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".events-*.jsonl")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, e := range s.data {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	if s.file != nil {
+		s.file.Close()
+		f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			s.file = nil
+			return err
+		}
+		s.file = f
+	}
+	return nil
 }
 
 // AddEvent adds a new event to the store.
-// It appends the event to the data slice and saves the updated slice to the file.
+// It appends the event to the data slice and to the file. Events without
+// an ID are given one.
 func (s *JSONEventStore) AddEvent(ctx context.Context, e model.EventEntry) error {
+	if e.ID == "" {
+		e.ID = uuid.NewString()
+	}
+
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	if s.file != nil {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := s.file.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
 	s.data = append(s.data, e)
-	s.save()
-
 	return nil
 }
 
-// QueryEvents retrieves events from the store based on the provided filter.
-// It iterates through the data slice in reverse order and applies the filter criteria.
-// The results are returned as a slice of EventEntry structs.
+// QueryEvents returns a page of the events matching the query.
+func (s *JSONEventStore) QueryEvents(ctx context.Context, q EventQuery) (EventPage, error) {
+	var (
+		cursorTS time.Time
+		cursorID string
+		err      error
+	)
+	if q.Cursor != "" {
+		if cursorTS, cursorID, err = DecodeCursor(q.Cursor); err != nil {
+			return EventPage{}, err
+		}
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+
+	s.lock.RLock()
+	var matched []model.EventEntry
+	for _, e := range s.data {
+		if !q.Matches(e) {
+			continue
+		}
+		if q.Cursor != "" && !q.AfterCursor(e, cursorTS, cursorID) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+	s.lock.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		if q.Ascending() {
+			return Before(matched[i], matched[j])
+		}
+		return Before(matched[j], matched[i])
+	})
+
+	page := EventPage{Events: matched}
+	if len(matched) > limit {
+		page.Events = matched[:limit]
+		page.NextCursor = EncodeCursor(page.Events[limit-1])
+	}
+	return page, nil
+}
+
+// DeleteOlderThan removes the events with a timestamp before the given time
+// and rewrites the file without them.
+func (s *JSONEventStore) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	kept := make([]model.EventEntry, 0, len(s.data))
+	for _, e := range s.data {
+		if !e.Timestamp.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	removed := int64(len(s.data) - len(kept))
+	if removed == 0 {
+		return 0, nil
+	}
+
+	previous := s.data
+	s.data = kept
+	if s.path != "" {
+		if err := s.rewrite(); err != nil {
+			s.data = previous
+			return 0, err
+		}
+	}
+	return removed, nil
+}
+
+// GetRecentEvents retrieves events from the store based on the provided filter.
+// It applies the filter criteria and returns a slice of EventEntry structs.
 // The filter can include various criteria such as level, type, category,
 // source, scope, target, and time range.
-// QueryEvents retrieves events from the store based on the provided filter.
-// It applies the filter criteria and returns a slice of EventEntry structs.
 func (s *JSONEventStore) GetRecentEvents(ctx context.Context, filter model.EventFilter) ([]model.EventEntry, error) {
-	fmt.Printf("[DEBUG] Querying events with HostID = %s\n", filter.HostID)
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
@@ -99,44 +268,10 @@ func (s *JSONEventStore) GetRecentEvents(ctx context.Context, filter model.Event
 
 	// Iterate through events and apply filter criteria
 	for _, e := range s.data {
-		if filter.Level != "" && e.Level != filter.Level {
-			continue
-		}
-		if filter.Type != "" && e.Type != filter.Type {
-			continue
-		}
-		if filter.Category != "" && e.Category != filter.Category {
-			continue
-		}
-		if filter.Scope != "" && e.Scope != filter.Scope {
-			continue
-		}
-		if filter.Target != "" && e.Target != filter.Target {
-			continue
-		}
-		if filter.Source != "" && !containsIgnoreCase(e.Source, filter.Source) {
-			continue
-		}
-		if filter.Contains != "" && !containsIgnoreCase(e.Message, filter.Contains) {
-			continue
-		}
-		if filter.Start != nil && e.Timestamp.Before(*filter.Start) {
-			continue
-		}
-		if filter.End != nil && e.Timestamp.After(*filter.End) {
-			continue
-		}
-		if filter.EndpointID != "" && e.EndpointID != filter.EndpointID {
+		if !MatchesFilter(filter, e) {
 			continue
 		}
 
-		// HostID filter
-		if filter.HostID != "" {
-			if e.Meta == nil || e.Meta["host_id"] != filter.HostID {
-				continue
-			}
-		}
-
 		// Add event to results
 		result = append(result, e)
 
@@ -149,53 +284,50 @@ func (s *JSONEventStore) GetRecentEvents(ctx context.Context, filter model.Event
 	return result, nil
 }
 
-// containsIgnoreCase checks if the haystack string contains the needle string,
-// ignoring case differences. It returns true if the needle is found in the
-// haystack, and false otherwise. If the needle is empty, it returns true.
-func containsIgnoreCase(haystack, needle string) bool {
-	return len(needle) == 0 || (len(haystack) >= len(needle) &&
-		containsFold(haystack, needle))
-}
-
-// containsFold checks if the haystack string contains the needle string,
-// ignoring case differences. It uses a case-insensitive comparison to check
-// for the presence of the needle in the haystack. It returns true if the
-// needle is found in the haystack, and false otherwise. If the needle is
-// empty, it returns true.
-func containsFold(s, substr string) bool {
-	return len(substr) == 0 ||
-		len(s) >= len(substr) &&
-			stringContainsFold(s, substr)
-}
-
-// stringContainsFold checks if the haystack string contains the needle string,
-// ignoring case differences. It uses a case-insensitive comparison to check
-// for the presence of the needle in the haystack. It returns true if the
-// needle is found in the haystack, and false otherwise. If the needle is
-// empty, it returns true.
-func stringContainsFold(s, substr string) bool {
-	s, substr = toLower(s), toLower(substr)
-	return contains(s, substr)
-}
-
-// toLower converts the input string to lowercase.
-
-func toLower(s string) string {
-	return stringFold(s)
-}
-
-// stringFold converts the input string to a normalized form.
-func stringFold(s string) string {
-	return string([]rune(s))
-}
-
-// contains checks if the haystack string contains the needle string.
-func contains(s, substr string) bool {
-	return len(substr) == 0 || (len(s) >= len(substr) &&
-		index(s, substr) >= 0)
+// MatchesFilter reports whether an event passes the criteria of an
+// EventFilter as GetRecentEvents applies them: source and contains match
+// substrings ignoring case, the other fields exactly. The limit and sort
+// order are not considered.
+func MatchesFilter(filter model.EventFilter, e model.EventEntry) bool {
+	if filter.Level != "" && e.Level != filter.Level {
+		return false
+	}
+	if filter.Type != "" && e.Type != filter.Type {
+		return false
+	}
+	if filter.Category != "" && e.Category != filter.Category {
+		return false
+	}
+	if filter.Scope != "" && e.Scope != filter.Scope {
+		return false
+	}
+	if filter.Target != "" && e.Target != filter.Target {
+		return false
+	}
+	if filter.Source != "" && !containsIgnoreCase(e.Source, filter.Source) {
+		return false
+	}
+	if filter.Contains != "" && !containsIgnoreCase(e.Message, filter.Contains) {
+		return false
+	}
+	if filter.Start != nil && e.Timestamp.Before(*filter.Start) {
+		return false
+	}
+	if filter.End != nil && e.Timestamp.After(*filter.End) {
+		return false
+	}
+	if filter.EndpointID != "" && e.EndpointID != filter.EndpointID {
+		return false
+	}
+	// HostID filter
+	if filter.HostID != "" && e.Meta["host_id"] != filter.HostID {
+		return false
+	}
+	return true
 }
 
-// index returns the index of the first occurrence of substr in s.
-func index(s, substr string) int {
-	return len(s) - len([]rune(s)) + len([]rune(substr))
+// containsIgnoreCase checks if the haystack string contains the needle string,
+// ignoring case differences. If the needle is empty, it returns true.
+func containsIgnoreCase(haystack, needle string) bool {
+	return strings.Contains(strings.ToLower(haystack), strings.ToLower(needle))
 }
@@ -0,0 +1,552 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package localeventstore implements eventstore.EventStore as an embedded,
+// append-only store for single-node deployments that don't run PostgreSQL.
+//
+// Events are appended to segment files in a directory. The newest segment
+// takes new events until it reaches the configured size or age; it is then
+// synced, sealed and given a sidecar index of each event's time, ID,
+// category and position, so startup reads the indexes rather than every
+// event. Queries narrow the segments and events by time and category from
+// the index before reading events from disk. Retention deletes segments
+// whose events are all expired and compacts the ones only partly expired.
+package localeventstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/store/eventstore"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/google/uuid"
+)
+
+// Defaults for Options left at zero.
+const (
+	DefaultSegmentSize     = 64 << 20
+	DefaultSegmentDuration = 24 * time.Hour
+)
+
+// Options sets when the active segment is sealed and a new one started.
+type Options struct {
+	SegmentSize     int64         // bytes; defaults to 64 MiB
+	SegmentDuration time.Duration // defaults to 24h
+}
+
+// location is where an event is stored.
+type location struct {
+	seg   *segment
+	index int
+}
+
+// LocalEventStore is a segment-based implementation of the
+// eventstore.EventStore interface.
+type LocalEventStore struct {
+	dir  string
+	opts Options
+
+	lock     sync.RWMutex
+	segments []*segment // oldest first; the last one is active
+	byID     map[string]location
+}
+
+// NewLocalEventStore opens the store in dir, creating the directory if
+// needed. Segments are recovered on open: a segment ending in a torn or
+// corrupt record, as left by a crash mid-write, is truncated to its last
+// complete event.
+func NewLocalEventStore(dir string, opts Options) (*LocalEventStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("local event store needs a directory")
+	}
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = DefaultSegmentSize
+	}
+	if opts.SegmentDuration <= 0 {
+		opts.SegmentDuration = DefaultSegmentDuration
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	s := &LocalEventStore{dir: dir, opts: opts, byID: make(map[string]location)}
+	if err := s.open(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// open loads the segments in the directory and makes sure there is an
+// active segment.
+func (s *LocalEventStore) open() error {
+	names, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	var seqs []uint64
+	for _, n := range names {
+		if strings.HasPrefix(n.Name(), ".tmp-") {
+			// Left behind by a compaction or index write that didn't finish
+			_ = os.Remove(filepath.Join(s.dir, n.Name()))
+			continue
+		}
+		if seq, ok := parseSegmentName(n.Name()); ok {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	for i, seq := range seqs {
+		seg, err := s.openSegment(seq, i < len(seqs)-1)
+		if err != nil {
+			return err
+		}
+		s.segments = append(s.segments, seg)
+		s.indexIDs(seg)
+	}
+
+	if len(s.segments) == 0 {
+		return s.roll(time.Now())
+	}
+	return nil
+}
+
+// openSegment opens an existing segment. Sealed segments load their index
+// when it is current; otherwise the segment is scanned, truncated after its
+// last valid record, and sealed segments get their index rewritten.
+func (s *LocalEventStore) openSegment(seq uint64, sealed bool) (*segment, error) {
+	seg := &segment{seq: seq, path: segmentPath(s.dir, seq), sealed: sealed}
+	f, err := os.OpenFile(seg.path, os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	seg.file = f
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	seg.size = info.Size()
+	seg.createdAt = info.ModTime().UnixNano()
+
+	if sealed && seg.loadIndex() {
+		return seg, nil
+	}
+	valid, err := seg.scan()
+	if err != nil {
+		return nil, err
+	}
+	if valid < seg.size {
+		utils.Warn("Event segment %s: discarding %d bytes after the last complete event", seg.path, seg.size-valid)
+		if err := f.Truncate(valid); err != nil {
+			return nil, err
+		}
+		seg.size = valid
+	}
+	if len(seg.entries) > 0 {
+		seg.createdAt = seg.minTS
+	}
+	if sealed {
+		if err := seg.writeIndex(); err != nil {
+			return nil, err
+		}
+	}
+	return seg, nil
+}
+
+// roll seals the active segment, if any, and starts a new one. The caller
+// must hold the write lock.
+func (s *LocalEventStore) roll(now time.Time) error {
+	var seq uint64 = 1
+	if n := len(s.segments); n > 0 {
+		active := s.segments[n-1]
+		if err := s.seal(active); err != nil {
+			return err
+		}
+		seq = active.seq + 1
+	}
+
+	seg := &segment{seq: seq, path: segmentPath(s.dir, seq), createdAt: now.UnixNano()}
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_RDWR|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	seg.file = f
+	s.segments = append(s.segments, seg)
+	return syncDir(s.dir)
+}
+
+// seal syncs a segment and writes its index.
+func (s *LocalEventStore) seal(seg *segment) error {
+	if seg.sealed {
+		return nil
+	}
+	if err := seg.file.Sync(); err != nil {
+		return err
+	}
+	if err := seg.writeIndex(); err != nil {
+		return err
+	}
+	seg.sealed = true
+	return nil
+}
+
+// indexIDs adds a segment's events to the ID lookup.
+func (s *LocalEventStore) indexIDs(seg *segment) {
+	for i, en := range seg.entries {
+		s.byID[en.ID] = location{seg: seg, index: i}
+	}
+}
+
+// AddEvent appends an event to the active segment, first starting a new
+// segment when the active one is full or old enough. Events without an ID
+// are given one.
+func (s *LocalEventStore) AddEvent(ctx context.Context, e model.EventEntry) error {
+	if e.ID == "" {
+		e.ID = uuid.NewString()
+	}
+	rec, err := encodeRecord(e)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	active := s.segments[len(s.segments)-1]
+	if len(active.entries) > 0 &&
+		(active.size+int64(len(rec)) > s.opts.SegmentSize || now.Sub(time.Unix(0, active.createdAt)) > s.opts.SegmentDuration) {
+		if err := s.roll(now); err != nil {
+			return err
+		}
+		active = s.segments[len(s.segments)-1]
+	}
+
+	if _, err := active.file.WriteAt(rec, active.size); err != nil {
+		// Don't leave a partial record in front of the next one
+		_ = active.file.Truncate(active.size)
+		return err
+	}
+	active.add(entry{TS: e.Timestamp.UnixNano(), ID: e.ID, Category: e.Category, Offset: active.size, Length: int32(len(rec) - headerSize)})
+	active.size += int64(len(rec))
+	s.byID[e.ID] = location{seg: active, index: len(active.entries) - 1}
+	return nil
+}
+
+// GetByID returns the event with the given ID.
+func (s *LocalEventStore) GetByID(ctx context.Context, id string) (model.EventEntry, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	loc, ok := s.byID[id]
+	if !ok {
+		return model.EventEntry{}, eventstore.ErrEventNotFound
+	}
+	return loc.seg.readRecord(loc.seg.entries[loc.index])
+}
+
+// candidate is an index entry that passed the index filters.
+type candidate struct {
+	seg *segment
+	en  entry
+}
+
+// candidates returns the entries in the time range and category, in
+// ascending or descending (timestamp, ID) order. The caller must hold the
+// read lock.
+func (s *LocalEventStore) candidates(start, end time.Time, category string, asc bool, keep func(entry) bool) []candidate {
+	var list []candidate
+	for _, seg := range s.segments {
+		if len(seg.entries) == 0 {
+			continue
+		}
+		if !start.IsZero() && seg.maxTS < start.UnixNano() {
+			continue
+		}
+		if !end.IsZero() && seg.minTS > end.UnixNano() {
+			continue
+		}
+		if category != "" && seg.categories[category] == 0 {
+			continue
+		}
+		for _, en := range seg.entries {
+			if !start.IsZero() && en.TS < start.UnixNano() {
+				continue
+			}
+			if !end.IsZero() && en.TS > end.UnixNano() {
+				continue
+			}
+			if category != "" && en.Category != category {
+				continue
+			}
+			if keep != nil && !keep(en) {
+				continue
+			}
+			list = append(list, candidate{seg: seg, en: en})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i].en, list[j].en
+		if a.TS != b.TS {
+			return (a.TS < b.TS) == asc
+		}
+		return (a.ID < b.ID) == asc
+	})
+	return list
+}
+
+// QueryEvents returns a page of the events matching the query.
+func (s *LocalEventStore) QueryEvents(ctx context.Context, q eventstore.EventQuery) (eventstore.EventPage, error) {
+	var keep func(entry) bool
+	if q.Cursor != "" {
+		ts, id, err := eventstore.DecodeCursor(q.Cursor)
+		if err != nil {
+			return eventstore.EventPage{}, err
+		}
+		keep = func(en entry) bool {
+			return q.AfterCursor(model.EventEntry{Timestamp: time.Unix(0, en.TS), ID: en.ID}, ts, id)
+		}
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = eventstore.DefaultQueryLimit
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var page eventstore.EventPage
+	for _, c := range s.candidates(q.Start, q.End, q.Category, q.Ascending(), keep) {
+		if err := ctx.Err(); err != nil {
+			return eventstore.EventPage{}, err
+		}
+		e, err := c.seg.readRecord(c.en)
+		if err != nil {
+			return eventstore.EventPage{}, err
+		}
+		if !q.Matches(e) {
+			continue
+		}
+		if len(page.Events) == limit {
+			page.NextCursor = eventstore.EncodeCursor(page.Events[limit-1])
+			break
+		}
+		page.Events = append(page.Events, e)
+	}
+	return page, nil
+}
+
+// GetRecentEvents retrieves events from the store based on the provided
+// filter, newest first unless the filter's sort order is "asc".
+func (s *LocalEventStore) GetRecentEvents(ctx context.Context, filter model.EventFilter) ([]model.EventEntry, error) {
+	var start, end time.Time
+	if filter.Start != nil {
+		start = *filter.Start
+	}
+	if filter.End != nil {
+		end = *filter.End
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var result []model.EventEntry
+	for _, c := range s.candidates(start, end, filter.Category, filter.SortOrder == "asc", nil) {
+		e, err := c.seg.readRecord(c.en)
+		if err != nil {
+			return nil, err
+		}
+		if !eventstore.MatchesFilter(filter, e) {
+			continue
+		}
+		result = append(result, e)
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// DeleteOlderThan removes the events with a timestamp before the given
+// time. Segments holding only such events are deleted; segments holding
+// some are compacted into a copy without them. The active segment is
+// sealed first if it holds any.
+func (s *LocalEventStore) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	cutoff := before.UnixNano()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if active := s.segments[len(s.segments)-1]; len(active.entries) > 0 && active.minTS < cutoff {
+		if err := s.roll(time.Now()); err != nil {
+			return 0, err
+		}
+	}
+
+	var removed int64
+	kept := make([]*segment, 0, len(s.segments))
+	for i, seg := range s.segments {
+		var err error
+		switch {
+		case !seg.sealed || len(seg.entries) == 0 || seg.minTS >= cutoff:
+			kept = append(kept, seg)
+		case seg.maxTS < cutoff:
+			if err = s.removeSegment(seg); err == nil {
+				removed += int64(len(seg.entries))
+			}
+		default:
+			var n int64
+			if n, err = s.compact(seg, cutoff); err == nil {
+				removed += n
+				kept = append(kept, seg)
+			}
+		}
+		if err != nil {
+			s.segments = append(kept, s.segments[i:]...)
+			return removed, err
+		}
+	}
+	s.segments = kept
+	return removed, nil
+}
+
+// removeSegment deletes a sealed segment and its index.
+func (s *LocalEventStore) removeSegment(seg *segment) error {
+	seg.file.Close()
+	if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	_ = os.Remove(seg.indexPath())
+	for _, en := range seg.entries {
+		delete(s.byID, en.ID)
+	}
+	return nil
+}
+
+// compact rewrites a sealed segment without its events older than cutoff.
+// The copy is written and synced under a temporary name and then renamed
+// over the segment, so a crash leaves either the old or the new segment;
+// the index is rewritten afterwards and rebuilt on open if it is stale.
+func (s *LocalEventStore) compact(seg *segment, cutoff int64) (int64, error) {
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	var (
+		entries []entry
+		off     int64
+	)
+	for _, en := range seg.entries {
+		if en.TS < cutoff {
+			continue
+		}
+		rec := make([]byte, headerSize+int(en.Length))
+		if _, err := seg.file.ReadAt(rec, en.Offset); err != nil {
+			tmp.Close()
+			return 0, err
+		}
+		if _, err := tmp.Write(rec); err != nil {
+			tmp.Close()
+			return 0, err
+		}
+		en.Offset = off
+		entries = append(entries, en)
+		off += int64(len(rec))
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), seg.path); err != nil {
+		return 0, err
+	}
+	if err := syncDir(s.dir); err != nil {
+		return 0, err
+	}
+
+	f, err := os.OpenFile(seg.path, os.O_RDWR, 0644)
+	if err != nil {
+		return 0, err
+	}
+	seg.file.Close()
+	seg.file = f
+
+	removed := int64(len(seg.entries) - len(entries))
+	for _, en := range seg.entries {
+		if en.TS < cutoff {
+			delete(s.byID, en.ID)
+		}
+	}
+	seg.reset()
+	for _, en := range entries {
+		seg.add(en)
+	}
+	seg.size = off
+	s.indexIDs(seg)
+	return removed, seg.writeIndex()
+}
+
+// Close syncs the active segment and closes the segment files.
+func (s *LocalEventStore) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var firstErr error
+	for _, seg := range s.segments {
+		if !seg.sealed {
+			if err := seg.file.Sync(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if err := seg.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// syncDir syncs a directory so that files created or renamed in it survive
+// a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
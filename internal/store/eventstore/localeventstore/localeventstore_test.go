@@ -0,0 +1,289 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package localeventstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/store/eventstore"
+	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var base = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// perSegment returns options that roll the active segment after n events
+// made by testEvent with IDs of idLen characters.
+func perSegment(t *testing.T, n int, idLen int) Options {
+	rec, err := encodeRecord(testEvent(fmt.Sprintf("%0*d", idLen, 0), base, "system"))
+	require.NoError(t, err)
+	return Options{SegmentSize: int64(n*len(rec) + len(rec)/2)}
+}
+
+func openStore(t *testing.T, dir string, opts Options) *LocalEventStore {
+	s, err := NewLocalEventStore(dir, opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func testEvent(id string, ts time.Time, category string) model.EventEntry {
+	return model.EventEntry{
+		ID:         id,
+		Timestamp:  ts,
+		Level:      "info",
+		Type:       "system",
+		Category:   category,
+		Source:     "agent",
+		Message:    "event " + id,
+		EndpointID: "host-web-1",
+		Meta:       map[string]string{"host_id": "web-1"},
+	}
+}
+
+// addEvents adds n events a second apart from start, IDs prefixed with prefix.
+func addEvents(t *testing.T, s *LocalEventStore, prefix string, start time.Time, n int) []string {
+	var ids []string
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("%s%02d", prefix, i)
+		require.NoError(t, s.AddEvent(context.Background(), testEvent(id, start.Add(time.Duration(i)*time.Second), "system")))
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// allIDs returns the IDs of every event, oldest first.
+func allIDs(t *testing.T, s *LocalEventStore) []string {
+	page, err := s.QueryEvents(context.Background(), eventstore.EventQuery{Order: "asc", Limit: 1000})
+	require.NoError(t, err)
+	var ids []string
+	for _, e := range page.Events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func segmentFiles(t *testing.T, dir, ext string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "events-*"+ext))
+	require.NoError(t, err)
+	return files
+}
+
+func TestTornLastRecordIsCut(t *testing.T) {
+	dir := t.TempDir()
+	s := openStore(t, dir, Options{})
+	ids := addEvents(t, s, "e", base, 3)
+	require.NoError(t, s.Close())
+
+	// A crash mid-write leaves half a record at the end of the active segment.
+	segs := segmentFiles(t, dir, segmentExt)
+	require.Len(t, segs, 1)
+	rec, err := encodeRecord(testEvent("torn", base.Add(time.Minute), "system"))
+	require.NoError(t, err)
+	f, err := os.OpenFile(segs[0], os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.Write(rec[:len(rec)/2])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s = openStore(t, dir, Options{})
+	assert.Equal(t, ids, allIDs(t, s))
+	_, err = s.GetByID(context.Background(), "torn")
+	assert.ErrorIs(t, err, eventstore.ErrEventNotFound)
+
+	// New events go after the last complete one.
+	require.NoError(t, s.AddEvent(context.Background(), testEvent("e03", base.Add(time.Hour), "system")))
+	require.NoError(t, s.Close())
+	s = openStore(t, dir, Options{})
+	assert.Equal(t, append(ids, "e03"), allIDs(t, s))
+}
+
+func TestCorruptLastRecordIsCut(t *testing.T) {
+	dir := t.TempDir()
+	s := openStore(t, dir, Options{})
+	addEvents(t, s, "e", base, 2)
+	require.NoError(t, s.Close())
+
+	// Flip a byte in the last record's payload so its checksum fails.
+	segs := segmentFiles(t, dir, segmentExt)
+	require.Len(t, segs, 1)
+	data, err := os.ReadFile(segs[0])
+	require.NoError(t, err)
+	data[len(data)-2] ^= 0xff
+	require.NoError(t, os.WriteFile(segs[0], data, 0644))
+
+	s = openStore(t, dir, Options{})
+	assert.Equal(t, []string{"e00"}, allIDs(t, s))
+}
+
+func TestSealedSegmentIndexIsRebuilt(t *testing.T) {
+	dir := t.TempDir()
+	opts := perSegment(t, 2, 3)
+	s := openStore(t, dir, opts)
+	ids := addEvents(t, s, "e", base, 9)
+	require.NoError(t, s.Close())
+
+	indexes := segmentFiles(t, dir, indexExt)
+	require.GreaterOrEqual(t, len(indexes), 3, "every sealed segment has an index")
+	// One index goes missing, one is cut short and one describes a segment
+	// of a different size.
+	require.NoError(t, os.Remove(indexes[0]))
+	require.NoError(t, os.WriteFile(indexes[1], []byte(`{"size":`), 0644))
+	require.NoError(t, os.WriteFile(indexes[2], []byte(`{"size":1,"entries":[]}`), 0644))
+
+	s = openStore(t, dir, opts)
+	assert.Equal(t, ids, allIDs(t, s))
+	for _, id := range ids {
+		e, err := s.GetByID(context.Background(), id)
+		require.NoError(t, err, id)
+		assert.Equal(t, "event "+id, e.Message)
+	}
+
+	// The indexes were written again and are used on the next open.
+	for _, seg := range s.segments {
+		if !seg.sealed {
+			continue
+		}
+		reloaded := &segment{path: seg.path, size: seg.size}
+		require.True(t, reloaded.loadIndex(), seg.path)
+		assert.Equal(t, seg.entries, reloaded.entries)
+	}
+}
+
+func TestCursorPagesAcrossSegments(t *testing.T) {
+	s := openStore(t, t.TempDir(), perSegment(t, 2, 3))
+	var want []string
+	// Pairs of events share a timestamp, so ties are broken by ID.
+	for i := 0; i < 11; i++ {
+		id := fmt.Sprintf("e%02d", i)
+		category := "system"
+		if i%4 == 3 {
+			category = "security"
+		}
+		require.NoError(t, s.AddEvent(context.Background(), testEvent(id, base.Add(time.Duration(i/2)*time.Second), category)))
+		want = append(want, id)
+	}
+	require.Greater(t, len(s.segments), 3)
+
+	page := func(q eventstore.EventQuery) []string {
+		var ids []string
+		for {
+			p, err := s.QueryEvents(context.Background(), q)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(p.Events), q.Limit)
+			for _, e := range p.Events {
+				ids = append(ids, e.ID)
+			}
+			if p.NextCursor == "" {
+				return ids
+			}
+			q.Cursor = p.NextCursor
+		}
+	}
+
+	assert.Equal(t, want, page(eventstore.EventQuery{Order: "asc", Limit: 3}))
+
+	var reversed []string
+	for i := len(want) - 1; i >= 0; i-- {
+		reversed = append(reversed, want[i])
+	}
+	assert.Equal(t, reversed, page(eventstore.EventQuery{Limit: 4}))
+
+	assert.Equal(t, []string{"e03", "e07"}, page(eventstore.EventQuery{Category: "security", Order: "asc", Limit: 1}))
+	assert.Equal(t, []string{"e04", "e05", "e06", "e07"}, page(eventstore.EventQuery{
+		Start: base.Add(2 * time.Second), End: base.Add(3 * time.Second), Order: "asc", Limit: 2,
+	}))
+	assert.Len(t, page(eventstore.EventQuery{HostID: "web-1", Limit: 5}), len(want))
+	assert.Empty(t, page(eventstore.EventQuery{HostID: "web-2", Limit: 5}))
+
+	_, err := s.QueryEvents(context.Background(), eventstore.EventQuery{Cursor: "not-a-cursor", Limit: 3})
+	assert.ErrorIs(t, err, eventstore.ErrInvalidCursor)
+}
+
+func TestRetentionDeletesWholeSegments(t *testing.T) {
+	dir := t.TempDir()
+	opts := perSegment(t, 2, 5)
+	s := openStore(t, dir, opts)
+	old := addEvents(t, s, "old", base, 6)
+	recent := addEvents(t, s, "new", base.Add(time.Hour), 5)
+	before := segmentFiles(t, dir, segmentExt)
+
+	removed, err := s.DeleteOlderThan(context.Background(), base.Add(time.Hour))
+	require.NoError(t, err)
+	assert.EqualValues(t, len(old), removed)
+	assert.Equal(t, recent, allIDs(t, s))
+	for _, id := range old {
+		_, err := s.GetByID(context.Background(), id)
+		assert.ErrorIs(t, err, eventstore.ErrEventNotFound, id)
+	}
+
+	// Segments holding only expired events are gone along with their
+	// indexes; the rest remain.
+	after := segmentFiles(t, dir, segmentExt)
+	assert.Less(t, len(after), len(before))
+	for _, seg := range before[:len(old)/2] {
+		assert.NoFileExists(t, seg)
+		assert.NoFileExists(t, segmentIndexPath(seg))
+	}
+
+	// The store reopens to the same events and keeps appending.
+	require.NoError(t, s.Close())
+	s = openStore(t, dir, opts)
+	assert.Equal(t, recent, allIDs(t, s))
+	require.NoError(t, s.AddEvent(context.Background(), testEvent("later", base.Add(2*time.Hour), "system")))
+	assert.Equal(t, append(recent, "later"), allIDs(t, s))
+}
+
+func TestRetentionCompactsPartlyExpiredSegment(t *testing.T) {
+	dir := t.TempDir()
+	opts := perSegment(t, 4, 3)
+	s := openStore(t, dir, opts)
+	addEvents(t, s, "e", base, 3)
+	addEvents(t, s, "f", base.Add(time.Hour), 5)
+
+	// The first segment holds both old and recent events.
+	require.Len(t, s.segments, 2)
+	first := s.segments[0]
+	require.Less(t, first.minTS, base.Add(time.Hour).UnixNano())
+	require.GreaterOrEqual(t, first.maxTS, base.Add(time.Hour).UnixNano())
+
+	removed, err := s.DeleteOlderThan(context.Background(), base.Add(time.Hour))
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, removed)
+	want := []string{"f00", "f01", "f02", "f03", "f04"}
+	assert.Equal(t, want, allIDs(t, s))
+	assert.FileExists(t, first.path)
+
+	require.NoError(t, s.Close())
+	s = openStore(t, dir, opts)
+	assert.Equal(t, want, allIDs(t, s))
+}
+
+func segmentIndexPath(segPath string) string {
+	return (&segment{path: segPath}).indexPath()
+}
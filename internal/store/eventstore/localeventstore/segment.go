@@ -0,0 +1,260 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package localeventstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aaronlmathis/gosight-shared/model"
+)
+
+// A segment file is a sequence of records, each an 8 byte header (payload
+// length and CRC-32 of the payload, big endian) followed by the event as
+// JSON. A record cut short or failing its checksum marks the end of the
+// valid data: everything after it is discarded when the segment is opened.
+const (
+	headerSize     = 8
+	segmentExt     = ".seg"
+	indexExt       = ".idx"
+	maxRecordBytes = 16 << 20
+)
+
+// errCorrupt is returned when a record fails its checksum or is truncated.
+var errCorrupt = errors.New("corrupt record")
+
+// entry locates one event in its segment. Entries are what queries filter
+// on before reading any event.
+type entry struct {
+	TS       int64  `json:"ts"` // unix nanoseconds
+	ID       string `json:"id"`
+	Category string `json:"cat,omitempty"`
+	Offset   int64  `json:"off"`
+	Length   int32  `json:"len"` // payload length
+}
+
+// segmentIndex is the sidecar index of a sealed segment. Size is the
+// segment's size when the index was written; an index whose size doesn't
+// match the segment is stale and rebuilt by scanning the segment.
+type segmentIndex struct {
+	Size    int64   `json:"size"`
+	Entries []entry `json:"entries"`
+}
+
+// segment is one segment file and its index. Only the newest segment is
+// appended to; the others are sealed and change only through compaction.
+type segment struct {
+	seq        uint64
+	path       string
+	file       *os.File
+	size       int64
+	createdAt  int64 // unix nanoseconds, for rolling by age
+	sealed     bool
+	minTS      int64
+	maxTS      int64
+	categories map[string]int
+	entries    []entry
+}
+
+// segmentPath returns the path of the segment with the given sequence
+// number.
+func segmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("events-%020d%s", seq, segmentExt))
+}
+
+// parseSegmentName returns the sequence number of a segment file name.
+func parseSegmentName(name string) (uint64, bool) {
+	if !strings.HasPrefix(name, "events-") || !strings.HasSuffix(name, segmentExt) {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, "events-"), segmentExt), 10, 64)
+	return seq, err == nil
+}
+
+// indexPath returns the path of the segment's sidecar index.
+func (s *segment) indexPath() string {
+	return strings.TrimSuffix(s.path, segmentExt) + indexExt
+}
+
+// add records an appended or recovered event in the segment's index.
+func (s *segment) add(en entry) {
+	if len(s.entries) == 0 || en.TS < s.minTS {
+		s.minTS = en.TS
+	}
+	if len(s.entries) == 0 || en.TS > s.maxTS {
+		s.maxTS = en.TS
+	}
+	if s.categories == nil {
+		s.categories = make(map[string]int)
+	}
+	s.categories[en.Category]++
+	s.entries = append(s.entries, en)
+}
+
+// reset clears the segment's index.
+func (s *segment) reset() {
+	s.entries, s.categories = nil, nil
+	s.minTS, s.maxTS = 0, 0
+}
+
+// encodeRecord frames an event as a segment record.
+func encodeRecord(e model.EventEntry) ([]byte, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > maxRecordBytes {
+		return nil, fmt.Errorf("event %s is too large (%d bytes)", e.ID, len(payload))
+	}
+	rec := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(rec[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(rec[4:8], crc32.ChecksumIEEE(payload))
+	copy(rec[headerSize:], payload)
+	return rec, nil
+}
+
+// readRecord reads and decodes the event of an index entry.
+func (s *segment) readRecord(en entry) (model.EventEntry, error) {
+	var e model.EventEntry
+	rec := make([]byte, headerSize+int(en.Length))
+	if _, err := s.file.ReadAt(rec, en.Offset); err != nil {
+		return e, err
+	}
+	payload, err := checkRecord(rec)
+	if err != nil {
+		return e, fmt.Errorf("%s at %d: %w", s.path, en.Offset, err)
+	}
+	err = json.Unmarshal(payload, &e)
+	return e, err
+}
+
+// checkRecord verifies a record's header and checksum and returns its
+// payload.
+func checkRecord(rec []byte) ([]byte, error) {
+	if len(rec) < headerSize {
+		return nil, errCorrupt
+	}
+	n := binary.BigEndian.Uint32(rec[0:4])
+	if int(n) != len(rec)-headerSize {
+		return nil, errCorrupt
+	}
+	payload := rec[headerSize:]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(rec[4:8]) {
+		return nil, errCorrupt
+	}
+	return payload, nil
+}
+
+// scan rebuilds the segment's index by reading every record. It returns
+// the size of the valid data, which is less than the file size when the
+// segment ends in a torn or corrupt record.
+func (s *segment) scan() (int64, error) {
+	s.reset()
+	info, err := s.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+
+	var off int64
+	header := make([]byte, headerSize)
+	for off+headerSize <= size {
+		if _, err := s.file.ReadAt(header, off); err != nil {
+			return off, err
+		}
+		n := int64(binary.BigEndian.Uint32(header[0:4]))
+		if n == 0 || n > maxRecordBytes || off+headerSize+n > size {
+			break
+		}
+		rec := make([]byte, headerSize+n)
+		if _, err := s.file.ReadAt(rec, off); err != nil && err != io.EOF {
+			return off, err
+		}
+		payload, err := checkRecord(rec)
+		if err != nil {
+			break
+		}
+		var e model.EventEntry
+		if err := json.Unmarshal(payload, &e); err != nil {
+			break
+		}
+		s.add(entry{TS: e.Timestamp.UnixNano(), ID: e.ID, Category: e.Category, Offset: off, Length: int32(n)})
+		off += headerSize + n
+	}
+	return off, nil
+}
+
+// loadIndex reads the sidecar index of a sealed segment. It reports false
+// when the index is missing or stale.
+func (s *segment) loadIndex() bool {
+	raw, err := os.ReadFile(s.indexPath())
+	if err != nil {
+		return false
+	}
+	var idx segmentIndex
+	if err := json.Unmarshal(raw, &idx); err != nil || idx.Size != s.size {
+		return false
+	}
+	s.reset()
+	for _, en := range idx.Entries {
+		s.add(en)
+	}
+	return true
+}
+
+// writeIndex writes the sidecar index atomically through a temporary file.
+func (s *segment) writeIndex() error {
+	raw, err := json.Marshal(segmentIndex{Size: s.size, Entries: s.entries})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.indexPath(), raw)
+}
+
+// writeFileAtomic replaces path with data through a synced temporary file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
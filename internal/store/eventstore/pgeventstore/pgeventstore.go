@@ -4,12 +4,23 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/aaronlmathis/gosight-server/internal/store/eventstore"
 	"github.com/aaronlmathis/gosight-shared/model"
 	"github.com/google/uuid"
 )
 
+// eventColumns are the columns scanned by scanEvent, in order.
+const eventColumns = `id, timestamp, level, type, category, message,
+	source, scope, target, endpoint_id, meta`
+
+// searchDocument is the text searched by full-text queries. It must match
+// the expression of the idx_events_search index.
+const searchDocument = `to_tsvector('simple', coalesce(message, '') || ' ' || coalesce(meta::text, ''))`
+
 type PGEventStore struct {
 	db *sql.DB
 }
@@ -121,3 +132,119 @@ func (s *PGEventStore) GetRecentEvents(ctx context.Context, filter model.EventFi
 
 	return results, rows.Err()
 }
+
+// GetByID returns the event with the given ID.
+func (s *PGEventStore) GetByID(ctx context.Context, id string) (model.EventEntry, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.EventEntry{}, eventstore.ErrEventNotFound
+	}
+	row := s.db.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events WHERE id = $1`, id)
+	e, err := scanEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.EventEntry{}, eventstore.ErrEventNotFound
+	}
+	return e, err
+}
+
+// QueryEvents returns a page of the events matching the query. Pages are
+// read with keyset pagination on (timestamp, id), so deep pages cost no
+// more than the first.
+func (s *PGEventStore) QueryEvents(ctx context.Context, q eventstore.EventQuery) (eventstore.EventPage, error) {
+	query := `SELECT ` + eventColumns + ` FROM events WHERE 1=1`
+	args := []interface{}{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	for _, f := range []struct{ column, value string }{
+		{"level", q.Level},
+		{"type", q.Type},
+		{"category", q.Category},
+		{"scope", q.Scope},
+		{"target", q.Target},
+		{"source", q.Source},
+		{"endpoint_id", q.EndpointID},
+		{"meta->>'host_id'", q.HostID},
+	} {
+		if f.value != "" {
+			query += " AND " + f.column + " = " + arg(f.value)
+		}
+	}
+	if !q.Start.IsZero() {
+		query += " AND timestamp >= " + arg(q.Start)
+	}
+	if !q.End.IsZero() {
+		query += " AND timestamp <= " + arg(q.End)
+	}
+	if q.Search != "" {
+		query += " AND " + searchDocument + " @@ plainto_tsquery('simple', " + arg(q.Search) + ")"
+	}
+
+	order, cmp := "DESC", "<"
+	if q.Ascending() {
+		order, cmp = "ASC", ">"
+	}
+	if q.Cursor != "" {
+		ts, id, err := eventstore.DecodeCursor(q.Cursor)
+		if err != nil {
+			return eventstore.EventPage{}, err
+		}
+		if _, err := uuid.Parse(id); err != nil {
+			return eventstore.EventPage{}, eventstore.ErrInvalidCursor
+		}
+		query += fmt.Sprintf(" AND (timestamp, id) %s (%s, %s::uuid)", cmp, arg(ts), arg(id))
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = eventstore.DefaultQueryLimit
+	}
+	query += fmt.Sprintf(" ORDER BY timestamp %s, id %s LIMIT %s", order, order, arg(limit+1))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return eventstore.EventPage{}, err
+	}
+	defer rows.Close()
+
+	var page eventstore.EventPage
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return eventstore.EventPage{}, err
+		}
+		page.Events = append(page.Events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return eventstore.EventPage{}, err
+	}
+	if len(page.Events) > limit {
+		page.Events = page.Events[:limit]
+		page.NextCursor = eventstore.EncodeCursor(page.Events[limit-1])
+	}
+	return page, nil
+}
+
+// DeleteOlderThan removes the events with a timestamp before the given time.
+func (s *PGEventStore) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM events WHERE timestamp < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// scanEvent reads a row selected with eventColumns.
+func scanEvent(row interface{ Scan(...interface{}) error }) (model.EventEntry, error) {
+	var e model.EventEntry
+	var meta []byte
+	if err := row.Scan(&e.ID, &e.Timestamp, &e.Level, &e.Type, &e.Category, &e.Message,
+		&e.Source, &e.Scope, &e.Target, &e.EndpointID, &meta); err != nil {
+		return e, err
+	}
+	if len(meta) > 0 {
+		_ = json.Unmarshal(meta, &e.Meta)
+	}
+	return e, nil
+}
@@ -0,0 +1,158 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package eventstore
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/aaronlmathis/gosight-shared/model"
+)
+
+// DefaultQueryLimit is the page size of queries that don't set one.
+const DefaultQueryLimit = 100
+
+// EventQuery selects a page of events. Empty fields match every event.
+// Search is a full-text search: every word must appear in the message or
+// in a meta key or value, ignoring case. Events are ordered by timestamp,
+// newest first unless Order is "asc", with the ID breaking ties, and Cursor
+// continues from the page a previous query returned.
+type EventQuery struct {
+	Start      time.Time
+	End        time.Time
+	Level      string
+	Type       string
+	Category   string
+	Scope      string
+	Target     string
+	Source     string
+	EndpointID string
+	HostID     string // matches the host_id meta key
+	Search     string
+	Cursor     string
+	Limit      int
+	Order      string // asc or desc (default)
+}
+
+// EventPage is one page of a query. NextCursor is empty on the last page.
+type EventPage struct {
+	Events     []model.EventEntry `json:"events"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// Ascending reports whether the query pages from oldest to newest.
+func (q EventQuery) Ascending() bool {
+	return strings.EqualFold(q.Order, "asc")
+}
+
+// Matches reports whether an event passes the query's filters. The cursor
+// and limit are not considered.
+func (q EventQuery) Matches(e model.EventEntry) bool {
+	for _, c := range []struct{ want, got string }{
+		{q.Level, e.Level},
+		{q.Type, e.Type},
+		{q.Category, e.Category},
+		{q.Scope, e.Scope},
+		{q.Target, e.Target},
+		{q.Source, e.Source},
+		{q.EndpointID, e.EndpointID},
+		{q.HostID, e.Meta["host_id"]},
+	} {
+		if c.want != "" && c.want != c.got {
+			return false
+		}
+	}
+	if !q.Start.IsZero() && e.Timestamp.Before(q.Start) {
+		return false
+	}
+	if !q.End.IsZero() && e.Timestamp.After(q.End) {
+		return false
+	}
+	return matchesSearch(e, SearchTerms(q.Search))
+}
+
+// SearchTerms splits a full-text search into lower-cased words.
+func SearchTerms(search string) []string {
+	return strings.Fields(strings.ToLower(search))
+}
+
+// matchesSearch reports whether every term appears in the event's message
+// or meta.
+func matchesSearch(e model.EventEntry, terms []string) bool {
+	if len(terms) == 0 {
+		return true
+	}
+	var text strings.Builder
+	text.WriteString(strings.ToLower(e.Message))
+	for k, v := range e.Meta {
+		text.WriteString(" " + strings.ToLower(k) + " " + strings.ToLower(v))
+	}
+	haystack := text.String()
+	for _, t := range terms {
+		if !strings.Contains(haystack, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Before reports whether event a sorts before event b in ascending order.
+func Before(a, b model.EventEntry) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.Before(b.Timestamp)
+	}
+	return a.ID < b.ID
+}
+
+// EncodeCursor returns the cursor of the page that follows event e.
+func EncodeCursor(e model.EventEntry) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(e.Timestamp.UTC().Format(time.RFC3339Nano) + "|" + e.ID))
+}
+
+// DecodeCursor returns the timestamp and ID of the last event of the
+// previous page.
+func DecodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return t, id, nil
+}
+
+// AfterCursor reports whether event e comes after the cursor position in
+// the query's order.
+func (q EventQuery) AfterCursor(e model.EventEntry, ts time.Time, id string) bool {
+	last := model.EventEntry{Timestamp: ts, ID: id}
+	if q.Ascending() {
+		return Before(last, e)
+	}
+	return Before(e, last)
+}
@@ -0,0 +1,63 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package eventstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
+
+// retentionInterval is how often RunRetention prunes old events.
+const retentionInterval = time.Hour
+
+// RunRetention deletes events older than retention from the store right away
+// and then every hour, until ctx is done. A zero retention keeps events
+// forever.
+func RunRetention(ctx context.Context, store EventStore, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	prune := func() {
+		n, err := store.DeleteOlderThan(ctx, time.Now().Add(-retention))
+		if err != nil {
+			utils.Warn("Event retention failed: %v", err)
+			return
+		}
+		if n > 0 {
+			utils.Info("Deleted %d events older than %s", n, retention)
+		}
+	}
+
+	prune()
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prune()
+		}
+	}
+}
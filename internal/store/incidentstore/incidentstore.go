@@ -0,0 +1,42 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package incidentstore persists incidents built by correlating events.
+package incidentstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+)
+
+// ErrIncidentNotFound is returned when no incident has the requested ID.
+var ErrIncidentNotFound = errors.New("incident not found")
+
+// IncidentStore is an interface for storing and retrieving incidents.
+// UpsertIncident creates the incident or replaces the one with the same ID.
+// ListIncidents returns the newest incidents first.
+type IncidentStore interface {
+	UpsertIncident(ctx context.Context, inc alertmodel.Incident) error
+	GetIncident(ctx context.Context, id string) (alertmodel.Incident, error)
+	ListIncidents(ctx context.Context, filter alertmodel.IncidentFilter) ([]alertmodel.Incident, error)
+}
@@ -0,0 +1,146 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package incidentstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+)
+
+// JSONIncidentStore is an IncidentStore backed by a JSON file holding a list
+// of incidents. With an empty path the incidents are kept in memory only.
+type JSONIncidentStore struct {
+	path      string
+	lock      sync.RWMutex
+	incidents map[string]alertmodel.Incident
+}
+
+// NewJSONIncidentStore creates a JSONIncidentStore, loading any existing
+// incidents from path.
+func NewJSONIncidentStore(path string) (*JSONIncidentStore, error) {
+	s := &JSONIncidentStore{
+		path:      path,
+		incidents: make(map[string]alertmodel.Incident),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the incidents file. A missing file is not an error.
+func (s *JSONIncidentStore) load() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	var list []alertmodel.Incident
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("parse incidents %s: %w", s.path, err)
+	}
+	for _, inc := range list {
+		s.incidents[inc.ID] = inc
+	}
+	return nil
+}
+
+// save rewrites the incidents file. The caller must hold the write lock.
+func (s *JSONIncidentStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// sorted returns the incidents newest first, then by ID.
+func (s *JSONIncidentStore) sorted() []alertmodel.Incident {
+	list := make([]alertmodel.Incident, 0, len(s.incidents))
+	for _, inc := range s.incidents {
+		list = append(list, inc)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].StartedAt.Equal(list[j].StartedAt) {
+			return list[i].StartedAt.After(list[j].StartedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// UpsertIncident creates or replaces an incident.
+func (s *JSONIncidentStore) UpsertIncident(ctx context.Context, inc alertmodel.Incident) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.incidents[inc.ID] = inc
+	return s.save()
+}
+
+// GetIncident returns the incident with the given ID.
+func (s *JSONIncidentStore) GetIncident(ctx context.Context, id string) (alertmodel.Incident, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	inc, ok := s.incidents[id]
+	if !ok {
+		return alertmodel.Incident{}, ErrIncidentNotFound
+	}
+	return inc, nil
+}
+
+// ListIncidents returns the incidents matching filter, newest first.
+func (s *JSONIncidentStore) ListIncidents(ctx context.Context, filter alertmodel.IncidentFilter) ([]alertmodel.Incident, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var list []alertmodel.Incident
+	for _, inc := range s.sorted() {
+		if !filter.Matches(inc) {
+			continue
+		}
+		list = append(list, inc)
+		if filter.Limit > 0 && len(list) >= filter.Limit {
+			break
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,140 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package pgincidentstore implements incidentstore.IncidentStore on
+// PostgreSQL. Incidents, including their timelines, are stored as JSONB
+// documents in the incidents table.
+package pgincidentstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/incidentstore"
+	"github.com/lib/pq"
+)
+
+// PGIncidentStore is a PostgreSQL implementation of the IncidentStore interface.
+type PGIncidentStore struct {
+	db *sql.DB
+}
+
+// NewPGIncidentStore creates a new PGIncidentStore.
+func NewPGIncidentStore(db *sql.DB) *PGIncidentStore {
+	return &PGIncidentStore{db: db}
+}
+
+// UpsertIncident inserts an incident or replaces the one with the same ID.
+func (s *PGIncidentStore) UpsertIncident(ctx context.Context, inc alertmodel.Incident) error {
+	doc, err := json.Marshal(inc)
+	if err != nil {
+		return err
+	}
+	resolvedAt := sql.NullTime{Time: inc.ResolvedAt, Valid: !inc.ResolvedAt.IsZero()}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO incidents (id, status, level, host_id, endpoint_ids, started_at, updated_at, resolved_at, incident)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			level = EXCLUDED.level,
+			host_id = EXCLUDED.host_id,
+			endpoint_ids = EXCLUDED.endpoint_ids,
+			updated_at = EXCLUDED.updated_at,
+			resolved_at = EXCLUDED.resolved_at,
+			incident = EXCLUDED.incident
+	`, inc.ID, inc.Status, inc.Level, inc.HostID, pq.Array(inc.EndpointIDs), inc.StartedAt, inc.UpdatedAt, resolvedAt, doc)
+	return err
+}
+
+// GetIncident returns the incident with the given ID.
+func (s *PGIncidentStore) GetIncident(ctx context.Context, id string) (alertmodel.Incident, error) {
+	var doc []byte
+	err := s.db.QueryRowContext(ctx, `SELECT incident FROM incidents WHERE id = $1`, id).Scan(&doc)
+	if errors.Is(err, sql.ErrNoRows) {
+		return alertmodel.Incident{}, incidentstore.ErrIncidentNotFound
+	}
+	if err != nil {
+		return alertmodel.Incident{}, err
+	}
+
+	var inc alertmodel.Incident
+	if err := json.Unmarshal(doc, &inc); err != nil {
+		return alertmodel.Incident{}, err
+	}
+	return inc, nil
+}
+
+// ListIncidents returns the incidents matching filter, newest first.
+func (s *PGIncidentStore) ListIncidents(ctx context.Context, filter alertmodel.IncidentFilter) ([]alertmodel.Incident, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.EndpointID != "" {
+		add("$%d = ANY(endpoint_ids)", filter.EndpointID)
+	}
+	if !filter.Start.IsZero() {
+		add("started_at >= $%d", filter.Start)
+	}
+	if !filter.End.IsZero() {
+		add("started_at < $%d", filter.End)
+	}
+
+	query := `SELECT incident FROM incidents`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY started_at DESC, id"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []alertmodel.Incident
+	for rows.Next() {
+		var doc []byte
+		if err := rows.Scan(&doc); err != nil {
+			return nil, err
+		}
+		var inc alertmodel.Incident
+		if err := json.Unmarshal(doc, &inc); err != nil {
+			return nil, err
+		}
+		list = append(list, inc)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,198 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package notificationstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+)
+
+// JSONNotificationStore is a NotificationStore backed by a JSON file holding
+// a list of deliveries. With an empty path the deliveries are kept in memory
+// only, so pending retries are lost on restart.
+type JSONNotificationStore struct {
+	path       string
+	lock       sync.RWMutex
+	deliveries map[string]alertmodel.Delivery
+}
+
+// NewJSONNotificationStore creates a JSONNotificationStore, loading any
+// existing deliveries from path.
+func NewJSONNotificationStore(path string) (*JSONNotificationStore, error) {
+	s := &JSONNotificationStore{
+		path:       path,
+		deliveries: make(map[string]alertmodel.Delivery),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the deliveries file. A missing file is not an error.
+func (s *JSONNotificationStore) load() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	var list []alertmodel.Delivery
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("parse deliveries %s: %w", s.path, err)
+	}
+	for _, d := range list {
+		s.deliveries[d.ID] = d
+	}
+	return nil
+}
+
+// save rewrites the deliveries file. The caller must hold the write lock.
+func (s *JSONNotificationStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	list := s.sorted(func(alertmodel.Delivery) bool { return true })
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// sorted returns the deliveries accepted by keep, newest first.
+func (s *JSONNotificationStore) sorted(keep func(alertmodel.Delivery) bool) []alertmodel.Delivery {
+	list := make([]alertmodel.Delivery, 0, len(s.deliveries))
+	for _, d := range s.deliveries {
+		if keep(d) {
+			list = append(list, d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.After(list[j].CreatedAt)
+		}
+		return list[i].ID > list[j].ID
+	})
+	return list
+}
+
+// SaveDelivery creates or replaces a delivery.
+func (s *JSONNotificationStore) SaveDelivery(ctx context.Context, d alertmodel.Delivery) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.deliveries[d.ID] = d
+	return s.save()
+}
+
+// GetDelivery returns the delivery with the given ID.
+func (s *JSONNotificationStore) GetDelivery(ctx context.Context, id string) (alertmodel.Delivery, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	d, ok := s.deliveries[id]
+	if !ok {
+		return alertmodel.Delivery{}, ErrDeliveryNotFound
+	}
+	return d, nil
+}
+
+// ListDeliveries returns the deliveries matching the query, newest first.
+func (s *JSONNotificationStore) ListDeliveries(ctx context.Context, q DeliveryQuery) ([]alertmodel.Delivery, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	list := s.sorted(func(d alertmodel.Delivery) bool {
+		return (q.Status == "" || d.Status == q.Status) &&
+			(q.RouteID == "" || d.RouteID == q.RouteID) &&
+			(q.RuleID == "" || d.RuleID == q.RuleID) &&
+			(q.AlertID == "" || d.AlertID == q.AlertID) &&
+			(q.ActionType == "" || strings.EqualFold(d.Action.Type, q.ActionType))
+	})
+	if q.Offset >= len(list) {
+		return []alertmodel.Delivery{}, nil
+	}
+	list = list[q.Offset:]
+	if q.Limit > 0 && len(list) > q.Limit {
+		list = list[:q.Limit]
+	}
+	return list, nil
+}
+
+// DueDeliveries returns the pending and retrying deliveries due at now,
+// oldest first.
+func (s *JSONNotificationStore) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]alertmodel.Delivery, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	list := s.sorted(func(d alertmodel.Delivery) bool {
+		return (d.Status == alertmodel.DeliveryPending || d.Status == alertmodel.DeliveryRetrying) &&
+			!d.NextAttempt.After(now)
+	})
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].NextAttempt.Before(list[j].NextAttempt)
+	})
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// PruneDeliveries removes delivered and dead deliveries last updated before
+// the cutoff.
+func (s *JSONNotificationStore) PruneDeliveries(ctx context.Context, before time.Time) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	n := 0
+	for id, d := range s.deliveries {
+		if (d.Status == alertmodel.DeliveryDelivered || d.Status == alertmodel.DeliveryDead) && d.UpdatedAt.Before(before) {
+			delete(s.deliveries, id)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return n, s.save()
+}
@@ -0,0 +1,60 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package notificationstore persists notification deliveries: the queue of
+// notifications waiting to be (re)sent and the history of what was sent.
+package notificationstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+)
+
+// ErrDeliveryNotFound is returned when no delivery has the requested ID.
+var ErrDeliveryNotFound = errors.New("delivery not found")
+
+// DeliveryQuery filters the delivery history. Empty fields match anything;
+// results are ordered newest first.
+type DeliveryQuery struct {
+	Status     string
+	RouteID    string
+	RuleID     string
+	AlertID    string
+	ActionType string
+	Limit      int
+	Offset     int
+}
+
+// NotificationStore is an interface for storing notification deliveries.
+// SaveDelivery creates the delivery or replaces the one with the same ID.
+// DueDeliveries returns pending and retrying deliveries whose next attempt
+// is due, oldest first. PruneDeliveries removes delivered and dead
+// deliveries last updated before the cutoff.
+type NotificationStore interface {
+	SaveDelivery(ctx context.Context, d alertmodel.Delivery) error
+	GetDelivery(ctx context.Context, id string) (alertmodel.Delivery, error)
+	ListDeliveries(ctx context.Context, q DeliveryQuery) ([]alertmodel.Delivery, error)
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]alertmodel.Delivery, error)
+	PruneDeliveries(ctx context.Context, before time.Time) (int, error)
+}
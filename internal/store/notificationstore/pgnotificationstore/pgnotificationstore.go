@@ -0,0 +1,173 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package pgnotificationstore implements notificationstore.NotificationStore
+// on PostgreSQL. Deliveries are stored as JSONB documents in the
+// notification_deliveries table, with the columns used for filtering and
+// scheduling kept alongside.
+package pgnotificationstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/notificationstore"
+)
+
+// PGNotificationStore is a PostgreSQL implementation of the
+// NotificationStore interface.
+type PGNotificationStore struct {
+	db *sql.DB
+}
+
+// NewPGNotificationStore creates a new PGNotificationStore.
+func NewPGNotificationStore(db *sql.DB) *PGNotificationStore {
+	return &PGNotificationStore{db: db}
+}
+
+// SaveDelivery inserts a delivery or replaces the one with the same ID.
+func (s *PGNotificationStore) SaveDelivery(ctx context.Context, d alertmodel.Delivery) error {
+	doc, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	nextAttempt := sql.NullTime{Time: d.NextAttempt, Valid: !d.NextAttempt.IsZero()}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO notification_deliveries
+			(id, route_id, rule_id, alert_id, action_type, status, next_attempt, created_at, updated_at, delivery)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			next_attempt = EXCLUDED.next_attempt,
+			updated_at = EXCLUDED.updated_at,
+			delivery = EXCLUDED.delivery
+	`, d.ID, d.RouteID, d.RuleID, d.AlertID, strings.ToLower(d.Action.Type), d.Status, nextAttempt, d.CreatedAt, d.UpdatedAt, doc)
+	return err
+}
+
+// GetDelivery returns the delivery with the given ID.
+func (s *PGNotificationStore) GetDelivery(ctx context.Context, id string) (alertmodel.Delivery, error) {
+	var doc []byte
+	err := s.db.QueryRowContext(ctx, `SELECT delivery FROM notification_deliveries WHERE id = $1`, id).Scan(&doc)
+	if errors.Is(err, sql.ErrNoRows) {
+		return alertmodel.Delivery{}, notificationstore.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return alertmodel.Delivery{}, err
+	}
+
+	var d alertmodel.Delivery
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return alertmodel.Delivery{}, err
+	}
+	return d, nil
+}
+
+// ListDeliveries returns the deliveries matching the query, newest first.
+func (s *PGNotificationStore) ListDeliveries(ctx context.Context, q notificationstore.DeliveryQuery) ([]alertmodel.Delivery, error) {
+	var where []string
+	var args []interface{}
+	for _, f := range []struct {
+		column string
+		value  string
+	}{
+		{"status", q.Status},
+		{"route_id", q.RouteID},
+		{"rule_id", q.RuleID},
+		{"alert_id", q.AlertID},
+		{"action_type", strings.ToLower(q.ActionType)},
+	} {
+		if f.value != "" {
+			args = append(args, f.value)
+			where = append(where, fmt.Sprintf("%s = $%d", f.column, len(args)))
+		}
+	}
+
+	query := `SELECT delivery FROM notification_deliveries`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY created_at DESC, id DESC`
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if q.Offset > 0 {
+		args = append(args, q.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+	return s.query(ctx, query, args...)
+}
+
+// DueDeliveries returns the pending and retrying deliveries due at now,
+// oldest first. A limit of zero returns all of them.
+func (s *PGNotificationStore) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]alertmodel.Delivery, error) {
+	max := sql.NullInt64{Int64: int64(limit), Valid: limit > 0}
+	return s.query(ctx, `
+		SELECT delivery FROM notification_deliveries
+		WHERE status IN ($1, $2) AND (next_attempt IS NULL OR next_attempt <= $3)
+		ORDER BY next_attempt
+		LIMIT $4
+	`, alertmodel.DeliveryPending, alertmodel.DeliveryRetrying, now, max)
+}
+
+// PruneDeliveries removes delivered and dead deliveries last updated before
+// the cutoff.
+func (s *PGNotificationStore) PruneDeliveries(ctx context.Context, before time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM notification_deliveries
+		WHERE status IN ($1, $2) AND updated_at < $3
+	`, alertmodel.DeliveryDelivered, alertmodel.DeliveryDead, before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// query runs a query selecting delivery documents.
+func (s *PGNotificationStore) query(ctx context.Context, query string, args ...interface{}) ([]alertmodel.Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []alertmodel.Delivery{}
+	for rows.Next() {
+		var doc []byte
+		if err := rows.Scan(&doc); err != nil {
+			return nil, err
+		}
+		var d alertmodel.Delivery
+		if err := json.Unmarshal(doc, &d); err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
@@ -22,38 +22,103 @@ along with GoSight. If not, see https://www.gnu.org/licenses/.
 package routestore
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"time"
 
-	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/core/events/dispatcher"
 	"gopkg.in/yaml.v3"
 )
 
-// RouteStore is a structure that holds a list of action routes.
-type RouteStore struct {
-	Routes []model.ActionRoute
-}
+var (
+	// ErrRouteNotFound is returned when no route has the requested ID.
+	ErrRouteNotFound = errors.New("route not found")
+
+	// ErrRouteExists is returned when a new route reuses an existing ID.
+	ErrRouteExists = errors.New("route already exists")
+)
 
-// LoadRoutesFromFile loads action routes from a YAML file.
-func NewRouteStore(path string) (*RouteStore, error) {
+// LoadRouteSet reads and validates a routes file. A missing file yields an
+// empty set so routes can be managed entirely through the API.
+func LoadRouteSet(path string) (alertmodel.RouteSet, error) {
+	var set alertmodel.RouteSet
 	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return set, nil
+	}
 	if err != nil {
-		return nil, err
+		return set, err
+	}
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return set, err
 	}
+	return set, ValidateRouteSet(set)
+}
 
-	var config model.ActionRouteSet
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, err
+// ValidateRouteSet checks every route and inhibition rule of a set and that
+// route IDs, including those of child routes, are unique.
+func ValidateRouteSet(set alertmodel.RouteSet) error {
+	seen := make(map[string]bool)
+	var checkIDs func(routes []alertmodel.Route) error
+	checkIDs = func(routes []alertmodel.Route) error {
+		for _, r := range routes {
+			if seen[r.ID] {
+				return fmt.Errorf("route %s: %w", r.ID, ErrRouteExists)
+			}
+			seen[r.ID] = true
+			if err := checkIDs(r.Routes); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, r := range set.Routes {
+		if err := ValidateRoute(r); err != nil {
+			return err
+		}
+	}
+	if err := checkIDs(set.Routes); err != nil {
+		return err
 	}
 
-	return &RouteStore{Routes: config.Routes}, nil
+	for i, r := range set.InhibitRules {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("inhibit_rules[%d]: %w", i, err)
+		}
+	}
+	return nil
 }
 
-func (rs *RouteStore) BuildMap() map[string]model.ActionRoute {
-	routeMap := make(map[string]model.ActionRoute)
-	for _, r := range rs.Routes {
-		if r.ID != "" {
-			routeMap[r.ID] = r
+// ValidateRoute checks that a route and its child routes have an ID, valid
+// matchers and grouping durations, and valid actions.
+func ValidateRoute(r alertmodel.Route) error {
+	if r.ID == "" {
+		return fmt.Errorf("route id is required")
+	}
+	for _, m := range r.Match.Matchers {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("route %s: %w", r.ID, err)
+		}
+	}
+	for _, v := range []string{r.GroupWait, r.GroupInterval} {
+		if v == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+			return fmt.Errorf("route %s: invalid duration %q", r.ID, v)
+		}
+	}
+	for j, a := range r.Actions {
+		if err := dispatcher.ValidateAction(a); err != nil {
+			return fmt.Errorf("route %s: actions[%d]: %w", r.ID, j, err)
+		}
+	}
+	for _, child := range r.Routes {
+		if err := ValidateRoute(child); err != nil {
+			return err
 		}
 	}
-	return routeMap
+	return nil
 }
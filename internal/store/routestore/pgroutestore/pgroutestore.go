@@ -0,0 +1,163 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package pgroutestore implements routestore.Backend on PostgreSQL. Routes
+// are stored as JSONB documents in the alert_routes table, in the order they
+// were created. Every write issues a NOTIFY on the gosight_routes channel;
+// Watch listens on it so all server instances reload their routes when any
+// of them changes one.
+package pgroutestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/routestore"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/lib/pq"
+)
+
+// notifyChannel is the LISTEN/NOTIFY channel route changes are announced on.
+const notifyChannel = "gosight_routes"
+
+// PGRouteStore is a PostgreSQL implementation of the routestore.Backend
+// interface.
+type PGRouteStore struct {
+	db  *sql.DB
+	dsn string
+}
+
+// NewPGRouteStore creates a new PGRouteStore. The DSN is used by Watch to
+// open the dedicated LISTEN connection.
+func NewPGRouteStore(db *sql.DB, dsn string) *PGRouteStore {
+	return &PGRouteStore{db: db, dsn: dsn}
+}
+
+// LoadRoutes returns all routes in creation order.
+func (s *PGRouteStore) LoadRoutes(ctx context.Context) ([]alertmodel.Route, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT route FROM alert_routes ORDER BY position, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var routes []alertmodel.Route
+	for rows.Next() {
+		var doc []byte
+		if err := rows.Scan(&doc); err != nil {
+			return nil, err
+		}
+		var r alertmodel.Route
+		if err := json.Unmarshal(doc, &r); err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+	}
+	return routes, rows.Err()
+}
+
+// SaveRoute inserts a route at the end of the list, or replaces the route
+// with the same ID in place, and announces the change.
+func (s *PGRouteStore) SaveRoute(ctx context.Context, r alertmodel.Route) error {
+	doc, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO alert_routes (id, position, route, updated_at)
+		VALUES ($1, (SELECT COALESCE(MAX(position), 0) + 1 FROM alert_routes), $2, now())
+		ON CONFLICT (id) DO UPDATE SET
+			route = EXCLUDED.route,
+			updated_at = EXCLUDED.updated_at
+	`, r.ID, doc); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, notifyChannel, r.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteRoute removes a route and announces the change.
+func (s *PGRouteStore) DeleteRoute(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM alert_routes WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return routestore.ErrRouteNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, notifyChannel, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Watch listens for route changes made by any server instance and calls
+// reload for each, until ctx is done.
+func (s *PGRouteStore) Watch(ctx context.Context, reload func()) {
+	listener := pq.NewListener(s.dsn, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			utils.Warn("[routes] listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(notifyChannel); err != nil {
+		utils.Error("[routes] failed to listen on %s: %v", notifyChannel, err)
+		return
+	}
+	utils.Info("[routes] watching %s for route changes", notifyChannel)
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			// A nil notification means the connection was re-established.
+			if n != nil {
+				utils.Debug("[routes] route %s changed, reloading", n.Extra)
+			}
+			reload()
+		case <-ping.C:
+			go func() { _ = listener.Ping() }()
+		}
+	}
+}
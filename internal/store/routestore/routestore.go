@@ -0,0 +1,374 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package routestore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// reloadInterval is how often Watch checks the routes file for changes.
+const reloadInterval = 5 * time.Second
+
+// Backend persists routes in place of the routes file, e.g. in PostgreSQL.
+// The file then only supplies the inhibition rules and, while the backend is
+// empty, the routes it is seeded with.
+type Backend interface {
+	LoadRoutes(ctx context.Context) ([]alertmodel.Route, error)
+	SaveRoute(ctx context.Context, r alertmodel.Route) error
+	DeleteRoute(ctx context.Context, id string) error
+}
+
+// Watcher is implemented by backends that announce changes made by other
+// server instances. Watch calls reload for every change until ctx is done.
+type Watcher interface {
+	Watch(ctx context.Context, reload func())
+}
+
+// RouteStore is a structure that holds a list of action routes and the
+// inhibition rules applied before alerts are dispatched. The routes come
+// from a YAML file, or from a Backend when one is configured, and can be
+// changed at runtime through the API or by editing the file; listeners
+// registered with OnChange see every new version.
+type RouteStore struct {
+	path    string
+	backend Backend
+
+	lock      sync.RWMutex
+	routes    []alertmodel.Route
+	inhibit   []alertmodel.InhibitRule
+	fileState os.FileInfo // routes file as of the last load or write
+	listeners []func(*RouteStore)
+}
+
+// NewRouteStore loads action routes from a YAML file.
+func NewRouteStore(path string) (*RouteStore, error) {
+	rs := &RouteStore{path: path}
+	set, info, err := rs.readFile()
+	if err != nil {
+		return nil, err
+	}
+	rs.routes, rs.inhibit, rs.fileState = set.Routes, set.InhibitRules, info
+	return rs, nil
+}
+
+// NewBackendRouteStore loads action routes from a backend and inhibition
+// rules from the YAML file at path. An empty backend is seeded with the
+// routes of the file.
+func NewBackendRouteStore(ctx context.Context, path string, backend Backend) (*RouteStore, error) {
+	rs := &RouteStore{path: path, backend: backend}
+	set, info, err := rs.readFile()
+	if err != nil {
+		return nil, err
+	}
+	routes, err := backend.LoadRoutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 && len(set.Routes) > 0 {
+		utils.Info("Seeding route store with %d routes from %s", len(set.Routes), path)
+		for _, r := range set.Routes {
+			if err := backend.SaveRoute(ctx, r); err != nil {
+				return nil, err
+			}
+		}
+		routes = set.Routes
+	}
+	rs.routes, rs.inhibit, rs.fileState = routes, set.InhibitRules, info
+	return rs, nil
+}
+
+// Routes returns the current routes.
+func (rs *RouteStore) Routes() []alertmodel.Route {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+
+	return append([]alertmodel.Route(nil), rs.routes...)
+}
+
+// InhibitRules returns the current inhibition rules.
+func (rs *RouteStore) InhibitRules() []alertmodel.InhibitRule {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+
+	return append([]alertmodel.InhibitRule(nil), rs.inhibit...)
+}
+
+// GetRoute returns the route with the given ID.
+func (rs *RouteStore) GetRoute(id string) (alertmodel.Route, error) {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+
+	for _, r := range rs.routes {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return alertmodel.Route{}, ErrRouteNotFound
+}
+
+// BuildMap returns the current routes keyed by ID.
+func (rs *RouteStore) BuildMap() map[string]alertmodel.Route {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+
+	routeMap := make(map[string]alertmodel.Route)
+	for _, r := range rs.routes {
+		if r.ID != "" {
+			routeMap[r.ID] = r
+		}
+	}
+	return routeMap
+}
+
+// OnChange registers fn to be called after the routes or inhibition rules
+// change.
+func (rs *RouteStore) OnChange(fn func(*RouteStore)) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	rs.listeners = append(rs.listeners, fn)
+}
+
+// AddRoute validates and stores a new route.
+func (rs *RouteStore) AddRoute(ctx context.Context, r alertmodel.Route) error {
+	if err := ValidateRoute(r); err != nil {
+		return err
+	}
+	return rs.modify(ctx, func(routes []alertmodel.Route) ([]alertmodel.Route, error) {
+		for _, existing := range routes {
+			if existing.ID == r.ID {
+				return nil, ErrRouteExists
+			}
+		}
+		return append(routes, r), nil
+	}, &r, "")
+}
+
+// UpdateRoute validates a route and replaces the stored route with its ID.
+func (rs *RouteStore) UpdateRoute(ctx context.Context, r alertmodel.Route) error {
+	if err := ValidateRoute(r); err != nil {
+		return err
+	}
+	return rs.modify(ctx, func(routes []alertmodel.Route) ([]alertmodel.Route, error) {
+		for i, existing := range routes {
+			if existing.ID == r.ID {
+				routes[i] = r
+				return routes, nil
+			}
+		}
+		return nil, ErrRouteNotFound
+	}, &r, "")
+}
+
+// DeleteRoute removes a route.
+func (rs *RouteStore) DeleteRoute(ctx context.Context, id string) error {
+	return rs.modify(ctx, func(routes []alertmodel.Route) ([]alertmodel.Route, error) {
+		for i, existing := range routes {
+			if existing.ID == id {
+				return append(routes[:i], routes[i+1:]...), nil
+			}
+		}
+		return nil, ErrRouteNotFound
+	}, nil, id)
+}
+
+// modify applies change to a copy of the routes, persists the result (the
+// saved route or the deleted ID to the backend, otherwise the whole file)
+// and makes it current.
+func (rs *RouteStore) modify(ctx context.Context, change func([]alertmodel.Route) ([]alertmodel.Route, error), saved *alertmodel.Route, deleted string) error {
+	rs.lock.Lock()
+	routes, err := change(append([]alertmodel.Route(nil), rs.routes...))
+	if err == nil {
+		// Child route IDs must stay unique across all routes as well.
+		err = ValidateRouteSet(alertmodel.RouteSet{Routes: routes})
+	}
+	if err != nil {
+		rs.lock.Unlock()
+		return err
+	}
+
+	switch {
+	case rs.backend != nil && saved != nil:
+		err = rs.backend.SaveRoute(ctx, *saved)
+	case rs.backend != nil:
+		err = rs.backend.DeleteRoute(ctx, deleted)
+	default:
+		err = rs.writeFile(alertmodel.RouteSet{Routes: routes, InhibitRules: rs.inhibit})
+	}
+	if err != nil {
+		rs.lock.Unlock()
+		return err
+	}
+	rs.routes = routes
+	rs.lock.Unlock()
+
+	rs.notify()
+	return nil
+}
+
+// Reload rereads the routes file, and the backend's routes when there is
+// one, and swaps them in. An invalid file leaves the current routes in
+// place.
+func (rs *RouteStore) Reload(ctx context.Context) error {
+	set, info, err := rs.readFile()
+	if err != nil {
+		return err
+	}
+	routes := set.Routes
+	if rs.backend != nil {
+		if routes, err = rs.backend.LoadRoutes(ctx); err != nil {
+			return err
+		}
+		if err := ValidateRouteSet(alertmodel.RouteSet{Routes: routes}); err != nil {
+			return err
+		}
+	}
+
+	rs.lock.Lock()
+	rs.routes, rs.inhibit, rs.fileState = routes, set.InhibitRules, info
+	rs.lock.Unlock()
+
+	utils.Info("Reloaded %d routes and %d inhibit rules", len(routes), len(set.InhibitRules))
+	rs.notify()
+	return nil
+}
+
+// Watch reloads the routes whenever the routes file changes, or the backend
+// announces a change, until ctx is done. A file that fails to load or
+// validate is reported and the previous routes stay active until it is
+// fixed.
+func (rs *RouteStore) Watch(ctx context.Context) {
+	if w, ok := rs.backend.(Watcher); ok {
+		go w.Watch(ctx, func() {
+			if err := rs.Reload(ctx); err != nil {
+				utils.Error("[routes] reload failed, keeping current routes: %v", err)
+			}
+		})
+	}
+	if rs.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !rs.fileChanged() {
+				continue
+			}
+			if err := rs.Reload(ctx); err != nil {
+				utils.Error("[routes] %s is invalid, keeping current routes: %v", rs.path, err)
+				rs.lock.Lock()
+				rs.fileState, _ = os.Stat(rs.path) // don't retry until it changes again
+				rs.lock.Unlock()
+			}
+		}
+	}
+}
+
+// fileChanged reports whether the routes file was modified, created or
+// removed since it was last loaded or written.
+func (rs *RouteStore) fileChanged() bool {
+	info, err := os.Stat(rs.path)
+	if err != nil {
+		info = nil
+	}
+
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	last := rs.fileState
+	if info == nil || last == nil {
+		return (info == nil) != (last == nil)
+	}
+	return !info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size()
+}
+
+// readFile loads and validates the routes file.
+func (rs *RouteStore) readFile() (alertmodel.RouteSet, os.FileInfo, error) {
+	if rs.path == "" {
+		return alertmodel.RouteSet{}, nil, nil
+	}
+	info, _ := os.Stat(rs.path)
+	set, err := LoadRouteSet(rs.path)
+	if err != nil {
+		return set, info, fmt.Errorf("%s: %w", rs.path, err)
+	}
+	return set, info, nil
+}
+
+// writeFile replaces the routes file with the given set, atomically through
+// a temporary file. Comments in the file are not preserved. The caller must
+// hold the write lock.
+func (rs *RouteStore) writeFile(set alertmodel.RouteSet) error {
+	if rs.path == "" {
+		return fmt.Errorf("no routes file configured")
+	}
+	data, err := yaml.Marshal(set)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(rs.path), ".routes-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), rs.path); err != nil {
+		return err
+	}
+	rs.fileState, _ = os.Stat(rs.path)
+	return nil
+}
+
+// notify calls the change listeners.
+func (rs *RouteStore) notify() {
+	rs.lock.RLock()
+	listeners := make([]func(*RouteStore), len(rs.listeners))
+	copy(listeners, rs.listeners)
+	rs.lock.RUnlock()
+
+	for _, fn := range listeners {
+		fn(rs)
+	}
+}
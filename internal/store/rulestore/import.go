@@ -0,0 +1,90 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rulestore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"gopkg.in/yaml.v3"
+)
+
+// ReadRulesFile reads a list of rules from a YAML or JSON rules file, as
+// written by the YAML and JSON stores. The format is chosen by extension.
+func ReadRulesFile(path string) ([]alertmodel.AlertRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []alertmodel.AlertRule
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &list)
+	default:
+		err = yaml.Unmarshal(data, &list)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// ImportRules copies rules from a YAML or JSON rules file into dst. Rules
+// whose ID already exists in dst are skipped, so running an import twice is
+// harmless. When revisions is not nil, each imported rule gets a "create"
+// revision by the given author.
+func ImportRules(ctx context.Context, dst RuleStore, revisions RevisionStore, path, author string) (imported, skipped int, err error) {
+	rules, err := ReadRulesFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, rule := range rules {
+		if rule.ID == "" {
+			return imported, skipped, fmt.Errorf("rule %q has no id", rule.Name)
+		}
+		if _, err := dst.GetRuleByID(ctx, rule.ID); err == nil {
+			skipped++
+			continue
+		}
+		if err := dst.AddRule(ctx, rule); err != nil {
+			return imported, skipped, fmt.Errorf("import rule %s: %w", rule.ID, err)
+		}
+		if revisions != nil {
+			_, _ = revisions.AddRevision(ctx, alertmodel.RuleRevision{
+				RuleID: rule.ID,
+				Action: alertmodel.RevisionCreate,
+				Author: author,
+				Rule:   rule,
+				Diff:   alertmodel.DiffRules(alertmodel.AlertRule{}, rule),
+			})
+		}
+		imported++
+	}
+	return imported, skipped, nil
+}
@@ -24,28 +24,33 @@ package rulestore
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"sync"
 
-	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
 )
 
 // JSONRuleStore is a rule store that uses a JSON file for persistence.
 // It implements the RuleStore interface and provides methods for adding,
 type JSONRuleStore struct {
 	path  string
-	rules map[string]model.AlertRule
+	rules map[string]alertmodel.AlertRule
 	lock  sync.RWMutex
 }
 
 // NewJSONStore creates a new JSONRuleStore with the specified file path.
-// It loads existing rules from the file if it exists.
+// It loads existing rules from the file if it exists, and fails if any of
+// them is invalid.
 func NewJSONStore(path string) (*JSONRuleStore, error) {
 	j := &JSONRuleStore{
 		path:  path,
-		rules: make(map[string]model.AlertRule),
+		rules: make(map[string]alertmodel.AlertRule),
+	}
+	if err := j.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
 	}
-	_ = j.load()
 	return j, nil
 }
 
@@ -57,27 +62,36 @@ func (j *JSONRuleStore) load() error {
 	if err != nil {
 		return err
 	}
-	var list []model.AlertRule
+	var list []alertmodel.AlertRule
 	if err := json.Unmarshal(data, &list); err != nil {
 		return err
 	}
+	for _, r := range list {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+	}
 	for _, r := range list {
 		j.rules[r.ID] = r
 	}
 	return nil
 }
 
-// save writes the current rules to the JSON file.
-// It is called after any modification to the rules map.
+// save writes the current rules to the JSON file as a list, in the same
+// shape load expects. It is called after any modification to the rules map.
 
 func (j *JSONRuleStore) save() {
-	data, _ := json.MarshalIndent(j.rules, "", "  ")
+	data, _ := json.MarshalIndent(sortedRules(j.rules), "", "  ")
 	_ = os.WriteFile(j.path, data, 0644)
 }
 
 // AddRule adds a new rule to the store.
 // It locks the store for writing, adds the rule, and then saves the rules to the file.
-func (j *JSONRuleStore) AddRule(ctx context.Context, r model.AlertRule) error {
+// Rules that fail validation are rejected without being stored.
+func (j *JSONRuleStore) AddRule(ctx context.Context, r alertmodel.AlertRule) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
 	j.lock.Lock()
 	defer j.lock.Unlock()
 	j.rules[r.ID] = r
@@ -87,7 +101,7 @@ func (j *JSONRuleStore) AddRule(ctx context.Context, r model.AlertRule) error {
 
 // UpdateRule updates an existing rule in the store.
 // It locks the store for writing, updates the rule, and then saves the rules to the file.
-func (j *JSONRuleStore) UpdateRule(ctx context.Context, r model.AlertRule) error {
+func (j *JSONRuleStore) UpdateRule(ctx context.Context, r alertmodel.AlertRule) error {
 	return j.AddRule(ctx, r)
 }
 
@@ -104,11 +118,11 @@ func (j *JSONRuleStore) DeleteRule(ctx context.Context, id string) error {
 // ListRules returns a list of all rules in the store.
 // It locks the store for reading and returns a slice of AlertRule.
 
-func (j *JSONRuleStore) ListRules(ctx context.Context) ([]model.AlertRule, error) {
+func (j *JSONRuleStore) ListRules(ctx context.Context) ([]alertmodel.AlertRule, error) {
 	j.lock.RLock()
 	defer j.lock.RUnlock()
 
-	var list []model.AlertRule
+	var list []alertmodel.AlertRule
 	for _, r := range j.rules {
 		list = append(list, r)
 	}
@@ -117,9 +131,9 @@ func (j *JSONRuleStore) ListRules(ctx context.Context) ([]model.AlertRule, error
 
 // GetActiveRules returns a list of all active rules in the store.
 // It calls ListRules to get all rules and filters them based on the Enabled field.
-func (j *JSONRuleStore) GetActiveRules(ctx context.Context) ([]model.AlertRule, error) {
+func (j *JSONRuleStore) GetActiveRules(ctx context.Context) ([]alertmodel.AlertRule, error) {
 	all, _ := j.ListRules(ctx)
-	var active []model.AlertRule
+	var active []alertmodel.AlertRule
 	for _, r := range all {
 		if r.Enabled {
 			active = append(active, r)
@@ -129,18 +143,18 @@ func (j *JSONRuleStore) GetActiveRules(ctx context.Context) ([]model.AlertRule,
 }
 
 // GetRuleByID retrieves a rule by its ID from the JSON rule store.
-func (s *JSONRuleStore) GetRuleByID(ctx context.Context, id string) (model.AlertRule, error) {
+func (s *JSONRuleStore) GetRuleByID(ctx context.Context, id string) (alertmodel.AlertRule, error) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
 	if rule, ok := s.rules[id]; ok {
 		return rule, nil
 	}
-	return model.AlertRule{}, os.ErrNotExist
+	return alertmodel.AlertRule{}, os.ErrNotExist
 }
 
 // GetRuleByName retrieves a rule by its Name from the JSON rule store.
-func (s *JSONRuleStore) GetRuleByName(ctx context.Context, name string) (model.AlertRule, error) {
+func (s *JSONRuleStore) GetRuleByName(ctx context.Context, name string) (alertmodel.AlertRule, error) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
@@ -149,5 +163,5 @@ func (s *JSONRuleStore) GetRuleByName(ctx context.Context, name string) (model.A
 			return rule, nil
 		}
 	}
-	return model.AlertRule{}, os.ErrNotExist
+	return alertmodel.AlertRule{}, os.ErrNotExist
 }
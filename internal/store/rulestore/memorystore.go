@@ -23,15 +23,16 @@ package rulestore
 
 import (
 	"context"
+	"os"
 	"sync"
 
-	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
 )
 
 // MemoryRuleStore is an in-memory implementation of the RuleStore interface.
 // It provides methods for adding, updating, deleting, and retrieving rules.
 type MemoryRuleStore struct {
-	rules map[string]model.AlertRule
+	rules map[string]alertmodel.AlertRule
 	lock  sync.RWMutex
 }
 
@@ -39,12 +40,16 @@ type MemoryRuleStore struct {
 // It initializes an empty rules map.
 // This store is not persistent and will lose data on application restart.
 func NewMemoryStore() *MemoryRuleStore {
-	return &MemoryRuleStore{rules: make(map[string]model.AlertRule)}
+	return &MemoryRuleStore{rules: make(map[string]alertmodel.AlertRule)}
 }
 
 // AddRule adds a new rule to the store.
 // If a rule with the same ID already exists, it will be overwritten.
-func (s *MemoryRuleStore) AddRule(ctx context.Context, r model.AlertRule) error {
+// Rules that fail validation are rejected without being stored.
+func (s *MemoryRuleStore) AddRule(ctx context.Context, r alertmodel.AlertRule) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	s.rules[r.ID] = r
@@ -53,7 +58,7 @@ func (s *MemoryRuleStore) AddRule(ctx context.Context, r model.AlertRule) error
 
 // UpdateRule updates an existing rule in the store.
 // If the rule does not exist, it will be added as a new rule.
-func (s *MemoryRuleStore) UpdateRule(ctx context.Context, r model.AlertRule) error {
+func (s *MemoryRuleStore) UpdateRule(ctx context.Context, r alertmodel.AlertRule) error {
 	return s.AddRule(ctx, r)
 }
 
@@ -68,11 +73,11 @@ func (s *MemoryRuleStore) DeleteRule(ctx context.Context, id string) error {
 
 // ListRules returns a list of all rules in the store.
 // It returns an empty list if no rules are present.
-func (s *MemoryRuleStore) ListRules(ctx context.Context) ([]model.AlertRule, error) {
+func (s *MemoryRuleStore) ListRules(ctx context.Context) ([]alertmodel.AlertRule, error) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
-	var out []model.AlertRule
+	var out []alertmodel.AlertRule
 	for _, rule := range s.rules {
 		out = append(out, rule)
 	}
@@ -82,9 +87,9 @@ func (s *MemoryRuleStore) ListRules(ctx context.Context) ([]model.AlertRule, err
 // GetActiveRules returns a list of all active rules in the store.
 // An active rule is one that has its Enabled field set to true.
 // It returns an empty list if no active rules are present.
-func (s *MemoryRuleStore) GetActiveRules(ctx context.Context) ([]model.AlertRule, error) {
+func (s *MemoryRuleStore) GetActiveRules(ctx context.Context) ([]alertmodel.AlertRule, error) {
 	all, _ := s.ListRules(ctx)
-	var filtered []model.AlertRule
+	var filtered []alertmodel.AlertRule
 	for _, r := range all {
 		if r.Enabled {
 			filtered = append(filtered, r)
@@ -92,3 +97,27 @@ func (s *MemoryRuleStore) GetActiveRules(ctx context.Context) ([]model.AlertRule
 	}
 	return filtered, nil
 }
+
+// GetRuleByID retrieves a rule by its ID.
+func (s *MemoryRuleStore) GetRuleByID(ctx context.Context, id string) (alertmodel.AlertRule, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if rule, ok := s.rules[id]; ok {
+		return rule, nil
+	}
+	return alertmodel.AlertRule{}, os.ErrNotExist
+}
+
+// GetRuleByName retrieves a rule by its Name (case-sensitive).
+func (s *MemoryRuleStore) GetRuleByName(ctx context.Context, name string) (alertmodel.AlertRule, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, rule := range s.rules {
+		if rule.Name == name {
+			return rule, nil
+		}
+	}
+	return alertmodel.AlertRule{}, os.ErrNotExist
+}
@@ -0,0 +1,355 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package pgrulestore implements rulestore.RuleStore and
+// rulestore.RevisionStore on PostgreSQL. Rules are kept as JSONB documents
+// and cached in memory, since the evaluator reads them on every payload.
+// Every write issues a NOTIFY on the gosight_rules channel; Watch listens on
+// it so all server instances reload their cache when any of them changes a
+// rule.
+package pgrulestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/rulestore"
+	"github.com/aaronlmathis/gosight-shared/utils"
+	"github.com/lib/pq"
+)
+
+// notifyChannel is the LISTEN/NOTIFY channel rule changes are announced on.
+const notifyChannel = "gosight_rules"
+
+// PGRuleStore is a PostgreSQL implementation of the RuleStore interface.
+type PGRuleStore struct {
+	db  *sql.DB
+	dsn string
+
+	lock  sync.RWMutex
+	rules map[string]alertmodel.AlertRule
+}
+
+// NewPGRuleStore creates a new PGRuleStore and loads the current rules.
+// The DSN is used by Watch to open the dedicated LISTEN connection.
+func NewPGRuleStore(db *sql.DB, dsn string) (*PGRuleStore, error) {
+	s := &PGRuleStore{
+		db:    db,
+		dsn:   dsn,
+		rules: make(map[string]alertmodel.AlertRule),
+	}
+	if err := s.reload(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload replaces the cache with the rules currently in the database.
+func (s *PGRuleStore) reload(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT rule FROM alert_rules`)
+	if err != nil {
+		return fmt.Errorf("load rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make(map[string]alertmodel.AlertRule)
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return err
+		}
+		var rule alertmodel.AlertRule
+		if err := json.Unmarshal(raw, &rule); err != nil {
+			utils.Warn("[rules] skipping unreadable rule: %v", err)
+			continue
+		}
+		rules[rule.ID] = rule
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.rules = rules
+	s.lock.Unlock()
+	return nil
+}
+
+// Watch listens for rule changes made by any server instance and reloads
+// the cache when one is announced or the listener reconnects (changes may
+// have been missed while disconnected). It blocks until ctx is cancelled.
+func (s *PGRuleStore) Watch(ctx context.Context) {
+	listener := pq.NewListener(s.dsn, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			utils.Warn("[rules] listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(notifyChannel); err != nil {
+		utils.Error("[rules] failed to listen on %s: %v", notifyChannel, err)
+		return
+	}
+	utils.Info("[rules] watching %s for rule changes", notifyChannel)
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			// A nil notification means the connection was re-established.
+			if n != nil {
+				utils.Debug("[rules] rule %s changed, reloading", n.Extra)
+			}
+			if err := s.reload(ctx); err != nil {
+				utils.Error("[rules] reload failed: %v", err)
+			}
+		case <-ping.C:
+			go func() { _ = listener.Ping() }()
+		}
+	}
+}
+
+// AddRule inserts or replaces a rule and announces the change.
+// Rules that fail validation are rejected without being stored.
+func (s *PGRuleStore) AddRule(ctx context.Context, r alertmodel.AlertRule) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO alert_rules (id, name, type, enabled, rule, updated_at)
+		VALUES ($1, $2, $3, $4, $5, now())
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			type = EXCLUDED.type,
+			enabled = EXCLUDED.enabled,
+			rule = EXCLUDED.rule,
+			updated_at = now()
+	`, r.ID, r.Name, r.Type, r.Enabled, raw)
+	if err != nil {
+		return fmt.Errorf("save rule: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, notifyChannel, r.ID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.rules[r.ID] = r
+	s.lock.Unlock()
+	return nil
+}
+
+// UpdateRule updates an existing rule, adding it if it does not exist.
+func (s *PGRuleStore) UpdateRule(ctx context.Context, r alertmodel.AlertRule) error {
+	return s.AddRule(ctx, r)
+}
+
+// DeleteRule removes a rule and announces the change.
+func (s *PGRuleStore) DeleteRule(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM alert_rules WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("delete rule: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, notifyChannel, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	delete(s.rules, id)
+	s.lock.Unlock()
+	return nil
+}
+
+// ListRules returns all cached rules ordered by ID.
+func (s *PGRuleStore) ListRules(ctx context.Context) ([]alertmodel.AlertRule, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	out := make([]alertmodel.AlertRule, 0, len(s.rules))
+	for _, r := range s.rules {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// GetActiveRules returns the enabled rules.
+func (s *PGRuleStore) GetActiveRules(ctx context.Context) ([]alertmodel.AlertRule, error) {
+	all, _ := s.ListRules(ctx)
+	var active []alertmodel.AlertRule
+	for _, r := range all {
+		if r.Enabled {
+			active = append(active, r)
+		}
+	}
+	return active, nil
+}
+
+// GetRuleByID retrieves a rule by its ID.
+func (s *PGRuleStore) GetRuleByID(ctx context.Context, id string) (alertmodel.AlertRule, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if rule, ok := s.rules[id]; ok {
+		return rule, nil
+	}
+	return alertmodel.AlertRule{}, os.ErrNotExist
+}
+
+// GetRuleByName retrieves a rule by its Name (case-sensitive).
+func (s *PGRuleStore) GetRuleByName(ctx context.Context, name string) (alertmodel.AlertRule, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, rule := range s.rules {
+		if rule.Name == name {
+			return rule, nil
+		}
+	}
+	return alertmodel.AlertRule{}, os.ErrNotExist
+}
+
+// AddRevision stores a revision with the next version for its rule. The
+// version is computed in the INSERT itself; the unique (rule_id, version)
+// key makes concurrent writers from other instances retry.
+func (s *PGRuleStore) AddRevision(ctx context.Context, rev alertmodel.RuleRevision) (alertmodel.RuleRevision, error) {
+	if rev.Timestamp.IsZero() {
+		rev.Timestamp = time.Now().UTC()
+	}
+	ruleJSON, err := json.Marshal(rev.Rule)
+	if err != nil {
+		return rev, err
+	}
+	diffJSON, err := json.Marshal(rev.Diff)
+	if err != nil {
+		return rev, err
+	}
+
+	const attempts = 3
+	for i := 0; i < attempts; i++ {
+		err = s.db.QueryRowContext(ctx, `
+			INSERT INTO alert_rule_revisions (rule_id, version, action, author, timestamp, rule, diff, restored_from)
+			SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5, $6, $7
+			FROM alert_rule_revisions WHERE rule_id = $1
+			RETURNING version
+		`, rev.RuleID, rev.Action, rev.Author, rev.Timestamp, ruleJSON, diffJSON, rev.RestoredFrom).Scan(&rev.Version)
+
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			continue
+		}
+		break
+	}
+	if err != nil {
+		return rev, fmt.Errorf("save revision: %w", err)
+	}
+	return rev, nil
+}
+
+// ListRevisions returns a rule's revisions, newest first.
+func (s *PGRuleStore) ListRevisions(ctx context.Context, ruleID string) ([]alertmodel.RuleRevision, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT rule_id, version, action, author, timestamp, rule, diff, restored_from
+		FROM alert_rule_revisions WHERE rule_id = $1
+		ORDER BY version DESC
+	`, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []alertmodel.RuleRevision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rev)
+	}
+	return out, rows.Err()
+}
+
+// GetRevision returns a single revision of a rule.
+func (s *PGRuleStore) GetRevision(ctx context.Context, ruleID string, version int) (alertmodel.RuleRevision, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT rule_id, version, action, author, timestamp, rule, diff, restored_from
+		FROM alert_rule_revisions WHERE rule_id = $1 AND version = $2
+	`, ruleID, version)
+	rev, err := scanRevision(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return rev, rulestore.ErrRevisionNotFound
+	}
+	return rev, err
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanRevision reads a revision row.
+func scanRevision(row scanner) (alertmodel.RuleRevision, error) {
+	var rev alertmodel.RuleRevision
+	var ruleJSON, diffJSON []byte
+	if err := row.Scan(&rev.RuleID, &rev.Version, &rev.Action, &rev.Author, &rev.Timestamp, &ruleJSON, &diffJSON, &rev.RestoredFrom); err != nil {
+		return rev, err
+	}
+	if err := json.Unmarshal(ruleJSON, &rev.Rule); err != nil {
+		return rev, err
+	}
+	if len(diffJSON) > 0 {
+		_ = json.Unmarshal(diffJSON, &rev.Diff)
+	}
+	return rev, nil
+}
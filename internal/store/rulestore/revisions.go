@@ -0,0 +1,172 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rulestore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+)
+
+// ErrRevisionNotFound is returned when a rule has no revision with the
+// requested version.
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// RevisionStore keeps the change history of alert rules. AddRevision assigns
+// the next version number for the rule and returns the stored revision.
+type RevisionStore interface {
+	AddRevision(ctx context.Context, rev alertmodel.RuleRevision) (alertmodel.RuleRevision, error)
+	ListRevisions(ctx context.Context, ruleID string) ([]alertmodel.RuleRevision, error)
+	GetRevision(ctx context.Context, ruleID string, version int) (alertmodel.RuleRevision, error)
+}
+
+// FileRevisionStore is a RevisionStore that appends revisions to a JSON
+// lines file, one revision per line. With an empty path it keeps the
+// history in memory only.
+type FileRevisionStore struct {
+	path      string
+	lock      sync.RWMutex
+	revisions map[string][]alertmodel.RuleRevision // ruleID -> ordered by version
+}
+
+// RevisionsPath returns the default history file for a rules file, e.g.
+// "rules.yaml" -> "rules.revisions.jsonl" in the same directory.
+func RevisionsPath(rulesPath string) string {
+	if rulesPath == "" {
+		return ""
+	}
+	ext := filepath.Ext(rulesPath)
+	return strings.TrimSuffix(rulesPath, ext) + ".revisions.jsonl"
+}
+
+// NewFileRevisionStore creates a FileRevisionStore, loading any existing
+// history from path.
+func NewFileRevisionStore(path string) (*FileRevisionStore, error) {
+	s := &FileRevisionStore{
+		path:      path,
+		revisions: make(map[string][]alertmodel.RuleRevision),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the history file. A missing file is an empty history.
+func (s *FileRevisionStore) load() error {
+	if s.path == "" {
+		return nil
+	}
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var rev alertmodel.RuleRevision
+		if err := json.Unmarshal(line, &rev); err != nil {
+			return err
+		}
+		s.revisions[rev.RuleID] = append(s.revisions[rev.RuleID], rev)
+	}
+	return scanner.Err()
+}
+
+// AddRevision stores a new revision for rev.RuleID with the next version.
+func (s *FileRevisionStore) AddRevision(ctx context.Context, rev alertmodel.RuleRevision) (alertmodel.RuleRevision, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	history := s.revisions[rev.RuleID]
+	rev.Version = 1
+	if n := len(history); n > 0 {
+		rev.Version = history[n-1].Version + 1
+	}
+	if rev.Timestamp.IsZero() {
+		rev.Timestamp = time.Now().UTC()
+	}
+
+	if s.path != "" {
+		data, err := json.Marshal(rev)
+		if err != nil {
+			return rev, err
+		}
+		f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return rev, err
+		}
+		_, err = f.Write(append(data, '\n'))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return rev, err
+		}
+	}
+
+	s.revisions[rev.RuleID] = append(history, rev)
+	return rev, nil
+}
+
+// ListRevisions returns a rule's revisions, newest first.
+func (s *FileRevisionStore) ListRevisions(ctx context.Context, ruleID string) ([]alertmodel.RuleRevision, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	history := s.revisions[ruleID]
+	out := make([]alertmodel.RuleRevision, len(history))
+	for i, rev := range history {
+		out[len(history)-1-i] = rev
+	}
+	return out, nil
+}
+
+// GetRevision returns a single revision of a rule.
+func (s *FileRevisionStore) GetRevision(ctx context.Context, ruleID string, version int) (alertmodel.RuleRevision, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, rev := range s.revisions[ruleID] {
+		if rev.Version == version {
+			return rev, nil
+		}
+	}
+	return alertmodel.RuleRevision{}, ErrRevisionNotFound
+}
@@ -27,17 +27,30 @@ package rulestore
 
 import (
 	"context"
+	"sort"
 
-	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
 )
 
 // RuleStore defines the interface for managing alert rules.
+// AddRule and UpdateRule must reject rules that fail AlertRule.Validate.
 type RuleStore interface {
-	AddRule(ctx context.Context, rule model.AlertRule) error
-	UpdateRule(ctx context.Context, rule model.AlertRule) error
+	AddRule(ctx context.Context, rule alertmodel.AlertRule) error
+	UpdateRule(ctx context.Context, rule alertmodel.AlertRule) error
 	DeleteRule(ctx context.Context, id string) error
-	GetActiveRules(ctx context.Context) ([]model.AlertRule, error)
-	GetRuleByID(ctx context.Context, id string) (model.AlertRule, error)
-	GetRuleByName(ctx context.Context, name string) (model.AlertRule, error)
-	ListRules(ctx context.Context) ([]model.AlertRule, error)
+	GetActiveRules(ctx context.Context) ([]alertmodel.AlertRule, error)
+	GetRuleByID(ctx context.Context, id string) (alertmodel.AlertRule, error)
+	GetRuleByName(ctx context.Context, name string) (alertmodel.AlertRule, error)
+	ListRules(ctx context.Context) ([]alertmodel.AlertRule, error)
+}
+
+// sortedRules returns the rules ordered by ID so files written by the
+// file-backed stores are stable across saves.
+func sortedRules(rules map[string]alertmodel.AlertRule) []alertmodel.AlertRule {
+	list := make([]alertmodel.AlertRule, 0, len(rules))
+	for _, r := range rules {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, k int) bool { return list[i].ID < list[k].ID })
+	return list
 }
@@ -0,0 +1,76 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package rulestore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStoresRejectInvalidRulesAtLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		open    func(path string) error
+	}{
+		{
+			name:    "yaml",
+			file:    "rules.yaml",
+			content: "- id: cpu\n  type: metric\n- id: both\n  type: composite\n  composite:\n    operator: xor\n",
+			open:    func(path string) error { _, err := NewYAMLStore(path); return err },
+		},
+		{
+			name:    "json",
+			file:    "rules.json",
+			content: `[{"id": "cpu", "type": "metric"}, {"id": "trace", "type": "trace"}]`,
+			open:    func(path string) error { _, err := NewJSONStore(path); return err },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+			assert.ErrorIs(t, tt.open(path), alertmodel.ErrInvalidRule)
+		})
+	}
+}
+
+func TestJSONStoreStartsEmptyWithoutFile(t *testing.T) {
+	s, err := NewJSONStore(filepath.Join(t.TempDir(), "rules.json"))
+	require.NoError(t, err)
+	rules, err := s.ListRules(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+}
+
+func TestAddRuleRejectsUnknownType(t *testing.T) {
+	s := NewMemoryStore()
+	err := s.AddRule(context.Background(), alertmodel.AlertRule{ID: "cpu", Type: "gauge"})
+	assert.ErrorIs(t, err, alertmodel.ErrInvalidRule)
+}
@@ -26,10 +26,11 @@ package rulestore
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"sync"
 
-	"github.com/aaronlmathis/gosight-shared/model"
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
 	"gopkg.in/yaml.v3"
 )
 
@@ -39,14 +40,14 @@ import (
 type YAMLRuleStore struct {
 	path  string
 	lock  sync.RWMutex
-	rules map[string]model.AlertRule
+	rules map[string]alertmodel.AlertRule
 }
 
 // NewYAMLStore creates a new YAMLRuleStore with the specified file path.
 func NewYAMLStore(path string) (*YAMLRuleStore, error) {
 	s := &YAMLRuleStore{
 		path:  path,
-		rules: make(map[string]model.AlertRule),
+		rules: make(map[string]alertmodel.AlertRule),
 	}
 	if err := s.load(); err != nil {
 		return nil, err
@@ -62,32 +63,29 @@ func (s *YAMLRuleStore) load() error {
 		return err
 	}
 
-	var list []model.AlertRule
+	var list []alertmodel.AlertRule
 	if err := yaml.Unmarshal(data, &list); err != nil {
 		return err
 	}
 
+	for _, r := range list {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+	}
+
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	for _, r := range list {
 		s.rules[r.ID] = r
-
 	}
-
 	return nil
 }
 
-// save writes the current rules to the YAML file.
+// save writes the current rules to the YAML file as a list, in the same
+// shape load expects. The caller must hold the write lock.
 func (s *YAMLRuleStore) save() error {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-
-	var list []model.AlertRule
-	for _, r := range s.rules {
-		list = append(list, r)
-	}
-
-	data, err := yaml.Marshal(list)
+	data, err := yaml.Marshal(sortedRules(s.rules))
 	if err != nil {
 		return err
 	}
@@ -95,7 +93,11 @@ func (s *YAMLRuleStore) save() error {
 }
 
 // AddRule adds a new rule to the store.
-func (s *YAMLRuleStore) AddRule(ctx context.Context, r model.AlertRule) error {
+// Rules that fail validation are rejected without being stored.
+func (s *YAMLRuleStore) AddRule(ctx context.Context, r alertmodel.AlertRule) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	s.rules[r.ID] = r
@@ -103,7 +105,7 @@ func (s *YAMLRuleStore) AddRule(ctx context.Context, r model.AlertRule) error {
 }
 
 // UpdateRule updates an existing rule in the store.
-func (s *YAMLRuleStore) UpdateRule(ctx context.Context, r model.AlertRule) error {
+func (s *YAMLRuleStore) UpdateRule(ctx context.Context, r alertmodel.AlertRule) error {
 	return s.AddRule(ctx, r)
 }
 
@@ -116,11 +118,11 @@ func (s *YAMLRuleStore) DeleteRule(ctx context.Context, id string) error {
 }
 
 // ListRules returns a list of all rules in the store.
-func (s *YAMLRuleStore) ListRules(ctx context.Context) ([]model.AlertRule, error) {
+func (s *YAMLRuleStore) ListRules(ctx context.Context) ([]alertmodel.AlertRule, error) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
-	var out []model.AlertRule
+	var out []alertmodel.AlertRule
 	for _, r := range s.rules {
 		out = append(out, r)
 	}
@@ -130,9 +132,9 @@ func (s *YAMLRuleStore) ListRules(ctx context.Context) ([]model.AlertRule, error
 // GetActiveRules returns a list of active rules in the store.
 // It filters the rules based on their Enabled status.
 
-func (s *YAMLRuleStore) GetActiveRules(ctx context.Context) ([]model.AlertRule, error) {
+func (s *YAMLRuleStore) GetActiveRules(ctx context.Context) ([]alertmodel.AlertRule, error) {
 	all, _ := s.ListRules(ctx)
-	var filtered []model.AlertRule
+	var filtered []alertmodel.AlertRule
 	for _, r := range all {
 		if r.Enabled {
 			filtered = append(filtered, r)
@@ -142,18 +144,18 @@ func (s *YAMLRuleStore) GetActiveRules(ctx context.Context) ([]model.AlertRule,
 }
 
 // GetRuleByID retrieves a rule by its ID.
-func (s *YAMLRuleStore) GetRuleByID(ctx context.Context, id string) (model.AlertRule, error) {
+func (s *YAMLRuleStore) GetRuleByID(ctx context.Context, id string) (alertmodel.AlertRule, error) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
 	if rule, ok := s.rules[id]; ok {
 		return rule, nil
 	}
-	return model.AlertRule{}, os.ErrNotExist
+	return alertmodel.AlertRule{}, os.ErrNotExist
 }
 
 // GetRuleByName retrieves a rule by its Name (case-sensitive).
-func (s *YAMLRuleStore) GetRuleByName(ctx context.Context, name string) (model.AlertRule, error) {
+func (s *YAMLRuleStore) GetRuleByName(ctx context.Context, name string) (alertmodel.AlertRule, error) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 
@@ -162,5 +164,5 @@ func (s *YAMLRuleStore) GetRuleByName(ctx context.Context, name string) (model.A
 			return rule, nil
 		}
 	}
-	return model.AlertRule{}, os.ErrNotExist
+	return alertmodel.AlertRule{}, os.ErrNotExist
 }
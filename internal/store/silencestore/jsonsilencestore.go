@@ -0,0 +1,148 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+package silencestore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+)
+
+// JSONSilenceStore is a SilenceStore backed by a JSON file holding a list of
+// silences. With an empty path the silences are kept in memory only.
+type JSONSilenceStore struct {
+	path     string
+	lock     sync.RWMutex
+	silences map[string]alertmodel.Silence
+}
+
+// NewJSONSilenceStore creates a JSONSilenceStore, loading any existing
+// silences from path.
+func NewJSONSilenceStore(path string) (*JSONSilenceStore, error) {
+	s := &JSONSilenceStore{
+		path:     path,
+		silences: make(map[string]alertmodel.Silence),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the silences file. A missing file is not an error.
+func (s *JSONSilenceStore) load() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	var list []alertmodel.Silence
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("parse silences %s: %w", s.path, err)
+	}
+	for _, sil := range list {
+		s.silences[sil.ID] = sil
+	}
+	return nil
+}
+
+// save rewrites the silences file. The caller must hold the write lock.
+func (s *JSONSilenceStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// sorted returns the silences ordered by start time, then ID.
+func (s *JSONSilenceStore) sorted() []alertmodel.Silence {
+	list := make([]alertmodel.Silence, 0, len(s.silences))
+	for _, sil := range s.silences {
+		list = append(list, sil)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].StartsAt.Equal(list[j].StartsAt) {
+			return list[i].StartsAt.Before(list[j].StartsAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// UpsertSilence creates or replaces a silence.
+func (s *JSONSilenceStore) UpsertSilence(ctx context.Context, sil alertmodel.Silence) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.silences[sil.ID] = sil
+	return s.save()
+}
+
+// DeleteSilence removes a silence.
+func (s *JSONSilenceStore) DeleteSilence(ctx context.Context, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.silences[id]; !ok {
+		return ErrSilenceNotFound
+	}
+	delete(s.silences, id)
+	return s.save()
+}
+
+// GetSilence returns the silence with the given ID.
+func (s *JSONSilenceStore) GetSilence(ctx context.Context, id string) (alertmodel.Silence, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	sil, ok := s.silences[id]
+	if !ok {
+		return alertmodel.Silence{}, ErrSilenceNotFound
+	}
+	return sil, nil
+}
+
+// ListSilences returns all silences, including expired ones.
+func (s *JSONSilenceStore) ListSilences(ctx context.Context) ([]alertmodel.Silence, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.sorted(), nil
+}
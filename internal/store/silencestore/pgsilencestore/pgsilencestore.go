@@ -0,0 +1,117 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package pgsilencestore implements silencestore.SilenceStore on PostgreSQL.
+// Silences are stored as JSONB documents in the silences table.
+package pgsilencestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+	"github.com/aaronlmathis/gosight-server/internal/store/silencestore"
+)
+
+// PGSilenceStore is a PostgreSQL implementation of the SilenceStore interface.
+type PGSilenceStore struct {
+	db *sql.DB
+}
+
+// NewPGSilenceStore creates a new PGSilenceStore.
+func NewPGSilenceStore(db *sql.DB) *PGSilenceStore {
+	return &PGSilenceStore{db: db}
+}
+
+// UpsertSilence inserts a silence or replaces the one with the same ID.
+func (s *PGSilenceStore) UpsertSilence(ctx context.Context, sil alertmodel.Silence) error {
+	doc, err := json.Marshal(sil)
+	if err != nil {
+		return err
+	}
+	endsAt := sql.NullTime{Time: sil.EndsAt, Valid: !sil.EndsAt.IsZero()}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO silences (id, starts_at, ends_at, created_by, created_at, silence)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			starts_at = EXCLUDED.starts_at,
+			ends_at = EXCLUDED.ends_at,
+			created_by = EXCLUDED.created_by,
+			created_at = EXCLUDED.created_at,
+			silence = EXCLUDED.silence
+	`, sil.ID, sil.StartsAt, endsAt, sil.CreatedBy, sil.CreatedAt, doc)
+	return err
+}
+
+// DeleteSilence removes a silence.
+func (s *PGSilenceStore) DeleteSilence(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM silences WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return silencestore.ErrSilenceNotFound
+	}
+	return nil
+}
+
+// GetSilence returns the silence with the given ID.
+func (s *PGSilenceStore) GetSilence(ctx context.Context, id string) (alertmodel.Silence, error) {
+	var doc []byte
+	err := s.db.QueryRowContext(ctx, `SELECT silence FROM silences WHERE id = $1`, id).Scan(&doc)
+	if errors.Is(err, sql.ErrNoRows) {
+		return alertmodel.Silence{}, silencestore.ErrSilenceNotFound
+	}
+	if err != nil {
+		return alertmodel.Silence{}, err
+	}
+
+	var sil alertmodel.Silence
+	if err := json.Unmarshal(doc, &sil); err != nil {
+		return alertmodel.Silence{}, err
+	}
+	return sil, nil
+}
+
+// ListSilences returns all silences ordered by start time.
+func (s *PGSilenceStore) ListSilences(ctx context.Context) ([]alertmodel.Silence, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT silence FROM silences ORDER BY starts_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []alertmodel.Silence
+	for rows.Next() {
+		var doc []byte
+		if err := rows.Scan(&doc); err != nil {
+			return nil, err
+		}
+		var sil alertmodel.Silence
+		if err := json.Unmarshal(doc, &sil); err != nil {
+			return nil, err
+		}
+		list = append(list, sil)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,42 @@
+/*
+SPDX-License-Identifier: GPL-3.0-or-later
+
+Copyright (C) 2025 Aaron Mathis aaron.mathis@gmail.com
+
+This file is part of GoSight.
+
+GoSight is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+GoSight is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with GoSight. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package silencestore persists alert silences and maintenance windows.
+package silencestore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
+)
+
+// ErrSilenceNotFound is returned when no silence has the requested ID.
+var ErrSilenceNotFound = errors.New("silence not found")
+
+// SilenceStore is an interface for storing and retrieving silences.
+// UpsertSilence creates the silence or replaces the one with the same ID.
+type SilenceStore interface {
+	UpsertSilence(ctx context.Context, s alertmodel.Silence) error
+	DeleteSilence(ctx context.Context, id string) error
+	GetSilence(ctx context.Context, id string) (alertmodel.Silence, error)
+	ListSilences(ctx context.Context) ([]alertmodel.Silence, error)
+}
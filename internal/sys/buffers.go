@@ -21,7 +21,10 @@ along with GoSight. If not, see https://www.gnu.org/licenses/.
 
 package sys
 
-import "github.com/aaronlmathis/gosight-server/internal/bufferengine"
+import (
+	"github.com/aaronlmathis/gosight-server/internal/bufferengine"
+	"github.com/aaronlmathis/gosight-shared/utils"
+)
 
 // BufferModule is a struct that holds buffered stores for different types of data.
 type BufferModule struct {
@@ -30,4 +33,17 @@ type BufferModule struct {
 	Data    bufferengine.BufferedStore
 	Events  bufferengine.BufferedStore
 	Alerts  bufferengine.BufferedStore
+
+	// FlushOnDisconnect lists the buffers configured with flush_on_disconnect.
+	FlushOnDisconnect []bufferengine.BufferedStore
+}
+
+// AgentDisconnected flushes the buffers configured with flush_on_disconnect so
+// the last payloads of a disconnecting agent don't wait for the next interval.
+func (b *BufferModule) AgentDisconnected() {
+	for _, store := range b.FlushOnDisconnect {
+		if err := store.Flush(); err != nil {
+			utils.Warn("Flush on disconnect failed for [%s]: %v", store.Name(), err)
+		}
+	}
 }
@@ -25,25 +25,32 @@ import (
 	"github.com/aaronlmathis/gosight-server/internal/store/alertstore"
 	"github.com/aaronlmathis/gosight-server/internal/store/datastore"
 	"github.com/aaronlmathis/gosight-server/internal/store/eventstore"
+	"github.com/aaronlmathis/gosight-server/internal/store/incidentstore"
 	"github.com/aaronlmathis/gosight-server/internal/store/logstore"
 	"github.com/aaronlmathis/gosight-server/internal/store/metricstore"
+	"github.com/aaronlmathis/gosight-server/internal/store/notificationstore"
 	"github.com/aaronlmathis/gosight-server/internal/store/resourcestore"
 	"github.com/aaronlmathis/gosight-server/internal/store/routestore"
 	"github.com/aaronlmathis/gosight-server/internal/store/rulestore"
+	"github.com/aaronlmathis/gosight-server/internal/store/silencestore"
 	"github.com/aaronlmathis/gosight-server/internal/store/userstore"
 )
 
 // StoreModule contains all persistent or semi-persistent storage components.
 type StoreModule struct {
-	Metrics   metricstore.MetricStore     // Time-series metrics (e.g., VictoriaMetrics)
-	Logs      logstore.LogStore           // Structured logs (e.g., journald, /var/log/secure)
-	Users     userstore.UserStore         // User auth, roles, permissions
-	Data      datastore.DataStore         // Hosts, endpoints, agents, etc.
-	Events    eventstore.EventStore       // Event logs, audit, alert events
-	Rules     rulestore.RuleStore         // Alert rules defined by users
-	Actions   *routestore.RouteStore      // Routes loaded from alert_routes.yaml
-	Alerts    alertstore.AlertStore       // Alert instances (active, resolved, history)
-	Resources resourcestore.ResourceStore // Resource management (e.g., hosts, endpoints)
+	Metrics       metricstore.MetricStore             // Time-series metrics (e.g., VictoriaMetrics)
+	Logs          logstore.LogStore                   // Structured logs (e.g., journald, /var/log/secure)
+	Users         userstore.UserStore                 // User auth, roles, permissions
+	Data          datastore.DataStore                 // Hosts, endpoints, agents, etc.
+	Events        eventstore.EventStore               // Event logs, audit, alert events
+	Rules         rulestore.RuleStore                 // Alert rules defined by users
+	Revisions     rulestore.RevisionStore             // Change history of alert rules
+	Actions       *routestore.RouteStore              // Routes loaded from alert_routes.yaml
+	Alerts        alertstore.AlertStore               // Alert instances (active, resolved, history)
+	Silences      silencestore.SilenceStore           // Alert silences and maintenance windows
+	Notifications notificationstore.NotificationStore // Notification deliveries, retries and dead letters
+	Incidents     incidentstore.IncidentStore         // Incidents grouping correlated events
+	Resources     resourcestore.ResourceStore         // Resource management (e.g., hosts, endpoints)
 }
 
 // NewStoreModule creates a new StoreModule with the provided components.
@@ -54,19 +61,27 @@ func NewStoreModule(
 	data datastore.DataStore,
 	events eventstore.EventStore,
 	rules rulestore.RuleStore,
+	revisions rulestore.RevisionStore,
 	actions *routestore.RouteStore,
 	alerts alertstore.AlertStore,
+	silences silencestore.SilenceStore,
+	notifications notificationstore.NotificationStore,
+	incidents incidentstore.IncidentStore,
 	resources resourcestore.ResourceStore,
 ) *StoreModule {
 	return &StoreModule{
-		Metrics:   metrics,
-		Logs:      logs,
-		Users:     users,
-		Data:      data,
-		Events:    events,
-		Rules:     rules,
-		Actions:   actions,
-		Alerts:    alerts,
-		Resources: resources,
+		Metrics:       metrics,
+		Logs:          logs,
+		Users:         users,
+		Data:          data,
+		Events:        events,
+		Rules:         rules,
+		Revisions:     revisions,
+		Actions:       actions,
+		Alerts:        alerts,
+		Silences:      silences,
+		Notifications: notifications,
+		Incidents:     incidents,
+		Resources:     resources,
 	}
 }
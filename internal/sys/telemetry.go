@@ -28,6 +28,8 @@ import (
 	"github.com/aaronlmathis/gosight-server/internal/alerts"
 	"github.com/aaronlmathis/gosight-server/internal/core/events/dispatcher"
 	"github.com/aaronlmathis/gosight-server/internal/events"
+	"github.com/aaronlmathis/gosight-server/internal/events/sinks"
+	"github.com/aaronlmathis/gosight-server/internal/incidents"
 	"github.com/aaronlmathis/gosight-server/internal/rules"
 	"github.com/aaronlmathis/gosight-server/internal/store/metastore"
 	"github.com/aaronlmathis/gosight-server/internal/store/metricindex"
@@ -46,9 +48,12 @@ type TelemetryModule struct {
 	Index             *metricindex.MetricIndex // Metric name/dimension catalog
 	Meta              *metastore.MetaTracker   // Tracks source metadata (labels, tags, endpoint info)
 	Evaluator         *rules.Evaluator         // Rule evaluator (metrics → match?)
+	Scheduler         *rules.Scheduler         // Evaluates rules on their eval_interval
 	Alerts            *alerts.Manager          // Tracks alert state per rule/endpoint
 	Emitter           *events.Emitter          // Emits events (alerts, system actions)
 	Dispatcher        *dispatcher.Dispatcher   // Routes alert events to actions
+	Incidents         *incidents.Correlator    // Groups related events into incidents
+	Sinks             *sinks.Sinks             // Publishes events and alert changes to external systems
 	ResourceDiscovery ResourceDiscoverer       // Discovers and tracks resources
 }
 
@@ -58,18 +63,24 @@ func NewTelemetryModule(
 	index *metricindex.MetricIndex,
 	meta *metastore.MetaTracker,
 	evaluator *rules.Evaluator,
+	scheduler *rules.Scheduler,
 	alerts *alerts.Manager,
 	emitter *events.Emitter,
 	dispatcher *dispatcher.Dispatcher,
+	incidents *incidents.Correlator,
+	sinks *sinks.Sinks,
 	resourceDiscovery ResourceDiscoverer,
 ) *TelemetryModule {
 	return &TelemetryModule{
 		Index:             index,
 		Meta:              meta,
 		Evaluator:         evaluator,
+		Scheduler:         scheduler,
 		Alerts:            alerts,
 		Emitter:           emitter,
 		Dispatcher:        dispatcher,
+		Incidents:         incidents,
+		Sinks:             sinks,
 		ResourceDiscovery: resourceDiscovery,
 	}
 }
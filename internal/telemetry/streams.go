@@ -64,6 +64,9 @@ func (h *StreamHandler) Stream(stream pb.StreamService_StreamServer) error {
 		req, err := stream.Recv()
 		if err != nil {
 			utils.Error("Stream receive error: %v", err)
+			if h.Sys.Buffers != nil {
+				go h.Sys.Buffers.AgentDisconnected()
+			}
 			return err
 		}
 
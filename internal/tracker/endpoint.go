@@ -113,7 +113,7 @@ func (t *EndpointTracker) UpdateAgent(meta *model.Meta) {
 					Scope:      "endpoint",
 					Target:     agent.AgentID,
 					EndpointID: agent.EndpointID,
-					Meta:       BuildAgentEventMeta(agent),
+					Meta:       WithStatus(BuildAgentEventMeta(agent), "Online", ""),
 				})
 			}
 			t.agents[meta.AgentID] = agent
@@ -188,7 +188,7 @@ func (t *EndpointTracker) UpdateContainer(meta *model.Meta) {
 			Scope:      "container",
 			Target:     c.ContainerID,
 			EndpointID: c.EndpointID,
-			Meta:       BuildContainerEventMeta(c),
+			Meta:       WithStatus(BuildContainerEventMeta(c), newStatus, ""),
 		})
 		return
 	}
@@ -206,7 +206,7 @@ func (t *EndpointTracker) UpdateContainer(meta *model.Meta) {
 			Scope:      "container",
 			Target:     c.ContainerID,
 			EndpointID: c.EndpointID,
-			Meta:       BuildContainerEventMeta(c),
+			Meta:       WithStatus(BuildContainerEventMeta(c), newStatus, c.Status),
 		})
 	}
 
@@ -230,7 +230,7 @@ func (t *EndpointTracker) UpdateContainer(meta *model.Meta) {
 			Scope:      "container",
 			Target:     c.ContainerID,
 			EndpointID: c.EndpointID,
-			Meta:       BuildContainerEventMeta(c),
+			Meta:       WithStatus(BuildContainerEventMeta(c), newStatus, c.Status),
 		})
 
 		c.Status = newStatus // VERY IMPORTANT
@@ -250,7 +250,7 @@ func (t *EndpointTracker) UpdateContainer(meta *model.Meta) {
 			Scope:      "container",
 			Target:     c.ContainerID,
 			EndpointID: c.EndpointID,
-			Meta:       BuildContainerEventMeta(c),
+			Meta:       WithStatus(BuildContainerEventMeta(c), "Online", "Inactive"),
 		})
 	}
 
@@ -399,6 +399,7 @@ func (t *EndpointTracker) CheckAgentStatusesAndEmitEvents() {
 
 		if agent.Status != status {
 			utils.Debug("Updating agent %s status from %s to %s", agent.Hostname, agent.Status, status)
+			previous := agent.Status
 			agent.Status = status
 			agent.Updated = true
 
@@ -417,7 +418,7 @@ func (t *EndpointTracker) CheckAgentStatusesAndEmitEvents() {
 				Scope:      "endpoint",
 				Target:     agent.AgentID,
 				EndpointID: agent.EndpointID,
-				Meta:       BuildAgentEventMeta(agent),
+				Meta:       WithStatus(BuildAgentEventMeta(agent), status, previous),
 			})
 		}
 	}
@@ -441,6 +442,7 @@ func (t *EndpointTracker) CheckContainerStatusesAndEmit() {
 
 		if container.Heartbeat != newHeartbeat {
 			utils.Debug("Updating container %s status from %s to %s", container.Name, container.Heartbeat, newHeartbeat)
+			previous := container.Heartbeat
 			container.Heartbeat = newHeartbeat
 			container.Updated = true
 
@@ -460,7 +462,7 @@ func (t *EndpointTracker) CheckContainerStatusesAndEmit() {
 				Scope:      "container",
 				Target:     container.ContainerID,
 				EndpointID: container.EndpointID,
-				Meta:       BuildContainerEventMeta(container),
+				Meta:       WithStatus(BuildContainerEventMeta(container), newHeartbeat, previous),
 			})
 		}
 	}
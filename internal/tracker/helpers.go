@@ -76,6 +76,36 @@ func BuildContainerEventMeta(c *model.Container) map[string]string {
 	}
 }
 
+// WithStatus records a lifecycle transition in an event's meta: the status
+// the agent or container moved to in "status" and, when known, the one it had
+// before in "previous_status", so consumers don't have to parse the message.
+func WithStatus(meta map[string]string, status, previous string) map[string]string {
+	meta["status"] = status
+	if previous != "" {
+		meta["previous_status"] = previous
+	} else {
+		delete(meta, "previous_status")
+	}
+	return meta
+}
+
+// IsDownStatus reports whether a status recorded by WithStatus means the
+// agent or container is down.
+func IsDownStatus(status string) bool {
+	switch status {
+	case "Offline", "Exited", "Stopped", "Dead":
+		return true
+	}
+	return false
+}
+
+// IsUpStatus reports whether a status recorded by WithStatus means the agent
+// or container is up. Transitional states such as Idle or Restarting are
+// neither up nor down.
+func IsUpStatus(status string) bool {
+	return status == "Online" || status == "Running"
+}
+
 func NormalizeContainerStatus(raw string) string {
 	switch strings.ToLower(raw) {
 	case "created":
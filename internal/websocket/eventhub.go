@@ -31,6 +31,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aaronlmathis/gosight-server/internal/alertmodel"
 	gosightauth "github.com/aaronlmathis/gosight-server/internal/auth"
 	"github.com/aaronlmathis/gosight-server/internal/store/metastore"
 	"github.com/aaronlmathis/gosight-shared/model"
@@ -41,14 +42,23 @@ import (
 type EventsHub struct {
 	clients     map[*Client]bool
 	broadcast   chan model.EventEntry
+	incidents   chan alertmodel.Incident
 	lock        sync.Mutex
 	metaTracker *metastore.MetaTracker
 }
 
+// IncidentMessage is sent to event clients when a new incident is opened.
+// Its type field tells it apart from the event entries on the same socket.
+type IncidentMessage struct {
+	Type     string              `json:"type"` // always "incident"
+	Incident alertmodel.Incident `json:"incident"`
+}
+
 func NewEventsHub(metaTracker *metastore.MetaTracker) *EventsHub {
 	return &EventsHub{
 		clients:     make(map[*Client]bool),
 		broadcast:   make(chan model.EventEntry, 300), // bursty but important
+		incidents:   make(chan alertmodel.Incident, 50),
 		metaTracker: metaTracker,
 	}
 }
@@ -80,6 +90,19 @@ func (h *EventsHub) Run(ctx context.Context) {
 
 			h.lock.Unlock()
 
+		case inc := <-h.incidents:
+			data, _ := json.Marshal(IncidentMessage{Type: "incident", Incident: inc})
+
+			h.lock.Lock()
+			for client := range h.clients {
+				if h.shouldDeliverIncident(inc, client) && !safeSend(client, data) {
+					utils.Warn("Client send failed, removing: endpoint=%s agent=%s", client.EndpointID, client.AgentID)
+					client.Close()
+					delete(h.clients, client)
+				}
+			}
+			h.lock.Unlock()
+
 		case <-ctx.Done():
 			// Context cancelled — shut down cleanly
 			h.lock.Lock()
@@ -162,6 +185,28 @@ func (h *EventsHub) Broadcast(payload model.EventEntry) {
 	}
 }
 
+// AnnounceIncident sends a new incident to the connected event clients
+// watching any of its endpoints, or all endpoints.
+func (h *EventsHub) AnnounceIncident(inc alertmodel.Incident) {
+	select {
+	case h.incidents <- inc:
+	default:
+		// drop if full
+	}
+}
+
+func (h *EventsHub) shouldDeliverIncident(inc alertmodel.Incident, client *Client) bool {
+	if client.EndpointID == "" {
+		return true
+	}
+	for _, eid := range inc.EndpointIDs {
+		if eid == client.EndpointID {
+			return true
+		}
+	}
+	return inc.HostID != "" && inc.HostID == client.HostID
+}
+
 func (h *EventsHub) shouldDeliver(payload model.EventEntry, client *Client) bool {
 	if client.EndpointID == "" {
 		return true // No filter